@@ -0,0 +1,251 @@
+/*
+The console is the frontend for the -debug flag. Each time that the script is
+paused, it shows the statement that is about to be executed along with its
+arguments (with the variables in its strings replaced) and reads commands
+from standard input until it is told to carry on. What it shows goes to
+standard error so that the output of the script isn't mixed up with it.
+*/
+package debugger
+
+import (
+	"appetit/parser"
+	"appetit/utils"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The prompt that is shown while the script is paused
+const PROMPT string = "(debug) "
+
+// The number of lines shown either side of the paused line by list
+const LIST_CONTEXT_LINES int = 3
+
+/*
+The Console type houses the console frontend. The structure of the console is
+as follows:
+  - Debugger [*Debugger]: the debugger that the console drives
+*/
+type Console struct {
+	Debugger *Debugger
+}
+
+/*
+Create a debugger that is driven from the console and attach it to an
+interpreter. Parameters include the interpreter. Returns the debugger.
+*/
+func NewConsole(interpreter *parser.Interpreter) *Debugger {
+	console := &Console{}
+	console.Debugger = New(interpreter, console)
+	return console.Debugger
+}
+
+/*
+Get the console commands along with what each does. No parameters. Returns a
+map of the names of the commands, with any short form, to their descriptions.
+*/
+func Commands() map[string]string {
+	return map[string]string{
+		"break, b":    "Add a breakpoint or list them (eg. b 12, b run).",
+		"continue, c": "Run until the next breakpoint.",
+		"delete, d":   "Remove a breakpoint (eg. d 12).",
+		"help, h":     "Show this help.",
+		"list, l":     "Show the lines around the statement.",
+		"next, n":     "Run the statement, stepping over procedures and runs.",
+		"out, o":      "Run until the procedure or run target returns.",
+		"print, p":    "Print a variable or, without a name, every variable.",
+		"quit, q":     "Stop the script.",
+		"set":         "Set a variable (eg. set name = \"World\").",
+		"stack, bt":   "Show the scripts and procedures being executed.",
+		"step, s":     "Run the statement, stepping into procedures and runs.",
+	}
+}
+
+/*
+Show where the script is paused and read commands until one carries on.
+Parameters include where the script is paused. Returns what to do next,
+continuing if there is nothing more to read.
+*/
+func (console *Console) Pause(stop Stop) Action {
+	output := console.Debugger.Interpreter.Stderr
+	fmt.Fprintf(output, ":: %s at %s (%s)\n",
+		utils.ColouriseBlue("Paused"),
+		utils.ColouriseMagenta(
+			filepath.Base(stop.Script)+":"+strconv.Itoa(stop.Line)),
+		stop.Reason)
+	fmt.Fprintln(output, "=> "+strings.TrimSpace(
+		stop.Statement.Tokens[0].FullLineOfCode))
+	arguments := Arguments(console.Debugger.Interpreter, stop.Statement)
+	if len(arguments) > 0 {
+		fmt.Fprintln(output, "   "+utils.ColouriseCyan(stop.Statement.Name)+
+			" "+strings.Join(arguments, " "))
+	}
+
+	for {
+		fmt.Fprint(output, PROMPT)
+		line, read_error := console.Debugger.Interpreter.ReadLine()
+		if read_error != nil {
+			fmt.Fprintln(output)
+			return ACTION_CONTINUE
+		}
+		command, argument, _ := strings.Cut(strings.TrimSpace(line), " ")
+		argument = strings.TrimSpace(argument)
+		switch command {
+		// Enter on its own steps, as does step
+		case "", "step", "s":
+			return ACTION_STEP
+		case "next", "n":
+			return ACTION_NEXT
+		case "out", "o":
+			return ACTION_OUT
+		case "continue", "c":
+			return ACTION_CONTINUE
+		case "quit", "q":
+			return ACTION_QUIT
+		case "break", "b":
+			console.Break(argument)
+		case "delete", "d":
+			console.Report(console.Debugger.RemoveBreakpoint(argument))
+		case "print", "p":
+			console.Print(argument)
+		case "set":
+			console.Set(argument)
+		case "list", "l":
+			console.List(stop)
+		case "stack", "bt":
+			for _, frame := range slices.Backward(console.Debugger.Stack()) {
+				fmt.Fprintf(output, "   %s %s\n",
+					utils.ColouriseMagenta(filepath.Base(frame.Script)+":"+
+						strconv.Itoa(frame.Line)),
+					utils.ColouriseCyan(frame.Statement.Name))
+			}
+		case "help", "h":
+			commands := Commands()
+			for _, name := range slices.Sorted(maps.Keys(commands)) {
+				fmt.Fprintf(output, "\t%s\t%s\n",
+					utils.ColouriseMagenta(name), commands[name])
+			}
+		default:
+			console.Report(parser.ReportSimple(
+				"The command - " + utils.ColouriseYellow(command) +
+					" - isn't a debugger command. Type " +
+					utils.ColouriseMagenta("help") + " to see the commands.",
+			).WithCategory(parser.ERROR_USAGE))
+		}
+	}
+}
+
+/*
+Print an error from a command, if there was one. Parameters include the
+error. Returns nothing.
+*/
+func (console *Console) Report(command_error error) {
+	if command_error != nil {
+		parser.PrintError(console.Debugger.Interpreter.Stderr, command_error)
+	}
+}
+
+/*
+Add a breakpoint or, without one, list the breakpoints. Parameters include
+the breakpoint. Returns nothing.
+*/
+func (console *Console) Break(breakpoint string) {
+	output := console.Debugger.Interpreter.Stderr
+	if breakpoint != "" {
+		console.Report(console.Debugger.AddBreakpoint(breakpoint))
+		return
+	}
+	breakpoints := console.Debugger.Breakpoints()
+	if len(breakpoints) == 0 {
+		fmt.Fprintln(output, "There are no breakpoints.")
+	}
+	for _, breakpoint := range breakpoints {
+		fmt.Fprintln(output, "   "+utils.ColouriseMagenta(breakpoint))
+	}
+}
+
+/*
+Print a variable or, without a name, every variable bar the reserved
+variables. Parameters include the name of the variable. Returns nothing.
+*/
+func (console *Console) Print(name string) {
+	output := console.Debugger.Interpreter.Stderr
+	variables := console.Debugger.Interpreter.Variables
+	name = strings.TrimPrefix(name, parser.SYMBOL_VARIABLE_SUBSTITUTION)
+	if name != "" {
+		value, exists := variables[name]
+		if !exists {
+			console.Report(parser.ReportSimple(
+				"There is no variable called " +
+					utils.ColouriseYellow(name) + ".",
+			).WithCategory(parser.ERROR_USAGE))
+			return
+		}
+		fmt.Fprintf(output, "%s = %s\n", utils.ColouriseMagenta(name),
+			utils.ColouriseGreen(value.Literal()))
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		if !strings.HasPrefix(name, parser.SYMBOL_RESERVED_VARIABLE_PREFIX) {
+			fmt.Fprintf(output, "%s = %s\n", utils.ColouriseMagenta(name),
+				utils.ColouriseGreen(variables[name].Literal()))
+		}
+	}
+}
+
+/*
+Set a variable. The value can be quoted as it would be in a script.
+Parameters include the name and value of the variable, seperated by an
+equals sign. Returns nothing.
+*/
+func (console *Console) Set(argument string) {
+	name, value, found := strings.Cut(argument, "=")
+	if !found {
+		console.Report(parser.ReportSimple(
+			"The " + utils.ColouriseMagenta("set") + " command needs a " +
+				"variable and a value (eg. " + utils.ColouriseMagenta("set") +
+				utils.ColouriseGreen(" name = \"World\"") + ").",
+		).WithCategory(parser.ERROR_USAGE))
+		return
+	}
+	parsed_value, parse_error := ParseValue(strings.TrimSpace(value))
+	if parse_error != nil {
+		console.Report(parse_error)
+		return
+	}
+	console.Report(console.Debugger.SetVariable(
+		strings.TrimPrefix(strings.TrimSpace(name),
+			parser.SYMBOL_VARIABLE_SUBSTITUTION),
+		parsed_value))
+}
+
+/*
+Show the lines of the script around where it is paused. Parameters include
+where the script is paused. Returns nothing.
+*/
+func (console *Console) List(stop Stop) {
+	output := console.Debugger.Interpreter.Stderr
+	contents, read_error := os.ReadFile(stop.Script)
+	if read_error != nil {
+		console.Report(parser.ReportSimple(
+			"The script - " + utils.ColouriseYellow(stop.Script) +
+				" - couldn't be read.",
+		).WithErr(read_error))
+		return
+	}
+	lines := strings.Split(string(contents), "\n")
+	first := max(stop.Line-LIST_CONTEXT_LINES, 1)
+	last := min(stop.Line+LIST_CONTEXT_LINES, len(lines))
+	for line_number := first; line_number <= last; line_number++ {
+		marker := "  "
+		if line_number == stop.Line {
+			marker = "=>"
+		}
+		fmt.Fprintf(output, "%s %4d  %s\n",
+			marker, line_number, lines[line_number-1])
+	}
+}
@@ -0,0 +1,597 @@
+/*
+The debug adapter is the frontend for the -debugport flag. It speaks the Debug
+Adapter Protocol over a socket so that an editor (eg. VS Code) can set
+breakpoints, step through the script, and look at and change its variables.
+Messages are framed in the same way as those of the language server (ie. each
+starts with a Content-Length header). The script only starts once the editor
+has sent its breakpoints and there is only ever the one thread. See
+https://microsoft.github.io/debug-adapter-protocol/ for the full protocol.
+*/
+package debugger
+
+import (
+	"appetit/lsp"
+	"appetit/parser"
+	"appetit/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The id of the one thread that a script runs on
+const DAP_THREAD_ID int = 1
+
+// The references for the two scopes of variables
+const (
+	DAP_SCOPE_VARIABLES int = 1
+	DAP_SCOPE_RESERVED  int = 2
+)
+
+// How long to wait for the editor to disconnect once the script has ended
+const DAP_DISCONNECT_TIMEOUT time.Duration = time.Second
+
+/*
+The Request type houses a request from the editor. Only the fields that the
+adapter uses are included.
+*/
+type Request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+/*
+The Response type houses a response to a request. The message is only set
+where the request failed.
+*/
+type Response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+/*
+The Event type houses an event that is sent to the editor (eg. that the
+script has stopped).
+*/
+type Event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+/*
+The Adapter type houses the state of the debug adapter. The structure of the
+adapter is as follows:
+  - Debugger [*Debugger]: the debugger that the adapter drives
+*/
+type Adapter struct {
+	Debugger *Debugger
+	reader   *bufio.Reader
+	writer   io.Writer
+	// Hold the mutex while writing so that messages aren't interleaved
+	write_mutex sync.Mutex
+	sequence    int
+	/*
+		Whether the script is paused, waiting on what to do next, and whether
+		it has been asked to stop
+	*/
+	pause_mutex sync.Mutex
+	paused      bool
+	quitting    bool
+	resume      chan Action
+	// Closed once the editor has sent its breakpoints
+	configured      chan struct{}
+	configured_once sync.Once
+	// Closed once there is nothing more to read from the editor
+	disconnected chan struct{}
+}
+
+/*
+The output_writer type sends what is written to it to the editor as output
+events, as well as writing it to where it would otherwise have gone.
+*/
+type output_writer struct {
+	adapter  *Adapter
+	category string
+	writer   io.Writer
+}
+
+/*
+Send output to the editor. Parameters include the output. Returns how much of
+the output was written and an error if it couldn't be.
+*/
+func (output output_writer) Write(bytes []byte) (int, error) {
+	output.adapter.Event("output", map[string]string{
+		"category": output.category,
+		"output":   string(bytes),
+	})
+	return output.writer.Write(bytes)
+}
+
+/*
+Listen on an address for an editor and debug a script for it. Parameters
+include the interpreter, the address (eg. 127.0.0.1:4711), and a function that
+runs the script. Returns an error if the address couldn't be listened on or
+the script failed.
+*/
+func Listen(
+	interpreter *parser.Interpreter,
+	address string,
+	run func() error,
+) error {
+	listener, listen_error := net.Listen("tcp", address)
+	if listen_error != nil {
+		return parser.ReportSimple(
+			"The debugger couldn't listen on " +
+				utils.ColouriseYellow(address) + ".",
+		).WithErr(listen_error)
+	}
+	fmt.Fprintln(interpreter.Stderr, ":: "+utils.ColouriseBlue("Waiting")+
+		" for a debugger on "+utils.ColouriseMagenta(
+		listener.Addr().String())+"...")
+	// Only the one editor is debugged so stop listening once it connects
+	connection, accept_error := listener.Accept()
+	listener.Close()
+	if accept_error != nil {
+		return parser.ReportSimple(
+			"The debugger couldn't accept a connection.",
+		).WithErr(accept_error)
+	}
+	defer connection.Close()
+	return NewAdapter(interpreter, connection, connection).Serve(run)
+}
+
+/*
+Create a debug adapter and attach it to an interpreter. The output of the
+script is sent to the editor as well. Parameters include the interpreter and
+where to read requests from and write messages to. Returns the adapter.
+*/
+func NewAdapter(
+	interpreter *parser.Interpreter,
+	reader io.Reader,
+	writer io.Writer,
+) *Adapter {
+	adapter := &Adapter{
+		reader:       bufio.NewReader(reader),
+		writer:       writer,
+		resume:       make(chan Action, 1),
+		configured:   make(chan struct{}),
+		disconnected: make(chan struct{}),
+	}
+	adapter.Debugger = New(interpreter, adapter)
+	interpreter.Stdout = output_writer{adapter, "stdout", interpreter.Stdout}
+	return adapter
+}
+
+/*
+Handle requests from the editor and run the script once the editor is ready.
+Parameters include a function that runs the script. Returns an error if the
+script failed.
+*/
+func (adapter *Adapter) Serve(run func() error) error {
+	go adapter.ReadRequests()
+	// Wait for the breakpoints, unless the editor leaves first
+	select {
+	case <-adapter.configured:
+	case <-adapter.disconnected:
+		return nil
+	}
+
+	run_error := run()
+	exit_code := 0
+	if run_error != nil {
+		exit_code = parser.ExitCode(run_error)
+		adapter.Event("output", map[string]string{
+			"category": "stderr",
+			"output":   run_error.Error() + "\n",
+		})
+	}
+	adapter.Event("exited", map[string]int{"exitCode": exit_code})
+	adapter.Event("terminated", nil)
+
+	// Give the editor a moment to disconnect
+	select {
+	case <-adapter.disconnected:
+	case <-time.After(DAP_DISCONNECT_TIMEOUT):
+	}
+	return run_error
+}
+
+/*
+Read and handle requests until the editor disconnects or there is nothing
+more to read, in which case the script is stopped. No parameters. Returns
+nothing.
+*/
+func (adapter *Adapter) ReadRequests() {
+	defer close(adapter.disconnected)
+	defer adapter.Quit()
+	for {
+		content, read_error := lsp.ReadContent(adapter.reader)
+		if read_error != nil {
+			return
+		}
+		request := &Request{}
+		if json.Unmarshal(content, request) != nil ||
+			request.Type != "request" {
+			continue
+		}
+		if !adapter.Handle(request) {
+			return
+		}
+	}
+}
+
+/*
+Handle a request. Parameters include the request. Returns false once the
+editor has disconnected.
+*/
+func (adapter *Adapter) Handle(request *Request) bool {
+	debugger := adapter.Debugger
+	switch request.Command {
+	case "initialize":
+		adapter.Respond(request, map[string]bool{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsFunctionBreakpoints":      true,
+			"supportsSetVariable":              true,
+			"supportsTerminateRequest":         true,
+		})
+		adapter.Event("initialized", nil)
+	case "launch", "attach":
+		var arguments struct {
+			StopOnEntry bool `json:"stopOnEntry"`
+		}
+		json.Unmarshal(request.Arguments, &arguments)
+		debugger.SetStopOnEntry(arguments.StopOnEntry)
+		adapter.Respond(request, nil)
+	case "setBreakpoints":
+		adapter.SetBreakpoints(request)
+	case "setFunctionBreakpoints":
+		adapter.SetFunctionBreakpoints(request)
+	case "setExceptionBreakpoints":
+		adapter.Respond(request, map[string][]any{"breakpoints": {}})
+	case "configurationDone":
+		adapter.configured_once.Do(func() { close(adapter.configured) })
+		adapter.Respond(request, nil)
+	case "threads":
+		adapter.Respond(request, map[string]any{
+			"threads": []map[string]any{
+				{"id": DAP_THREAD_ID, "name": "script"},
+			},
+		})
+	case "stackTrace":
+		adapter.StackTrace(request)
+	case "scopes":
+		adapter.Respond(request, map[string]any{
+			"scopes": []map[string]any{
+				{"name": "Variables", "expensive": false,
+					"variablesReference": DAP_SCOPE_VARIABLES},
+				{"name": "Reserved", "expensive": false,
+					"variablesReference": DAP_SCOPE_RESERVED},
+			},
+		})
+	case "variables":
+		adapter.Variables(request)
+	case "setVariable":
+		adapter.SetVariable(request)
+	case "evaluate":
+		adapter.Evaluate(request)
+	case "continue":
+		adapter.Respond(request, map[string]bool{"allThreadsContinued": true})
+		adapter.Resume(ACTION_CONTINUE)
+	case "next":
+		adapter.Respond(request, nil)
+		adapter.Resume(ACTION_NEXT)
+	case "stepIn":
+		adapter.Respond(request, nil)
+		adapter.Resume(ACTION_STEP)
+	case "stepOut":
+		adapter.Respond(request, nil)
+		adapter.Resume(ACTION_OUT)
+	case "pause":
+		debugger.RequestPause()
+		adapter.Respond(request, nil)
+	case "terminate":
+		adapter.Quit()
+		adapter.Respond(request, nil)
+	case "disconnect":
+		adapter.Quit()
+		adapter.Respond(request, nil)
+		return false
+	default:
+		adapter.Fail(request, "The request - "+request.Command+
+			" - isn't supported.")
+	}
+	return true
+}
+
+/*
+Set the line breakpoints for a script. Parameters include the request.
+Returns nothing.
+*/
+func (adapter *Adapter) SetBreakpoints(request *Request) {
+	var arguments struct {
+		Source struct {
+			Path string `json:"path"`
+		} `json:"source"`
+		Breakpoints []struct {
+			Line int `json:"line"`
+		} `json:"breakpoints"`
+	}
+	json.Unmarshal(request.Arguments, &arguments)
+	var lines []int
+	breakpoints := []map[string]any{}
+	for _, breakpoint := range arguments.Breakpoints {
+		lines = append(lines, breakpoint.Line)
+		breakpoints = append(breakpoints, map[string]any{
+			"verified": breakpoint.Line > 0,
+			"line":     breakpoint.Line,
+		})
+	}
+	if arguments.Source.Path != "" {
+		adapter.Debugger.SetLineBreakpoints(arguments.Source.Path, lines)
+	}
+	adapter.Respond(request, map[string]any{"breakpoints": breakpoints})
+}
+
+/*
+Set the statement breakpoints, which the protocol calls function breakpoints.
+Those that aren't statements aren't verified. Parameters include the request.
+Returns nothing.
+*/
+func (adapter *Adapter) SetFunctionBreakpoints(request *Request) {
+	var arguments struct {
+		Breakpoints []struct {
+			Name string `json:"name"`
+		} `json:"breakpoints"`
+	}
+	json.Unmarshal(request.Arguments, &arguments)
+	var statement_names []string
+	breakpoints := []map[string]any{}
+	for _, breakpoint := range arguments.Breakpoints {
+		is_statement := slices.Contains(
+			adapter.Debugger.Interpreter.StatementNames, breakpoint.Name)
+		if is_statement {
+			statement_names = append(statement_names, breakpoint.Name)
+		}
+		breakpoints = append(breakpoints,
+			map[string]any{"verified": is_statement})
+	}
+	adapter.Debugger.SetStatementBreakpoints(statement_names)
+	adapter.Respond(request, map[string]any{"breakpoints": breakpoints})
+}
+
+/*
+Send where each script that is being executed is up to, innermost first.
+Parameters include the request. Returns nothing.
+*/
+func (adapter *Adapter) StackTrace(request *Request) {
+	frames := []map[string]any{}
+	for _, stop := range slices.Backward(adapter.Debugger.Stack()) {
+		frames = append(frames, map[string]any{
+			"id":   stop.Depth,
+			"name": filepath.Base(stop.Script),
+			"source": map[string]string{
+				"name": filepath.Base(stop.Script),
+				"path": stop.Script,
+			},
+			"line":   stop.Line,
+			"column": 1,
+		})
+	}
+	adapter.Respond(request, map[string]any{
+		"stackFrames": frames,
+		"totalFrames": len(frames),
+	})
+}
+
+/*
+Send the variables in a scope. The variables can only be looked at while the
+script is paused. Parameters include the request. Returns nothing.
+*/
+func (adapter *Adapter) Variables(request *Request) {
+	var arguments struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	json.Unmarshal(request.Arguments, &arguments)
+	variables := []map[string]any{}
+	if adapter.IsPaused() {
+		all_variables := adapter.Debugger.Interpreter.Variables
+		for _, name := range slices.Sorted(maps.Keys(all_variables)) {
+			is_reserved := strings.HasPrefix(
+				name, parser.SYMBOL_RESERVED_VARIABLE_PREFIX)
+			if is_reserved !=
+				(arguments.VariablesReference == DAP_SCOPE_RESERVED) {
+				continue
+			}
+			variables = append(variables, map[string]any{
+				"name":               name,
+				"value":              all_variables[name].Literal(),
+				"type":               all_variables[name].Type.String(),
+				"variablesReference": 0,
+			})
+		}
+	}
+	adapter.Respond(request, map[string]any{"variables": variables})
+}
+
+/*
+Set a variable while the script is paused. Parameters include the request.
+Returns nothing.
+*/
+func (adapter *Adapter) SetVariable(request *Request) {
+	var arguments struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	json.Unmarshal(request.Arguments, &arguments)
+	if !adapter.IsPaused() {
+		adapter.Fail(request, "Variables can only be set while the script "+
+			"is paused.")
+		return
+	}
+	value, set_error := ParseValue(arguments.Value)
+	if set_error == nil {
+		set_error = adapter.Debugger.SetVariable(arguments.Name, value)
+	}
+	if set_error != nil {
+		adapter.Fail(request, set_error.Error())
+		return
+	}
+	adapter.Respond(request, map[string]string{
+		"value": value.Literal(),
+		"type":  value.Type.String(),
+	})
+}
+
+/*
+Evaluate an expression while the script is paused, which is either the name
+of a variable or a string whose variables are replaced. Parameters include
+the request. Returns nothing.
+*/
+func (adapter *Adapter) Evaluate(request *Request) {
+	var arguments struct {
+		Expression string `json:"expression"`
+	}
+	json.Unmarshal(request.Arguments, &arguments)
+	if !adapter.IsPaused() {
+		adapter.Fail(request, "Expressions can only be evaluated while the "+
+			"script is paused.")
+		return
+	}
+	interpreter := adapter.Debugger.Interpreter
+	value, exists := interpreter.Variables[strings.TrimPrefix(
+		arguments.Expression, parser.SYMBOL_VARIABLE_SUBSTITUTION)]
+	result := value.Literal()
+	if !exists {
+		result = interpreter.VariableTemplater(
+			parser.FixStringCombined(arguments.Expression))
+	}
+	adapter.Respond(request, map[string]any{
+		"result":             result,
+		"variablesReference": 0,
+	})
+}
+
+/*
+Tell the editor that the script is paused and wait for it to say what to do
+next. Parameters include where the script is paused. Returns what to do next.
+*/
+func (adapter *Adapter) Pause(stop Stop) Action {
+	adapter.pause_mutex.Lock()
+	// The editor may have left just before the script paused
+	if adapter.quitting {
+		adapter.pause_mutex.Unlock()
+		return ACTION_QUIT
+	}
+	adapter.paused = true
+	adapter.pause_mutex.Unlock()
+	adapter.Event("stopped", map[string]any{
+		"reason":            stop.Reason,
+		"threadId":          DAP_THREAD_ID,
+		"allThreadsStopped": true,
+	})
+	return <-adapter.resume
+}
+
+/*
+Check whether the script is paused. No parameters. Returns true if it is.
+*/
+func (adapter *Adapter) IsPaused() bool {
+	adapter.pause_mutex.Lock()
+	defer adapter.pause_mutex.Unlock()
+	return adapter.paused
+}
+
+/*
+Carry on from a pause. This does nothing if the script isn't paused.
+Parameters include what to do next. Returns nothing.
+*/
+func (adapter *Adapter) Resume(action Action) {
+	adapter.pause_mutex.Lock()
+	defer adapter.pause_mutex.Unlock()
+	if adapter.paused {
+		adapter.paused = false
+		adapter.resume <- action
+	}
+}
+
+/*
+Stop the script, whether it is paused or running. No parameters. Returns
+nothing.
+*/
+func (adapter *Adapter) Quit() {
+	adapter.Debugger.RequestQuit()
+	adapter.pause_mutex.Lock()
+	adapter.quitting = true
+	adapter.pause_mutex.Unlock()
+	adapter.Resume(ACTION_QUIT)
+}
+
+/*
+Respond to a request that succeeded. Parameters include the request and the
+body of the response, which can be nil. Returns nothing.
+*/
+func (adapter *Adapter) Respond(request *Request, body any) {
+	adapter.Send(&Response{
+		Type:       "response",
+		RequestSeq: request.Seq,
+		Success:    true,
+		Command:    request.Command,
+		Body:       body,
+	})
+}
+
+/*
+Respond to a request that failed. Parameters include the request and why it
+failed. Returns nothing.
+*/
+func (adapter *Adapter) Fail(request *Request, message string) {
+	adapter.Send(&Response{
+		Type:       "response",
+		RequestSeq: request.Seq,
+		Command:    request.Command,
+		Message:    message,
+	})
+}
+
+/*
+Send an event. Parameters include the name of the event and its body, which
+can be nil. Returns nothing.
+*/
+func (adapter *Adapter) Event(event string, body any) {
+	adapter.Send(&Event{Type: "event", Event: event, Body: body})
+}
+
+/*
+Send a response or event, numbering it. Any error is ignored as the editor
+has gone where a message can't be sent. Parameters include the message.
+Returns nothing.
+*/
+func (adapter *Adapter) Send(message any) {
+	adapter.write_mutex.Lock()
+	defer adapter.write_mutex.Unlock()
+	adapter.sequence += 1
+	switch message := message.(type) {
+	case *Response:
+		message.Seq = adapter.sequence
+	case *Event:
+		message.Seq = adapter.sequence
+	}
+	lsp.WriteMessage(adapter.writer, message)
+}
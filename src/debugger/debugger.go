@@ -0,0 +1,449 @@
+/*
+The debugger pauses a script before its statements so that the script can be
+stepped through. It is told about each statement by the interpreter (see the
+Debugger interface in the parser package) and decides whether to pause there,
+which it does where it is stepping, where there is a breakpoint for the line
+or the statement, or where a pause was asked for. While paused, a frontend
+decides what to do next. There are two frontends:
+  - the console (see console.go), which is used with the -debug flag and
+    reads commands from standard input
+  - the debug adapter (see dap.go), which is used with the -debugport flag
+    and lets an editor (eg. VS Code) drive the debugger over a socket
+*/
+package debugger
+
+import (
+	"appetit/parser"
+	"appetit/utils"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// What to do once the script has been paused
+type Action int
+
+const (
+	// Run until a breakpoint
+	ACTION_CONTINUE Action = iota
+	// Pause at the next statement, even inside of a procedure or run target
+	ACTION_STEP
+	// Pause at the next statement in the same script or one it returns to
+	ACTION_NEXT
+	// Pause once the procedure or run target has been returned from
+	ACTION_OUT
+	// Stop the script
+	ACTION_QUIT
+)
+
+// Why the script was paused
+const (
+	REASON_BREAKPOINT string = "breakpoint"
+	REASON_ENTRY      string = "entry"
+	REASON_PAUSE      string = "pause"
+	REASON_STEP       string = "step"
+)
+
+/*
+The Stop type houses where the script is paused (or, on the stack, where each
+script that is being executed is up to). The structure of the stop is as
+follows:
+  - Reason [string]: why the script was paused (eg. REASON_BREAKPOINT)
+  - Statement [parser.Statement]: the statement that is about to be executed
+  - Script [string]: the full path to the script that the statement is in
+  - Line [int]: the line of the statement
+  - Depth [int]: how many scripts deep the statement is, 1 being the script
+    itself
+*/
+type Stop struct {
+	Reason    string
+	Statement parser.Statement
+	Script    string
+	Line      int
+	Depth     int
+}
+
+/*
+The Frontend interface is for what decides what to do once the script is
+paused (eg. the console). Pause() is called with where the script is paused
+and returns what to do next.
+*/
+type Frontend interface {
+	Pause(stop Stop) Action
+}
+
+/*
+The Debugger type houses the state of the debugger. It is safe to use from
+more than one goroutine as the debug adapter sets breakpoints while the script
+is running. The structure of the debugger is as follows:
+  - Interpreter [*parser.Interpreter]: the interpreter running the script
+  - Frontend [Frontend]: what decides what to do when the script is paused
+*/
+type Debugger struct {
+	Interpreter *parser.Interpreter
+	Frontend    Frontend
+	mutex       sync.Mutex
+	// Whether the script has been paused yet, the first pause being the entry
+	started bool
+	// What was last asked for and how many scripts deep it was asked for
+	action       Action
+	action_depth int
+	// Whether the script should pause at the next statement regardless
+	pause_requested bool
+	/*
+		The lines to pause at in each script (by the full path to the script)
+		where a script of "" is any script, and the statement names to pause at
+	*/
+	line_breakpoints      map[string][]int
+	statement_breakpoints []string
+	// Where each script that is being executed is up to, outermost first
+	stack []Stop
+}
+
+/*
+Create a debugger and attach it to an interpreter. The debugger starts by
+stepping so that the script pauses before its first statement. Parameters
+include the interpreter and the frontend. Returns the debugger.
+*/
+func New(interpreter *parser.Interpreter, frontend Frontend) *Debugger {
+	debugger := &Debugger{
+		Interpreter:      interpreter,
+		Frontend:         frontend,
+		action:           ACTION_STEP,
+		line_breakpoints: map[string][]int{},
+	}
+	interpreter.Debugger = debugger
+	return debugger
+}
+
+/*
+Decide whether to pause before a statement and, if so, ask the frontend what
+to do. This is called by the interpreter before each statement. Parameters
+include the statement, the script that it is in, and how many scripts deep it
+is. Returns parser.ErrScriptExit if the script should be stopped.
+*/
+func (debugger *Debugger) BeforeStatement(
+	statement parser.Statement,
+	script *parser.Script,
+	depth int,
+) error {
+	// Stop straight away if the script was asked to quit while it was running
+	debugger.mutex.Lock()
+	quitting := debugger.action == ACTION_QUIT
+	debugger.mutex.Unlock()
+	if quitting {
+		return parser.ErrScriptExit
+	}
+
+	script_name, _ := filepath.Abs(script.Name)
+	stop := Stop{
+		Statement: statement,
+		Script:    script_name,
+		Line:      statement.Tokens[0].LineNumber,
+		Depth:     depth,
+	}
+
+	debugger.mutex.Lock()
+	// Note where this script is up to, dropping any scripts that have ended
+	debugger.stack = append(
+		debugger.stack[:min(depth-1, len(debugger.stack))], stop)
+	stop.Reason = debugger.StopReason(stop)
+	debugger.mutex.Unlock()
+	if stop.Reason == "" {
+		return nil
+	}
+
+	// Ask the frontend what to do next and hold onto it for the next statement
+	action := debugger.Frontend.Pause(stop)
+	debugger.mutex.Lock()
+	debugger.action = action
+	debugger.action_depth = depth
+	debugger.mutex.Unlock()
+	if action == ACTION_QUIT {
+		return parser.ErrScriptExit
+	}
+	return nil
+}
+
+/*
+Work out why to pause at a statement. The mutex must be held. Parameters
+include where the statement is. Returns the reason or "" if the script
+shouldn't pause.
+*/
+func (debugger *Debugger) StopReason(stop Stop) string {
+	switch {
+	case debugger.pause_requested:
+		debugger.pause_requested = false
+		return REASON_PAUSE
+	case !debugger.started && debugger.action == ACTION_STEP:
+		debugger.started = true
+		return REASON_ENTRY
+	case slices.Contains(debugger.line_breakpoints[stop.Script], stop.Line),
+		slices.Contains(debugger.line_breakpoints[""], stop.Line),
+		slices.Contains(debugger.statement_breakpoints, stop.Statement.Name):
+		return REASON_BREAKPOINT
+	case debugger.action == ACTION_STEP,
+		debugger.action == ACTION_NEXT && stop.Depth <= debugger.action_depth,
+		debugger.action == ACTION_OUT && stop.Depth < debugger.action_depth:
+		return REASON_STEP
+	}
+	return ""
+}
+
+/*
+Set whether the script pauses before its first statement, which it does by
+default. Parameters include whether to pause. Returns nothing.
+*/
+func (debugger *Debugger) SetStopOnEntry(stop_on_entry bool) {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.action = ACTION_CONTINUE
+	if stop_on_entry {
+		debugger.action = ACTION_STEP
+	}
+}
+
+/*
+Ask the script to stop at the next statement. No parameters. Returns nothing.
+*/
+func (debugger *Debugger) RequestQuit() {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.action = ACTION_QUIT
+}
+
+/*
+Ask the script to pause at the next statement. No parameters. Returns
+nothing.
+*/
+func (debugger *Debugger) RequestPause() {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.pause_requested = true
+}
+
+/*
+Get where each script that is being executed is up to. No parameters. Returns
+a copy of the stack, outermost first.
+*/
+func (debugger *Debugger) Stack() []Stop {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	return slices.Clone(debugger.stack)
+}
+
+/*
+Set the line breakpoints for a script, replacing any that it had. Parameters
+include the script, "" for any script, and the lines. Returns nothing.
+*/
+func (debugger *Debugger) SetLineBreakpoints(script string, lines []int) {
+	if script != "" {
+		script, _ = filepath.Abs(script)
+	}
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	if len(lines) == 0 {
+		delete(debugger.line_breakpoints, script)
+		return
+	}
+	debugger.line_breakpoints[script] = slices.Clone(lines)
+}
+
+/*
+Set the statement breakpoints, replacing any that there were. Parameters
+include the names of the statements. Returns nothing.
+*/
+func (debugger *Debugger) SetStatementBreakpoints(statement_names []string) {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.statement_breakpoints = slices.Clone(statement_names)
+}
+
+/*
+Add a breakpoint. A breakpoint is a line number (for any script), a script and
+line number seperated by a colon, or the name of a statement. Parameters
+include the breakpoint. Returns an error if the breakpoint isn't valid.
+*/
+func (debugger *Debugger) AddBreakpoint(breakpoint string) error {
+	script, line, is_line, parse_error := debugger.ParseBreakpoint(breakpoint)
+	if parse_error != nil {
+		return parse_error
+	}
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	if is_line {
+		if !slices.Contains(debugger.line_breakpoints[script], line) {
+			debugger.line_breakpoints[script] = append(
+				debugger.line_breakpoints[script], line)
+		}
+	} else if !slices.Contains(debugger.statement_breakpoints, breakpoint) {
+		debugger.statement_breakpoints = append(
+			debugger.statement_breakpoints, breakpoint)
+	}
+	return nil
+}
+
+/*
+Remove a breakpoint that was added with AddBreakpoint(). Parameters include
+the breakpoint. Returns an error if the breakpoint isn't valid.
+*/
+func (debugger *Debugger) RemoveBreakpoint(breakpoint string) error {
+	script, line, is_line, parse_error := debugger.ParseBreakpoint(breakpoint)
+	if parse_error != nil {
+		return parse_error
+	}
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	if is_line {
+		debugger.line_breakpoints[script] = slices.DeleteFunc(
+			debugger.line_breakpoints[script],
+			func(existing int) bool { return existing == line })
+	} else {
+		debugger.statement_breakpoints = slices.DeleteFunc(
+			debugger.statement_breakpoints,
+			func(existing string) bool { return existing == breakpoint })
+	}
+	return nil
+}
+
+/*
+Get the breakpoints in the form that AddBreakpoint() takes them. No
+parameters. Returns the breakpoints, lines first.
+*/
+func (debugger *Debugger) Breakpoints() []string {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	var breakpoints []string
+	for _, script := range slices.Sorted(
+		maps.Keys(debugger.line_breakpoints)) {
+		for _, line := range slices.Sorted(
+			slices.Values(debugger.line_breakpoints[script])) {
+			if script == "" {
+				breakpoints = append(breakpoints, strconv.Itoa(line))
+			} else {
+				breakpoints = append(breakpoints,
+					script+":"+strconv.Itoa(line))
+			}
+		}
+	}
+	return append(breakpoints, debugger.statement_breakpoints...)
+}
+
+/*
+Parse a breakpoint. Parameters include the breakpoint. Returns the full path
+to the script ("" for any script), the line, whether the breakpoint is for a
+line rather than a statement, and an error if it is neither.
+*/
+func (debugger *Debugger) ParseBreakpoint(
+	breakpoint string,
+) (string, int, bool, error) {
+	// A line on its own is for any script
+	if line, line_error := strconv.Atoi(breakpoint); line_error == nil {
+		return "", line, true, CheckLine(breakpoint, line)
+	}
+	// Otherwise, a line can follow the script
+	if separator := strings.LastIndex(breakpoint, ":"); separator > 0 {
+		line, line_error := strconv.Atoi(breakpoint[separator+1:])
+		if line_error == nil {
+			script, _ := filepath.Abs(breakpoint[:separator])
+			return script, line, true, CheckLine(breakpoint, line)
+		}
+	}
+	// Failing that, it must be a statement
+	if !slices.Contains(debugger.Interpreter.StatementNames, breakpoint) {
+		return "", 0, false, parser.ReportSimple(
+			"The breakpoint - " + utils.ColouriseYellow(breakpoint) +
+				" - isn't a line number, " +
+				"a script and line number (eg. script.apt:12), or a " +
+				"statement.",
+		).WithCategory(parser.ERROR_USAGE)
+	}
+	return "", 0, false, nil
+}
+
+/*
+Check that the line of a breakpoint could be a line. Parameters include the
+breakpoint and its line. Returns an error if the line is less than 1.
+*/
+func CheckLine(breakpoint string, line int) error {
+	if line < 1 {
+		return parser.ReportSimple(
+			"The breakpoint - " + utils.ColouriseYellow(breakpoint) +
+				" - needs a line number " +
+				"of 1 or more.",
+		).WithCategory(parser.ERROR_USAGE)
+	}
+	return nil
+}
+
+/*
+Get the arguments of a statement as they will be used, that is, with the
+variables in its strings replaced. Parameters include the interpreter and the
+statement. Returns the arguments, each string being double quoted.
+*/
+func Arguments(
+	interpreter *parser.Interpreter,
+	statement parser.Statement,
+) []string {
+	var arguments []string
+	// Skip the line token and the name of the statement
+	for _, token := range statement.Tokens[min(2, len(statement.Tokens)):] {
+		argument := token.TokenValue
+		if strings.IndexAny(argument, "\"'`") == 0 {
+			argument = strconv.Quote(interpreter.VariableTemplater(
+				parser.FixStringCombined(argument)))
+		}
+		arguments = append(arguments, argument)
+	}
+	return arguments
+}
+
+/*
+Read a value as it is written in a set statement. A quoted value is a string
+and anything else is calculated (eg. 42 is an integer). Parameters include
+the value as it is written. Returns the value and an error if it is an
+expression that can't be calculated.
+*/
+func ParseValue(written string) (parser.Value, error) {
+	if strings.IndexAny(written, "\"'`") == 0 {
+		return parser.StringValue(parser.FixStringCombined(written)), nil
+	}
+	value, calculate_error := parser.CalculateValue(written, nil)
+	if calculate_error != nil {
+		return value, parser.ReportSimple(
+			"The value " + utils.ColouriseGreen(written) + " can't be " +
+				"calculated. " + calculate_error.Error(),
+		).WithCategory(parser.ERROR_USAGE).WithErr(calculate_error)
+	}
+	return value, nil
+}
+
+/*
+Set a variable while the script is paused. Reserved variables can't be set as
+the interpreter sets those itself. Parameters include the name of the
+variable and its value. Returns an error if the variable is reserved or the
+name isn't valid.
+*/
+func (debugger *Debugger) SetVariable(name string, value parser.Value) error {
+	is_invalid := func(character rune) bool {
+		return !parser.IsNameCharacter(character)
+	}
+	if name == "" || strings.IndexFunc(name, is_invalid) >= 0 {
+		return parser.ReportSimple(
+			"The variable name - " + utils.ColouriseYellow(name) +
+				" - isn't valid.",
+		).WithCategory(parser.ERROR_USAGE)
+	}
+	if strings.HasPrefix(name, parser.SYMBOL_RESERVED_VARIABLE_PREFIX) {
+		return parser.ReportSimple(
+			"The variable - " + utils.ColouriseYellow(name) +
+				" - is a reserved variable and " +
+				"can't be set.",
+		).WithCategory(parser.ERROR_USAGE)
+	}
+	debugger.Interpreter.Variables[name] = value
+	return nil
+}
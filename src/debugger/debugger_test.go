@@ -0,0 +1,289 @@
+package debugger
+
+import (
+	"appetit/lsp"
+	"appetit/parser"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+Write a script, and a script that it runs, to a temporary directory.
+Parameters include the test. Returns the full path to the script.
+*/
+func WriteScripts(t *testing.T) string {
+	directory := t.TempDir()
+	scripts := map[string]string{
+		"main.apt": "minver 1\n" +
+			"set name = \"World\"\n" +
+			"set greeting = \"Hello\"\n" +
+			"define \"greet\"\n" +
+			"    writeln \"#greeting #name\"\n" +
+			"end\n" +
+			"call \"greet\"\n" +
+			"run \"" + filepath.Join(directory, "inner.apt") + "\"\n" +
+			"writeln \"done\"\n",
+		"inner.apt": "minver 1\nwriteln \"inner #name\"\n",
+	}
+	for name, contents := range scripts {
+		write_error := os.WriteFile(
+			filepath.Join(directory, name), []byte(contents), 0644)
+		if write_error != nil {
+			t.Fatalf("[scripts] Couldn't write %s: %v", name, write_error)
+		}
+	}
+	return filepath.Join(directory, "main.apt")
+}
+
+/*
+Check to make sure that the console pauses before the first statement, steps
+over and into procedures, stops at breakpoints, and changes variables.
+*/
+func TestConsole(t *testing.T) {
+	script := WriteScripts(t)
+	var output bytes.Buffer
+	var debugger_output bytes.Buffer
+	interpreter := parser.New(parser.Options{
+		Stdout: &output,
+		Stderr: &debugger_output,
+		Stdin: strings.NewReader(
+			// Step to the second set and change the name
+			"n\nn\nset name = \"Moon\"\n" +
+				// Break inside of the run target and step into the procedure
+				"b " + filepath.Join(filepath.Dir(script), "inner.apt") +
+				":2\nb 5\nb bogus\nn\nn\nn\ns\n" +
+				// Print the variable in the procedure and carry on
+				"p greeting\nc\nbt\nq\n",
+		),
+	})
+	NewConsole(interpreter)
+	run_error := interpreter.RunFile(script)
+	if run_error != nil {
+		t.Fatalf("[console] Expected no error, got %v", run_error)
+	}
+
+	// The procedure ran with the new name but the script quit before done
+	if output.String() != "Hello Moon\n" {
+		t.Errorf("[console] Expected %q, got %q", "Hello Moon\n",
+			output.String())
+	}
+	for _, expected := range []string{
+		"(entry)",
+		"main.apt:3",
+		"greeting = \"Hello\"",
+		"main.apt:5",
+		"\"Hello Moon\"",
+		"bogus",
+		"inner.apt:2",
+		"(breakpoint)",
+	} {
+		if !strings.Contains(debugger_output.String(), expected) {
+			t.Errorf("[console] Expected %q in the output, got %q",
+				expected,
+				debugger_output.String())
+		}
+	}
+}
+
+/*
+Check to make sure that breakpoints are parsed as lines, scripts and lines, or
+statements and that anything else is an error.
+*/
+func TestBreakpoints(t *testing.T) {
+	debugger := New(parser.New(parser.Options{}), nil)
+	for _, breakpoint := range []string{"12", "script.apt:3", "writeln"} {
+		if add_error := debugger.AddBreakpoint(breakpoint); add_error != nil {
+			t.Errorf("[break] Expected %q to be added, got %v",
+				breakpoint,
+				add_error)
+		}
+	}
+	for _, breakpoint := range []string{"0", "bogus", "script.apt:-1"} {
+		if debugger.AddBreakpoint(breakpoint) == nil {
+			t.Errorf("[break] Expected an error for %q", breakpoint)
+		}
+	}
+	debugger.RemoveBreakpoint("writeln")
+	breakpoints := debugger.Breakpoints()
+	if len(breakpoints) != 2 || breakpoints[0] != "12" ||
+		!strings.HasSuffix(breakpoints[1], "script.apt:3") {
+		t.Errorf("[break] Expected 12 and script.apt:3, got %v", breakpoints)
+	}
+}
+
+/*
+Send requests to a debug adapter and read what it sends back. Parameters
+include the test and the adapter's end of the connection. Returns a function
+to send a request and one to wait for a message, the latter taking a
+function that picks out the message.
+*/
+func Connect(t *testing.T, connection net.Conn) (
+	func(string, any),
+	func(func(map[string]any) bool) map[string]any,
+) {
+	messages := make(chan map[string]any, 100)
+	go func() {
+		reader := bufio.NewReader(connection)
+		for {
+			content, read_error := lsp.ReadContent(reader)
+			if read_error != nil {
+				close(messages)
+				return
+			}
+			message := map[string]any{}
+			json.Unmarshal(content, &message)
+			messages <- message
+		}
+	}()
+	sequence := 0
+	send := func(command string, arguments any) {
+		sequence += 1
+		lsp.WriteMessage(connection, map[string]any{
+			"seq":       sequence,
+			"type":      "request",
+			"command":   command,
+			"arguments": arguments,
+		})
+	}
+	wait := func(picks func(map[string]any) bool) map[string]any {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case message, open := <-messages:
+				if !open {
+					t.Fatalf("[dap] The adapter closed the connection")
+				}
+				if picks(message) {
+					return message
+				}
+			case <-timeout:
+				t.Fatalf("[dap] Timed out waiting for a message")
+			}
+		}
+	}
+	return send, wait
+}
+
+/*
+Pick out an event or a response to a command. Parameters include the type
+and the name of the event or command. Returns a function that picks it out.
+*/
+func Message(kind string, name string) func(map[string]any) bool {
+	return func(message map[string]any) bool {
+		return message["type"] == kind &&
+			(message["event"] == name || message["command"] == name)
+	}
+}
+
+/*
+Check to make sure that the debug adapter stops at line and statement
+breakpoints, shows the stack and variables, and lets variables be changed.
+*/
+func TestAdapter(t *testing.T) {
+	script := WriteScripts(t)
+	client, server := net.Pipe()
+	defer client.Close()
+	interpreter := parser.New(parser.Options{
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+	})
+	adapter := NewAdapter(interpreter, server, server)
+	done := make(chan error)
+	go func() {
+		done <- adapter.Serve(func() error {
+			return interpreter.RunFile(script)
+		})
+	}()
+	send, wait := Connect(t, client)
+
+	send("initialize", map[string]any{})
+	wait(Message("event", "initialized"))
+	send("launch", map[string]any{"stopOnEntry": false})
+	send("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": script},
+		"breakpoints": []map[string]any{{"line": 3}},
+	})
+	response := wait(Message("response", "setBreakpoints"))
+	if !strings.Contains(
+		EncodeJSON(response["body"]), "\"verified\":true") {
+		t.Errorf("[dap] Expected the breakpoint to be verified, got %v",
+			response)
+	}
+	send("setFunctionBreakpoints", map[string]any{
+		"breakpoints": []map[string]any{{"name": "call"}},
+	})
+	send("configurationDone", nil)
+
+	// Stop at line 3 and change the name
+	stopped := wait(Message("event", "stopped"))
+	if EncodeJSON(stopped["body"]) !=
+		`{"allThreadsStopped":true,"reason":"breakpoint","threadId":1}` {
+		t.Errorf("[dap] Expected a breakpoint, got %v", stopped)
+	}
+	send("stackTrace", map[string]any{"threadId": 1})
+	response = wait(Message("response", "stackTrace"))
+	if !strings.Contains(EncodeJSON(response["body"]), "\"line\":3") {
+		t.Errorf("[dap] Expected line 3 on the stack, got %v", response)
+	}
+	send("variables", map[string]any{"variablesReference": 1})
+	response = wait(Message("response", "variables"))
+	if EncodeJSON(response["body"]) != `{"variables":[{"name":"name",`+
+		`"type":"string","value":"\"World\"","variablesReference":0}]}` {
+		t.Errorf("[dap] Expected the name variable, got %v", response)
+	}
+	send("setVariable", map[string]any{
+		"variablesReference": 1, "name": "name", "value": "\"Moon\"",
+	})
+	wait(Message("response", "setVariable"))
+	send("continue", map[string]any{"threadId": 1})
+
+	// Stop at the call statement and step into the procedure
+	wait(Message("event", "stopped"))
+	send("stepIn", map[string]any{"threadId": 1})
+	wait(Message("event", "stopped"))
+	send("stackTrace", map[string]any{"threadId": 1})
+	response = wait(Message("response", "stackTrace"))
+	if !strings.Contains(EncodeJSON(response["body"]), "\"totalFrames\":2") {
+		t.Errorf("[dap] Expected two frames, got %v", response)
+	}
+	send("evaluate", map[string]any{"expression": "#greeting #name"})
+	response = wait(Message("response", "evaluate"))
+	if EncodeJSON(response["body"]) !=
+		`{"result":"Hello Moon","variablesReference":0}` {
+		t.Errorf("[dap] Expected Hello Moon, got %v", response)
+	}
+	send("continue", map[string]any{"threadId": 1})
+
+	// The output is sent on and the script runs to the end
+	output := wait(Message("event", "output"))
+	if EncodeJSON(output["body"]) !=
+		`{"category":"stdout","output":"Hello Moon\n"}` {
+		t.Errorf("[dap] Expected the output, got %v", output)
+	}
+	exited := wait(Message("event", "exited"))
+	if EncodeJSON(exited["body"]) != `{"exitCode":0}` {
+		t.Errorf("[dap] Expected an exit code of 0, got %v", exited)
+	}
+	wait(Message("event", "terminated"))
+	send("disconnect", nil)
+	if run_error := <-done; run_error != nil {
+		t.Errorf("[dap] Expected no error, got %v", run_error)
+	}
+}
+
+/*
+Encode a value as JSON, with its keys sorted, for comparison. Parameters
+include the value. Returns the JSON.
+*/
+func EncodeJSON(value any) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,453 @@
+/*
+This houses the documents that the client has opened and the work of making
+sense of them (eg. finding the word at a position or where a variable is set).
+The documents are checked with the parser's check mode so that the server
+reports exactly what appetit -check would. The client counts the characters
+of a line in UTF-16 code units (eg. an emoji is two) so positions are turned
+into byte offsets into the line on the way in and back on the way out.
+*/
+package lsp
+
+import (
+	"appetit/parser"
+	"io"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/*
+The Document type houses a document that the client has opened. The
+structure of the document is as follows:
+  - URI [string]: the URI that the client uses for the document
+  - Path [string]: the path to the document on disk
+  - Text [string]: the full text of the document
+  - Lines [[]string]: the text split into lines
+*/
+type Document struct {
+	URI   string
+	Path  string
+	Text  string
+	Lines []string
+}
+
+/*
+Create a document. Parameters include the URI of the document and its text.
+Returns the document.
+*/
+func NewDocument(uri string, text string) *Document {
+	return &Document{
+		URI:   uri,
+		Path:  URIToPath(uri),
+		Text:  text,
+		Lines: strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n"),
+	}
+}
+
+/*
+Get the path to a file from its URI. Parameters include the URI. Returns the
+path or the URI as it is if it isn't a file URI.
+*/
+func URIToPath(uri string) string {
+	parsed_uri, parse_error := url.Parse(uri)
+	if parse_error != nil || parsed_uri.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(parsed_uri.Path)
+}
+
+/*
+Get the URI of a file from its path. Parameters include the path. Returns the
+URI.
+*/
+func PathToURI(path string) string {
+	// Use the full path where we can get it
+	if absolute_path, abs_error := filepath.Abs(path); abs_error == nil {
+		path = absolute_path
+	}
+	file_uri := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return file_uri.String()
+}
+
+/*
+Get the byte offset into a line of a character that the client sends.
+Parameters include the line and the character, in UTF-16 code units. Returns
+the byte offset, which is at most the length of the line.
+*/
+func ByteOffset(line string, character int) int {
+	units := 0
+	for offset, value := range line {
+		if units >= character {
+			return offset
+		}
+		units += utf16.RuneLen(value)
+	}
+	return len(line)
+}
+
+/*
+Get the character that the client expects for a byte offset into a line.
+Parameters include the line and the byte offset. Returns the character, in
+UTF-16 code units.
+*/
+func Character(line string, offset int) int {
+	units := 0
+	for _, value := range line[:min(max(offset, 0), len(line))] {
+		units += utf16.RuneLen(value)
+	}
+	return units
+}
+
+/*
+Get the character that the client expects for a column that the parser
+reports, which counts from one in runes. Parameters include the line and the
+column. Returns the character, in UTF-16 code units.
+*/
+func ColumnCharacter(line string, column int) int {
+	runes := []rune(line)
+	return len(utf16.Encode(runes[:min(max(column-1, 0), len(runes))]))
+}
+
+/*
+Create an interpreter to look at a document with. The interpreter is in check
+mode so nothing is ever executed and execute statements are allowed so that
+scripts that are meant to be run with -allowexec aren't flagged. No
+parameters. Returns the interpreter.
+*/
+func NewCheckInterpreter() *parser.Interpreter {
+	return parser.New(parser.Options{
+		AllowExec:   true,
+		Check:       true,
+		Diagnostics: true,
+		Stdout:      io.Discard,
+		Stdin:       strings.NewReader(""),
+		Stderr:      io.Discard,
+	})
+}
+
+/*
+Check the document for problems. Only the problems in this document are kept
+as any in the scripts that it runs are reported when those are opened.
+No parameters. Returns the diagnostics for the document.
+*/
+func (document *Document) Diagnostics() []Diagnostic {
+	// Check the document as appetit -check would
+	interpreter := NewCheckInterpreter()
+	interpreter.ScriptName = document.Path
+	run_error := interpreter.RunString(document.Text)
+
+	// Start with an empty list so that no diagnostics clears the old ones
+	diagnostics := []Diagnostic{}
+	for _, diagnostic := range parser.Diagnostics(
+		run_error, interpreter.Warnings, document.Path) {
+		if diagnostic.File != document.Path {
+			continue
+		}
+		// Lines and columns start at one for the parser and zero here
+		line := max(diagnostic.Line-1, 0)
+		character := max(diagnostic.Column-1, 0)
+		// Highlight from where the problem is to the end of the line
+		end_character := character
+		if line < len(document.Lines) {
+			text := document.Lines[line]
+			character = ColumnCharacter(text, diagnostic.Column)
+			end_character = max(Character(text, len(text)), character)
+		}
+		// Get the severity
+		severity := SEVERITY_ERROR
+		if diagnostic.Severity == "warning" {
+			severity = SEVERITY_WARNING
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range: Range{
+				Start: Position{Line: line, Character: character},
+				End:   Position{Line: line, Character: end_character},
+			},
+			Severity: severity,
+			Code:     diagnostic.RuleID,
+			Source:   "appetit",
+			Message:  diagnostic.Message,
+		})
+	}
+	return diagnostics
+}
+
+/*
+Get the word at a position in the document. Parameters include the position.
+Returns the word, the characters where it starts and ends on the line, and
+whether it is a variable (ie. it follows the variable symbol). The word is
+empty if there isn't one at the position.
+*/
+func (document *Document) WordAt(
+	position Position) (string, int, int, bool) {
+	// If the position is past the end of the document, there's no word
+	if position.Line < 0 || position.Line >= len(document.Lines) {
+		return "", 0, 0, false
+	}
+	line := document.Lines[position.Line]
+	offset := ByteOffset(line, position.Character)
+
+	// Work out to the start and end of the word
+	start := WordStart(line[:offset])
+	end := offset + parser.NameLength(line[offset:])
+	// Note whether the word is a variable
+	is_variable := parser.IsVariableStart(line[:start])
+	return line[start:end],
+		Character(line, start),
+		Character(line, end),
+		is_variable
+}
+
+/*
+Find where the word that text ends with starts. Parameters include the text.
+Returns the byte offset of the start of the word, which is the length of the
+text if it doesn't end with one.
+*/
+func WordStart(text string) int {
+	start := len(text)
+	for start > 0 {
+		value, size := utf8.DecodeLastRuneInString(text[:start])
+		if !parser.IsNameCharacter(value) {
+			break
+		}
+		start -= size
+	}
+	return start
+}
+
+/*
+Get the statements in the document. Each line is tokenised on its own and
+lines that can't be tokenised or don't hold a well formed statement are left
+out so that a line that is still being typed is never looked into. No
+parameters. Returns the tokens of each statement.
+*/
+func (document *Document) Statements() [][]parser.Token {
+	interpreter := NewCheckInterpreter()
+	var statements [][]parser.Token
+	for line_index, line := range parser.RemoveComments(document.Lines) {
+		tokens, tokenise_error := parser.Tokenise(
+			line, line_index+1, line_index+1)
+		if tokenise_error != nil || len(tokens) < 2 ||
+			interpreter.CheckStatement(tokens) != nil {
+			continue
+		}
+		statements = append(statements, tokens)
+	}
+	return statements
+}
+
+/*
+Get the name of the variable that a statement sets, if it sets one, along with
+the token that holds the name. The statement inside of any if or repeat
+statements is the one that is looked at. Parameters include the tokens of the
+statement. Returns the name of the variable and its token, or an empty string
+if the statement doesn't set a variable.
+*/
+func SetVariable(tokens []parser.Token) (string, parser.Token) {
+	tokens = parser.InnermostStatementTokens(tokens)
+	switch {
+	case tokens[1].TokenValue == "set" && len(tokens) > 2:
+		return tokens[2].TokenValue, tokens[2]
+	case tokens[1].TokenValue == "ask" && len(tokens) > 4:
+		return parser.FixStringCombined(tokens[4].TokenValue), tokens[4]
+	}
+	return "", parser.Token{}
+}
+
+/*
+Get the range of a token. Parameters include the token. Returns the range
+that the token covers.
+*/
+func TokenRange(token parser.Token) Range {
+	line := token.LineNumber - 1
+	column, _ := strconv.Atoi(token.TokenPosition)
+	character := ColumnCharacter(token.FullLineOfCode, column)
+	length := len(utf16.Encode([]rune(token.TokenValue)))
+	return Range{
+		Start: Position{Line: line, Character: character},
+		End:   Position{Line: line, Character: character + length},
+	}
+}
+
+/*
+Find where a variable is set. A variable is replaced wherever its name is at
+the start of what follows the variable symbol so the longest variable that
+the word starts with is the one that is used. Where a variable is set more
+than once, the closest set before the line is used. Parameters include the
+word that follows the variable symbol and the line that it is on. Returns the
+location of the variable or nil if it's never set.
+*/
+func (document *Document) FindVariable(word string, line int) *Location {
+	// Hold the best match
+	var best_token *parser.Token
+	best_name := ""
+	for _, tokens := range document.Statements() {
+		variable_name, name_token := SetVariable(tokens)
+		if variable_name == "" || !strings.HasPrefix(word, variable_name) {
+			continue
+		}
+		// A longer variable name wins as it's the one that is replaced
+		if len(variable_name) < len(best_name) {
+			continue
+		}
+		/*
+			For the same variable, keep the last set before the line but
+			otherwise, the first set.
+		*/
+		if len(variable_name) == len(best_name) &&
+			name_token.LineNumber-1 >= line {
+			continue
+		}
+		best_token = &name_token
+		best_name = variable_name
+	}
+	if best_token == nil {
+		return nil
+	}
+	return &Location{URI: document.URI, Range: TokenRange(*best_token)}
+}
+
+/*
+Find the script that a run statement on a line runs. The script is looked for
+relative to the working directory, as the interpreter does, and then relative
+to the document. Parameters include the line. Returns the location of the
+script or nil if the line isn't a run statement or the script doesn't exist.
+*/
+func (document *Document) FindRunTarget(line int) *Location {
+	for _, tokens := range document.Statements() {
+		if tokens[0].LineNumber-1 != line {
+			continue
+		}
+		tokens = parser.InnermostStatementTokens(tokens)
+		if tokens[1].TokenValue != "run" || len(tokens) < 3 {
+			return nil
+		}
+		// Get the name of the script with the reserved variables filled in
+		interpreter := NewCheckInterpreter()
+		interpreter.ScriptName = document.Path
+		interpreter.BuildReservedVariables()
+		script_name := interpreter.VariableTemplater(
+			parser.FixStringCombined(tokens[2].TokenValue))
+
+		// Look for the script
+		candidates := []string{script_name}
+		if !filepath.IsAbs(script_name) {
+			candidates = append(candidates, filepath.Join(
+				filepath.Dir(document.Path), script_name))
+		}
+		for _, candidate := range candidates {
+			if parser.CheckFileExists(candidate) {
+				return &Location{URI: PathToURI(candidate)}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+/*
+Get the completions at a position. At the start of a line (or after the then
+or times keywords), the statements are offered and after the variable symbol,
+the reserved variables and the variables that the document sets are offered.
+Parameters include the position. Returns the completions.
+*/
+func (document *Document) Completions(position Position) []CompletionItem {
+	// Start with an empty list so that no completions is sent as []
+	completions := []CompletionItem{}
+	if position.Line < 0 || position.Line >= len(document.Lines) {
+		return completions
+	}
+	// Get the line up to the position along with the word being typed
+	line := document.Lines[position.Line]
+	before := line[:ByteOffset(line, position.Character)]
+	word_start := WordStart(before)
+	// Get the words before the word being typed
+	previous_words := strings.Fields(before[:word_start])
+	interpreter := NewCheckInterpreter()
+
+	// If a variable is being typed, offer the variables
+	if parser.IsVariableStart(before[:word_start]) {
+		interpreter.ScriptName = document.Path
+		interpreter.BuildReservedVariables()
+		// Offer the reserved variables with their values
+		for _, variable_name := range slices.Sorted(
+			maps.Keys(interpreter.Variables)) {
+			completions = append(completions, CompletionItem{
+				Label:  variable_name,
+				Kind:   COMPLETION_VARIABLE,
+				Detail: interpreter.Variables[variable_name].Literal(),
+			})
+		}
+		// Offer the variables that the document sets
+		offered := map[string]bool{}
+		for _, tokens := range document.Statements() {
+			variable_name, name_token := SetVariable(tokens)
+			if variable_name == "" || offered[variable_name] {
+				continue
+			}
+			offered[variable_name] = true
+			completions = append(completions, CompletionItem{
+				Label: variable_name,
+				Kind:  COMPLETION_VARIABLE,
+				Detail: "set on line " +
+					strconv.Itoa(name_token.LineNumber),
+			})
+		}
+		return completions
+	}
+
+	// If a statement is being typed, offer the statements
+	if len(previous_words) == 0 ||
+		previous_words[len(previous_words)-1] == parser.SYMBOL_CONDITION_THEN ||
+		previous_words[len(previous_words)-1] == parser.SYMBOL_REPEAT_TIMES {
+		for _, statement_name := range interpreter.StatementNames {
+			completions = append(completions, CompletionItem{
+				Label:         statement_name,
+				Kind:          COMPLETION_KEYWORD,
+				Documentation: interpreter.StatementUsage(statement_name),
+			})
+		}
+	}
+	return completions
+}
+
+/*
+Get the hover for a position. A statement name shows the form that the
+statement needs to follow. Parameters include the position. Returns the hover
+or nil if there is nothing to show.
+*/
+func (document *Document) Hover(position Position) *Hover {
+	word, start, end, is_variable := document.WordAt(position)
+	if word == "" || is_variable {
+		return nil
+	}
+	usage := NewCheckInterpreter().StatementUsage(word)
+	if usage == "" {
+		return nil
+	}
+	return &Hover{
+		Contents: MarkupContent{Kind: "plaintext", Value: usage},
+		Range: &Range{
+			Start: Position{Line: position.Line, Character: start},
+			End:   Position{Line: position.Line, Character: end},
+		},
+	}
+}
+
+/*
+Get the definition for a position. A variable goes to where it is set and a
+run statement goes to the script that it runs. Parameters include the
+position. Returns the location or nil if there isn't a definition.
+*/
+func (document *Document) Definition(position Position) *Location {
+	word, _, _, is_variable := document.WordAt(position)
+	if is_variable {
+		return document.FindVariable(word, position.Line)
+	}
+	return document.FindRunTarget(position.Line)
+}
@@ -0,0 +1,262 @@
+/*
+This houses the parts of the Language Server Protocol that the server uses.
+Messages are JSON-RPC 2.0 sent over standard input and output, each of which
+starts with a Content-Length header that gives the length of the JSON that
+follows. Only the fields of each type that the server uses are included. See
+https://microsoft.github.io/language-server-protocol/ for the full protocol.
+*/
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The JSON-RPC error codes that the server uses
+const (
+	ERROR_PARSE            int = -32700
+	ERROR_METHOD_NOT_FOUND int = -32601
+	ERROR_INVALID_PARAMS   int = -32602
+)
+
+// The severity of a diagnostic
+const (
+	SEVERITY_ERROR   int = 1
+	SEVERITY_WARNING int = 2
+)
+
+// The kind of a completion item
+const (
+	COMPLETION_VARIABLE int = 6
+	COMPLETION_KEYWORD  int = 14
+)
+
+/*
+Returned by ReadMessage() when a message was read but isn't valid JSON. The
+message has been read in full so the next one can still be read.
+*/
+var ErrInvalidMessage = errors.New("invalid message")
+
+// The version of JSON-RPC that every message uses
+const JSONRPC_VERSION string = "2.0"
+
+/*
+The Message type houses a JSON-RPC message from the client. A request has an
+ID and a method while a notification has only a method. Either may have
+parameters which are decoded once the method is known.
+*/
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+/*
+The Response type houses the result of a request. The result is always sent,
+even when it is null, as that is how a client knows that the request worked.
+*/
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+// The ErrorResponse type houses an error that is sent back for a request.
+type ErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   ResponseError   `json:"error"`
+}
+
+// The ResponseError type houses the details of an error response.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// The Notification type houses a message from the server to the client.
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+/*
+The Position type houses a place in a document. Both the line and character
+start at zero.
+*/
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// The Range type houses a span of a document.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// The Location type houses a span of a particular document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// The Diagnostic type houses an error or warning for a document.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// The CompletionItem type houses a suggestion for completing a word.
+type CompletionItem struct {
+	Label         string `json:"label"`
+	Kind          int    `json:"kind"`
+	Detail        string `json:"detail,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// The MarkupContent type houses the text of a hover.
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// The Hover type houses the result of a hover request.
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// The TextDocumentItem type houses a document that has been opened.
+type TextDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+// The TextDocumentIdentifier type houses the document that a request is for.
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// The DidOpenParams type houses the parameters of textDocument/didOpen.
+type DidOpenParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+/*
+The DidChangeParams type houses the parameters of textDocument/didChange. The
+server asks for the full text of the document with each change so the last
+change holds the whole document.
+*/
+type DidChangeParams struct {
+	TextDocument   TextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// The DidCloseParams type houses the parameters of textDocument/didClose.
+type DidCloseParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+/*
+The PositionParams type houses the parameters of the requests that are made
+at a place in a document (ie. completion, hover, and definition).
+*/
+type PositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// The PublishDiagnosticsParams type houses the diagnostics for a document.
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+/*
+Read the content of a message, that is, the headers are read and the content
+that they give the length of is returned. This is shared with the debug
+adapter, which frames its messages in the same way. Parameters include the
+reader to read from. Returns the content and an error if it couldn't be read,
+which is io.EOF once there are no more messages.
+*/
+func ReadContent(reader *bufio.Reader) ([]byte, error) {
+	// Read the headers, holding onto the length of the content
+	content_length := -1
+	for {
+		header, read_error := reader.ReadString('\n')
+		if read_error != nil {
+			return nil, read_error
+		}
+		// A blank line ends the headers
+		header = strings.TrimSpace(header)
+		if header == "" {
+			break
+		}
+		name, value, _ := strings.Cut(header, ":")
+		if strings.EqualFold(name, "Content-Length") {
+			length, length_error := strconv.Atoi(strings.TrimSpace(value))
+			if length_error != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %s", value)
+			}
+			content_length = length
+		}
+	}
+	// A message can't be read without knowing how long it is
+	if content_length < 0 {
+		return nil, errors.New("missing Content-Length header")
+	}
+
+	// Read the content
+	content := make([]byte, content_length)
+	if _, read_error := io.ReadFull(reader, content); read_error != nil {
+		return nil, read_error
+	}
+	return content, nil
+}
+
+/*
+Read a message. Parameters include the reader to read from. Returns the
+message and an error if the message couldn't be read. The error is io.EOF
+once there are no more messages and wraps ErrInvalidMessage if the message
+isn't valid JSON.
+*/
+func ReadMessage(reader *bufio.Reader) (*Message, error) {
+	content, read_error := ReadContent(reader)
+	if read_error != nil {
+		return nil, read_error
+	}
+	// Decode the content
+	message := &Message{}
+	if decode_error := json.Unmarshal(content, message); decode_error != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, decode_error)
+	}
+	return message, nil
+}
+
+/*
+Write a message. Parameters include the writer to write to and the message
+(ie. a Response, ErrorResponse, or Notification). Returns an error if the
+message couldn't be written.
+*/
+func WriteMessage(writer io.Writer, message any) error {
+	content, encode_error := json.Marshal(message)
+	if encode_error != nil {
+		return encode_error
+	}
+	_, write_error := fmt.Fprintf(
+		writer, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	return write_error
+}
@@ -0,0 +1,237 @@
+/*
+The language server. This reads messages from the client (ie. an editor),
+keeps track of the documents that are open, and answers requests for
+diagnostics, completions, hovers, and definitions. A server is started with
+appetit -lsp and talks to the client over standard input and output.
+*/
+package lsp
+
+import (
+	"appetit/parser"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+/*
+The Server type houses the state of the language server. The structure of the
+server is as follows:
+  - reader [*bufio.Reader]: where messages from the client are read from
+  - writer [io.Writer]: where messages to the client are written to
+  - documents [map[string]*Document]: the documents that are open, by URI
+  - shutdown [bool]: whether the client has asked the server to shut down
+*/
+type Server struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	documents map[string]*Document
+	shutdown  bool
+}
+
+/*
+Create a server. Parameters include the reader to read messages from and the
+writer to write messages to. Returns the server.
+*/
+func NewServer(reader io.Reader, writer io.Writer) *Server {
+	return &Server{
+		reader:    bufio.NewReader(reader),
+		writer:    writer,
+		documents: map[string]*Document{},
+	}
+}
+
+/*
+Run the server until the client asks it to exit or stops sending messages. No
+parameters. Returns an error if the client exited without asking the server to
+shut down first or if a message couldn't be read or written.
+*/
+func (server *Server) Run() error {
+	for {
+		message, read_error := ReadMessage(server.reader)
+		// A message that isn't JSON gets an error but the server carries on
+		if errors.Is(read_error, ErrInvalidMessage) {
+			write_error := server.SendError(
+				nil, ERROR_PARSE, read_error.Error())
+			if write_error != nil {
+				return write_error
+			}
+			continue
+		}
+		// If the client has gone away, stop
+		if read_error == io.EOF {
+			return nil
+		}
+		if read_error != nil {
+			return read_error
+		}
+		// The exit notification stops the server
+		if message.Method == "exit" {
+			if !server.shutdown {
+				return errors.New("exit before shutdown")
+			}
+			return nil
+		}
+		if handle_error := server.Handle(message); handle_error != nil {
+			return handle_error
+		}
+	}
+}
+
+/*
+Handle a message from the client. Parameters include the message. Returns an
+error if the reply couldn't be written.
+*/
+func (server *Server) Handle(message *Message) error {
+	// A message without an ID is a notification and gets no reply
+	is_request := len(message.ID) > 0
+
+	switch message.Method {
+	case "initialize":
+		return server.SendResult(message.ID, map[string]any{
+			"capabilities": map[string]any{
+				// Positions count the characters of a line in UTF-16
+				"positionEncoding": "utf-16",
+				// Ask for the full text of a document with each change
+				"textDocumentSync": 1,
+				"completionProvider": map[string]any{
+					"triggerCharacters": []string{
+						parser.SYMBOL_VARIABLE_SUBSTITUTION,
+						parser.SYMBOL_VARIABLE_OPEN,
+					},
+				},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]any{
+				"name":    parser.LANG_NAME,
+				"version": strconv.Itoa(parser.LANG_VERSION),
+			},
+		})
+	case "shutdown":
+		server.shutdown = true
+		return server.SendResult(message.ID, nil)
+	case "textDocument/didOpen":
+		var params DidOpenParams
+		if json.Unmarshal(message.Params, &params) != nil {
+			return nil
+		}
+		return server.Open(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params DidChangeParams
+		if json.Unmarshal(message.Params, &params) != nil ||
+			len(params.ContentChanges) == 0 {
+			return nil
+		}
+		return server.Open(
+			params.TextDocument.URI,
+			params.ContentChanges[len(params.ContentChanges)-1].Text,
+		)
+	case "textDocument/didClose":
+		var params DidCloseParams
+		if json.Unmarshal(message.Params, &params) != nil {
+			return nil
+		}
+		// Forget the document and clear its diagnostics
+		delete(server.documents, params.TextDocument.URI)
+		return server.Notify("textDocument/publishDiagnostics",
+			PublishDiagnosticsParams{
+				URI:         params.TextDocument.URI,
+				Diagnostics: []Diagnostic{},
+			})
+	case "textDocument/completion", "textDocument/hover",
+		"textDocument/definition":
+		var params PositionParams
+		if json.Unmarshal(message.Params, &params) != nil {
+			return server.SendError(message.ID, ERROR_INVALID_PARAMS,
+				"invalid parameters for "+message.Method)
+		}
+		// A document that isn't open has nothing to offer
+		document, is_open := server.documents[params.TextDocument.URI]
+		if !is_open {
+			return server.SendResult(message.ID, nil)
+		}
+		switch message.Method {
+		case "textDocument/completion":
+			return server.SendResult(
+				message.ID, document.Completions(params.Position))
+		case "textDocument/hover":
+			// Send null rather than a nil pointer wrapped in an interface
+			if hover := document.Hover(params.Position); hover != nil {
+				return server.SendResult(message.ID, hover)
+			}
+		case "textDocument/definition":
+			location := document.Definition(params.Position)
+			if location != nil {
+				return server.SendResult(message.ID, location)
+			}
+		}
+		return server.SendResult(message.ID, nil)
+	}
+
+	// Let the client know of any request that isn't supported
+	if is_request {
+		return server.SendError(message.ID, ERROR_METHOD_NOT_FOUND,
+			"method not found: "+message.Method)
+	}
+	// Otherwise, ignore the notification (eg. initialized)
+	return nil
+}
+
+/*
+Open a document, or update it if it's already open, and send its diagnostics.
+Parameters include the URI of the document and its text. Returns an error if
+the diagnostics couldn't be sent.
+*/
+func (server *Server) Open(uri string, text string) error {
+	document := NewDocument(uri, text)
+	server.documents[uri] = document
+	return server.Notify("textDocument/publishDiagnostics",
+		PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: document.Diagnostics(),
+		})
+}
+
+/*
+Send the result of a request. Parameters include the ID of the request and
+the result. Returns an error if the result couldn't be written.
+*/
+func (server *Server) SendResult(id json.RawMessage, result any) error {
+	return WriteMessage(server.writer, Response{
+		JSONRPC: JSONRPC_VERSION,
+		ID:      id,
+		Result:  result,
+	})
+}
+
+/*
+Send an error for a request. Parameters include the ID of the request (nil if
+it isn't known), the error code, and the message. Returns an error if the
+error couldn't be written.
+*/
+func (server *Server) SendError(
+	id json.RawMessage, code int, message string) error {
+	// An unknown ID is sent as null
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return WriteMessage(server.writer, ErrorResponse{
+		JSONRPC: JSONRPC_VERSION,
+		ID:      id,
+		Error:   ResponseError{Code: code, Message: message},
+	})
+}
+
+/*
+Send a notification to the client. Parameters include the method and its
+parameters. Returns an error if the notification couldn't be written.
+*/
+func (server *Server) Notify(method string, params any) error {
+	return WriteMessage(server.writer, Notification{
+		JSONRPC: JSONRPC_VERSION,
+		Method:  method,
+		Params:  params,
+	})
+}
@@ -0,0 +1,234 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/*
+The reply type houses a message from the server as it is read back in a
+test, inclusive of the fields that only responses have.
+*/
+type reply struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *ResponseError  `json:"error"`
+}
+
+/*
+Run a server over messages from a client. Parameters include the messages.
+Returns the replies from the server, by ID for responses and by method for
+notifications, and the error that the server stopped with.
+*/
+func RunServer(
+	t *testing.T, messages ...map[string]any) (map[string]reply, error) {
+	// Write the messages
+	var input bytes.Buffer
+	for _, message := range messages {
+		message["jsonrpc"] = JSONRPC_VERSION
+		if write_error := WriteMessage(&input, message); write_error != nil {
+			t.Fatalf("[lsp] Couldn't write a message: %v", write_error)
+		}
+	}
+	// Run the server
+	var output bytes.Buffer
+	run_error := NewServer(&input, &output).Run()
+
+	// Read back the replies
+	replies := map[string]reply{}
+	reader := bufio.NewReader(&output)
+	for {
+		// Read the length of the content and skip the blank line
+		header, read_error := reader.ReadString('\n')
+		if read_error != nil {
+			break
+		}
+		reader.ReadString('\n')
+		length, _ := strconv.Atoi(strings.TrimSpace(
+			strings.TrimPrefix(header, "Content-Length:")))
+		// Read the content and decode it
+		content := make([]byte, length)
+		io.ReadFull(reader, content)
+		var message reply
+		if json.Unmarshal(content, &message) != nil {
+			t.Fatalf("[lsp] Couldn't read a reply: %s", content)
+		}
+		// Hold responses by ID and notifications by method
+		key := string(message.ID)
+		if message.Method != "" {
+			key = message.Method
+		}
+		replies[key] = message
+	}
+	return replies, run_error
+}
+
+/*
+Create the parameters for a request at a position. Parameters include the
+URI of the document, the line, and the character. Returns the parameters.
+*/
+func AtPosition(uri string, line int, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+/*
+Check to make sure that the server sends diagnostics for a document that is
+opened and answers requests for completions, hovers, and definitions.
+*/
+func TestServer(t *testing.T) {
+	uri := "file:///tmp/script.apt"
+	script := "set name = \"World\"\nwriteln \"Hello #name\"\n" +
+		"movefile \"a.txt\"\nwriteln \"#b_\"\n"
+	replies, run_error := RunServer(t,
+		map[string]any{"id": 1, "method": "initialize",
+			"params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen",
+			"params": map[string]any{
+				"textDocument": map[string]any{"uri": uri, "text": script},
+			}},
+		map[string]any{"id": 2, "method": "textDocument/completion",
+			"params": AtPosition(uri, 3, 12)},
+		map[string]any{"id": 3, "method": "textDocument/completion",
+			"params": AtPosition(uri, 4, 0)},
+		map[string]any{"id": 4, "method": "textDocument/hover",
+			"params": AtPosition(uri, 2, 3)},
+		map[string]any{"id": 5, "method": "textDocument/definition",
+			"params": AtPosition(uri, 1, 17)},
+		map[string]any{"id": 6, "method": "textDocument/formatting",
+			"params": map[string]any{}},
+		map[string]any{"id": 7, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	if run_error != nil {
+		t.Fatalf("[lsp] Expected no error, got %v", run_error)
+	}
+
+	// Check that the capabilities were sent
+	if !strings.Contains(string(replies["1"].Result), "hoverProvider") {
+		t.Errorf("[initialize] Expected capabilities, got %s",
+			replies["1"].Result)
+	}
+
+	/*
+		Check that the movefile statement and the unfinished reserved variable
+		are the diagnostics.
+	*/
+	var diagnostics PublishDiagnosticsParams
+	json.Unmarshal(
+		replies["textDocument/publishDiagnostics"].Params, &diagnostics)
+	if len(diagnostics.Diagnostics) != 2 ||
+		diagnostics.Diagnostics[0].Range.Start.Line != 2 ||
+		diagnostics.Diagnostics[0].Code != "syntax/movefile" {
+		t.Errorf("[diagnostics] Expected a movefile diagnostic, got %+v",
+			diagnostics)
+	}
+
+	// Check that the variables and statements are completed
+	var variables []CompletionItem
+	json.Unmarshal(replies["2"].Result, &variables)
+	var statements []CompletionItem
+	json.Unmarshal(replies["3"].Result, &statements)
+	labels := func(items []CompletionItem) string {
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Label)
+		}
+		return " " + strings.Join(names, " ") + " "
+	}
+	if !strings.Contains(labels(variables), " b_os ") ||
+		!strings.Contains(labels(variables), " name ") {
+		t.Errorf("[completion] Expected variables, got %s", labels(variables))
+	}
+	if !strings.Contains(labels(statements), " writeln ") ||
+		strings.Contains(labels(statements), " b_os ") {
+		t.Errorf("[completion] Expected statements, got %s",
+			labels(statements))
+	}
+
+	// Check that the hover shows the form of the statement
+	var hover Hover
+	json.Unmarshal(replies["4"].Result, &hover)
+	if !strings.Contains(hover.Contents.Value, "movefile") {
+		t.Errorf("[hover] Expected the movefile form, got %+v", hover)
+	}
+
+	// Check that the variable goes to where it is set
+	var location Location
+	json.Unmarshal(replies["5"].Result, &location)
+	if location.URI != uri || location.Range.Start.Line != 0 ||
+		location.Range.Start.Character != 4 {
+		t.Errorf("[definition] Expected the set statement, got %+v",
+			location)
+	}
+
+	// Check that an unsupported request is refused
+	if replies["6"].Error == nil ||
+		replies["6"].Error.Code != ERROR_METHOD_NOT_FOUND {
+		t.Errorf("[formatting] Expected an error, got %+v", replies["6"])
+	}
+}
+
+/*
+Check to make sure that a message that isn't JSON gets an error without
+stopping the server and that exiting without shutting down is an error.
+*/
+func TestServerErrors(t *testing.T) {
+	var input bytes.Buffer
+	input.WriteString("Content-Length: 1\r\n\r\n{")
+	WriteMessage(&input, map[string]any{
+		"jsonrpc": JSONRPC_VERSION, "method": "exit",
+	})
+	var output bytes.Buffer
+	run_error := NewServer(&input, &output).Run()
+	if run_error == nil {
+		t.Errorf("[exit] Expected an error for exiting before shutdown")
+	}
+	if !strings.Contains(output.String(), "-32700") {
+		t.Errorf("[parse] Expected a parse error, got %s", output.String())
+	}
+}
+
+/*
+Check to make sure that positions are counted in UTF-16 code units, as the
+client counts them, on lines with characters that aren't ASCII.
+*/
+func TestDocumentPositions(t *testing.T) {
+	document := NewDocument("file:///tmp/script.apt",
+		"set 名前 = \"😀\"\nwriteln \"😀 #名前\"\n")
+
+	// The emoji is four bytes and two code units and 名 is three and one
+	line := document.Lines[1]
+	if ByteOffset(line, 11) != 13 || Character(line, 13) != 11 ||
+		ColumnCharacter(line, 12) != 12 {
+		t.Errorf("[positions] Expected 13, 11, and 12, got %d, %d, and %d",
+			ByteOffset(line, 11),
+			Character(line, 13),
+			ColumnCharacter(line, 12))
+	}
+
+	// The word and where it is set are found and sent back in code units
+	word, start, end, is_variable := document.WordAt(
+		Position{Line: 1, Character: 14})
+	if word != "名前" || start != 13 || end != 15 || !is_variable {
+		t.Errorf("[WordAt] Expected 名前 from 13 to 15, got %s from %d to %d",
+			word,
+			start,
+			end)
+	}
+	location := document.Definition(Position{Line: 1, Character: 14})
+	if location == nil || location.Range.Start.Character != 4 ||
+		location.Range.End.Character != 6 {
+		t.Errorf("[definition] Expected 4 to 6, got %+v", location)
+	}
+}
@@ -5,14 +5,17 @@ Nothing of note here needs to be mentioned that is unique to this package.
 package main
 
 import (
+	"appetit/debugger"
+	"appetit/lsp"
 	"appetit/parser"
+	"appetit/repl"
 	"appetit/utils"
 	_ "embed"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 	"runtime"
 	"runtime/trace"
 	"time"
@@ -40,11 +43,12 @@ func DocsHandler(writer http.ResponseWriter, request *http.Request) {
 }
 
 /*
-Get memory stats. This takes no parameters and returns nothing. Thanks to
+Get memory stats. This takes the token tree of the interpreter that ran the
+script and returns nothing. Thanks to
 https://reintech.io/blog/introduction-to-gos-runtime-package-memory-
 management-performance
 */
-func PrintDevInfo() {
+func PrintDevInfo(token_tree []parser.Token) {
 	var mem_stats runtime.MemStats
 	runtime.ReadMemStats(&mem_stats)
 
@@ -53,15 +57,15 @@ func PrintDevInfo() {
 	fmt.Printf(
 		utils.ColouriseCyan(":: Total Tokens (incl. line number tokens):")+
 			" %s",
-		utils.CommaSeperator(float64(len(parser.TOKEN_TREE))),
+		utils.CommaSeperator(float64(len(token_tree))),
 	)
 
 	// Get the size of a single token
-	token_memory_size := unsafe.Sizeof(parser.TOKEN_TREE[0])
+	token_memory_size := unsafe.Sizeof(token_tree[0])
 	// Calculate the size of the token tree as a whole
-	memory_token_tree := uintptr(cap(parser.TOKEN_TREE)) * token_memory_size
+	memory_token_tree := uintptr(cap(token_tree)) * token_memory_size
 	fmt.Printf(
-		utils.ColouriseCyan("\n:: Total Memory Usage of TOKEN_TREE:")+
+		utils.ColouriseCyan("\n:: Total Memory Usage of the Token Tree:")+
 			" %s bytes",
 		utils.CommaSeperator(float64(memory_token_tree)),
 	)
@@ -90,6 +94,58 @@ func PrintDevInfo() {
 	)
 }
 
+/*
+Format scripts. Each script is rewritten in place unless the differences are
+to be printed or the scripts are only being checked. Parameters include the
+names of the scripts, whether to only check them, and whether to print the
+differences. Returns the exit code, which is non-zero if a script couldn't be
+read or, when checking, isn't formatted.
+*/
+func FormatScripts(file_names []string, check bool, diff bool) int {
+	exit_code := 0
+	for _, file_name := range file_names {
+		original, formatted, format_error := parser.FormatFile(file_name)
+		if format_error != nil {
+			parser.PrintError(os.Stderr, format_error)
+			exit_code = parser.ExitCode(format_error)
+			continue
+		}
+		// If the script is already formatted, there is nothing to do
+		if original == formatted {
+			continue
+		}
+		// Print the differences if they were asked for
+		if diff {
+			fmt.Print(utils.Diff(
+				file_name+".orig", file_name, original, formatted))
+		}
+		// If checking, note that the script isn't formatted
+		if check {
+			unformatted_error := parser.ReportSimple(
+				"The script - " + utils.ColouriseYellow(file_name) +
+					" - isn't formatted.",
+			).WithCategory(parser.ERROR_SYNTAX).WithRule(
+				"format/unformatted").WithHint(
+				"Run appetit -fmt " + file_name + " to format it.")
+			parser.PrintError(os.Stderr, unformatted_error)
+			exit_code = parser.ExitCode(unformatted_error)
+			continue
+		}
+		// Otherwise, rewrite the script unless only the diff was asked for
+		if !diff {
+			write_error := os.WriteFile(file_name, []byte(formatted), 0644)
+			if write_error != nil {
+				parser.PrintError(os.Stderr, parser.ReportSimple(
+					"The script - "+utils.ColouriseYellow(file_name)+
+						" - couldn't be written.",
+				).WithErr(write_error))
+				exit_code = int(parser.ERROR_RUNTIME)
+			}
+		}
+	}
+	return exit_code
+}
+
 /*
 The main function. No parameters and no returns.
 */
@@ -101,11 +157,59 @@ func main() {
 		"Information about the interpreter.",
 	)
 
-	// Allow the user to execute system commands, defaults to false
-	allowexec_flag := flag.Bool(
+	/*
+		Allow the user to execute system commands, defaults to false. Passed
+		on its own, every command is allowed. Otherwise, only the commands
+		passed are (eg. -allowexec=git,rsync).
+	*/
+	allowexec_flag := &parser.ExecAllowList{}
+	flag.Var(
+		allowexec_flag,
 		"allowexec",
+		"Allow execution of system commands, either every command or only "+
+			"those passed (eg. -allowexec=git,rsync).",
+	)
+
+	// Log every command that is allowed or denied
+	audit_flag := flag.String(
+		"audit",
+		"",
+		"Append a line of JSON to the file specified for every command that "+
+			"the execute statement is allowed or denied.",
+	)
+
+	// Trust the certificates in a CA bundle when making requests
+	ca_bundle_flag := flag.String(
+		"cabundle",
+		"",
+		"Trust the PEM certificates in the file specified, along with the "+
+			"system's, when downloading (defaults to $"+
+			parser.ENVIRONMENT_CA_BUNDLE+").",
+	)
+
+	// Check the script for problems without executing it
+	check_flag := flag.Bool(
+		"check",
+		false,
+		"Check the script, and any scripts that it runs, for problems "+
+			"without executing it.",
+	)
+
+	// Step through the script from the console
+	debug_flag := flag.Bool(
+		"debug",
 		false,
-		"Allow execution of system commands.",
+		"Pause before each statement of the script to step through it, "+
+			"set breakpoints, and look at or change variables.",
+	)
+
+	// Let an editor step through the script
+	debug_port_flag := flag.String(
+		"debugport",
+		"",
+		"Wait for an editor to connect on the port specified (on "+
+			"127.0.0.1) and let it debug the script with the Debug Adapter "+
+			"Protocol.",
 	)
 
 	// Create a template script to work from
@@ -123,6 +227,31 @@ func main() {
 			"you are an enduser, this information will not be helpful.",
 	)
 
+	// Print what the script would do without touching anything
+	dry_run_flag := flag.Bool(
+		"dryrun",
+		false,
+		"Print what the script would do without touching any files, "+
+			"downloading anything, or executing any commands.",
+	)
+
+	// Print the differences that formatting would make
+	diff_flag := flag.Bool(
+		"diff",
+		false,
+		"Used with -fmt, print the differences that formatting would make "+
+			"rather than rewriting the scripts.",
+	)
+
+	// Report errors and warnings in a machine readable format
+	diagnostics_flag := flag.String(
+		"diagnostics",
+		"",
+		"Report errors and warnings to standard error as "+
+			parser.DIAGNOSTICS_JSON+" or "+parser.DIAGNOSTICS_SARIF+
+			" rather than as text.",
+	)
+
 	// Serve up the documentation
 	docs_flag := flag.String(
 		"docs",
@@ -130,6 +259,77 @@ func main() {
 		"Serve up documentation for the language on port 8000.",
 	)
 
+	// Load environment variables from a file before the script runs
+	env_file_flag := flag.String(
+		"env-file",
+		"",
+		"Load environment variables from a file of NAME=value lines before "+
+			"the script runs.",
+	)
+
+	// Only allow the commands in a policy file to be executed
+	exec_policy_flag := flag.String(
+		"execpolicy",
+		"",
+		"Only allow the execute statement to run the commands that the "+
+			"rules in the file specified allow.",
+	)
+
+	// Format scripts
+	fmt_flag := flag.Bool(
+		"fmt",
+		false,
+		"Rewrite the scripts in their canonical format. Use with -check to "+
+			"exit with a non-zero exit code if a script isn't formatted.",
+	)
+
+	// Send headers with every request
+	header_flag := parser.HeaderList{}
+	flag.Var(
+		header_flag,
+		"header",
+		"Send a header, written as \"[name]: [value]\", with every "+
+			"request that the script makes. Can be passed more than once.",
+	)
+
+	// Set how long to wait for a server before a request is given up on
+	http_timeout_flag := flag.Float64(
+		"httptimeout",
+		0,
+		"The number of seconds to wait for a server to connect and start "+
+			"responding (defaults to $"+parser.ENVIRONMENT_HTTP_TIMEOUT+
+			" or "+parser.DEFAULT_HTTP_TIMEOUT.String()+").",
+	)
+
+	// Run a language server for editors
+	lsp_flag := flag.Bool(
+		"lsp",
+		false,
+		"Run a language server over standard input and output for editors.",
+	)
+
+	// Set the number of times that a script can loop before it is stopped
+	max_iterations_flag := flag.Int(
+		"maxiterations",
+		parser.DEFAULT_MAX_ITERATIONS,
+		"The number of times that a script can loop before it is stopped.",
+	)
+
+	// Send requests through a proxy
+	proxy_flag := flag.String(
+		"proxy",
+		"",
+		"The URL of the proxy to send requests through (defaults to "+
+			"$HTTP_PROXY or $HTTPS_PROXY, skipping the hosts in $NO_PROXY).",
+	)
+
+	// Set the number of times that a download that stopped is tried again
+	retries_flag := flag.Int(
+		"retries",
+		parser.DEFAULT_DOWNLOAD_RETRIES,
+		"The number of times that a download that stopped is tried again.",
+	)
+
 	// Time the execution of the script
 	timer_flag := flag.Bool(
 		"timer",
@@ -144,6 +344,15 @@ func main() {
 		"[Dev] Execute a runtime trace on the interpreter.",
 	)
 
+	// Set the User-Agent that requests are made with
+	user_agent_flag := flag.String(
+		"useragent",
+		"",
+		"The User-Agent to make requests with (defaults to $"+
+			parser.ENVIRONMENT_USER_AGENT+" or "+parser.DefaultUserAgent()+
+			").",
+	)
+
 	// Get whether we are being verbose with output or not, defaults to false
 	verbose_flag := flag.Bool(
 		"verbose",
@@ -151,6 +360,14 @@ func main() {
 		"Verbose mode",
 	)
 
+	// Warn about variables that haven't been set rather than stopping
+	warn_undefined_flag := flag.Bool(
+		"warnundefined",
+		false,
+		"Warn about variables that haven't been set, leaving them as they "+
+			"were written, rather than stopping the script.",
+	)
+
 	// Parse the flags
 	flag.Parse()
 
@@ -246,42 +463,184 @@ func main() {
 		)
 	}
 
-	// Set the output to verbose
-	parser.MODE_VERBOSE = *verbose_flag
+	/*
+		If the lsp flag is passed, run the language server until the editor
+		asks it to exit. The server needs no script name as the editor sends
+		the scripts to it.
+	*/
+	if *lsp_flag {
+		server := lsp.NewServer(os.Stdin, os.Stdout)
+		if server_error := server.Run(); server_error != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
-	// Set the allow exec setting
-	parser.MODE_ALLOW_EXEC = *allowexec_flag
+	// Load the environment file, if there is one
+	var environment map[string]string
+	if *env_file_flag != "" {
+		var environment_error error
+		environment, environment_error = parser.LoadEnvironmentFile(
+			*env_file_flag)
+		if environment_error != nil {
+			parser.PrintError(os.Stderr, environment_error)
+			os.Exit(parser.ExitCode(environment_error))
+		}
+	}
+
+	// Gather the rules for the commands that can be executed
+	exec_rules := allowexec_flag.Rules
+	if *exec_policy_flag != "" {
+		policy_rules, policy_error := parser.LoadExecPolicy(*exec_policy_flag)
+		if policy_error != nil {
+			parser.PrintError(os.Stderr, policy_error)
+			os.Exit(parser.ExitCode(policy_error))
+		}
+		exec_rules = append(exec_rules, policy_rules...)
+	}
+	// Open the audit log, if there is one, adding to what is already there
+	var audit_log io.Writer
+	if *audit_flag != "" {
+		audit_file, audit_error := os.OpenFile(
+			*audit_flag, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if audit_error != nil {
+			parser.PrintError(os.Stderr, parser.ReportSimple(
+				"The audit log - "+utils.ColouriseYellow(*audit_flag)+
+					" - couldn't be opened.",
+			).WithCategory(parser.ERROR_USAGE).WithErr(audit_error))
+			os.Exit(int(parser.ERROR_USAGE))
+		}
+		// The log is written to until the interpreter exits
+		audit_log = audit_file
+	}
+
+	/*
+		Anything after the name of the script is passed to the script. The
+		flags stop at the name of the script so these can look like flags
+		(eg. appetit backup.apt --target /srv).
+	*/
+	var script_arguments []string
+	if flag.NArg() > 1 && !*fmt_flag {
+		script_arguments = flag.Args()[1:]
+	}
+
+	/*
+		Create the interpreter, setting the output to verbose, the allow exec
+		and audit settings, developer mode, the maximum number of
+		iterations, the download retries and HTTP settings, the environment,
+		and the arguments for the script as per the flags.
+	*/
+	interpreter := parser.New(parser.Options{
+		AllowExec:       allowexec_flag.AllowAll,
+		ExecRules:       exec_rules,
+		Audit:           audit_log,
+		Check:           *check_flag,
+		Dev:             *dev_flag,
+		Diagnostics:     *diagnostics_flag != "",
+		DryRun:          *dry_run_flag,
+		Verbose:         *verbose_flag,
+		WarnUndefined:   *warn_undefined_flag,
+		MaxIterations:   *max_iterations_flag,
+		DownloadRetries: *retries_flag,
+		HTTP: parser.HTTPSettings{
+			Headers:   header_flag,
+			UserAgent: *user_agent_flag,
+			Timeout: time.Duration(
+				*http_timeout_flag * float64(time.Second)),
+			Proxy:    *proxy_flag,
+			CABundle: *ca_bundle_flag,
+		},
+		Environment: environment,
+		Arguments:   script_arguments,
+	})
 
 	// Get the file name
 	file_name := flag.Args()
-	// If there are no tailing arguments (ie. the file name)
+	/*
+		If there are no tailing arguments (ie. the file name), start the REPL
+		unless the flags passed only make sense for a script.
+	*/
+	if len(file_name) == 0 && !*check_flag && !*fmt_flag &&
+		*diagnostics_flag == "" {
+		repl.New(interpreter).Run()
+		os.Exit(0)
+	}
+	// Otherwise, a script is needed
 	if len(file_name) == 0 {
 		// Error out
-		parser.ReportSimple(
-			"You need to pass a script name to the interpreter.",
+		parser.PrintError(
+			os.Stderr,
+			parser.ReportSimple(
+				"You need to pass a script name to the interpreter.",
+			),
 		)
+		os.Exit(int(parser.ERROR_USAGE))
 	}
 
-	/*
-		Set the script name as a central value so that it can be accessed in
-		places such as the reserved variables. This, by default, includes the
-		full path to the script.
-	*/
-	parser.SCRIPT_NAME, _ = filepath.Abs(file_name[0])
+	// If the fmt flag is passed, format the scripts rather than run them
+	if *fmt_flag {
+		os.Exit(FormatScripts(file_name, *check_flag, *diff_flag))
+	}
 
-	// Prep the script by opening it and removing the comments
-	contents := parser.PrepScript(parser.SCRIPT_NAME)
+	// Check the diagnostics format before the script is run
+	if *diagnostics_flag != "" {
+		format_error := parser.CheckDiagnosticsFormat(*diagnostics_flag)
+		if format_error != nil {
+			parser.PrintError(os.Stderr, format_error)
+			os.Exit(parser.ExitCode(format_error))
+		}
+	}
 
-	/* If the dev flag is set, run the script and report back developer
-	information.
+	/* If the dev flag is set, the interpreter will print out the tokens
+	rather than execute the script so note that here.
 	*/
 	if *dev_flag {
-		parser.MODE_DEV = true
 		// Start printing out the tokens
 		fmt.Println(utils.ColouriseYellow("\nTokens"))
-		parser.Start(contents, true)
+	}
+	// Run the script, stepping through it if it is being debugged
+	var run_error error
+	if *debug_port_flag != "" {
+		run_error = debugger.Listen(
+			interpreter,
+			"127.0.0.1:"+*debug_port_flag,
+			func() error { return interpreter.RunFile(file_name[0]) },
+		)
 	} else {
-		parser.Start(contents, false)
+		if *debug_flag {
+			debugger.NewConsole(interpreter)
+		}
+		run_error = interpreter.RunFile(file_name[0])
+	}
+	/*
+		If diagnostics were asked for, write out the errors and warnings in
+		place of printing them and exit with the exit code for the category of
+		error, if there was one.
+	*/
+	if *diagnostics_flag != "" {
+		parser.WriteDiagnostics(
+			os.Stderr,
+			*diagnostics_flag,
+			parser.Diagnostics(
+				run_error, interpreter.Warnings, interpreter.ScriptName),
+		)
+		if run_error != nil {
+			os.Exit(parser.ExitCode(run_error))
+		}
+	}
+	/*
+		If the script failed, print the error and exit with the exit code for
+		the category of error so that whatever ran the interpreter (eg. a cron
+		job) knows that the script failed.
+	*/
+	if run_error != nil {
+		parser.PrintError(os.Stderr, run_error)
+		os.Exit(parser.ExitCode(run_error))
+	}
+	// If we were only checking the script, note that all is well
+	if *check_flag {
+		fmt.Println(utils.ColouriseMagenta(
+			"No problems found in " + file_name[0] + "."))
 	}
 
 	// If the timer flag is true, print the results
@@ -305,7 +664,7 @@ func main() {
 
 	// If the developer flag is set, print out developer information.
 	if *dev_flag {
-		PrintDevInfo()
+		PrintDevInfo(interpreter.TokenTree)
 	}
 
 }
@@ -0,0 +1,521 @@
+/*
+The archive extractor houses how the unzip and untar statements unpack
+archives, for example:
+
+	download "https://example.com/tool.tar.gz" to "#b_home"
+	untar "#b_home/tool.tar.gz" to "#b_home/tool"
+
+Every path in an archive is checked to make sure that it ends up in the
+directory that the archive is unpacked to so that an archive with entries
+such as ../../.bashrc (known as zip slip) or with links that point outside of
+the directory can't write anywhere else. Files keep their permissions (less
+those masked by the umask) and when they were last modified.
+*/
+package parser
+
+import (
+	"appetit/xz"
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+Returned (wrapped) when an entry in an archive would be unpacked outside of
+the directory that the archive is being unpacked to.
+*/
+var ErrUnsafeArchivePath = errors.New("the path is outside of the directory")
+
+// The most that the target of a link in a zip archive can be
+const MAX_ARCHIVE_LINK_SIZE = 4096
+
+/*
+The ArchiveEntry type houses a file, directory, or link in an archive. The
+structure of an entry is as follows:
+  - Name [string]: the path of the entry in the archive, separated by slashes
+  - Mode [fs.FileMode]: the type of the entry and its permissions
+  - ModTime [time.Time]: when the entry was last modified
+  - Size [int64]: the number of bytes in a file
+  - Link [string]: what a link points to, empty if the entry isn't a link
+  - HardLink [bool]: whether the link is a hard link to another entry in the
+    archive rather than a symbolic link
+*/
+type ArchiveEntry struct {
+	Name     string
+	Mode     fs.FileMode
+	ModTime  time.Time
+	Size     int64
+	Link     string
+	HardLink bool
+}
+
+/*
+The ArchiveReader type houses a function that reads each entry of an archive
+in turn. Parameters include the path of the archive and the function to pass
+each entry and its contents to, which stops the reading by returning an
+error. Returns an error if the archive couldn't be read.
+*/
+type ArchiveReader func(
+	archive_path string, handle func(ArchiveEntry, io.Reader) error) error
+
+/*
+Get the statements that unpack archives and the reader for the archives that
+each unpacks. No parameters. Returns the readers by the statement names.
+*/
+func ArchiveReaders() map[string]ArchiveReader {
+	return map[string]ArchiveReader{
+		"unzip": ReadZipEntries,
+		"untar": ReadTarEntries,
+	}
+}
+
+/*
+Read each entry of a zip archive. Parameters include the path of the archive
+and the function to pass each entry to. Returns an error if the archive
+couldn't be read or the function returned one.
+*/
+func ReadZipEntries(
+	archive_path string, handle func(ArchiveEntry, io.Reader) error) error {
+	archive, open_error := zip.OpenReader(archive_path)
+	if open_error != nil {
+		return open_error
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		entry := ArchiveEntry{
+			Name:    file.Name,
+			Mode:    file.Mode(),
+			ModTime: file.Modified,
+			Size:    int64(file.UncompressedSize64),
+		}
+		contents, open_error := file.Open()
+		if open_error != nil {
+			return fmt.Errorf("%s couldn't be read: %w", file.Name, open_error)
+		}
+		// The target of a link is stored as its contents
+		if entry.Mode&fs.ModeSymlink != 0 {
+			target, read_error := io.ReadAll(
+				io.LimitReader(contents, MAX_ARCHIVE_LINK_SIZE))
+			if read_error != nil {
+				contents.Close()
+				return fmt.Errorf("%s couldn't be read: %w",
+					file.Name, read_error)
+			}
+			entry.Link = string(target)
+		}
+		handle_error := handle(entry, contents)
+		contents.Close()
+		if handle_error != nil {
+			return handle_error
+		}
+	}
+	return nil
+}
+
+/*
+Read each entry of a tar archive, which can be uncompressed or compressed
+with gzip, bzip2, or xz. The compression is worked out from the start of the
+file rather than its extension. Parameters include the path of the archive
+and the function to pass each entry to. Returns an error if the archive
+couldn't be read or the function returned one.
+*/
+func ReadTarEntries(
+	archive_path string, handle func(ArchiveEntry, io.Reader) error) error {
+	file, open_error := os.Open(archive_path)
+	if open_error != nil {
+		return open_error
+	}
+	defer file.Close()
+
+	// Work out how the archive is compressed from its first few bytes
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(6)
+	var contents io.Reader = buffered
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gzip_reader, gzip_error := gzip.NewReader(buffered)
+		if gzip_error != nil {
+			return gzip_error
+		}
+		defer gzip_reader.Close()
+		contents = gzip_reader
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		contents = bzip2.NewReader(buffered)
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		xz_reader, xz_error := xz.NewReader(buffered)
+		if xz_error != nil {
+			return xz_error
+		}
+		contents = xz_reader
+	}
+
+	tar_reader := tar.NewReader(contents)
+	for {
+		header, next_error := tar_reader.Next()
+		if next_error == io.EOF {
+			return nil
+		}
+		if next_error != nil {
+			return next_error
+		}
+		entry := ArchiveEntry{
+			Name:    header.Name,
+			Mode:    header.FileInfo().Mode(),
+			ModTime: header.ModTime,
+			Size:    header.Size,
+		}
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			entry.Link = header.Linkname
+		case tar.TypeLink:
+			entry.Link, entry.HardLink = header.Linkname, true
+		}
+		if handle_error := handle(entry, tar_reader); handle_error != nil {
+			return handle_error
+		}
+	}
+}
+
+/*
+The ArchiveExtractor type houses the unpacking of an archive into a
+directory. The structure of the extractor is as follows:
+  - Destination [string]: the directory that the archive is unpacked to,
+    with any links in its path followed
+  - Output [io.Writer]: where each entry is noted as it is unpacked, nil to
+    unpack quietly
+  - Files [int]: the number of files unpacked so far
+  - Bytes [int64]: the number of bytes written so far
+*/
+type ArchiveExtractor struct {
+	Destination string
+	Output      io.Writer
+	Files       int
+	Bytes       int64
+	/* Hold the directories in the archive so that their permissions and when
+	they were last modified can be set once everything in them is unpacked
+	*/
+	directories map[string]ArchiveEntry
+}
+
+/*
+Create an extractor for a directory, making the directory if it isn't there
+yet. Parameters include the directory and where to note each entry, nil for
+nowhere. Returns the extractor and an error if the directory couldn't be
+made.
+*/
+func NewArchiveExtractor(
+	destination string, output io.Writer) (*ArchiveExtractor, error) {
+	if make_error := os.MkdirAll(destination, 0750); make_error != nil {
+		return nil, make_error
+	}
+	resolved, resolve_error := filepath.EvalSymlinks(destination)
+	if resolve_error != nil {
+		return nil, resolve_error
+	}
+	resolved, resolve_error = filepath.Abs(resolved)
+	if resolve_error != nil {
+		return nil, resolve_error
+	}
+	return &ArchiveExtractor{
+		Destination: resolved,
+		Output:      output,
+		directories: map[string]ArchiveEntry{},
+	}, nil
+}
+
+/*
+Get the path of an entry relative to where the archive is unpacked. Archives
+made on Windows can separate paths with backslashes so those are treated as
+slashes. Parameters include the name of the entry. Returns the path and an
+error wrapping ErrUnsafeArchivePath if it would be outside of where the
+archive is unpacked (eg. ../../.bashrc or /etc/passwd).
+*/
+func LocalArchivePath(name string) (string, error) {
+	local := filepath.FromSlash(strings.ReplaceAll(name, "\\", "/"))
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("%s: %w", name, ErrUnsafeArchivePath)
+	}
+	return local, nil
+}
+
+/*
+Get where an entry is unpacked to. Parameters include the name of the entry.
+Returns the path and an error if it would be outside of the destination (see
+LocalArchivePath()).
+*/
+func (extractor *ArchiveExtractor) EntryPath(name string) (string, error) {
+	local, local_error := LocalArchivePath(name)
+	if local_error != nil {
+		return "", local_error
+	}
+	return filepath.Join(extractor.Destination, local), nil
+}
+
+/*
+Check whether a path is in the destination, following any links in it. As
+much of the path as exists is checked given that what is left is yet to be
+made. The path isn't cleaned first so that a .. is taken from where a link
+leads rather than from the path as it is written. Parameters include the
+path, which starts with the destination. Returns an error wrapping
+ErrUnsafeArchivePath if it isn't in the destination.
+*/
+func (extractor *ArchiveExtractor) CheckInside(path string) error {
+	separator := string(filepath.Separator)
+	parts := strings.Split(
+		strings.TrimPrefix(path, extractor.Destination), separator)
+	existing, index := extractor.Destination, 0
+	for ; index < len(parts); index++ {
+		if parts[index] == "" {
+			continue
+		}
+		if _, lstat_error := os.Lstat(
+			existing + separator + parts[index]); lstat_error != nil {
+			break
+		}
+		existing += separator + parts[index]
+	}
+	resolved, resolve_error := filepath.EvalSymlinks(existing)
+	if resolve_error != nil {
+		return resolve_error
+	}
+	relative, relative_error := filepath.Rel(extractor.Destination,
+		filepath.Join(resolved, filepath.Join(parts[index:]...)))
+	if relative_error != nil ||
+		(relative != "." && !filepath.IsLocal(relative)) {
+		return fmt.Errorf("%s: %w",
+			strings.TrimPrefix(path, extractor.Destination+separator),
+			ErrUnsafeArchivePath)
+	}
+	return nil
+}
+
+/*
+Make way for an entry, checking that where it goes is in the destination,
+making the directories that it goes in, and removing a file or link that is
+already there so that a link can't be written through. Parameters include
+the path of the entry. Returns an error if it can't be unpacked there.
+*/
+func (extractor *ArchiveExtractor) Prepare(path string) error {
+	// The entry itself isn't followed as it is removed if it is a link
+	if inside_error := extractor.CheckInside(
+		filepath.Dir(path)); inside_error != nil {
+		return inside_error
+	}
+	if make_error := os.MkdirAll(filepath.Dir(path), 0755); make_error != nil {
+		return make_error
+	}
+	info, lstat_error := os.Lstat(path)
+	if lstat_error != nil {
+		return nil
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is already a directory", path)
+	}
+	return os.Remove(path)
+}
+
+/*
+Unpack an entry. Devices and named pipes are skipped. Parameters include the
+entry and its contents. Returns an error if it couldn't be unpacked.
+*/
+func (extractor *ArchiveExtractor) Extract(
+	entry ArchiveEntry, contents io.Reader) error {
+	path, path_error := extractor.EntryPath(entry.Name)
+	if path_error != nil {
+		return path_error
+	}
+	// The destination itself (eg. ./ in a tar archive) is already there
+	if path == extractor.Destination {
+		return nil
+	}
+	switch {
+	case entry.HardLink:
+		return extractor.ExtractHardLink(path, entry)
+	case entry.Mode.IsDir():
+		return extractor.ExtractDirectory(path, entry)
+	case entry.Mode&fs.ModeSymlink != 0:
+		return extractor.ExtractSymlink(path, entry)
+	case entry.Mode.IsRegular():
+		return extractor.ExtractFile(path, entry, contents)
+	}
+	extractor.Note("    :: Skipping %s as it isn't a file, directory, or "+
+		"link\n", entry.Name)
+	return nil
+}
+
+/*
+Note what is being unpacked where there is somewhere to note it. Parameters
+include the format and its arguments. No returns.
+*/
+func (extractor *ArchiveExtractor) Note(format string, arguments ...any) {
+	if extractor.Output != nil {
+		fmt.Fprintf(extractor.Output, format, arguments...)
+	}
+}
+
+/*
+Make a directory in the archive. Its permissions and when it was last
+modified are set by Finish(). Parameters include the path and the entry.
+Returns an error if the directory couldn't be made.
+*/
+func (extractor *ArchiveExtractor) ExtractDirectory(
+	path string, entry ArchiveEntry) error {
+	if inside_error := extractor.CheckInside(path); inside_error != nil {
+		return inside_error
+	}
+	extractor.Note(":: Making %s...\n", entry.Name)
+	// The directory needs to be writable until everything is unpacked into it
+	make_error := os.MkdirAll(path, entry.Mode.Perm()|0700)
+	if make_error != nil {
+		return make_error
+	}
+	extractor.directories[path] = entry
+	return nil
+}
+
+/*
+Write a file in the archive with its permissions and when it was last
+modified. Parameters include the path, the entry, and its contents. Returns
+an error if the file couldn't be written.
+*/
+func (extractor *ArchiveExtractor) ExtractFile(
+	path string, entry ArchiveEntry, contents io.Reader) error {
+	if prepare_error := extractor.Prepare(path); prepare_error != nil {
+		return prepare_error
+	}
+	extractor.Note("    :: Unpacking %s [%d bytes]...", entry.Name, entry.Size)
+	// The file is made anew so that the umask applies to its permissions
+	file, create_error := os.OpenFile(
+		path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, entry.Mode.Perm())
+	if create_error != nil {
+		return create_error
+	}
+	written, copy_error := io.Copy(file, contents)
+	close_error := file.Close()
+	if copy_error = errors.Join(copy_error, close_error); copy_error != nil {
+		return fmt.Errorf("%s couldn't be written: %w", entry.Name, copy_error)
+	}
+	extractor.Files += 1
+	extractor.Bytes += written
+	extractor.Note("done.\n")
+	return os.Chtimes(path, entry.ModTime, entry.ModTime)
+}
+
+/*
+Make a symbolic link in the archive, so long as it points to somewhere in the
+destination. Parameters include the path and the entry. Returns an error if
+the link couldn't be made or would point outside of the destination.
+*/
+func (extractor *ArchiveExtractor) ExtractSymlink(
+	path string, entry ArchiveEntry) error {
+	target := filepath.FromSlash(entry.Link)
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("%s links to %s: %w",
+			entry.Name, entry.Link, ErrUnsafeArchivePath)
+	}
+	if inside_error := extractor.CheckInside(filepath.Dir(path) +
+		string(filepath.Separator) + target); inside_error != nil {
+		return fmt.Errorf("%s links to %s: %w",
+			entry.Name, entry.Link, ErrUnsafeArchivePath)
+	}
+	if prepare_error := extractor.Prepare(path); prepare_error != nil {
+		return prepare_error
+	}
+	extractor.Note("    :: Linking %s to %s...\n", entry.Name, entry.Link)
+	return os.Symlink(target, path)
+}
+
+/*
+Make a hard link to a file that has already been unpacked from the archive.
+Parameters include the path and the entry. Returns an error if the link
+couldn't be made.
+*/
+func (extractor *ArchiveExtractor) ExtractHardLink(
+	path string, entry ArchiveEntry) error {
+	target, path_error := extractor.EntryPath(entry.Link)
+	if path_error != nil {
+		return path_error
+	}
+	if inside_error := extractor.CheckInside(target); inside_error != nil {
+		return inside_error
+	}
+	if info, lstat_error := os.Lstat(target); lstat_error != nil ||
+		!info.Mode().IsRegular() {
+		return fmt.Errorf("%s links to %s, which isn't a file in the archive",
+			entry.Name, entry.Link)
+	}
+	if prepare_error := extractor.Prepare(path); prepare_error != nil {
+		return prepare_error
+	}
+	extractor.Note("    :: Linking %s to %s...\n", entry.Name, entry.Link)
+	return os.Link(target, path)
+}
+
+/*
+Set the permissions of the directories in the archive and when they were last
+modified, which is done last as unpacking into a directory changes when it
+was last modified. The directories deepest in are done first. No parameters.
+Returns an error if any couldn't be set.
+*/
+func (extractor *ArchiveExtractor) Finish() error {
+	var finish_errors []error
+	paths := slices.Sorted(maps.Keys(extractor.directories))
+	slices.Reverse(paths)
+	for _, path := range paths {
+		entry := extractor.directories[path]
+		info, lstat_error := os.Lstat(path)
+		if lstat_error != nil {
+			finish_errors = append(finish_errors, lstat_error)
+			continue
+		}
+		// Keep the permissions that the umask left for others
+		mode := info.Mode().Perm()&^0700 | entry.Mode.Perm()&0700
+		finish_errors = append(finish_errors,
+			os.Chmod(path, mode),
+			os.Chtimes(path, entry.ModTime, entry.ModTime),
+		)
+	}
+	return errors.Join(finish_errors...)
+}
+
+/*
+Summarise what is in an archive without unpacking it (eg. for a plan line),
+checking that every entry would be unpacked into the directory. Parameters
+include the reader for the archive and its path. Returns a summary such as
+"(3 files, 12 KB)" or "(does not exist)" if there is no archive at the path
+yet, and an error if the archive couldn't be read or has an entry that would
+be unpacked outside of the directory.
+*/
+func ArchiveSummary(reader ArchiveReader, archive_path string) (string, error) {
+	// An earlier statement may make the archive, so that isn't a problem here
+	if _, stat_error := os.Stat(archive_path); stat_error != nil {
+		return "(does not exist)", nil
+	}
+	file_count := 0
+	var total_bytes int64
+	read_error := reader(archive_path,
+		func(entry ArchiveEntry, contents io.Reader) error {
+			if _, local_error := LocalArchivePath(entry.Name); local_error != nil {
+				return local_error
+			}
+			if entry.Mode.IsRegular() && !entry.HardLink {
+				file_count += 1
+				total_bytes += entry.Size
+			}
+			return nil
+		})
+	return FileCountSummary(file_count, total_bytes), read_error
+}
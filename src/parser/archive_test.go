@@ -0,0 +1,275 @@
+package parser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+A tar archive compressed by the xz command, which holds bin/tool (with the
+mode 0750) that has "echo xz" in it.
+*/
+const TEST_XZ_TAR = "/Td6WFoAAATm1rRGAgAhARwAAAAQz1jM4Cf/AHZdADEaShsFkZA3OlG9" +
+	"V3Gz1uIDp1bm79T1LiOWxoeubq0q8aX1/81cwRwPWVdxjwcnFvXd/b5ebFA1SFkXg4OO" +
+	"C2rzAlZY2Ip9cAzO/rJgqkYqlU2v6CnMFC+/0Vuoi/HCxkvO6mj2KksORfzjb37n44Ph" +
+	"k4vxRgAAAAAQJVWvVe8nCQABkgGAUAAArtJ+L7HEZ/sCAAAAAARZWg=="
+
+/*
+Write a zip archive with a file for each of the names and contents passed.
+Names that end with a slash are made as directories.
+*/
+func writeTestZip(t *testing.T, archive_path string, files [][2]string) {
+	archive, create_error := os.Create(archive_path)
+	if create_error != nil {
+		t.Fatal(create_error)
+	}
+	defer archive.Close()
+	writer := zip.NewWriter(archive)
+	for _, file := range files {
+		header := &zip.FileHeader{
+			Name:     file[0],
+			Modified: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		}
+		header.SetMode(0750)
+		if strings.HasSuffix(file[0], "/") {
+			header.SetMode(os.ModeDir | 0755)
+		}
+		contents, header_error := writer.CreateHeader(header)
+		if header_error != nil {
+			t.Fatal(header_error)
+		}
+		contents.Write([]byte(file[1]))
+	}
+	writer.Close()
+}
+
+/*
+Write a gzip compressed tar archive with the headers passed, each file
+holding its name as its contents.
+*/
+func writeTestTar(t *testing.T, archive_path string, headers []*tar.Header) {
+	var archive bytes.Buffer
+	gzip_writer := gzip.NewWriter(&archive)
+	writer := tar.NewWriter(gzip_writer)
+	for _, header := range headers {
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(header.Name))
+		}
+		if header_error := writer.WriteHeader(header); header_error != nil {
+			t.Fatal(header_error)
+		}
+		if header.Typeflag == tar.TypeReg {
+			writer.Write([]byte(header.Name))
+		}
+	}
+	writer.Close()
+	gzip_writer.Close()
+	os.WriteFile(archive_path, archive.Bytes(), 0644)
+}
+
+/*
+Check to make sure that the unzip and untar statements unpack each file with
+its permissions and when it was last modified, along with directories and
+links.
+*/
+func TestExtractStatements(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The permissions and links checked are Unix ones")
+	}
+	directory := t.TempDir()
+	zip_path := filepath.Join(directory, "tool.zip")
+	writeTestZip(t, zip_path, [][2]string{
+		{"tool/", ""},
+		{"tool/bin/run.sh", "echo hi"},
+		{"tool\\README", "Read me"},
+	})
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output, Verbose: true})
+	run_error := interpreter.RunString(
+		"unzip \"" + zip_path + "\" to \"" + directory + "/unzipped\"")
+	if run_error != nil {
+		t.Fatalf("[unzip] Expected no error, got %v", run_error)
+	}
+	script := filepath.Join(directory, "unzipped", "tool", "bin", "run.sh")
+	contents, _ := os.ReadFile(script)
+	info, stat_error := os.Stat(script)
+	if string(contents) != "echo hi" || stat_error != nil ||
+		info.Mode().Perm() != 0750 ||
+		!info.ModTime().Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("[unzip] Expected run.sh with its mode and time, got %q %v",
+			contents,
+			info)
+	}
+	if _, stat_error := os.Stat(filepath.Join(
+		directory, "unzipped", "tool", "README")); stat_error != nil {
+		t.Errorf("[unzip] Expected a backslash to separate the path")
+	}
+	if !strings.Contains(output.String(), "[2 files, 14 bytes written]") {
+		t.Errorf("[unzip] Expected the progress, got %q", output.String())
+	}
+
+	// A tar archive can have links too
+	tar_path := filepath.Join(directory, "tool.tar.gz")
+	writeTestTar(t, tar_path, []*tar.Header{
+		{Name: "./", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "lib/", Typeflag: tar.TypeDir, Mode: 0700,
+			ModTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "lib/core.so", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "core.so", Typeflag: tar.TypeSymlink, Linkname: "lib/core.so"},
+		{Name: "copy.so", Typeflag: tar.TypeLink, Linkname: "lib/core.so"},
+	})
+	interpreter = New(Options{Stdout: &bytes.Buffer{}})
+	run_error = interpreter.RunString(
+		"untar \"" + tar_path + "\" to \"" + directory + "/untarred\"")
+	if run_error != nil {
+		t.Fatalf("[untar] Expected no error, got %v", run_error)
+	}
+	for _, name := range []string{"lib/core.so", "core.so", "copy.so"} {
+		contents, _ := os.ReadFile(filepath.Join(directory, "untarred", name))
+		if string(contents) != "lib/core.so" {
+			t.Errorf("[untar] Expected %s to be unpacked, got %q",
+				name,
+				contents)
+		}
+	}
+	info, _ = os.Stat(filepath.Join(directory, "untarred", "lib"))
+	if info == nil || info.Mode().Perm() != 0700 ||
+		info.ModTime().Year() != 2023 {
+		t.Errorf("[untar] Expected lib to keep its mode and time, got %v",
+			info)
+	}
+
+	// A tar archive can be compressed with xz too
+	xz_path := filepath.Join(directory, "tool.tar.xz")
+	xz_data, _ := base64.StdEncoding.DecodeString(TEST_XZ_TAR)
+	os.WriteFile(xz_path, xz_data, 0644)
+	run_error = New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"untar \"" + xz_path + "\" to \"" + directory + "/unxzed\"")
+	if run_error != nil {
+		t.Fatalf("[untar] Expected no error, got %v", run_error)
+	}
+	tool := filepath.Join(directory, "unxzed", "bin", "tool")
+	contents, _ = os.ReadFile(tool)
+	info, _ = os.Stat(tool)
+	if string(contents) != "echo xz" || info == nil ||
+		info.Mode().Perm() != 0750 {
+		t.Errorf("[untar] Expected bin/tool with its mode, got %q %v",
+			contents,
+			info)
+	}
+
+	// A dry run only says what would be unpacked
+	output.Reset()
+	interpreter = New(Options{Stdout: &output, DryRun: true})
+	run_error = interpreter.RunString(
+		"untar \"" + tar_path + "\" to \"" + directory + "/planned\"")
+	_, stat_error = os.Stat(filepath.Join(directory, "planned"))
+	if run_error != nil || stat_error == nil ||
+		!strings.Contains(output.String(), "(1 file, 1 KB)") {
+		t.Errorf("[untar] Expected a plan, got %q (%v)",
+			output.String(),
+			run_error)
+	}
+
+	// An archive that an earlier statement would make is only planned too
+	output.Reset()
+	run_error = New(Options{Stdout: &output, DryRun: true}).RunString(
+		"unzip \"" + directory + "/later.zip\" to \"" + directory + "/planned\"")
+	if run_error != nil ||
+		!strings.Contains(output.String(), "(does not exist)") {
+		t.Errorf("[unzip] Expected a plan, got %q (%v)",
+			output.String(),
+			run_error)
+	}
+
+	// The form of each statement is checked
+	for _, line := range []string{
+		"unzip \"a.zip\"",
+		"untar \"a.tar\" = \"x\"",
+	} {
+		if New(Options{Check: true}).RunString(line) == nil {
+			t.Errorf("[unzip] Expected a problem with %q", line)
+		}
+	}
+}
+
+/*
+Check to make sure that nothing in an archive can be unpacked outside of the
+directory that it is unpacked to, whether by its path or through a link.
+*/
+func TestExtractUnsafePaths(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The links checked are Unix ones")
+	}
+	directory := t.TempDir()
+	outside := t.TempDir()
+	unpack_to := filepath.Join(directory, "unpacked")
+	os.MkdirAll(unpack_to, 0755)
+	// A link that was already there isn't written through
+	os.Symlink(outside, filepath.Join(unpack_to, "existing"))
+
+	cases := map[string][]*tar.Header{
+		"parent":   {{Name: "../evil.txt", Typeflag: tar.TypeReg}},
+		"absolute": {{Name: "/evil.txt", Typeflag: tar.TypeReg}},
+		"link": {
+			{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "../.."},
+		},
+		"absolute link": {
+			{Name: "etc", Typeflag: tar.TypeSymlink, Linkname: outside},
+		},
+		"chained link": {
+			{Name: "here", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "up", Typeflag: tar.TypeSymlink, Linkname: "here/.."},
+		},
+		"existing link": {
+			{Name: "existing/evil.txt", Typeflag: tar.TypeReg},
+		},
+		"hard link": {
+			{Name: "pw", Typeflag: tar.TypeLink, Linkname: "../../passwd"},
+		},
+	}
+	for name, headers := range cases {
+		tar_path := filepath.Join(directory, "archive.tar.gz")
+		writeTestTar(t, tar_path, headers)
+		run_error := New(Options{Stdout: &bytes.Buffer{}}).RunString(
+			"untar \"" + tar_path + "\" to \"" + unpack_to + "\"")
+		var script_error *ScriptError
+		if !errors.As(run_error, &script_error) ||
+			script_error.Rule != "runtime/archive-path" {
+			t.Errorf("[untar] Expected the %s to be refused, got %v",
+				name,
+				run_error)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("[untar] Expected nothing outside, got %v", entries)
+	}
+	if _, stat_error := os.Stat(
+		filepath.Join(directory, "evil.txt")); stat_error == nil {
+		t.Errorf("[untar] Expected evil.txt not to be written")
+	}
+
+	// The same goes for a zip archive, which is also refused in a dry run
+	zip_path := filepath.Join(directory, "archive.zip")
+	writeTestZip(t, zip_path, [][2]string{{"..\\evil.txt", "x"}})
+	for _, dry_run := range []bool{false, true} {
+		run_error := New(Options{Stdout: &bytes.Buffer{}, DryRun: dry_run}).
+			RunString("unzip \"" + zip_path + "\" to \"" + unpack_to + "\"")
+		var script_error *ScriptError
+		if !errors.As(run_error, &script_error) ||
+			script_error.Rule != "runtime/archive-path" {
+			t.Errorf("[unzip] Expected ..\\evil.txt to be refused, got %v",
+				run_error)
+		}
+	}
+}
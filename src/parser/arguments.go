@@ -0,0 +1,469 @@
+/*
+The arguments house the command line arguments that are passed to a script
+after its name (eg. appetit backup.apt /home --target /srv --force). The
+arguments are handed to the script as reserved variables:
+  - b_arg_count is the number of positional arguments (ie. those that aren't
+    options);
+  - b_arg_1, b_arg_2, and so on are the positional arguments;
+  - b_opt_[name] is the value of the option --[name] (eg. b_opt_target is
+    /srv) or true for an option without a value (eg. b_opt_force).
+
+A script can declare what it takes with params statements at the top of the
+script, before anything other than minver. Once a script declares its
+parameters, anything that it doesn't declare is an error, a declared
+parameter with a default is given its default when it isn't passed, and
+passing -h (or --help) prints the usage of the script rather than running it.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The kinds of parameters that a params statement can declare
+const (
+	PARAMETER_ARGUMENT = "argument"
+	PARAMETER_OPTION   = "option"
+	PARAMETER_FLAG     = "flag"
+)
+
+// The prefixes of the reserved variables that hold the arguments and options
+const (
+	RESERVED_ARGUMENT_PREFIX = SYMBOL_RESERVED_VARIABLE_PREFIX + "arg_"
+	RESERVED_OPTION_PREFIX   = SYMBOL_RESERVED_VARIABLE_PREFIX + "opt_"
+)
+
+/*
+The Parameter type houses a parameter declared with a params statement. The
+structure of the parameter is as follows:
+  - Kind [string]: the kind of parameter, one of PARAMETER_ARGUMENT,
+    PARAMETER_OPTION, or PARAMETER_FLAG
+  - Name [string]: the name of the parameter (eg. target for --target)
+  - Default [*Token]: the token of the default value, nil if there isn't one
+  - Help [string]: the help text shown by -h, empty if there isn't any
+  - Tokens [[]Token]: the tokens of the params statement
+*/
+type Parameter struct {
+	Kind    string
+	Name    string
+	Default *Token
+	Help    string
+	Tokens  []Token
+}
+
+/*
+Get the kinds of parameters that a params statement can declare. No
+parameters. Returns the kinds.
+*/
+func ParameterKinds() []string {
+	return []string{PARAMETER_ARGUMENT, PARAMETER_OPTION, PARAMETER_FLAG}
+}
+
+/*
+Pull the parameter out of a params statement. The statement is assumed to be
+well formed (see CheckParams()). Parameters include the tokens of the
+statement. Returns the parameter.
+*/
+func ParseParameter(tokens []Token) Parameter {
+	parameter := Parameter{
+		Kind:   tokens[2].TokenValue,
+		Name:   tokens[3].TokenValue,
+		Tokens: tokens,
+	}
+	// The help text follows the default, if there is one
+	help_index := 4
+	if len(tokens) > 5 && tokens[4].TokenValue == "=" {
+		parameter.Default = &tokens[5]
+		help_index = 6
+	}
+	if len(tokens) > help_index {
+		parameter.Help = FixStringCombined(tokens[help_index].TokenValue)
+	}
+	return parameter
+}
+
+/*
+Get the name of the reserved variable that a parameter is given in.
+Parameters include the position of the parameter among the arguments (from
+one), which is ignored for options and flags. Returns the name of the
+variable.
+*/
+func (parameter Parameter) VariableName(position int) string {
+	if parameter.Kind == PARAMETER_ARGUMENT {
+		return RESERVED_ARGUMENT_PREFIX + strconv.Itoa(position)
+	}
+	return RESERVED_OPTION_PREFIX + parameter.Name
+}
+
+/*
+Add a parameter to the script. The params statements need to come before
+anything else in the script (other than minver) so that the parameters are
+known before the script starts. Parameters include the tokens of the params
+statement and whether it is in a define block. Returns an error if the
+statement isn't at the top of the script or declares a parameter twice.
+*/
+func (script *Script) AddParameter(tokens []Token, in_define bool) error {
+	// Get the line and full line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	full_loc := tokens[0].FullLineOfCode
+	parameter := ParseParameter(tokens)
+
+	// The statement needs to be at the top of the script
+	is_header := !in_define
+	for _, statement := range script.Statements {
+		if statement.Name != "minver" && statement.Name != "params" {
+			is_header = false
+		}
+	}
+	if !is_header {
+		return Report(
+			"The "+utils.ColouriseCyan("params")+" statements need to be at "+
+				"the top of the script, before anything other than "+
+				utils.ColouriseCyan("minver")+", so that the parameters "+
+				"are known before the script starts.",
+			loc,
+			tokens[1].TokenPosition,
+			full_loc,
+		)
+	}
+
+	// Loop over the parameters that have been declared so far
+	for _, declared := range script.Parameters {
+		// Each parameter needs its own name
+		if declared.Name == parameter.Name {
+			return Report(
+				"The parameter "+utils.ColouriseYellow(parameter.Name)+
+					" is already declared on line "+utils.ColouriseYellow(
+					strconv.Itoa(declared.Tokens[0].LineNumber))+".",
+				loc,
+				tokens[3].TokenPosition,
+				full_loc,
+			)
+		}
+		/*
+			An argument without a default can't follow one with a default as
+			there would be no way to pass the one without skipping the other.
+		*/
+		if parameter.Kind == PARAMETER_ARGUMENT && parameter.Default == nil &&
+			declared.Kind == PARAMETER_ARGUMENT && declared.Default != nil {
+			return Report(
+				"The argument "+utils.ColouriseYellow(parameter.Name)+
+					" needs a default as it follows the argument "+
+					utils.ColouriseYellow(declared.Name)+" which has one.",
+				loc,
+				tokens[3].TokenPosition,
+				full_loc,
+			).WithHint("Declare the arguments that have to be passed " +
+				"before those with defaults.")
+		}
+	}
+	script.Parameters = append(script.Parameters, parameter)
+	return nil
+}
+
+/*
+Split the arguments passed to a script into its positional arguments and its
+options. An option is written as --name=value, --name value, or --name (a
+flag) and can use a single dash in place of two. Dashes in the name of an
+option are taken as underscores (eg. --dry-run is dry_run), anything after
+-- is a positional argument, and so is a negative number (eg. -1). Where the
+script declares parameters, the options need to be declared and a flag never
+takes the argument after it. Otherwise, an option takes the argument after it
+unless that starts with a dash. Parameters include the arguments and the
+parameters of the script. Returns the positional arguments, the options by
+their names, whether -h or --help was passed, and an error if the arguments
+don't match the parameters.
+*/
+func ParseArguments(arguments []string, parameters []Parameter) (
+	[]string, map[string]string, bool, error) {
+	// Hold the options that were declared by their names
+	declared := map[string]Parameter{}
+	declared_arguments := 0
+	for _, parameter := range parameters {
+		if parameter.Kind == PARAMETER_ARGUMENT {
+			declared_arguments++
+		} else {
+			declared[parameter.Name] = parameter
+		}
+	}
+
+	positional := []string{}
+	options := map[string]string{}
+	help := false
+	only_positional := false
+	for index := 0; index < len(arguments); index++ {
+		argument := arguments[index]
+		// Anything that isn't an option is a positional argument
+		if only_positional || !strings.HasPrefix(argument, "-") ||
+			argument == "-" || IsNegativeNumber(argument) {
+			positional = append(positional, argument)
+			continue
+		}
+		if argument == "--" {
+			only_positional = true
+			continue
+		}
+
+		// Get the name of the option and its value if it was given one
+		name, value, has_value := strings.Cut(
+			strings.TrimLeft(argument, "-"), "=")
+		name = strings.ReplaceAll(name, "-", "_")
+		parameter, is_declared := declared[name]
+		switch {
+		// Ask for the usage unless the script has its own option called help
+		case (name == "h" || name == "help") && !is_declared:
+			help = true
+			continue
+		case len(parameters) > 0 && !is_declared:
+			return nil, nil, false, ReportArguments(
+				"The script doesn't take the option " +
+					utils.ColouriseYellow(argument) + ".")
+		case parameter.Kind == PARAMETER_FLAG && has_value:
+			// A flag can be turned off (eg. --force=false)
+			if _, bool_error := strconv.ParseBool(value); bool_error != nil {
+				return nil, nil, false, ReportArguments(
+					"The option " + utils.ColouriseYellow("--"+name) +
+						" is either on or off so it needs to be " +
+						utils.ColouriseGreen("true") + " or " +
+						utils.ColouriseGreen("false") + " rather than " +
+						utils.ColouriseYellow(value) + ".")
+			}
+		case parameter.Kind == PARAMETER_FLAG:
+			value = "true"
+		case has_value:
+		// A declared option takes the argument after it, whatever it is
+		case is_declared:
+			if index+1 >= len(arguments) {
+				return nil, nil, false, ReportArguments(
+					"The option " + utils.ColouriseYellow(argument) +
+						" needs a value (eg. " +
+						utils.ColouriseYellow(argument+" value") + ").")
+			}
+			index++
+			value = arguments[index]
+		// Otherwise, guess whether the argument after it is its value
+		case index+1 < len(arguments) &&
+			!strings.HasPrefix(arguments[index+1], "-"):
+			index++
+			value = arguments[index]
+		default:
+			value = "true"
+		}
+		options[name] = value
+	}
+
+	// A script with parameters can't be passed more arguments than it takes
+	if len(parameters) > 0 && len(positional) > declared_arguments {
+		return nil, nil, false, ReportArguments(
+			"The script takes " + strconv.Itoa(declared_arguments) +
+				" arguments but was passed " + strconv.Itoa(len(positional)) +
+				" (" + utils.ColouriseYellow(strings.Join(
+				positional[declared_arguments:], " ")) + " is left over).")
+	}
+	return positional, options, help, nil
+}
+
+/*
+Check whether an argument is a negative number (eg. -1 or -2.5) rather than
+an option. Parameters include the argument. Returns true if it is.
+*/
+func IsNegativeNumber(argument string) bool {
+	_, parse_error := strconv.ParseFloat(argument, 64)
+	return strings.HasPrefix(argument, "-") && parse_error == nil
+}
+
+/*
+Report a problem with the arguments passed to a script. Parameters include
+the message. Returns the error.
+*/
+func ReportArguments(message string) *ScriptError {
+	return ReportSimple(message).WithCategory(ERROR_USAGE).WithRule(
+		"usage/arguments").WithHint(
+		"Pass " + utils.ColouriseYellow("-h") + " after the name of the " +
+			"script to see what it takes.")
+}
+
+/*
+Set the reserved variables for the arguments passed to the script. The
+declared parameters that weren't passed are given their defaults and a
+passed value is converted to the type of the default (eg. --retries 3 is an
+integer where the default is 3). When checking a script, an argument that
+has to be passed but wasn't is left empty so that the script can be checked
+without it. Parameters include the parameters of the script. Returns whether
+the usage was asked for (in which case no variables are set) and an error if
+the arguments don't match the parameters.
+*/
+func (interpreter *Interpreter) ApplyArguments(
+	parameters []Parameter) (bool, error) {
+	// Split the arguments into the positional arguments and options
+	positional, options, help, parse_error := ParseArguments(
+		interpreter.Arguments, parameters)
+	if parse_error != nil {
+		return false, parse_error
+	}
+	interpreter.Parameters = parameters
+	if help {
+		return true, nil
+	}
+
+	// Set the variables for everything that was passed
+	variables := interpreter.Variables
+	variables[RESERVED_ARGUMENT_PREFIX+"count"] = IntegerValue(
+		int64(len(positional)))
+	for index, argument := range positional {
+		variables[RESERVED_ARGUMENT_PREFIX+strconv.Itoa(index+1)] =
+			StringValue(argument)
+	}
+	for name, value := range options {
+		variables[RESERVED_OPTION_PREFIX+name] = StringValue(value)
+	}
+
+	// Loop over the parameters, filling in anything that wasn't passed
+	position := 0
+	for _, parameter := range parameters {
+		// Get what was passed for the parameter, if anything
+		var value string
+		var passed bool
+		if parameter.Kind == PARAMETER_ARGUMENT {
+			position++
+			passed = position <= len(positional)
+			if passed {
+				value = positional[position-1]
+			}
+		} else {
+			value, passed = options[parameter.Name]
+		}
+		variable_name := parameter.VariableName(position)
+
+		switch {
+		// A flag is either on or off
+		case parameter.Kind == PARAMETER_FLAG:
+			is_on, _ := strconv.ParseBool(value)
+			variables[variable_name] = BooleanValue(is_on)
+		case parameter.Default != nil:
+			// Work out the default so that a value can take its type
+			default_value, default_error := interpreter.EvaluateValue(
+				parameter.Tokens, *parameter.Default)
+			if default_error != nil {
+				return false, default_error
+			}
+			if !passed {
+				variables[variable_name] = default_value
+				continue
+			}
+			converted, convert_error := StringValue(value).Convert(
+				default_value.Type)
+			if convert_error != nil {
+				return false, ReportArguments(
+					"The " + parameter.Kind + " " +
+						utils.ColouriseYellow(parameter.Name) + " needs to " +
+						"be of the type " +
+						utils.ColouriseMagenta(default_value.Type.String()) +
+						" (like its default) but was passed " +
+						utils.ColouriseYellow(value) + ".").WithErr(
+					convert_error)
+			}
+			variables[variable_name] = converted
+		case passed:
+		// An option without a default is empty when it isn't passed
+		case parameter.Kind == PARAMETER_OPTION:
+			variables[variable_name] = StringValue("")
+		// An argument without a default has to be passed
+		case interpreter.ModeCheck:
+			variables[variable_name] = StringValue("")
+		default:
+			return false, ReportArguments(
+				"The script needs the argument " +
+					utils.ColouriseYellow(parameter.Name) + ".")
+		}
+	}
+	return false, nil
+}
+
+/*
+Get the usage of the script (ie. what -h prints), listing the parameters
+that it declares with their help and defaults. No parameters. Returns the
+usage.
+*/
+func (interpreter *Interpreter) ScriptUsage() string {
+	script_name := filepath.Base(interpreter.ScriptName)
+	if len(interpreter.Parameters) == 0 {
+		return "Usage: appetit " + script_name + " [arguments]\n\n" +
+			"The script doesn't declare its parameters with the params " +
+			"statement so it takes any arguments and options.\n"
+	}
+
+	// Hold the synopsis and the rows for the arguments and options
+	synopsis := []string{"appetit", script_name}
+	var argument_rows, option_rows [][2]string
+	for _, parameter := range interpreter.Parameters {
+		// Note the default, if there is one, after the help
+		help := parameter.Help
+		if parameter.Default != nil {
+			help = strings.TrimSpace(
+				help + " (default: " + parameter.Default.TokenValue + ")")
+		}
+		switch parameter.Kind {
+		case PARAMETER_ARGUMENT:
+			if parameter.Default != nil {
+				synopsis = append(synopsis, "["+parameter.Name+"]")
+			} else {
+				synopsis = append(synopsis, "<"+parameter.Name+">")
+			}
+			argument_rows = append(
+				argument_rows, [2]string{parameter.Name, help})
+		case PARAMETER_OPTION:
+			option_rows = append(option_rows, [2]string{
+				"--" + strings.ReplaceAll(parameter.Name, "_", "-") +
+					" <value>", help})
+		case PARAMETER_FLAG:
+			option_rows = append(option_rows, [2]string{
+				"--" + strings.ReplaceAll(parameter.Name, "_", "-"), help})
+		}
+	}
+	option_rows = append(option_rows, [2]string{"-h, --help", "Show this help"})
+	if len(option_rows) > 1 {
+		synopsis = slices.Insert(synopsis, 2, "[options]")
+	}
+
+	// Line up the help of the rows
+	width := 0
+	for _, row := range slices.Concat(argument_rows, option_rows) {
+		width = max(width, len(row[0]))
+	}
+	usage := "Usage: " + strings.Join(synopsis, " ") + "\n"
+	for _, section := range []struct {
+		title string
+		rows  [][2]string
+	}{{"Arguments", argument_rows}, {"Options", option_rows}} {
+		if len(section.rows) == 0 {
+			continue
+		}
+		usage += "\n" + section.title + ":\n"
+		for _, row := range section.rows {
+			usage += strings.TrimRight(
+				fmt.Sprintf("  %-*s  %s", width, row[0], row[1]), " ") + "\n"
+		}
+	}
+	return usage
+}
+
+/*
+Check whether a reserved variable holds an argument or an option (eg.
+b_arg_1 or b_opt_target). A script that doesn't declare its parameters can
+be passed anything so these can't be checked ahead of time. Parameters
+include the name of the variable. Returns true if it is.
+*/
+func IsArgumentVariable(variable_name string) bool {
+	if position, is_argument := strings.CutPrefix(
+		variable_name, RESERVED_ARGUMENT_PREFIX); is_argument {
+		return position != "" && position[0] >= '1' && position[0] <= '9'
+	}
+	name, is_option := strings.CutPrefix(variable_name, RESERVED_OPTION_PREFIX)
+	return is_option && name != ""
+}
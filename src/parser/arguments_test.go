@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that the arguments passed to a script without parameters
+are split into positional arguments and options.
+*/
+func TestParseArguments(t *testing.T) {
+	arguments := []string{
+		"/home", "--target", "/srv", "-1", "--dry-run=yes", "--force",
+		"--", "--literal",
+	}
+	positional, options, help, parse_error := ParseArguments(arguments, nil)
+	expected_positional := []string{"/home", "-1", "--literal"}
+	expected_options := map[string]string{
+		"target":  "/srv",
+		"dry_run": "yes",
+		"force":   "true",
+	}
+	if parse_error != nil || help ||
+		!slices.Equal(positional, expected_positional) ||
+		!maps.Equal(options, expected_options) {
+		t.Errorf("[ParseArguments] Expected %v and %v, got %v and %v (%v)",
+			expected_positional,
+			expected_options,
+			positional,
+			options,
+			parse_error)
+	}
+	if _, _, help, _ := ParseArguments([]string{"-h"}, nil); !help {
+		t.Errorf("[ParseArguments] Expected -h to ask for the usage")
+	}
+}
+
+/*
+Check to make sure that declared parameters are given what was passed or
+their defaults, that a passed value takes the type of its default, and that
+anything that wasn't declared is an error.
+*/
+func TestScriptArguments(t *testing.T) {
+	script := "params argument source \"What to back up\"\n" +
+		"params argument keep = 3\n" +
+		"params option target = \"/srv\" \"Where to copy to\"\n" +
+		"params flag force\n" +
+		"set kept = \"#b_arg_2 + 1\"\n" +
+		"writeln \"#b_arg_count #b_arg_1 #kept #b_opt_target #b_opt_force\""
+	// The arguments passed and the output they should give
+	cases := map[string]string{
+		"/home":                         "1 /home 4 /srv false\n",
+		"/home 5 --force":               "2 /home 6 /srv true\n",
+		"--target=/mnt /home --force=0": "1 /home 4 /mnt false\n",
+	}
+	for arguments, expected := range cases {
+		var output bytes.Buffer
+		interpreter := New(Options{
+			Stdout:    &output,
+			Arguments: strings.Fields(arguments),
+		})
+		run_error := interpreter.RunString(script)
+		if run_error != nil || output.String() != expected {
+			t.Errorf("[Arguments] %q printed %q (%v), expected %q",
+				arguments,
+				output.String(),
+				run_error,
+				expected)
+		}
+	}
+
+	// Arguments that don't match the parameters stop the script
+	for _, arguments := range []string{
+		"", "/home x", "/home 5 6", "/home --unknown", "/home --target",
+	} {
+		interpreter := New(Options{
+			Stdout:    &bytes.Buffer{},
+			Arguments: strings.Fields(arguments),
+		})
+		run_error := interpreter.RunString(script)
+		if ExitCode(run_error) != int(ERROR_USAGE) {
+			t.Errorf("[Arguments] Expected a usage error for %q, got %v",
+				arguments,
+				run_error)
+		}
+	}
+
+	// Asking for the usage prints it rather than running the script
+	var output bytes.Buffer
+	interpreter := New(Options{
+		Stdout:    &output,
+		Arguments: []string{"--help"},
+	})
+	interpreter.ScriptName = "backup.apt"
+	if run_error := interpreter.RunString(script); run_error != nil {
+		t.Fatalf("[Arguments] Expected no error, got %v", run_error)
+	}
+	for _, expected := range []string{
+		"Usage: appetit backup.apt [options] <source> [keep]",
+		"  --target <value>  Where to copy to (default: \"/srv\")",
+		"  keep              (default: 3)",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("[Arguments] Expected the usage to include %q, got %q",
+				expected,
+				output.String())
+		}
+	}
+}
+
+/*
+Check to make sure that params statements need to be at the top of the script
+and that the reserved variables that they declare can be checked.
+*/
+func TestParamsStatement(t *testing.T) {
+	interpreter := New(Options{Stdout: &bytes.Buffer{}})
+	run_error := interpreter.RunString("writeln \"Hi\"\nparams flag force")
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) || script_error.Line != 2 {
+		t.Errorf("[Params] Expected an error on line 2, got %v", run_error)
+	}
+
+	// A script that declares its parameters can only use those
+	interpreter = New(Options{Check: true})
+	check_error := interpreter.RunString("params argument source\n" +
+		"writeln \"#b_arg_1 #b_opt_force\"")
+	var check_errors *CheckError
+	if !errors.As(check_error, &check_errors) ||
+		len(check_errors.Problems) != 1 ||
+		!strings.Contains(check_errors.Problems[0].Error(), "b_opt_force") {
+		t.Errorf("[Params] Expected b_opt_force to be reported, got %v",
+			check_error)
+	}
+	// Otherwise, any argument or option can be used
+	interpreter = New(Options{Check: true})
+	check_error = interpreter.RunString("writeln \"#b_arg_1 #b_opt_force\"")
+	if check_error != nil {
+		t.Errorf("[Params] Expected no problems, got %v", check_error)
+	}
+}
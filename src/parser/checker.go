@@ -0,0 +1,314 @@
+/*
+The checker houses the static checker that is used with the -check flag. A
+check parses the script, inclusive of any scripts reached via the run
+statement, much as a normal run would but rather than stopping at the first
+problem, every problem is noted so that they can all be reported at once. Once
+the script is parsed, the variables that it uses are checked to make sure that
+each one is set before it is used. Nothing in the script is executed.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Note a problem found while parsing a script. In check mode, the problem is
+held onto so that parsing can carry on and every problem can be reported at
+once. Otherwise, the problem is handed back so that parsing stops. Either way,
+the problem is tagged with the script that it was found in. Parameters include
+the problem and the name of the script that it was found in. Returns nil in
+check mode and the problem otherwise.
+*/
+func (interpreter *Interpreter) NoteProblem(
+	err error, script_name string) error {
+	// Tag the problem with the script if it hasn't been already
+	var script_error *ScriptError
+	if errors.As(err, &script_error) && script_error.Script == "" {
+		script_error.Script = script_name
+	}
+	// If we aren't checking, stop here
+	if !interpreter.ModeCheck {
+		return err
+	}
+	// Hold onto the problem and carry on
+	interpreter.problems = append(interpreter.problems, err)
+	return nil
+}
+
+/*
+Finish checking a parsed script by checking the variables that it uses. This
+is called by Start() in check mode in place of executing the script.
+Parameters include the parsed script. Returns a CheckError with every problem
+that was found or nil if there were none.
+*/
+func (interpreter *Interpreter) CheckScript(script *Script) error {
+	/*
+		Start with the variables that exist before the script runs (ie. the
+		reserved variables and any that were set by whoever created the
+		interpreter).
+	*/
+	set_variables := map[string]bool{}
+	for variable_name := range interpreter.Variables {
+		set_variables[variable_name] = true
+	}
+	// Hold the scripts that have been checked so none are checked twice
+	checked_scripts := map[*Script]bool{}
+	// Check the script, following it into run targets and procedures
+	interpreter.CheckVariableUse(script, set_variables, checked_scripts)
+
+	/*
+		Check any procedures that were never called. They could be called
+		from anywhere so they are given every variable that the script sets.
+		Sort them so that the problems come out in the same order each time.
+	*/
+	for _, procedure_name := range slices.Sorted(
+		maps.Keys(interpreter.Procedures)) {
+		interpreter.CheckVariableUse(
+			interpreter.Procedures[procedure_name],
+			maps.Clone(set_variables),
+			checked_scripts,
+		)
+	}
+
+	// If there were no problems, the script is good to go
+	if len(interpreter.problems) == 0 {
+		return nil
+	}
+	return &CheckError{Problems: interpreter.problems}
+}
+
+/*
+Check that each variable used in a script is set before it is used. The
+statements are followed in the order that they appear which means that a
+variable set further down the script and reached with a goto statement is
+reported. Parameters include the script, the variables set so far (which is
+updated as variables are set), and the scripts that have been checked.
+Returns nothing as the problems are noted on the interpreter.
+*/
+func (interpreter *Interpreter) CheckVariableUse(
+	script *Script,
+	set_variables map[string]bool,
+	checked_scripts map[*Script]bool) {
+	// If the script has been checked already, there's nothing to do
+	if checked_scripts[script] {
+		return
+	}
+	checked_scripts[script] = true
+
+	// Loop over the statements, checking each before it sets anything
+	for _, statement := range script.Statements {
+		interpreter.CheckVariableReferences(
+			statement.Tokens, set_variables, script.Name)
+
+		// Get the statement that will actually be called
+		tokens := InnermostStatementTokens(statement.Tokens)
+		switch tokens[1].TokenValue {
+		case "ask":
+			set_variables[FixStringCombined(tokens[4].TokenValue)] = true
+		case "set":
+			set_variables[tokens[2].TokenValue] = true
+		case "execute":
+			// The output of the command can be saved to a variable
+			clauses := ClauseTokens(tokens, 3)
+			if token, exists := clauses[EXECUTE_CLAUSE_TO]; exists {
+				set_variables[FixStringCombined(token.TokenValue)] = true
+			}
+		case "fetch", "post", "put":
+			// What the server responds with can be saved to a variable
+			clauses := ClauseTokens(tokens, 3)
+			if token, exists := clauses[REQUEST_CLAUSE_TO]; exists {
+				set_variables[FixStringCombined(token.TokenValue)] = true
+			}
+		case "convert":
+			// A variable needs to have been set before it can be converted
+			variable_name := FixStringCombined(tokens[2].TokenValue)
+			if set_variables[variable_name] {
+				continue
+			}
+			interpreter.NoteProblem(AnnotateError(Report(
+				"The variable "+utils.ColouriseYellow(variable_name)+
+					" is converted before it is set.",
+				strconv.Itoa(tokens[0].LineNumber),
+				tokens[2].TokenPosition,
+				tokens[0].FullLineOfCode,
+			).WithRule("syntax/variable-before-set"), tokens, ERROR_SYNTAX),
+				script.Name)
+		case "call":
+			procedure_call, _ := ParseProcedureCall(tokens)
+			/*
+				A procedure starts with the variables of the script that
+				calls it along with its arguments.
+			*/
+			procedure := interpreter.Procedures[procedure_call.Name]
+			if procedure != nil {
+				procedure_variables := maps.Clone(set_variables)
+				for _, argument := range procedure_call.Arguments {
+					procedure_variables[argument.Name.TokenValue] = true
+				}
+				interpreter.CheckVariableUse(
+					procedure, procedure_variables, checked_scripts)
+			}
+			// Only the outputs make their way back
+			for _, output := range procedure_call.Returns {
+				set_variables[output.TokenValue] = true
+			}
+		case "run":
+			/*
+				A script that is run shares the variables of the script that
+				runs it. The target is only known if it was parsed.
+			*/
+			if statement.Script != nil {
+				interpreter.CheckVariableUse(
+					statement.Script, set_variables, checked_scripts)
+			}
+		}
+	}
+}
+
+/*
+Check the variables used in a statement. A variable without braces is only
+replaced when it is used if its name is at the start of what follows the
+variable symbol (eg. #names uses name) so this is also how a use is matched
+to a variable here. A variable in braces (eg. #{name}) needs to match.
+Parameters include the tokens of the statement, the variables set so far,
+and the name of the script that the statement is in. Returns nothing as the
+problems are noted on the interpreter.
+*/
+func (interpreter *Interpreter) CheckVariableReferences(
+	tokens []Token, set_variables map[string]bool, script_name string) {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	/*
+		Get the names of the reserved variables. These are taken from the
+		interpreter as some (eg. b_scriptname_only) are only added once the
+		reserved variables are built.
+	*/
+	reserved_variables := map[string]bool{}
+	for variable_name := range interpreter.Variables {
+		if strings.HasPrefix(variable_name, SYMBOL_RESERVED_VARIABLE_PREFIX) {
+			reserved_variables[variable_name] = true
+		}
+	}
+	// Hold the variables that have been reported so each is reported once
+	reported := map[string]bool{}
+
+	// Loop over each use of a variable in the line
+	for _, reference := range VariableReferences(full_loc) {
+		// Get the name of the variable and where it is on the line
+		variable_name := reference.Name
+		position := strconv.Itoa(
+			utf8.RuneCountInString(full_loc[:reference.Start]) + 1)
+		if reported[reference.Text] {
+			continue
+		}
+		// A name in braces has to be the whole name of a variable
+		is_variable := func(variables map[string]bool) bool {
+			if reference.Braced {
+				return variables[variable_name]
+			}
+			return StartsWithVariable(variable_name, variables)
+		}
+
+		/*
+			The environment depends on where the script runs so an
+			environment variable is only checked when it is used.
+		*/
+		if strings.HasPrefix(variable_name, SYMBOL_ENVIRONMENT_PREFIX) {
+			continue
+		}
+
+		/*
+			A reserved variable needs to be one that exists while any other
+			variable needs to have been set by now.
+		*/
+		var problem *ScriptError
+		switch {
+		case variable_name == "":
+			problem = Report(
+				"The variable - "+utils.ColouriseYellow(reference.Text)+
+					" - is missing its closing "+
+					utils.ColouriseMagenta(SYMBOL_VARIABLE_CLOSE)+".",
+				loc,
+				position,
+				full_loc,
+			).WithRule("syntax/unclosed-variable").WithHint(
+				"Use " + utils.ColouriseGreen(
+					SYMBOL_VARIABLE_SUBSTITUTION+
+						SYMBOL_VARIABLE_SUBSTITUTION) +
+					" for a literal " + SYMBOL_VARIABLE_SUBSTITUTION + ".")
+		case strings.HasPrefix(
+			variable_name, SYMBOL_RESERVED_VARIABLE_PREFIX):
+			if is_variable(reserved_variables) {
+				continue
+			}
+			/*
+				A script that doesn't declare its parameters can be passed
+				any arguments and options.
+			*/
+			if len(interpreter.Parameters) == 0 &&
+				IsArgumentVariable(variable_name) {
+				continue
+			}
+			problem = Report(
+				"There is no reserved variable called "+
+					utils.ColouriseYellow(variable_name)+".",
+				loc,
+				position,
+				full_loc,
+			).WithRule("syntax/unknown-reserved-variable").WithHint(
+				"The reserved variables are:" + ListReservedVariables())
+		default:
+			if is_variable(set_variables) {
+				continue
+			}
+			problem = Report(
+				"The variable "+utils.ColouriseYellow(variable_name)+" is "+
+					"used before it is set.",
+				loc,
+				position,
+				full_loc,
+			).WithRule("syntax/variable-before-set").WithHint(
+				"Set the variable on an earlier line (eg. " +
+					utils.ColouriseCyan("set") + " " + variable_name + " = " +
+					utils.ColouriseGreen("\"value\"") + ").",
+			)
+		}
+		reported[reference.Text] = true
+		/*
+			Where variables that haven't been set are warnings, one that is
+			used before it is set is a warning here too.
+		*/
+		if interpreter.ModeWarnUndefined &&
+			problem.Rule == "syntax/variable-before-set" {
+			AnnotateError(problem, tokens, ERROR_SYNTAX)
+			problem.Script = script_name
+			interpreter.Warning(problem)
+			continue
+		}
+		interpreter.NoteProblem(
+			AnnotateError(problem, tokens, ERROR_SYNTAX), script_name)
+	}
+}
+
+/*
+Check whether a name starts with the name of a variable. Parameters include
+the name and the names of the variables to look for. Returns true if the name
+starts with one of the variables.
+*/
+func StartsWithVariable(name string, variables map[string]bool) bool {
+	for variable_name := range variables {
+		if strings.HasPrefix(name, variable_name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/*
+Check to make sure that a check reports every problem in a script, inclusive
+of those in scripts that it runs, and that nothing is executed.
+*/
+func TestCheckReportsEveryProblem(t *testing.T) {
+	// Create a script to run that has a problem of its own
+	run_file := filepath.Join(t.TempDir(), "run.apt")
+	os.WriteFile(run_file, []byte("writeln \"fine\"\nbogus"), 0644)
+
+	// Create an interpreter that checks rather than executes
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output, Check: true})
+	run_error := interpreter.RunString(
+		"writeln \"before\"\n" +
+			"movefile \"a.txt\" \"b.txt\"\n" +
+			"run \"" + run_file + "\"\n" +
+			"goto \"nowhere\"\n" +
+			"call \"missing\"\n" +
+			"set b_name = 1",
+	)
+
+	// Get the error as a CheckError
+	var check_error *CheckError
+	if !errors.As(run_error, &check_error) {
+		t.Fatalf("[check] Expected a CheckError, got %v", run_error)
+	}
+	// Check that each problem was found
+	if len(check_error.Problems) != 5 {
+		t.Errorf("[check] Expected %d problems, got %d: %v",
+			5,
+			len(check_error.Problems),
+			check_error)
+	}
+	// Check that the problem in the script that is run notes the script
+	var script_error *ScriptError
+	for _, problem := range check_error.Problems {
+		if errors.As(problem, &script_error) &&
+			script_error.Script == run_file {
+			break
+		}
+		script_error = nil
+	}
+	if script_error == nil {
+		t.Errorf("[check] Expected a problem in %s, got %v",
+			run_file,
+			check_error)
+	}
+
+	// Check that a check fails with a non-zero exit code
+	if ExitCode(run_error) != int(ERROR_SYNTAX) {
+		t.Errorf("[check] Expected exit code %d, got %d",
+			ERROR_SYNTAX,
+			ExitCode(run_error))
+	}
+	// Check that nothing was executed
+	if output.Len() != 0 {
+		t.Errorf("[check] Expected no output, got %q", output.String())
+	}
+}
+
+/*
+Check to make sure that a check finds variables that are used before they are
+set and reserved variables that don't exist but is happy with those that are
+set first.
+*/
+func TestCheckVariableUse(t *testing.T) {
+	scripts := map[string]int{
+		// Variables that are set before they are used
+		"set name = \"World\"\nwriteln \"Hello #name, #names\"": 0,
+		"ask \"Name: \" to name\nwriteln \"#name\"":             0,
+		"writeln \"#b_home #b_scriptname_only Item #1\"":        0,
+		"call \"p\" returning x\nwriteln \"#x\"\n" +
+			"define \"p\"\n\tset x = \"#b_os\"\nend": 0,
+		"call \"p\" with x = 1\ndefine \"p\"\n\twriteln \"#x\"\nend": 0,
+		// Variables that aren't
+		"writeln \"#name\"\nset name = \"World\"":                    1,
+		"writeln \"#name #name #other\"":                             2,
+		"writeln \"#b_nothing\"":                                     1,
+		"if \"#name\" is \"a\" then set name = \"b\"":                1,
+		"call \"p\"\ndefine \"p\"\n\tset y = 1\nend\nwriteln \"#y\"": 1,
+	}
+
+	for script, expected := range scripts {
+		// Create an interpreter that checks rather than executes
+		interpreter := New(Options{Check: true})
+		run_error := interpreter.RunString(script)
+
+		// Get the number of problems
+		problem_count := 0
+		var check_error *CheckError
+		if errors.As(run_error, &check_error) {
+			problem_count = len(check_error.Problems)
+		} else if run_error != nil {
+			t.Fatalf("[check] Expected a CheckError for %q, got %v",
+				script,
+				run_error)
+		}
+		if problem_count != expected {
+			t.Errorf("[check] Expected %d problems for %q, got %v",
+				expected,
+				script,
+				run_error)
+		}
+	}
+}
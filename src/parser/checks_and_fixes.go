@@ -127,6 +127,10 @@ not (false) and a second boolean if we have a valid (true) or invalid (false)
 path.
 */
 func CheckShebang(line string) (bool, bool) {
+	// If the line is too short to hold a shebang, it can't be one
+	if len(line) < 2 {
+		return false, false
+	}
 	first_two_chars := line[0:2]
 	_, path_error := os.Stat(line[2:])
 
@@ -150,9 +154,9 @@ func CheckShebang(line string) (bool, bool) {
 Check that something is a valid statement. Parameters include value_name, the
 value to check. Returns bool, true if value_name is a valid statement name.
 */
-func CheckIsStatement(value_name string) bool {
+func (interpreter *Interpreter) CheckIsStatement(value_name string) bool {
 	// See if the value passed to this is a valid statement name
-	return slices.Contains(STATEMENT_NAMES, value_name)
+	return slices.Contains(interpreter.StatementNames, value_name)
 }
 
 /*
@@ -22,23 +22,19 @@ func TestCheckAction(t *testing.T) {
 
 // Test the CheckIsStatement() function
 func TestCheckIsStatement(t *testing.T) {
-	/* Generate a valid statement name for testing. Here, we're providing one
-	rather than pulling one from values.STATEMENT_NAMES which would be
-	logical but that slice is generated by the parser at runtime so we
-	need to "prepopulate" it here so to speak.
+	/* Create an interpreter to check against. The interpreter's
+	StatementNames are populated when it is created.
 	*/
-	STATEMENT_NAMES = append(
-		STATEMENT_NAMES,
-		"writeln",
-	)
+	interpreter := New(Options{})
+	// Set a valid statement name
 	valid_statement := "writeln"
 	// Set a random statement name that is invalid
 	invalid_statement := "RANDOM!"
 
 	// Get a response to the CheckIsStatement that should return true
-	valid_result := CheckIsStatement(valid_statement)
+	valid_result := interpreter.CheckIsStatement(valid_statement)
 	// Get a response to the CheckIsStatement that should return false
-	invalid_result := CheckIsStatement(invalid_statement)
+	invalid_result := interpreter.CheckIsStatement(invalid_statement)
 
 	// If a valid statement name returns false...
 	if !valid_result {
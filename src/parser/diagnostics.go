@@ -0,0 +1,243 @@
+/*
+Diagnostics are the machine readable form of errors and warnings. Where
+PrintError() and PrintWarning() present them for a person to read, the
+functions here turn them into structured records that other tools (eg. CI
+pipelines, editors, and GitHub code scanning) can consume. Two formats are
+supported:
+  - json: a list of the records as they are
+  - sarif: a SARIF 2.1.0 log (https://sarifweb.azurewebsites.net/)
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// The formats that diagnostics can be written in
+const (
+	DIAGNOSTICS_JSON  string = "json"
+	DIAGNOSTICS_SARIF string = "sarif"
+)
+
+// Where to find out more about the tool that wrote a SARIF log
+const DIAGNOSTICS_TOOL_URI string = "https://github.com/appetitlang/appetit"
+
+/*
+The Diagnostic type houses a single error or warning in a form that can be
+written out for other tools. The structure of the diagnostic is as follows:
+  - File [string]: the script that the diagnostic is for
+  - Line [int]: the line number, zero if it isn't line specific
+  - Column [int]: the position on the line, zero if it isn't known
+  - Severity [string]: either error or warning
+  - RuleID [string]: the id of the rule that was broken (eg. syntax/movefile)
+  - Message [string]: the message, stripped of any colour
+  - Fix [string]: a suggested fix, if there is one
+*/
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	RuleID   string `json:"rule_id"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"`
+}
+
+/*
+Check that a diagnostics format is one that can be written. Parameters include
+the format. Returns an error if the format isn't supported.
+*/
+func CheckDiagnosticsFormat(format string) error {
+	if format == DIAGNOSTICS_JSON || format == DIAGNOSTICS_SARIF {
+		return nil
+	}
+	return ReportSimple(
+		"The diagnostics format - " + utils.ColouriseYellow(format) +
+			" - isn't supported. Use " +
+			utils.ColouriseMagenta(DIAGNOSTICS_JSON) + " or " +
+			utils.ColouriseMagenta(DIAGNOSTICS_SARIF) + ".",
+	).WithCategory(ERROR_USAGE)
+}
+
+/*
+Create a diagnostic from an error or warning. Parameters include the error,
+the severity, and the script to use if the error doesn't know which script
+it came from. Returns the diagnostic.
+*/
+func NewDiagnostic(
+	err error, severity string, script_name string) Diagnostic {
+	// Start with what is known of any error
+	diagnostic := Diagnostic{
+		File:     script_name,
+		Severity: severity,
+		RuleID:   ERROR_RUNTIME.String(),
+		Message:  err.Error(),
+	}
+	// If it's a script error, fill in the details
+	var script_error *ScriptError
+	if errors.As(err, &script_error) {
+		if script_error.Script != "" {
+			diagnostic.File = script_error.Script
+		}
+		diagnostic.Line = script_error.Line
+		diagnostic.Column = script_error.Column
+		diagnostic.RuleID = script_error.RuleID()
+		diagnostic.Message = utils.StripColour(script_error.Message)
+		diagnostic.Fix = utils.StripColour(script_error.Hint)
+	}
+	return diagnostic
+}
+
+/*
+Create the diagnostics for a run of a script. Parameters include the error
+that the script returned (nil if it succeeded), the warnings that were raised,
+and the name of the script. Returns the diagnostics, warnings first, and one
+for each problem where the error is from a check.
+*/
+func Diagnostics(
+	err error, warnings []*ScriptError, script_name string) []Diagnostic {
+	// Start with an empty list so that no diagnostics is written as []
+	diagnostics := []Diagnostic{}
+	// Add the warnings
+	for _, warning := range warnings {
+		diagnostics = append(diagnostics,
+			NewDiagnostic(warning, "warning", script_name))
+	}
+	// If there was no error, we're done
+	if err == nil {
+		return diagnostics
+	}
+	// If the error is from a check, add each problem
+	var check_error *CheckError
+	if errors.As(err, &check_error) {
+		for _, problem := range check_error.Problems {
+			diagnostics = append(diagnostics,
+				NewDiagnostic(problem, "error", script_name))
+		}
+		return diagnostics
+	}
+	// Otherwise, add the error
+	return append(diagnostics, NewDiagnostic(err, "error", script_name))
+}
+
+/*
+Write diagnostics out in the format requested. Parameters include the writer
+to write to, the format (see CheckDiagnosticsFormat()), and the diagnostics.
+Returns an error if the diagnostics couldn't be written.
+*/
+func WriteDiagnostics(
+	writer io.Writer, format string, diagnostics []Diagnostic) error {
+	// Check the format before writing anything
+	if format_error := CheckDiagnosticsFormat(format); format_error != nil {
+		return format_error
+	}
+	// Set up an encoder so that the output is readable
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	// Write JSON as the list of diagnostics
+	if format == DIAGNOSTICS_JSON {
+		return encoder.Encode(diagnostics)
+	}
+	return encoder.Encode(SarifLog(diagnostics))
+}
+
+/*
+Create a SARIF log from diagnostics. The structure follows version 2.1.0 of
+the standard with a single run for the interpreter. Each rule that was broken
+is listed with the tool and each diagnostic is a result. Parameters include the
+diagnostics. Returns the log, ready to be encoded as JSON.
+*/
+func SarifLog(diagnostics []Diagnostic) map[string]any {
+	// Hold the rules that were broken, in the order they were first seen
+	var rule_ids []string
+	// Hold the results
+	results := []map[string]any{}
+
+	for _, diagnostic := range diagnostics {
+		if !slices.Contains(rule_ids, diagnostic.RuleID) {
+			rule_ids = append(rule_ids, diagnostic.RuleID)
+		}
+		// SARIF has no place for fix text alone so it goes in the message
+		message := diagnostic.Message
+		if diagnostic.Fix != "" {
+			message += "\n\nSuggested fix: " + diagnostic.Fix
+		}
+		result := map[string]any{
+			"ruleId":  diagnostic.RuleID,
+			"level":   diagnostic.Severity,
+			"message": map[string]any{"text": message},
+		}
+		// Add the location where the file is known
+		if diagnostic.File != "" {
+			physical_location := map[string]any{
+				"artifactLocation": map[string]any{
+					"uri": SarifURI(diagnostic.File),
+				},
+			}
+			// Add the line and column where they are known
+			if diagnostic.Line > 0 {
+				region := map[string]any{"startLine": diagnostic.Line}
+				if diagnostic.Column > 0 {
+					region["startColumn"] = diagnostic.Column
+				}
+				physical_location["region"] = region
+			}
+			result["locations"] = []map[string]any{
+				{"physicalLocation": physical_location},
+			}
+		}
+		results = append(results, result)
+	}
+
+	// Create a rule for each rule id
+	rules := []map[string]any{}
+	for _, rule_id := range rule_ids {
+		rules = append(rules, map[string]any{"id": rule_id})
+	}
+
+	return map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{
+			{
+				"tool": map[string]any{
+					"driver": map[string]any{
+						"name":           LANG_NAME,
+						"version":        strconv.Itoa(LANG_VERSION),
+						"informationUri": DIAGNOSTICS_TOOL_URI,
+						"rules":          rules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+}
+
+/*
+Get the URI of a file for a SARIF log. Tools such as GitHub code scanning
+expect paths relative to the root of the repository so a path is made relative
+to the working directory where it can be. Parameters include the path to the
+file. Returns the URI.
+*/
+func SarifURI(file_name string) string {
+	// Get the full path, leaving the path as it is if we can't
+	absolute_path, abs_error := filepath.Abs(file_name)
+	if abs_error != nil {
+		return filepath.ToSlash(file_name)
+	}
+	// Try to make the path relative to the working directory
+	working_directory, _ := filepath.Abs(".")
+	relative_path, rel_error := filepath.Rel(working_directory, absolute_path)
+	if rel_error == nil && filepath.IsLocal(relative_path) {
+		return filepath.ToSlash(relative_path)
+	}
+	// Otherwise, use the full path as a file URI
+	return "file://" + filepath.ToSlash(absolute_path)
+}
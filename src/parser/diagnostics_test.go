@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+/*
+Check to make sure that the problems found by a check and the warnings raised
+along the way become diagnostics with the details of each.
+*/
+func TestDiagnostics(t *testing.T) {
+	// Create an interpreter that checks and holds onto its warnings
+	var errors_output bytes.Buffer
+	interpreter := New(Options{
+		Check:       true,
+		Diagnostics: true,
+		Stderr:      &errors_output,
+	})
+	interpreter.ScriptName = "script.apt"
+	run_error := interpreter.RunString(
+		"set name = \"run\"\nrun \"#name.apt\"\nwriteln \"#nope\"",
+	)
+
+	// Check that the warning was held onto rather than printed
+	if errors_output.Len() != 0 {
+		t.Errorf("[diagnostics] Expected no output, got %q",
+			errors_output.String())
+	}
+
+	// Check each diagnostic
+	diagnostics := Diagnostics(
+		run_error, interpreter.Warnings, interpreter.ScriptName)
+	expected := []Diagnostic{
+		{
+			File:     "script.apt",
+			Line:     2,
+			Column:   5,
+			Severity: "warning",
+			RuleID:   "check/unchecked-run-target",
+		},
+		{
+			File:     "script.apt",
+			Line:     3,
+			Column:   10,
+			Severity: "error",
+			RuleID:   "syntax/variable-before-set",
+		},
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("[diagnostics] Expected %d diagnostics, got %v",
+			len(expected),
+			diagnostics)
+	}
+	for index, diagnostic := range diagnostics {
+		// Leave the message and fix out of the comparison
+		if diagnostic.Message == "" {
+			t.Errorf("[diagnostics] Expected a message for %v", diagnostic)
+		}
+		diagnostic.Message = ""
+		diagnostic.Fix = ""
+		if diagnostic != expected[index] {
+			t.Errorf("[diagnostics] Expected %v, got %v",
+				expected[index],
+				diagnostic)
+		}
+	}
+}
+
+/*
+Check to make sure that diagnostics are written out as JSON and SARIF and that
+an unsupported format is refused.
+*/
+func TestWriteDiagnostics(t *testing.T) {
+	diagnostics := []Diagnostic{
+		{
+			File:     "script.apt",
+			Line:     3,
+			Column:   10,
+			Severity: "error",
+			RuleID:   "syntax/variable-before-set",
+			Message:  "The variable nope is used before it is set.",
+			Fix:      "Set the variable on an earlier line.",
+		},
+	}
+
+	// Write the diagnostics as JSON and read them back
+	var json_output bytes.Buffer
+	json_error := WriteDiagnostics(&json_output, "json", diagnostics)
+	if json_error != nil {
+		t.Fatalf("[json] Expected no error, got %v", json_error)
+	}
+	var json_diagnostics []Diagnostic
+	json.Unmarshal(json_output.Bytes(), &json_diagnostics)
+	if len(json_diagnostics) != 1 || json_diagnostics[0] != diagnostics[0] {
+		t.Errorf("[json] Expected %v, got %s",
+			diagnostics,
+			json_output.String())
+	}
+
+	// Write the diagnostics as SARIF and read the result back
+	var sarif_output bytes.Buffer
+	sarif_error := WriteDiagnostics(&sarif_output, "sarif", diagnostics)
+	if sarif_error != nil {
+		t.Fatalf("[sarif] Expected no error, got %v", sarif_error)
+	}
+	var sarif_log struct {
+		Version string
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct {
+							StartLine   int
+							StartColumn int
+						}
+					}
+				}
+			}
+		}
+	}
+	json.Unmarshal(sarif_output.Bytes(), &sarif_log)
+	if sarif_log.Version != "2.1.0" || len(sarif_log.Runs) != 1 ||
+		len(sarif_log.Runs[0].Results) != 1 {
+		t.Fatalf("[sarif] Expected a log with one result, got %s",
+			sarif_output.String())
+	}
+	result := sarif_log.Runs[0].Results[0]
+	location := result.Locations[0].PhysicalLocation
+	if result.RuleID != "syntax/variable-before-set" ||
+		result.Level != "error" ||
+		location.ArtifactLocation.URI != "script.apt" ||
+		location.Region.StartLine != 3 ||
+		location.Region.StartColumn != 10 {
+		t.Errorf("[sarif] Unexpected result, got %s", sarif_output.String())
+	}
+
+	// An unsupported format is a usage error
+	format_error := WriteDiagnostics(&bytes.Buffer{}, "xml", diagnostics)
+	if ExitCode(format_error) != int(ERROR_USAGE) {
+		t.Errorf("[format] Expected a usage error, got %v", format_error)
+	}
+}
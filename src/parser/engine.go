@@ -1,19 +1,21 @@
 /*
 The engine deals with tokenising the script and delegating to the
-statement functions. This is home to only three functions:
+statement functions. This is home to only four functions:
   - Tokenise() - this will tokenise a line and return a line that has been
     tokenised.
-  - Start() - this starts the process of executing the script or, where needed,
-    tokenising empty lines to allow for clean execution.
+  - Parse() - this tokenises and checks every line of the script, building the
+    statement tree before anything is executed.
+  - Start() - this starts the process of executing the script by parsing it
+    and then executing the parsed script.
   - Call() - this executes the appropriate statement functions.
 */
 package parser
 
 import (
 	"appetit/utils"
-	"maps"
+	"errors"
+	"fmt"
 	"reflect"
-	"slices"
 	"strconv"
 	"strings"
 	"text/scanner"
@@ -30,11 +32,12 @@ tokenise) and loc, the lines of code that we are working with where:
 
 Returns a slice of Tokens that represents a tokenised line where the first
 element is the line number and each subsequent element is a token in the
-line.
+line. Adding the tokens to an interpreter's TokenTree is left to the caller.
+Also returns an error if the tokeniser reported one.
 */
 func Tokenise(
 	line_of_script string,
-	line_number int, non_comment_line_number int) []Token {
+	line_number int, non_comment_line_number int) ([]Token, error) {
 	// Set up a text scanner to tokenise the script
 	var ls scanner.Scanner
 	// Initialise against a line of the script passed
@@ -44,10 +47,14 @@ func Tokenise(
 		pkg_repo_tool/blob/82e14e49258f/parser/parser.go.
 	*/
 
+	// Hold the first error that the tokeniser reports
+	var tokeniser_error error
 	// Catch errors with the tokeniser and handle them
 	tokeniser.Error = func(s *scanner.Scanner, msg string) {
-		// Call the utilss
-		ReportTokeniserErrors(msg, line_number)
+		// Only keep the first error as the rest often follow from it
+		if tokeniser_error == nil {
+			tokeniser_error = ReportTokeniserErrors(msg, line_number)
+		}
 	}
 
 	// Create a slice that houses tokens for each part of the line of code
@@ -82,30 +89,61 @@ func Tokenise(
 		tokenised_line = append(tokenised_line, token)
 	}
 
-	// Append the tokens to the TOKEN_TREE
-	TOKEN_TREE = append(TOKEN_TREE, tokenised_line...)
-
 	// Return the tokenised line
-	return tokenised_line
+	return tokenised_line, tokeniser_error
 }
 
 /*
-Start executing commands in a script by passing the lines to the tokeniser
-and the Call() function. The first parameter is the lines of the script and the
-second parameter is a bool to set development mode. No returns.
+Parse a script into a statement tree. Each line is tokenised and checked so
+that any problem with the script is reported before anything is executed. Any
+script reached via the run statement is parsed along with it where the target
+is known ahead of time as is any procedure defined with the define statement.
+Parameters include the lines of the script and the name of the script. Returns
+the parsed script or, if any line is malformed, an error.
 */
-func Start(lines []string, dev_mode bool) {
+func (interpreter *Interpreter) Parse(
+	lines []string, script_name string) (*Script, error) {
 	/*
 		Before we start parsing, set any reserved variables that require
-		"computation". Do a quick check to make sure that the minver statement
+		"computation" so that they can be used to find the target of any run
+		statements. Do a quick check to make sure that the minver statement
 		call, if present, is the first language specific call.
 	*/
+	interpreter.BuildReservedVariables()
+
+	/*
+		If this isn't a script being parsed on behalf of another (ie. the
+		target of a run or define statement), the call statements are checked
+		once this script is parsed. Start with a clean slate for them and for
+		any problems noted by a check.
+	*/
+	outermost_script := len(interpreter.scripts_being_parsed) == 0
+	if outermost_script {
+		interpreter.calls_to_check = nil
+		interpreter.problems = nil
+	}
+
 	valid_minver, message := CheckValidMinverLocationAndCount(lines)
 	// If it's not appropriately located in the script, error out
 	if !valid_minver {
-		ReportSimple(message)
+		problem := interpreter.NoteProblem(
+			ReportSimple(message).WithCategory(ERROR_SYNTAX), script_name)
+		if problem != nil {
+			return nil, problem
+		}
 	}
 
+	// Create the script that the statements will be added to
+	script := &Script{Name: script_name}
+	/*
+		Hold the define block that statements are being added to instead of
+		the script, the define statement that started it, and every define
+		block in the script so that they can be checked once parsed.
+	*/
+	var define_block *Script
+	var define_tokens []Token
+	var define_blocks []*Script
+
 	// Counter for the non-comment lines
 	non_comment_line_count := 1
 	// Loop over the lines
@@ -125,55 +163,355 @@ func Start(lines []string, dev_mode bool) {
 				Here, we start by checking to see what the first character of
 				the line is to see if it's a SYMBOL_COMMENT. If it is not (ie.
 				it's a line that requires parsing), we send the line to the
-				tokeniser and the Call() function. While the RemoveComments()
-				function "removed" the comments, it only did so as far as it
-				stripped away all the characters instead of the comment symbol
-				so there are still comment line in that need to be accounted
-				for here.
+				tokeniser and check it. While the RemoveComments() function
+				"removed" the comments, it only did so as far as it stripped
+				away all the characters instead of the comment symbol so there
+				are still comment line in that need to be accounted for here.
 			*/
 			if string(line_as_string[0]) != SYMBOL_COMMENT {
 				/*
 					Tokenise the line, adding one to the line number to account
 					for the starting from zero.
 				*/
-				tokenised_line := Tokenise(
+				tokenised_line, tokenise_error := Tokenise(
 					lines[line],
 					line+1, non_comment_line_count)
+				// If the line couldn't be tokenised, error out
+				if tokenise_error != nil {
+					problem := interpreter.NoteProblem(AnnotateError(
+						tokenise_error, tokenised_line, ERROR_SYNTAX),
+						script_name)
+					if problem != nil {
+						return nil, problem
+					}
+					non_comment_line_count += 1
+					continue
+				}
 				// Increment the non_comment_line_count
 				non_comment_line_count += 1
-				// If dev_mode is enabled
-				if dev_mode {
-					// Print the tokens
-					PrintTokenInfo(tokenised_line)
-					// If dev mode is not enabled, delegate execution
-				} else {
-					// Delegate to the statements package to start execution
-					Call(tokenised_line)
+				// Append the tokens to the TokenTree
+				interpreter.TokenTree = append(
+					interpreter.TokenTree, tokenised_line...)
+
+				/*
+					If there is nothing but the line of code token (ie. the
+					line was blank), there is no statement to add.
+				*/
+				if len(tokenised_line) < 2 {
+					continue
+				}
+
+				/*
+					If this is a shebang line, set the ShebangPresent value to
+					true so that MinVer() calls can ignore that the minver
+					statement isn't on the first line. There is no statement
+					here to add.
+				*/
+				if valid_shebang, _ := CheckShebang(
+					tokenised_line[0].FullLineOfCode); valid_shebang {
+					interpreter.ShebangPresent = true
+					continue
+				}
+
+				// Check the statement call
+				check_error := interpreter.CheckStatement(tokenised_line)
+				if check_error != nil {
+					problem := interpreter.NoteProblem(AnnotateError(
+						check_error, tokenised_line, ERROR_SYNTAX),
+						script_name)
+					if problem != nil {
+						return nil, problem
+					}
+					continue
 				}
+
+				// Create the statement
+				statement := Statement{
+					Name:   tokenised_line[1].TokenValue,
+					Tokens: tokenised_line,
+				}
+				/*
+					Statements are added to the script unless they are in a
+					define block in which case they are added to the block.
+				*/
+				target_script := script
+				if define_block != nil {
+					target_script = define_block
+				}
+
+				// If this is an end statement, close the define block
+				if statement.Name == "end" {
+					if define_block == nil {
+						problem := interpreter.NoteProblem(AnnotateError(
+							ReportEndWithoutDefine(tokenised_line),
+							tokenised_line, ERROR_SYNTAX), script_name)
+						if problem != nil {
+							return nil, problem
+						}
+					}
+					define_block = nil
+					continue
+				}
+				/*
+					If this is a params statement, add the parameter to the
+					script. Only the parameters of the script that is started
+					are used but they are checked wherever they are.
+				*/
+				if statement.Name == "params" {
+					params_error := script.AddParameter(
+						tokenised_line, define_block != nil)
+					if params_error != nil {
+						problem := interpreter.NoteProblem(AnnotateError(
+							params_error, tokenised_line, ERROR_SYNTAX),
+							script_name)
+						if problem != nil {
+							return nil, problem
+						}
+					}
+				}
+				/*
+					If this is a define statement, parse the procedure. The
+					block form opens a define block that the statements up to
+					the end statement are added to.
+				*/
+				if statement.Name == "define" {
+					// A define block can't hold another define statement
+					if define_block != nil {
+						problem := interpreter.NoteProblem(AnnotateError(
+							ReportUnclosedDefine(define_tokens),
+							define_tokens, ERROR_SYNTAX), script_name)
+						if problem != nil {
+							return nil, problem
+						}
+					}
+					procedure, define_error := interpreter.ParseDefine(
+						tokenised_line, script_name)
+					if define_error != nil {
+						problem := interpreter.NoteProblem(AnnotateError(
+							define_error, tokenised_line, ERROR_SYNTAX),
+							script_name)
+						if problem != nil {
+							return nil, problem
+						}
+						/*
+							Carry on with an empty procedure so that the rest
+							of the block is still checked.
+						*/
+						procedure = &Script{Name: script_name}
+					}
+					statement.Script = procedure
+					if len(tokenised_line) == 3 {
+						define_block = procedure
+						define_tokens = tokenised_line
+						define_blocks = append(define_blocks, procedure)
+					}
+				}
+				// If this is a call statement, note it so it can be checked
+				innermost_tokens := InnermostStatementTokens(tokenised_line)
+				if innermost_tokens[1].TokenValue == "call" {
+					interpreter.calls_to_check = append(
+						interpreter.calls_to_check,
+						call_to_check{innermost_tokens, script_name})
+				}
+				// If this is a label statement, add the label to the script
+				if statement.Name == "label" {
+					label_error := target_script.AddLabel(tokenised_line)
+					if label_error != nil {
+						problem := interpreter.NoteProblem(AnnotateError(
+							label_error, tokenised_line, ERROR_SYNTAX),
+							script_name)
+						if problem != nil {
+							return nil, problem
+						}
+					}
+				}
+				/*
+					If this is a run statement, parse the script that will be
+					run so that it is checked ahead of time as well.
+				*/
+				if statement.Name == "run" {
+					run_script, run_error := interpreter.ParseRunTarget(
+						tokenised_line, script_name)
+					if run_error != nil {
+						problem := interpreter.NoteProblem(AnnotateError(
+							run_error, tokenised_line, ERROR_SYNTAX),
+							script_name)
+						if problem != nil {
+							return nil, problem
+						}
+					}
+					statement.Script = run_script
+				}
+				// Add the statement to the script
+				target_script.Statements = append(
+					target_script.Statements, statement)
 			}
 		} else if line_length == 0 {
 			/*
 				Pass an empty string. This is needed; if we don't pass this
 				here, blank lines are skipped which results in line counts not
-				being accurate. This is held in nothing as the tokenised value
-				is irrelevant so we can dispense with this.
+				being accurate. The tokenised value is irrelevant beyond being
+				added to the TokenTree so we can dispense with this.
 			*/
-			_ = Tokenise(" ", line+1, -1)
+			blank_line, _ := Tokenise(" ", line+1, -1)
+			interpreter.TokenTree = append(
+				interpreter.TokenTree, blank_line...)
 		}
 	}
+
+	// If a define block was never closed, report an error
+	if define_block != nil {
+		problem := interpreter.NoteProblem(AnnotateError(
+			ReportUnclosedDefine(define_tokens), define_tokens, ERROR_SYNTAX),
+			script_name)
+		if problem != nil {
+			return nil, problem
+		}
+	}
+
+	/*
+		Now that every label is known, check that each goto statement has
+		somewhere to go, inclusive of those in define blocks.
+	*/
+	for _, labelled_script := range append(define_blocks, script) {
+		for _, goto_error := range labelled_script.CheckGotoLabels() {
+			problem := interpreter.NoteProblem(goto_error, script_name)
+			if problem != nil {
+				return nil, problem
+			}
+		}
+	}
+
+	/*
+		Now that every procedure is known, check that each call statement has
+		something to call. Each problem has already been tagged with the
+		script that the call statement is in.
+	*/
+	if outermost_script {
+		for _, call_error := range interpreter.CheckCalls() {
+			problem := interpreter.NoteProblem(call_error, script_name)
+			if problem != nil {
+				return nil, problem
+			}
+		}
+	}
+
+	// Return the parsed script
+	return script, nil
+}
+
+/*
+Start executing commands in a script by parsing the lines and then executing
+the parsed script. Nothing is executed unless the whole script parses. The
+parameter is the lines of the script. If the interpreter is in check mode, the
+script is checked instead and if it is in development mode, the tokens are
+printed instead. Returns an error if the script failed.
+*/
+func (interpreter *Interpreter) Start(lines []string) error {
+	// Parse the script in full before anything is executed
+	script, parse_error := interpreter.Parse(lines, interpreter.ScriptName)
+	if parse_error != nil {
+		return parse_error
+	}
+	/*
+		Set the variables for the arguments passed to the script. If the
+		usage was asked for, print it rather than running the script.
+	*/
+	show_usage, arguments_error := interpreter.ApplyArguments(
+		script.Parameters)
+	if arguments_error != nil {
+		return arguments_error
+	}
+	if show_usage && !interpreter.ModeCheck {
+		fmt.Fprint(interpreter.Stdout, interpreter.ScriptUsage())
+		return nil
+	}
+	// If check mode is enabled, finish checking rather than executing
+	if interpreter.ModeCheck {
+		return interpreter.CheckScript(script)
+	}
+	// If dev mode is enabled, print the tokens
+	if interpreter.ModeDev {
+		interpreter.PrintScriptTokens(script)
+		return nil
+	}
+	// If dev mode is not enabled, delegate execution
+	execute_error := interpreter.Execute(script)
+	/*
+		The exit statement stops the script by returning ErrScriptExit. This
+		isn't a failure so it stops here.
+	*/
+	if errors.Is(execute_error, ErrScriptExit) {
+		return nil
+	}
+	return execute_error
+}
+
+/*
+Create a map of statements and their associated function calls. No
+parameters. Returns the map of statement names to function calls, each of
+which takes the tokens of the statement.
+*/
+func (interpreter *Interpreter) StatementMap() map[string]func([]Token) error {
+	return map[string]func([]Token) error{
+		"ask":             interpreter.Ask,
+		"call":            interpreter.CallProcedure,
+		"convert":         interpreter.Convert,
+		"copydirectory":   interpreter.CopyPath,
+		"copyfile":        interpreter.CopyFile,
+		"define":          interpreter.Define,
+		"deletedirectory": interpreter.DeletePath,
+		"deletefile":      interpreter.DeleteFile,
+		"download":        interpreter.Download,
+		"downloadall":     interpreter.DownloadAll,
+		"end":             interpreter.End,
+		"execute":         interpreter.ExecuteCommand,
+		"exit":            interpreter.Exit,
+		"fetch":           interpreter.Request,
+		"goto":            interpreter.Goto,
+		"header":          interpreter.Header,
+		"http":            interpreter.HTTPSetting,
+		"if":              interpreter.If,
+		"label":           interpreter.Label,
+		"log":             interpreter.Log,
+		"makedirectory":   interpreter.CreatePath,
+		"makefile":        interpreter.MakeFile,
+		"minver":          interpreter.MinVer,
+		"movedirectory":   interpreter.MovePath,
+		"movefile":        interpreter.MoveFile,
+		"params":          interpreter.Params,
+		"pause":           interpreter.Pause,
+		"post":            interpreter.Request,
+		"put":             interpreter.Request,
+		"repeat":          interpreter.Repeat,
+		"run":             interpreter.Run,
+		"set":             interpreter.Set,
+		"setenv":          interpreter.SetEnv,
+		"unsetenv":        interpreter.UnsetEnv,
+		"untar":           interpreter.Extract,
+		"unzip":           interpreter.Extract,
+		"write": func(tokens []Token) error {
+			return interpreter.Writeln(tokens, false)
+		},
+		"writeln": func(tokens []Token) error {
+			return interpreter.Writeln(tokens, true)
+		},
+		"zipdirectory": interpreter.ZipFromPath,
+		"zipfile":      interpreter.ZipFromFile,
+	}
 }
 
 /*
 Start delegating lines of tokens to the functions that will do the work of
 actually executing functionality. Parameters include tokens, a slice of
-strings that contains the tokens. Returns nothing.
+strings that contains the tokens. Returns an error if the statement failed.
 */
-func Call(tokens []Token) {
+func (interpreter *Interpreter) Call(tokens []Token) error {
 	/*
 		Build the list of reserved variables so that each statement call has
 		access to an up to date set of variables.
 	*/
-	BuildReservedVariables()
+	interpreter.BuildReservedVariables()
 	/*
 		This will catch empty lines as the slice will have a line number but no
 		other elements. So, if the number of tokens is greater than 1, we can
@@ -185,39 +523,7 @@ func Call(tokens []Token) {
 		// Get the statement name
 		stmt_name := tokens[1].TokenValue
 		// Create a map of statmements and their associated function calls
-		statement_map := map[string]func(){
-			"ask":             func() { Ask(tokens) },
-			"copydirectory":   func() { CopyPath(tokens) },
-			"copyfile":        func() { CopyFile(tokens) },
-			"deletedirectory": func() { DeletePath(tokens) },
-			"deletefile":      func() { DeleteFile(tokens) },
-			"download":        func() { Download(tokens) },
-			"execute":         func() { ExecuteCommand(tokens) },
-			"exit":            func() { Exit(tokens) },
-			"log":             func() { Log(tokens) },
-			"makedirectory":   func() { CreatePath(tokens) },
-			"makefile":        func() { MakeFile(tokens) },
-			"minver":          func() { MinVer(tokens) },
-			"movedirectory":   func() { MovePath(tokens) },
-			"movefile":        func() { MoveFile(tokens) },
-			"pause":           func() { Pause(tokens) },
-			"run":             func() { Run(tokens) },
-			"set":             func() { Set(tokens) },
-			"write":           func() { Writeln(tokens, false) },
-			"writeln":         func() { Writeln(tokens, true) },
-			"zipdirectory":    func() { ZipFromPath(tokens) },
-			"zipfile":         func() { ZipFromFile(tokens) },
-		}
-
-		/*
-			Here, we consolidate the keys from the statement_map map and set
-			the STATEMENT_NAMES to the list of keys. We only do this if
-			the list is empty, helping to cut down how many times that this may
-			need to happen. Thanks to https://stackoverflow.com/q/21362950.
-		*/
-		if len(STATEMENT_NAMES) == 0 {
-			STATEMENT_NAMES = slices.Collect(maps.Keys(statement_map))
-		}
+		statement_map := interpreter.StatementMap()
 
 		/*
 			First up, we need to check to see if there is a shebang line. This
@@ -230,19 +536,19 @@ func Call(tokens []Token) {
 
 		if valid_shebang {
 			/*
-				Set the SHEBANG_PRESENT value to true so that
+				Set the ShebangPresent value to true so that
 				MinVer() calls can ignore that the minver statement isn't on
 				the first line and is, instead, on the second line.
 			*/
-			SHEBANG_PRESENT = true
+			interpreter.ShebangPresent = true
 			/*
 				Break out of the function call as we know that we aren't
 				looking at a statement.
 			*/
-			return
+			return nil
 		} else {
 			// Check if we have a valid statement
-			valid_stmt := CheckIsStatement(tokens[1].TokenValue)
+			valid_stmt := interpreter.CheckIsStatement(tokens[1].TokenValue)
 			// If it's not a valid statement
 			if !valid_stmt {
 				// Get the line of the script
@@ -256,14 +562,15 @@ func Call(tokens []Token) {
 					false). Report back that it doesn't exist with a list of
 					valid statements.
 				*/
-				Report(
+				return Report(
 					"The statement passed - "+utils.ColouriseYellow(stmt_name)+
 						" - is not a valid statement. Valid statements "+
-						"include "+ListStatements()+".",
+						"include "+interpreter.ListStatements()+".",
 					loc,
 					tokens[0].TokenPosition,
 					full_loc,
-				)
+				).WithCategory(ERROR_SYNTAX).WithRule(
+					"syntax/unknown-statement")
 			}
 		}
 
@@ -273,8 +580,13 @@ func Call(tokens []Token) {
 			exist.
 		*/
 		if call_stmt, exists := statement_map[stmt_name]; exists {
-			// Call the corresponding statement from the statement_map
-			call_stmt()
+			/*
+				Call the corresponding statement from the statement_map,
+				filling in the details of any error that it returns.
+			*/
+			return AnnotateError(call_stmt(tokens), tokens, ERROR_RUNTIME)
 		}
 	}
+	// If we've gotten here, there was nothing to call
+	return nil
 }
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -12,8 +14,9 @@ func BenchmarkCall(b *testing.B) {
 	//q := quiet()
 	//defer q()
 
+	interpreter := New(Options{})
 	for b.Loop() {
-		Call(TEST_SET)
+		interpreter.Call(TEST_SET)
 	}
 }
 
@@ -24,11 +27,12 @@ doesn't produce any output.
 func BenchmarkStart(b *testing.B) {
 	//q := quiet()
 	//defer q()
+	interpreter := New(Options{})
 	for b.Loop() {
 		lines := []string{
 			"set name = \"Hello\"",
 		}
-		Start(lines, false)
+		interpreter.Start(lines)
 	}
 }
 
@@ -45,3 +49,56 @@ func BenchmarkTokenise(b *testing.B) {
 		)
 	}
 }
+
+/*
+Check to make sure that Parse() builds the statement tree, skipping comments
+and blank lines, and parses the target of a run statement ahead of time.
+*/
+func TestParse(t *testing.T) {
+	// Create a script for the run statement to point to
+	run_target := filepath.Join(t.TempDir(), "target.apt")
+	os.WriteFile(run_target, []byte("set inner = \"Hello\"\n"), 0644)
+
+	// A script with a comment, a blank line, and a run statement
+	lines := RemoveComments([]string{
+		"- A comment",
+		"set name = \"World\"",
+		"",
+		"run \"" + run_target + "\"",
+	})
+
+	// Parse the script
+	interpreter := New(Options{})
+	script, parse_error := interpreter.Parse(lines, "test.apt")
+	if parse_error != nil {
+		t.Fatalf("[Parse] Expected no error, got %v", parse_error)
+	}
+
+	// There should be two statements, the comment and blank line skipped
+	if len(script.Statements) != 2 {
+		t.Fatalf("[Parse] Expected %d statements, got %d",
+			2,
+			len(script.Statements))
+	}
+
+	// The first statement should be the set statement on line two
+	if script.Statements[0].Name != "set" ||
+		script.Statements[0].Tokens[0].LineNumber != 2 {
+		t.Errorf("[Parse] Expected %s on line %d, got %s on line %d",
+			"set",
+			2,
+			script.Statements[0].Name,
+			script.Statements[0].Tokens[0].LineNumber)
+	}
+
+	// The run statement should hold the parsed target
+	run_script := script.Statements[1].Script
+	if run_script == nil || len(run_script.Statements) != 1 {
+		t.Fatalf("[Parse] Expected the run target to be parsed")
+	}
+
+	// Nothing should have been executed while parsing
+	if _, exists := interpreter.Variables["inner"]; exists {
+		t.Errorf("[Parse] Expected nothing to be executed while parsing")
+	}
+}
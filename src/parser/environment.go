@@ -0,0 +1,172 @@
+/*
+The environment houses the environment variables that a script can read (eg.
+#env.PATH or #{env.HOME}) and that are passed on to the commands that it
+executes. Each interpreter has its own copy of the environment, taken from the
+process when the interpreter is created, so that the setenv and unsetenv
+statements only change what the script and its commands see. Environment
+variables can also be loaded from a file of KEY=VALUE lines (see
+ParseEnvironmentFile()) before the script runs.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"bufio"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The prefix of a variable that is an environment variable (eg. #env.PATH)
+const SYMBOL_ENVIRONMENT_PREFIX = "env."
+
+/*
+Get the environment of the process. No parameters. Returns the environment
+variables by their names.
+*/
+func ProcessEnvironment() map[string]string {
+	environment := map[string]string{}
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		// Windows has variables for each drive that start with an =
+		if name != "" {
+			environment[name] = value
+		}
+	}
+	return environment
+}
+
+/*
+Load an environment file (see ParseEnvironmentFile()). Parameters include the
+name of the file. Returns the environment variables by their names and an
+error if the file couldn't be read or has a line that isn't KEY=VALUE.
+*/
+func LoadEnvironmentFile(file_name string) (map[string]string, error) {
+	file, open_error := os.Open(file_name)
+	if open_error != nil {
+		return nil, ReportSimple(
+			"Unknown environment file: " + utils.ColouriseMagenta(file_name) +
+				".",
+		).WithCategory(ERROR_USAGE).WithErr(open_error)
+	}
+	defer file.Close()
+	return ParseEnvironmentFile(file, file_name)
+}
+
+/*
+Read the environment variables in a file. Each line is a KEY=VALUE pair, an
+optional export in front of the key is ignored, and the value can be wrapped
+in single quotes (taken as it is) or double quotes (with escapes handled as
+they are in a script). Blank lines and lines that start with a # are skipped.
+Parameters include the contents of the file and its name (for errors).
+Returns the environment variables by their names and an error if there is a
+line that isn't KEY=VALUE.
+*/
+func ParseEnvironmentFile(
+	reader io.Reader, file_name string) (map[string]string, error) {
+	environment := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	line_number := 0
+	for scanner.Scan() {
+		line_number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, found := strings.Cut(
+			strings.TrimPrefix(line, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !found || !IsEnvironmentName(name) {
+			return nil, ReportSimple(
+				"Line " + strconv.Itoa(line_number) + " of the environment " +
+					"file " + utils.ColouriseMagenta(file_name) + " - " +
+					utils.ColouriseYellow(line) + " - needs to follow the " +
+					"form " + utils.ColouriseYellow("NAME") + "=" +
+					utils.ColouriseGreen("value") + ".",
+			).WithCategory(ERROR_USAGE)
+		}
+		value = strings.TrimSpace(value)
+		// Take the quotes off of a quoted value
+		if len(value) > 1 && strings.IndexAny(value, "\"'") == 0 &&
+			value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				value = FixStringCombined(value)
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+		environment[name] = value
+	}
+	return environment, scanner.Err()
+}
+
+/*
+Check whether a name can be the name of an environment variable, that is,
+that it is made up of letters, numbers, and underscores and doesn't start
+with a number. Parameters include the name. Returns true if it can.
+*/
+func IsEnvironmentName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, character := range name {
+		if !IsNameCharacter(character) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Get the environment as a list of NAME=value strings, sorted by name, which is
+how the commands that the script executes are given it. No parameters.
+Returns the environment.
+*/
+func (interpreter *Interpreter) EnvironmentList() []string {
+	var environment []string
+	for name, value := range interpreter.Environment {
+		environment = append(environment, name+"="+value)
+	}
+	slices.Sort(environment)
+	return environment
+}
+
+/*
+Get the variables that a string can use, which are the interpreter's
+variables along with any environment variables that the string uses (eg.
+#env.PATH is the variable env.PATH). The interpreter's variables are handed
+back as they are where the string doesn't use the environment. Parameters
+include the string. Returns the variables.
+*/
+func (interpreter *Interpreter) VariablesFor(input string) map[string]Value {
+	variables := interpreter.Variables
+	is_copy := false
+	for _, reference := range VariableReferences(input) {
+		name, is_environment := strings.CutPrefix(
+			reference.Name, SYMBOL_ENVIRONMENT_PREFIX)
+		if !is_environment {
+			continue
+		}
+		// Copy the variables the first time an environment variable is used
+		if !is_copy {
+			variables = maps.Clone(interpreter.Variables)
+			is_copy = true
+		}
+		/*
+			A name in braces has to be an environment variable while, much
+			like any other variable, a name without them can start with one
+			(eg. #env.HOMEs is env.HOME followed by an s)
+		*/
+		for environment_name, value := range interpreter.Environment {
+			if environment_name == name || (!reference.Braced &&
+				strings.HasPrefix(name, environment_name)) {
+				variables[SYMBOL_ENVIRONMENT_PREFIX+environment_name] =
+					StringValue(value)
+			}
+		}
+	}
+	return variables
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that an environment file is read as NAME=value lines,
+skipping comments and blank lines and taking the quotes off of values.
+*/
+func TestParseEnvironmentFile(t *testing.T) {
+	contents := "# A comment\n\n" +
+		"PLAIN=value\n" +
+		"export EXPORTED = spaced\n" +
+		"QUOTED=\"Hello there\"\n" +
+		"SINGLE='It is'\n" +
+		"EMPTY=\n"
+	expected := map[string]string{
+		"PLAIN":    "value",
+		"EXPORTED": "spaced",
+		"QUOTED":   "Hello there",
+		"SINGLE":   "It is",
+		"EMPTY":    "",
+	}
+	environment, parse_error := ParseEnvironmentFile(
+		strings.NewReader(contents), "test.env")
+	if parse_error != nil || !maps.Equal(environment, expected) {
+		t.Errorf("[ParseEnvironmentFile] Expected %v, got %v (%v)",
+			expected,
+			environment,
+			parse_error)
+	}
+
+	// A line that isn't NAME=value is an error
+	for _, contents := range []string{"NO_EQUALS\n", "1ST=value\n"} {
+		_, parse_error := ParseEnvironmentFile(
+			strings.NewReader(contents), "test.env")
+		if parse_error == nil {
+			t.Errorf("[ParseEnvironmentFile] Expected an error for %q",
+				contents)
+		}
+	}
+}
+
+/*
+Check to make sure that environment variables can be read, set, and unset
+without touching the environment of the process.
+*/
+func TestEnvironmentVariables(t *testing.T) {
+	script := "writeln \"#env.APPETIT_TEST #{env.APPETIT_TEST}s\"\n" +
+		"setenv APPETIT_TEST = \"#env.APPETIT_TEST again\"\n" +
+		"set length = \"length(#env.APPETIT_TEST)\"\n" +
+		"writeln \"#length\"\n" +
+		"unsetenv APPETIT_TEST\n" +
+		"writeln \"#env.APPETIT_TEST\""
+	var output bytes.Buffer
+	interpreter := New(Options{
+		Stdout:      &output,
+		Environment: map[string]string{"APPETIT_TEST": "value"},
+	})
+	run_error := interpreter.RunString(script)
+
+	// The last line uses the variable after it has been unset
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) ||
+		script_error.Rule != "runtime/undefined-variable" ||
+		script_error.Line != 6 {
+		t.Errorf("[Environment] Expected an undefined variable on line 6, "+
+			"got %v", run_error)
+	}
+	if output.String() != "value values\n11\n" {
+		t.Errorf("[Environment] Expected %q, got %q",
+			"value values\n11\n",
+			output.String())
+	}
+	// A new interpreter starts with the environment of the process
+	if _, exists := New(Options{}).Environment["APPETIT_TEST"]; exists {
+		t.Errorf("[Environment] Expected the process to be untouched")
+	}
+}
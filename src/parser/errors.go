@@ -1,29 +1,365 @@
 /*
 These functions provide error handling and reporting for language specific
-issues.
+issues. Errors are returned as a ScriptError rather than printed so that
+whoever is running the script (eg. the command line interface or a Go tool
+that embeds Appetit) can decide what to do with them. PrintError() is the
+pretty printer that the command line interface uses to present them.
 */
 package parser
 
 import (
 	"appetit/utils"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 )
 
+/*
+The ErrorCategory type houses the broad category of an error. Each category
+maps to the exit code that the interpreter exits with when a script fails.
+*/
+type ErrorCategory int
+
+/*
+The categories of errors. The value of each category is the exit code that
+the interpreter exits with.
+*/
+const (
+	// Something went wrong while a statement was being executed
+	ERROR_RUNTIME ErrorCategory = 1
+	// The script is malformed and was caught before anything was executed
+	ERROR_SYNTAX ErrorCategory = 2
+	// The interpreter was used incorrectly (eg. no script or a missing script)
+	ERROR_USAGE ErrorCategory = 3
+	// The script requires a newer version of the language
+	ERROR_VERSION ErrorCategory = 4
+	// The script tried to do something that it isn't allowed to do
+	ERROR_PERMISSION ErrorCategory = 5
+)
+
+/*
+Return the name of the category (eg. syntax). This is used as the start of
+the rule id of an error. No parameters. Returns the name of the category.
+*/
+func (category ErrorCategory) String() string {
+	switch category {
+	case ERROR_RUNTIME:
+		return "runtime"
+	case ERROR_SYNTAX:
+		return "syntax"
+	case ERROR_USAGE:
+		return "usage"
+	case ERROR_VERSION:
+		return "version"
+	case ERROR_PERMISSION:
+		return "permission"
+	}
+	return "unknown"
+}
+
+/*
+The ScriptError type houses an error that stopped a script. The structure of
+the error is as follows:
+  - Line [int]: the line number that triggered the error, zero if the error
+    isn't line specific
+  - Column [int]: the position on the line where the error occured, zero if
+    it isn't known
+  - Statement [string]: the name of the statement that triggered the error
+  - Message [string]: the error message itself
+  - Hint [string]: a suggestion for fixing the error, if there is one
+  - FullLineOfCode [string]: the full line of code that triggered the error
+  - Script [string]: the script that triggered the error, empty if it isn't
+    known
+  - Rule [string]: the id of the rule that the error breaks, empty if the
+    rule is worked out from the category and statement (see RuleID())
+  - Category [ErrorCategory]: the broad category of the error
+  - Err [error]: the underlying error, if there is one
+*/
+type ScriptError struct {
+	Line           int
+	Column         int
+	Statement      string
+	Message        string
+	Hint           string
+	FullLineOfCode string
+	Script         string
+	Rule           string
+	Category       ErrorCategory
+	Err            error
+}
+
+/*
+Returned by the exit statement to stop the script. This isn't a failure so
+Start() swallows it once it has made its way back up.
+*/
+var ErrScriptExit = errors.New("script exited")
+
+/*
+The GotoError type is returned by the goto statement to jump to a label. Much
+like ErrScriptExit, this isn't a failure; Execute() catches it and carries on
+from the label. The structure of the error is as follows:
+  - Label [string]: the name of the label to jump to
+  - Tokens [[]Token]: the tokens of the goto statement
+*/
+type GotoError struct {
+	Label  string
+	Tokens []Token
+}
+
+/*
+Return the error as a string. No parameters. Returns the error as a string.
+*/
+func (goto_error *GotoError) Error() string {
+	return "goto " + goto_error.Label
+}
+
+/*
+The CheckError type houses every problem that was found when a script was
+checked rather than executed (ie. with the -check flag). The structure of the
+error is as follows:
+  - Problems [[]error]: the problems in the order that they were found
+*/
+type CheckError struct {
+	Problems []error
+}
+
+/*
+Return the error as a string with a line for each problem. No parameters.
+Returns the error as a string.
+*/
+func (check_error *CheckError) Error() string {
+	// Hold a line for each problem
+	var problems []string
+	for _, problem := range check_error.Problems {
+		problems = append(problems, problem.Error())
+	}
+	return strings.Join(problems, "\n")
+}
+
+/*
+Return the problems so that errors.Is() and errors.As() can see them. No
+parameters. Returns the problems.
+*/
+func (check_error *CheckError) Unwrap() []error {
+	return check_error.Problems
+}
+
+/*
+Return the error as a string, stripped of any colour, so that it can be used
+like any other Go error. No parameters. Returns the error as a string.
+*/
+func (script_error *ScriptError) Error() string {
+	// Start with the message
+	message := utils.StripColour(script_error.Message)
+	// If the error is line specific, prepend the line number
+	if script_error.Line > 0 {
+		message = fmt.Sprintf("line %d: %s", script_error.Line, message)
+	}
+	// Return the message
+	return message
+}
+
+/*
+Return the underlying error so that errors.Is() and errors.As() can see it. No
+parameters. Returns the underlying error.
+*/
+func (script_error *ScriptError) Unwrap() error {
+	return script_error.Err
+}
+
+/*
+Set the category of the error. Parameters include the category. Returns the
+error so that this can be chained onto a call to Report().
+*/
+func (script_error *ScriptError) WithCategory(
+	category ErrorCategory) *ScriptError {
+	script_error.Category = category
+	return script_error
+}
+
+/*
+Set the hint of the error. Parameters include the hint. Returns the error so
+that this can be chained onto a call to Report().
+*/
+func (script_error *ScriptError) WithHint(hint string) *ScriptError {
+	script_error.Hint = hint
+	return script_error
+}
+
+/*
+Set the rule id of the error. Parameters include the rule id. Returns the
+error so that this can be chained onto a call to Report().
+*/
+func (script_error *ScriptError) WithRule(rule string) *ScriptError {
+	script_error.Rule = rule
+	return script_error
+}
+
+/*
+Get the id of the rule that the error breaks. This is the rule that was set
+with WithRule() or, where one wasn't, the category followed by the statement
+(eg. syntax/movefile). No parameters. Returns the rule id.
+*/
+func (script_error *ScriptError) RuleID() string {
+	// If a rule has been set, use it
+	if script_error.Rule != "" {
+		return script_error.Rule
+	}
+	// Otherwise, use the category and statement where it's known
+	if script_error.Statement == "" {
+		return script_error.Category.String()
+	}
+	return script_error.Category.String() + "/" + script_error.Statement
+}
+
+/*
+Set the underlying error. Parameters include the underlying error. Returns
+the error so that this can be chained onto a call to Report().
+*/
+func (script_error *ScriptError) WithErr(err error) *ScriptError {
+	script_error.Err = err
+	return script_error
+}
+
+/*
+Get the exit code for an error. Parameters include the error. Returns the
+exit code, zero if there is no error and the runtime category if the error
+isn't a ScriptError.
+*/
+func ExitCode(err error) int {
+	// No error, no problem
+	if err == nil {
+		return 0
+	}
+	// If it's a script error, use its category
+	var script_error *ScriptError
+	if errors.As(err, &script_error) && script_error.Category != 0 {
+		return int(script_error.Category)
+	}
+	// Otherwise, it's something that went wrong at runtime
+	return int(ERROR_RUNTIME)
+}
+
+/*
+Fill in the details that an error doesn't already have. Errors are reported
+deep inside statements and checks which don't always know which statement
+they belong to or what category of error they are so this is done on the way
+back up by Parse() and Call(). Parameters include the error, the tokens of the
+statement that triggered it, and the category to use if the error doesn't
+already have one. Returns the error.
+*/
+func AnnotateError(
+	err error, tokens []Token, category ErrorCategory) error {
+	// If it isn't a script error, there's nothing to fill in
+	var script_error *ScriptError
+	if !errors.As(err, &script_error) {
+		return err
+	}
+	// Fill in the statement name if it's missing
+	if script_error.Statement == "" && len(tokens) > 1 {
+		script_error.Statement = tokens[1].TokenValue
+	}
+	// Fill in the category if it's missing
+	if script_error.Category == 0 {
+		script_error.Category = category
+	}
+	// Return the error
+	return err
+}
+
 /*
 Report an error. Parameters include error_message, the error message
 itself, the line_number, the line number that triggered the error, the
 token position, the place where the error occured on the line, and the full
-line of code as a string. Returns nothing.
+line of code as a string. Returns the error as a ScriptError. The line number
+and token position are strings as they are often "n/a".
 */
 func Report(
 	error_message string,
-	line_number string, token_pos string, full_loc string) {
+	line_number string, token_pos string, full_loc string) *ScriptError {
+	// Get the line number and position as integers, zero if they are "n/a"
+	line, _ := strconv.Atoi(line_number)
+	column, _ := strconv.Atoi(token_pos)
+	// The full line of code is "n/a" where it isn't known
+	if full_loc == "n/a" {
+		full_loc = ""
+	}
+	// Return the error
+	return &ScriptError{
+		Line:           line,
+		Column:         column,
+		Message:        error_message,
+		FullLineOfCode: full_loc,
+	}
+}
+
+/*
+Report an error. Parameters include error_message, the error message
+itself. Unlike Report(), this is designed for errors that aren't line or
+syntax specific. This includes something like
+parser.CheckValidMinverLocationCount() as an example or passing the
+interpreter no script. Returns the error as a ScriptError.
+*/
+func ReportSimple(error_message string) *ScriptError {
+	return &ScriptError{
+		Message: error_message,
+	}
+}
 
-	// Get the token position and convert it to an integer
-	position, _ := strconv.Atoi(token_pos)
+/*
+Print an error. This is the pretty printer that the command line interface
+uses to present an error. Parameters include the writer to print to and the
+error. Returns nothing.
+*/
+func PrintError(writer io.Writer, err error) {
+	// If this is the result of a check, print each problem in turn
+	var check_error *CheckError
+	if errors.As(err, &check_error) {
+		for _, problem := range check_error.Problems {
+			PrintError(writer, problem)
+		}
+		// Note how many problems there were
+		problem_count := len(check_error.Problems)
+		problems := "problems"
+		if problem_count == 1 {
+			problems = "problem"
+		}
+		fmt.Fprintln(writer, utils.ColouriseRed(
+			"["+strconv.Itoa(problem_count)+" "+problems+" found]"))
+		return
+	}
+
+	// Get the error as a ScriptError, making one if it isn't one
+	var script_error *ScriptError
+	if !errors.As(err, &script_error) {
+		script_error = ReportSimple(err.Error())
+	}
+
+	// If the error isn't line specific, print it simply
+	if script_error.Line == 0 {
+		fmt.Fprintln(writer, utils.ColouriseRed("\n[ERROR]"))
+		fmt.Fprintln(writer, script_error.Message+"\n")
+		// Print the hint if there is one
+		PrintHint(writer, script_error)
+		return
+	}
+
+	// Get the line number and token position as strings
+	line_number := strconv.Itoa(script_error.Line)
+	token_pos := "n/a"
+	if script_error.Column > 0 {
+		token_pos = strconv.Itoa(script_error.Column)
+	}
+	// Get the full line of code, "n/a" where it isn't known
+	full_loc := script_error.FullLineOfCode
+	if full_loc == "" {
+		full_loc = "n/a"
+	}
+
+	// Get the token position
+	position := script_error.Column
 	/*
 		Set the header for the line of code header so that we can also get its
 		length
@@ -37,36 +373,39 @@ func Report(
 	// Set up the error arrow
 	error_pos_symbol := utils.ColouriseRed("^") // ⇈
 	// Print the error information
-	fmt.Println(utils.ColouriseRed("\n[ERROR]\n\n[Location]"))
-	fmt.Println(utils.ColouriseMagenta(" Line Number: ") + line_number)
-	fmt.Println(utils.ColouriseMagenta("    Position: ") + token_pos)
-	fmt.Println(utils.ColouriseMagenta(loc_title) + full_loc)
+	fmt.Fprintln(writer, utils.ColouriseRed("\n[ERROR]\n\n[Location]"))
+	// If the error came from a known script, note which one
+	if script_error.Script != "" {
+		fmt.Fprintln(writer,
+			utils.ColouriseMagenta("      Script: ")+script_error.Script)
+	}
+	fmt.Fprintln(writer, utils.ColouriseMagenta(" Line Number: ")+line_number)
+	fmt.Fprintln(writer, utils.ColouriseMagenta("    Position: ")+token_pos)
+	fmt.Fprintln(writer, utils.ColouriseMagenta(loc_title)+full_loc)
 	/*
 		Print out the arrow to note where the error starts by repeating some
 		blank spaces at the beginning that is the length of the line of code
 		header.
 	*/
-	fmt.Printf("%s%s\n",
+	fmt.Fprintf(writer, "%s%s\n",
 		strings.Repeat(" ", position-1),
 		error_pos_symbol,
 	)
-	fmt.Println(utils.ColouriseRed("\n[Description]"))
-	fmt.Printf("%s\n\n", error_message)
-	// Abandon ship
-	os.Exit(0)
+	fmt.Fprintln(writer, utils.ColouriseRed("\n[Description]"))
+	fmt.Fprintf(writer, "%s\n\n", script_error.Message)
+	// Print the hint if there is one
+	PrintHint(writer, script_error)
 }
 
 /*
-Report an error. Parameters include error_message, the error message
-itself. Unlike Report(), this is designed for errors that aren't line or
-syntax specific. This includes something like
-parser.CheckValidMinverLocationCount() as an example or passing the
-interpreter no script. Returns nothing.
+Print the hint of an error if there is one. Parameters include the writer to
+print to and the error. Returns nothing.
 */
-func ReportSimple(error_message string) {
-	fmt.Println(utils.ColouriseRed("\n[ERROR]"))
-	fmt.Println(error_message + "\n")
-	os.Exit(0)
+func PrintHint(writer io.Writer, script_error *ScriptError) {
+	if script_error.Hint != "" {
+		fmt.Fprintln(writer, utils.ColouriseRed("[Hint]"))
+		fmt.Fprintf(writer, "%s\n\n", script_error.Hint)
+	}
 }
 
 /*
@@ -75,15 +414,15 @@ particularly helpful in those moments where we are dealing with errors that
 conform to Go standards (ie. first letter is lower case and there is no
 trailing punctuation). Parameters include error_message, the error message
 itself and line_number, the line number that triggered the error. Returns
-nothing.
+the error as a ScriptError.
 */
 func ReportWithFixes(error_message string, line_number string,
-	token_pos string, full_loc string) {
+	token_pos string, full_loc string) *ScriptError {
 	// Capitalise the error message
 	error_message = strings.ToTitle(string(error_message[0])) +
 		error_message[1:] + "."
 	// Report the error
-	Report(error_message, line_number, token_pos, full_loc)
+	return Report(error_message, line_number, token_pos, full_loc)
 }
 
 /*
@@ -91,13 +430,13 @@ This handles errors reported by the tokeniser that are not language
 specific but would nonetheless cause issues for the script. For instance,
 an unmatched pair of quotation marks isn't a language error (per se) but
 would nonetheless cause an issue. Parameters here include the message
-reported back by the tokeniser.
+reported back by the tokeniser. Returns the error as a ScriptError.
 */
-func ReportTokeniserErrors(message string, loc int) {
+func ReportTokeniserErrors(message string, loc int) *ScriptError {
 	/*
 		Set up an elaborate switch/case to capture any anticipated errors
 		Parameters include the scanner and the message that gets reported.
-		Returns nothing.
+		Returns the error.
 
 		If scanner.Init() throws an error, this is the place to fix it.
 		See here: https://cs.opensource.google/go/go/+/refs/tags/go1.25.1:src/
@@ -108,7 +447,7 @@ func ReportTokeniserErrors(message string, loc int) {
 	switch message {
 	// Catch an unterminated literal
 	case "literal not terminated":
-		ReportSimple(
+		return ReportSimple(
 			"Line " + strconv.Itoa(loc) + " has an incomplete string. Did " +
 				"you forget an opening or closing quotation mark? Something " +
 				"like the following line of code will trigger this error:" +
@@ -118,7 +457,7 @@ func ReportTokeniserErrors(message string, loc int) {
 		)
 	// Catch an invalid char literal
 	case "invalid char literal":
-		Report(
+		return Report(
 			"Your line of code use single quotation marks instead of "+
 				"the required double quotation marks. See the example:\n\n"+
 				utils.ColouriseCyan("writeln ")+
@@ -129,7 +468,7 @@ func ReportTokeniserErrors(message string, loc int) {
 			"n/a",
 		)
 	case "comment not terminated":
-		Report(
+		return Report(
 			"You've included a Go style comment as a statement call which "+
 				"is not valid. Comments are single line and take the "+
 				"following "+"form:\n\n"+utils.ColouriseGrey(
@@ -139,7 +478,7 @@ func ReportTokeniserErrors(message string, loc int) {
 			"n/a",
 		)
 	case "invalid char escape":
-		Report(
+		return Report(
 			"You've included an invalid character escape. You need to use "+
 				"one of the following: "+utils.ColouriseMagenta("\\n")+
 				" (for new line), "+utils.ColouriseMagenta("\\t")+" (for "+
@@ -152,7 +491,7 @@ func ReportTokeniserErrors(message string, loc int) {
 		/* Report everything else in their "Go form." It is hoped that, some
 		day, this will not need to exist.
 		*/
-		Report(
+		return Report(
 			message+". Please report this error with the erroneous line "+
 				"of code as this isn't accounted for in the error checking."+
 				"It may be some time before you see this error message fixed"+
@@ -164,8 +503,24 @@ func ReportTokeniserErrors(message string, loc int) {
 	}
 }
 
-func Warning(warning string, line_number string) {
-	fmt.Println(utils.ColouriseYellow("\n[WARNING]"))
-	fmt.Println(utils.ColouriseMagenta(" Line Number: ") + line_number)
-	fmt.Println(warning)
+/*
+Print a warning. A warning doesn't stop the script but notes something that
+the user should know about. Parameters include the writer to print to and the
+warning. Returns nothing.
+*/
+func PrintWarning(writer io.Writer, warning *ScriptError) {
+	fmt.Fprintln(writer, utils.ColouriseYellow("\n[WARNING]"))
+	// If the warning came from a known script, note which one
+	if warning.Script != "" {
+		fmt.Fprintln(writer,
+			utils.ColouriseMagenta("      Script: ")+warning.Script)
+	}
+	// If the warning is line specific, note the line
+	if warning.Line > 0 {
+		fmt.Fprintln(writer, utils.ColouriseMagenta(" Line Number: ")+
+			strconv.Itoa(warning.Line))
+	}
+	fmt.Fprintln(writer, warning.Message+"\n")
+	// Print the hint if there is one
+	PrintHint(writer, warning)
 }
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+/*
+Check to make sure that a malformed script returns a ScriptError that has
+the details of where things went wrong and that nothing in the script is
+executed.
+*/
+func TestScriptErrorDetails(t *testing.T) {
+	// Create an interpreter that writes to a buffer
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output})
+
+	// Run a script where the third line is malformed
+	run_error := interpreter.RunString(
+		"writeln \"first\"\n\nmovefile \"a.txt\" \"b.txt\"",
+	)
+
+	// Get the error as a ScriptError
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) {
+		t.Fatalf("[ScriptError] Expected a ScriptError, got %v", run_error)
+	}
+
+	// Check the details of the error
+	if script_error.Line != 3 {
+		t.Errorf("[ScriptError] Expected line %d, got %d",
+			3,
+			script_error.Line)
+	}
+	if script_error.Statement != "movefile" {
+		t.Errorf("[ScriptError] Expected statement %s, got %s",
+			"movefile",
+			script_error.Statement)
+	}
+	if script_error.Category != ERROR_SYNTAX {
+		t.Errorf("[ScriptError] Expected category %d, got %d",
+			ERROR_SYNTAX,
+			script_error.Category)
+	}
+
+	// Nothing should have been written out
+	if output.Len() != 0 {
+		t.Errorf("[ScriptError] Expected no output, got %q", output.String())
+	}
+}
+
+/*
+Check to make sure that ExitCode() gives a non-zero exit code for each
+category of error and zero when there is no error, including when the script
+stops via the exit statement.
+*/
+func TestExitCode(t *testing.T) {
+	// The execute statement isn't allowed without AllowExec
+	permission_error := New(Options{}).RunString("execute \"ls\"")
+	if ExitCode(permission_error) != int(ERROR_PERMISSION) {
+		t.Errorf("[ExitCode] Expected %d, got %d",
+			ERROR_PERMISSION,
+			ExitCode(permission_error))
+	}
+
+	// A script that needs a newer version of the language
+	version_error := New(Options{}).RunString("minver 999")
+	if ExitCode(version_error) != int(ERROR_VERSION) {
+		t.Errorf("[ExitCode] Expected %d, got %d",
+			ERROR_VERSION,
+			ExitCode(version_error))
+	}
+
+	// A script that stops part of the way through is not a failure
+	var output bytes.Buffer
+	exit_error := New(Options{Stdout: &output}).RunString(
+		"write \"before\"\nexit\nwrite \"after\"",
+	)
+	if ExitCode(exit_error) != 0 || output.String() != "before" {
+		t.Errorf("[ExitCode] Expected %d and %q, got %d and %q",
+			0,
+			"before",
+			ExitCode(exit_error),
+			output.String())
+	}
+
+	// Any other error is a runtime error
+	if ExitCode(errors.New("other")) != int(ERROR_RUNTIME) {
+		t.Errorf("[ExitCode] Expected %d, got %d",
+			ERROR_RUNTIME,
+			ExitCode(errors.New("other")))
+	}
+}
@@ -0,0 +1,456 @@
+/*
+The exec policy houses the rules that decide which commands the execute
+statement can run. Without any rules, the -allowexec flag allows every
+command. With rules, from a policy file (see ParseExecPolicy()) or passed
+with the flag (eg. -allowexec=git,rsync), only the commands that a rule
+allows are run and anything else stops the script with a report that says
+why. Each rule is the name of a program, or the full path to one, followed by
+optional patterns that its arguments need to match, for example:
+
+	# Any git command
+	git
+	# rsync but only to copy into /srv
+	rsync -a * /srv/*
+	# The one backup tool, with any arguments after the first
+	/opt/backup/bin/backup run ...
+
+A program named without a path is only allowed when it is run the same way
+(ie. found on the PATH). In a pattern, * matches anything (including /), ?
+matches any one character, and ... on its own at the end matches any
+arguments that are left. Every decision can be written to an audit log (see
+AuditCommand()).
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The pattern that matches any arguments that are left
+const SYMBOL_REMAINING_ARGUMENTS = "..."
+
+// The source of the rules passed with the -allowexec flag
+const EXEC_RULE_FLAG_SOURCE = "-allowexec"
+
+/*
+The ExecRule type houses a rule that allows a command to be executed. The
+structure of the rule is as follows:
+  - Program [string]: the name of the program (eg. git) or the full path to
+    it (eg. /usr/bin/git)
+  - Arguments [[]string]: the patterns that the arguments need to match, in
+    order, or empty if any arguments are allowed
+  - Source [string]: where the rule came from (eg. policy.txt:3 or
+    -allowexec)
+*/
+type ExecRule struct {
+	Program   string
+	Arguments []string
+	Source    string
+}
+
+/*
+Get the rule as it would be written in a policy file. No parameters. Returns
+the rule.
+*/
+func (rule ExecRule) String() string {
+	return strings.Join(append([]string{rule.Program}, rule.Arguments...), " ")
+}
+
+/*
+Check whether the rule is for a program. Parameters include the program as it
+was written in the command and the path that it was found at. Returns true if
+the rule is for the program.
+*/
+func (rule ExecRule) MatchesProgram(program string, program_path string) bool {
+	if filepath.IsAbs(rule.Program) {
+		return filepath.Clean(rule.Program) == program_path
+	}
+	// A program named without a path needs to have been found on the PATH
+	return filepath.Base(program) == program &&
+		ProgramName(program) == ProgramName(rule.Program)
+}
+
+/*
+Check whether the arguments of a command match the patterns of the rule.
+Parameters include the arguments (without the program). Returns true if they
+match.
+*/
+func (rule ExecRule) MatchesArguments(arguments []string) bool {
+	if len(rule.Arguments) == 0 {
+		return true
+	}
+	for index, pattern := range rule.Arguments {
+		if pattern == SYMBOL_REMAINING_ARGUMENTS &&
+			index == len(rule.Arguments)-1 {
+			return true
+		}
+		if index >= len(arguments) || !MatchPattern(pattern, arguments[index]) {
+			return false
+		}
+	}
+	return len(arguments) == len(rule.Arguments)
+}
+
+/*
+Check whether a value matches a pattern where * matches anything and ?
+matches any one character. Parameters include the pattern and the value.
+Returns true if the value matches.
+*/
+func MatchPattern(pattern string, value string) bool {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, "\\*", ".*")
+	expression = strings.ReplaceAll(expression, "\\?", ".")
+	matched, _ := regexp.MatchString("^(?s:"+expression+")$", value)
+	return matched
+}
+
+/*
+Get the name of a program without its path or, on Windows, its extension (eg.
+C:\Git\bin\git.exe is git). Parameters include the program. Returns the name.
+*/
+func ProgramName(program string) string {
+	name := filepath.Base(program)
+	if runtime.GOOS == "windows" {
+		name = strings.ToLower(strings.TrimSuffix(
+			name, filepath.Ext(name)))
+	}
+	return name
+}
+
+/*
+Get the rules passed with the -allowexec flag. Parameters include the value
+of the flag, which is a comma separated list of programs (eg. git,rsync).
+Returns the rules.
+*/
+func ParseAllowExec(value string) []ExecRule {
+	var rules []ExecRule
+	for _, program := range strings.Split(value, ",") {
+		program = strings.TrimSpace(program)
+		if program != "" {
+			rules = append(rules, ExecRule{
+				Program: program,
+				Source:  EXEC_RULE_FLAG_SOURCE,
+			})
+		}
+	}
+	return rules
+}
+
+/*
+The ExecAllowList type houses the value of the -allowexec flag, which can be
+passed on its own to allow every command or with the programs to allow (eg.
+-allowexec=git,rsync), as many times as need be. The structure of the list is
+as follows:
+  - AllowAll [bool]: whether the flag was passed on its own
+  - Rules [[]ExecRule]: the rules for the programs that were passed
+*/
+type ExecAllowList struct {
+	AllowAll bool
+	Rules    []ExecRule
+}
+
+/*
+Get the programs in the list as they would be passed to the flag. No
+parameters. Returns the programs.
+*/
+func (list *ExecAllowList) String() string {
+	if list == nil || (list.AllowAll && len(list.Rules) == 0) {
+		return ""
+	}
+	var programs []string
+	for _, rule := range list.Rules {
+		programs = append(programs, rule.Program)
+	}
+	return strings.Join(programs, ",")
+}
+
+/*
+Add the value of the flag to the list. Parameters include the value, which
+is true when the flag is passed on its own. Returns an error if no programs
+were passed.
+*/
+func (list *ExecAllowList) Set(value string) error {
+	if allow_all, bool_error := strconv.ParseBool(value); bool_error == nil {
+		list.AllowAll = allow_all
+		return nil
+	}
+	rules := ParseAllowExec(value)
+	if len(rules) == 0 {
+		return fmt.Errorf("no programs were passed")
+	}
+	list.Rules = append(list.Rules, rules...)
+	return nil
+}
+
+/*
+Let the flag be passed on its own (ie. -allowexec). No parameters. Returns
+true.
+*/
+func (list *ExecAllowList) IsBoolFlag() bool {
+	return true
+}
+
+/*
+Load an exec policy file (see ParseExecPolicy()). Parameters include the name
+of the file. Returns the rules and an error if the file couldn't be read or
+has a line that isn't a rule.
+*/
+func LoadExecPolicy(file_name string) ([]ExecRule, error) {
+	file, open_error := os.Open(file_name)
+	if open_error != nil {
+		return nil, ReportSimple(
+			"Unknown exec policy file: " + utils.ColouriseMagenta(file_name) +
+				".",
+		).WithCategory(ERROR_USAGE).WithErr(open_error)
+	}
+	defer file.Close()
+	return ParseExecPolicy(file, file_name)
+}
+
+/*
+Read the rules in an exec policy file. Each line is a rule, split into the
+program and the patterns for its arguments much as a command is (see
+SplitCommand()). Blank lines and lines that start with a # are skipped.
+Parameters include the contents of the file and its name (for errors and so
+that each rule can say where it came from). Returns the rules and an error if
+a line can't be split.
+*/
+func ParseExecPolicy(reader io.Reader, file_name string) ([]ExecRule, error) {
+	var rules []ExecRule
+	scanner := bufio.NewScanner(reader)
+	line_number := 0
+	for scanner.Scan() {
+		line_number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts, split_error := SplitCommand(line)
+		if split_error != nil {
+			return nil, ReportSimple(
+				"Line " + strconv.Itoa(line_number) + " of the exec policy " +
+					"file " + utils.ColouriseMagenta(file_name) + " - " +
+					utils.ColouriseYellow(line) + " - can't be read as " +
+					split_error.Error() + ".",
+			).WithCategory(ERROR_USAGE)
+		}
+		rules = append(rules, ExecRule{
+			Program:   parts[0],
+			Arguments: parts[1:],
+			Source:    file_name + ":" + strconv.Itoa(line_number),
+		})
+	}
+	return rules, scanner.Err()
+}
+
+/*
+Find the program that a command runs. A program with a path is taken as it
+is (relative to the directory that it is run in) while one without is looked
+for on the PATH that the script gives its commands (see setenv). Where the
+exec policy has rules, it is looked for on the PATH that the interpreter
+started with instead so that a script can't run a program of its own in place
+of one that a rule allows by changing the PATH. Parameters include the
+program and the directory that the command is run in. Returns the full path
+to the program and an exec.Error if it isn't on the PATH.
+*/
+func (interpreter *Interpreter) ResolveProgram(
+	program string, directory string) (string, error) {
+	if filepath.Base(program) != program {
+		if !filepath.IsAbs(program) {
+			program = filepath.Join(directory, program)
+		}
+		return filepath.Abs(program)
+	}
+
+	// Get the PATH and, on Windows, the extensions that a program can have
+	environment := interpreter.Environment
+	if len(interpreter.ExecRules) > 0 {
+		environment = interpreter.start_environment
+	}
+	var search_path string
+	extensions := []string{""}
+	for name, value := range environment {
+		switch {
+		case name == "PATH" ||
+			(runtime.GOOS == "windows" && strings.EqualFold(name, "PATH")):
+			search_path = value
+		case runtime.GOOS == "windows" && strings.EqualFold(name, "PATHEXT"):
+			extensions = append(extensions, filepath.SplitList(
+				strings.ToLower(value))...)
+		}
+	}
+	// Use the first executable file with the name of the program
+	for _, directory := range filepath.SplitList(search_path) {
+		for _, extension := range extensions {
+			candidate := filepath.Join(directory, program+extension)
+			info, stat_error := os.Stat(candidate)
+			if stat_error != nil || !info.Mode().IsRegular() ||
+				(runtime.GOOS != "windows" && info.Mode()&0111 == 0) {
+				continue
+			}
+			return filepath.Abs(candidate)
+		}
+	}
+	return "", &exec.Error{Name: program, Err: exec.ErrNotFound}
+}
+
+/*
+Check that the exec policy allows a command and note the decision in the
+audit log. Without any rules, every command is allowed. Parameters include
+the tokens of the execute statement, the command (the program as it was
+written followed by its arguments), the full path to the program, and the
+directory that it is run in. Returns an error that names the rule that
+blocked the command if it isn't allowed.
+*/
+func (interpreter *Interpreter) AuthoriseCommand(
+	tokens []Token,
+	command []string,
+	program_path string,
+	directory string) error {
+	// Without any rules, the -allowexec flag allows everything
+	if len(interpreter.ExecRules) == 0 {
+		interpreter.AuditCommand(
+			tokens, command, program_path, directory, EXEC_RULE_FLAG_SOURCE,
+			nil)
+		return nil
+	}
+
+	// Find the first rule that allows the command, noting the near misses
+	var program_rules, path_rules []ExecRule
+	for _, rule := range interpreter.ExecRules {
+		if !rule.MatchesProgram(command[0], program_path) {
+			// A rule for a program of the same name that was run by its path
+			if !filepath.IsAbs(rule.Program) &&
+				ProgramName(rule.Program) == ProgramName(command[0]) {
+				path_rules = append(path_rules, rule)
+			}
+			continue
+		}
+		if rule.MatchesArguments(command[1:]) {
+			interpreter.AuditCommand(
+				tokens, command, program_path, directory,
+				rule.String()+" ("+rule.Source+")", nil)
+			return nil
+		}
+		program_rules = append(program_rules, rule)
+	}
+
+	// List the rules so the report can say what would have been allowed
+	describe := func(rules []ExecRule) string {
+		var descriptions string
+		for _, rule := range rules {
+			descriptions += "\n\t- " + utils.ColouriseGreen(rule.String()) +
+				" (" + rule.Source + ")"
+		}
+		return descriptions
+	}
+	full_command := strings.Join(command, " ")
+	var message, hint string
+	switch {
+	case len(program_rules) > 0:
+		message = "The command " + utils.ColouriseYellow(full_command) +
+			" isn't allowed as its arguments don't match the rules for " +
+			utils.ColouriseYellow(command[0]) + ":" + describe(program_rules)
+		hint = "Add a rule for these arguments to the exec policy."
+	case len(path_rules) > 0:
+		message = "The command " + utils.ColouriseYellow(full_command) +
+			" isn't allowed as it runs " + utils.ColouriseYellow(program_path) +
+			" by its path and these rules only allow the program found on " +
+			"the PATH:" + describe(path_rules)
+		hint = "Run it by its name or add a rule with the full path to it " +
+			"(eg. " + utils.ColouriseGreen(program_path) + ")."
+	default:
+		message = "The command " + utils.ColouriseYellow(full_command) +
+			" isn't allowed as there is no rule for " +
+			utils.ColouriseYellow(command[0]) + ". The commands that are " +
+			"allowed are:" + describe(interpreter.ExecRules)
+		hint = "Add " + utils.ColouriseGreen(command[0]) + " to the " +
+			utils.ColouriseYellow("-allowexec") + " flag (eg. " +
+			utils.ColouriseYellow("-allowexec="+command[0]) + ") or a rule " +
+			"for it to the exec policy."
+	}
+	denied_error := Report(
+		message,
+		strconv.Itoa(tokens[0].LineNumber),
+		tokens[2].TokenPosition,
+		tokens[0].FullLineOfCode,
+	).WithCategory(ERROR_PERMISSION).WithRule(
+		"runtime/execute-denied").WithHint(hint)
+	interpreter.AuditCommand(
+		tokens, command, program_path, directory, "", denied_error)
+	return denied_error
+}
+
+/*
+The AuditRecord type houses an entry in the audit log, which is written as a
+line of JSON for each command that the execute statement is asked to run. The
+structure of the record is as follows:
+  - Time [string]: when the decision was made in RFC 3339 format
+  - Decision [string]: allowed or denied
+  - Script [string]: the script that the execute statement is in
+  - Line [int]: the line of the execute statement
+  - Program [string]: the full path to the program
+  - Arguments [[]string]: the arguments of the command
+  - Directory [string]: the directory that the command is run in, empty for
+    the working directory
+  - Rule [string]: the rule that allowed the command, empty if it was denied
+  - Reason [string]: why the command was denied, empty if it was allowed
+*/
+type AuditRecord struct {
+	Time      string   `json:"time"`
+	Decision  string   `json:"decision"`
+	Script    string   `json:"script"`
+	Line      int      `json:"line"`
+	Program   string   `json:"program"`
+	Arguments []string `json:"arguments"`
+	Directory string   `json:"directory,omitempty"`
+	Rule      string   `json:"rule,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+/*
+Write a decision about a command to the audit log, if there is one.
+Parameters include the tokens of the execute statement, the command, the full
+path to the program, the directory that it is run in, the rule that allowed
+it, and the error that denied it (nil if it was allowed). Returns nothing as
+a command isn't stopped by a log that can't be written.
+*/
+func (interpreter *Interpreter) AuditCommand(
+	tokens []Token,
+	command []string,
+	program_path string,
+	directory string,
+	rule string,
+	denied_error *ScriptError) {
+	if interpreter.Audit == nil {
+		return
+	}
+	record := AuditRecord{
+		Time:      time.Now().Format(time.RFC3339),
+		Decision:  "allowed",
+		Script:    interpreter.ScriptName,
+		Line:      tokens[0].LineNumber,
+		Program:   program_path,
+		Arguments: command[1:],
+		Directory: directory,
+		Rule:      rule,
+	}
+	if denied_error != nil {
+		record.Decision = "denied"
+		record.Reason = utils.StripColour(denied_error.Message)
+	}
+	encoded, _ := json.Marshal(record)
+	fmt.Fprintln(interpreter.Audit, string(encoded))
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that an exec policy file is read as a rule a line and that
+the -allowexec flag can be passed on its own or with programs.
+*/
+func TestParseExecPolicy(t *testing.T) {
+	contents := "# Any git command\ngit\n\nrsync -a '* *' /srv/*\n"
+	rules, parse_error := ParseExecPolicy(
+		strings.NewReader(contents), "policy.txt")
+	if parse_error != nil || len(rules) != 2 ||
+		rules[1].String() != "rsync -a * * /srv/*" ||
+		rules[1].Source != "policy.txt:4" {
+		t.Errorf("[ParseExecPolicy] Expected two rules, got %v (%v)",
+			rules,
+			parse_error)
+	}
+	if _, parse_error := ParseExecPolicy(
+		strings.NewReader("git 'open\n"), "policy.txt"); parse_error == nil {
+		t.Errorf("[ParseExecPolicy] Expected an error for an open quote")
+	}
+
+	var list ExecAllowList
+	for _, value := range []string{"true", "git, rsync", "make"} {
+		if set_error := list.Set(value); set_error != nil {
+			t.Fatalf("[ExecAllowList] Expected no error for %q, got %v",
+				value,
+				set_error)
+		}
+	}
+	if !list.AllowAll || list.String() != "git,rsync,make" {
+		t.Errorf("[ExecAllowList] Expected every command and git,rsync,make, "+
+			"got %v and %s",
+			list.AllowAll,
+			list.String())
+	}
+}
+
+/*
+Check to make sure that a rule only matches its program and the arguments
+that its patterns allow.
+*/
+func TestExecRuleMatches(t *testing.T) {
+	rule := ExecRule{Program: "rsync", Arguments: []string{"-a", "*", "/srv/*"}}
+	// The arguments and whether they should match
+	cases := map[string]bool{
+		"-a /home /srv/backups/today": true,
+		"-a /home /srv/":              true,
+		"-a /home /tmp":               false,
+		"-a /home":                    false,
+		"-a /home /srv/x --delete":    false,
+	}
+	for arguments, expected := range cases {
+		if rule.MatchesArguments(strings.Fields(arguments)) != expected {
+			t.Errorf("[MatchesArguments] Expected %v for %q",
+				expected,
+				arguments)
+		}
+	}
+	remaining := ExecRule{Program: "git", Arguments: []string{"log", "..."}}
+	if !remaining.MatchesArguments([]string{"log", "-1", "--oneline"}) ||
+		remaining.MatchesArguments([]string{"push"}) {
+		t.Errorf("[MatchesArguments] Expected ... to match what is left")
+	}
+
+	// A program named without a path needs to be run by its name
+	if !rule.MatchesProgram("rsync", "/usr/bin/rsync") ||
+		rule.MatchesProgram("./rsync", "/home/x/rsync") {
+		t.Errorf("[MatchesProgram] Expected rsync to only match on the PATH")
+	}
+}
+
+/*
+Check to make sure that a command that isn't allowed isn't run, that the
+report names the rules, and that each decision is audited.
+*/
+func TestAuthoriseCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The commands used are Unix commands")
+	}
+	var output, audit bytes.Buffer
+	interpreter := New(Options{
+		Stdout:    &output,
+		Audit:     &audit,
+		ExecRules: []ExecRule{{Program: "echo", Arguments: []string{"hi"}}},
+	})
+	run_error := interpreter.RunString("execute \"echo hi\"\n" +
+		"execute \"echo bye\"\nwriteln \"Not reached\"")
+
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) ||
+		script_error.Rule != "runtime/execute-denied" ||
+		ExitCode(run_error) != int(ERROR_PERMISSION) ||
+		!strings.Contains(script_error.Message, "echo hi") {
+		t.Fatalf("[execute] Expected echo bye to be denied, got %v", run_error)
+	}
+	if output.String() != "hi\n" {
+		t.Errorf("[execute] Expected %q, got %q", "hi\n", output.String())
+	}
+
+	// There should be a line of the audit log for each decision
+	var decisions []string
+	for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+		var record AuditRecord
+		if json.Unmarshal([]byte(line), &record) != nil {
+			t.Fatalf("[execute] Expected a JSON audit record, got %q", line)
+		}
+		decisions = append(decisions, record.Decision)
+	}
+	if strings.Join(decisions, ",") != "allowed,denied" {
+		t.Errorf("[execute] Expected allowed then denied, got %v", decisions)
+	}
+}
+
+/*
+Check to make sure that a script can't get a program of its own run in place
+of one that a rule allows by changing the PATH.
+*/
+func TestExecRulesIgnoreScriptPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The program written is a Unix shell script")
+	}
+	directory := t.TempDir()
+	os.WriteFile(filepath.Join(directory, "echo"),
+		[]byte("#!/bin/sh\nprintf 'evil\\n'\n"), 0755)
+
+	var output, audit bytes.Buffer
+	interpreter := New(Options{
+		Stdout:    &output,
+		Audit:     &audit,
+		ExecRules: []ExecRule{{Program: "echo", Source: "-allowexec"}},
+	})
+	run_error := interpreter.RunString(
+		"setenv PATH = \"" + directory + "\"\nexecute \"echo hi\"")
+	if run_error != nil || output.String() != "hi\n" ||
+		strings.Contains(audit.String(), directory) {
+		t.Errorf("[execute] Expected the echo on the PATH the interpreter "+
+			"started with, got %q (%v) %s",
+			output.String(),
+			run_error,
+			audit.String())
+	}
+
+	// Without any rules, the PATH that the script sets is used
+	output.Reset()
+	interpreter = New(Options{Stdout: &output, AllowExec: true})
+	run_error = interpreter.RunString(
+		"setenv PATH = \"" + directory + "\"\nexecute \"echo hi\"")
+	if run_error != nil || output.String() != "evil\n" {
+		t.Errorf("[execute] Expected the script's echo, got %q (%v)",
+			output.String(),
+			run_error)
+	}
+}
@@ -0,0 +1,1060 @@
+/*
+Expressions are the maths and text that a value can be calculated from (eg.
+"#count + 1" or "upper(#name)"). A value that isn't an expression is text and
+is left as it is, unless it is made up of the parts of an expression but
+isn't one (eg. (1 + 2, #count *, or 2 ** 3), which is an error. An expression is made up of the following:
+  - Numbers, which are integers (eg. 42) or floats (eg. 0.5). A number can't
+    start with a 0 unless it is 0 or a float (eg. 007 is text).
+  - Text in single or double quotes (eg. 'Hello #name'), where any variables
+    are replaced with their values
+  - true and false
+  - Variables (eg. #count or #{count}), which keep their type
+  - Functions (eg. round(#half)), where the name is right before the opening
+    bracket (see ExpressionFunctions())
+  - Brackets to group parts of the expression (eg. (1 + 2) * 3)
+
+The operators, from the tightest to the loosest binding, are as follows:
+  - - (negative) and ! (not)
+  - * (multiply), / (divide), and % (remainder)
+  - + (add or join) and - (subtract)
+  - ==, !=, <, <=, >, and >= (compare)
+  - && (and)
+  - || (or)
+
+Dividing two integers is an integer where it divides evenly and a float where
+it doesn't (eg. 7 / 2 is 3.5). + joins two lists or, where either side is
+text, joins the two sides as text. Anything an expression can't do (eg.
+dividing by zero or subtracting text) is an error rather than text.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+The ExpressionError type houses a problem with an expression. The structure
+of the error is as follows:
+  - Message [string]: what the problem is
+  - Offset [int]: where in the expression the problem is, in bytes
+  - Hint [string]: how the problem might be fixed, empty if there's no hint
+*/
+type ExpressionError struct {
+	Message string
+	Offset  int
+	Hint    string
+}
+
+// Get the message of the error. No parameters. Returns the message.
+func (expression_error *ExpressionError) Error() string {
+	return expression_error.Message
+}
+
+/*
+The ExpressionTokenKind type houses the kind of a token in an expression.
+*/
+type ExpressionTokenKind int
+
+// The kinds of tokens in an expression
+const (
+	// The end of the expression
+	EXPRESSION_END ExpressionTokenKind = iota
+	// A number or a boolean (eg. 42)
+	EXPRESSION_VALUE
+	// Text in quotes (eg. 'Hello')
+	EXPRESSION_TEXT
+	// A variable (eg. #count)
+	EXPRESSION_VARIABLE
+	// The name of a function (eg. round)
+	EXPRESSION_NAME
+	// An operator, a bracket, or a comma
+	EXPRESSION_OPERATOR
+)
+
+/*
+The ExpressionToken type houses a token in an expression. The structure of
+the token is as follows:
+  - Kind [ExpressionTokenKind]: the kind of token
+  - Text [string]: the token as it was written, the text in the quotes for
+    text, and the name of the variable for a variable
+  - Value [Value]: the value of a number or a boolean
+  - Offset [int]: where the token starts in the expression, in bytes
+*/
+type ExpressionToken struct {
+	Kind   ExpressionTokenKind
+	Text   string
+	Value  Value
+	Offset int
+}
+
+/*
+The operators that can be used in an expression. Those that are two
+characters long come first so that they are matched before the one character
+operator that they start with.
+*/
+var ExpressionOperators = []string{
+	"&&", "||", "==", "!=", "<=", ">=",
+	"<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ",",
+}
+
+/*
+Split an expression into its tokens. Parameters include the expression and
+the variables that it can use. Returns the tokens, ending with an
+EXPRESSION_END token, and an error if the expression isn't one (eg. it uses a
+variable that hasn't been set or has a character that isn't part of an
+expression).
+*/
+func LexExpression(
+	input string,
+	variables map[string]Value,
+) ([]ExpressionToken, error) {
+	// Get the variables in the expression by where they start
+	references := map[int]VariableReference{}
+	for _, reference := range VariableReferences(input) {
+		references[reference.Start] = reference
+	}
+
+	var tokens []ExpressionToken
+	for offset := 0; offset < len(input); {
+		character, size := utf8.DecodeRuneInString(input[offset:])
+		switch {
+		case unicode.IsSpace(character):
+			offset += size
+		case unicode.IsDigit(character):
+			token, end, number_error := LexNumber(input, offset)
+			if number_error != nil {
+				return nil, number_error
+			}
+			tokens = append(tokens, token)
+			offset = end
+		case character == '"' || character == '\'':
+			token, end, text_error := LexText(input, offset)
+			if text_error != nil {
+				return nil, text_error
+			}
+			tokens = append(tokens, token)
+			offset = end
+		case strings.HasPrefix(input[offset:], SYMBOL_VARIABLE_SUBSTITUTION):
+			// The whole name needs to be a variable that has been set
+			reference, is_reference := references[offset]
+			name, exists := ResolveVariable(reference, variables)
+			if !is_reference || !exists ||
+				(!reference.Braced && name != reference.Name) {
+				return nil, &ExpressionError{
+					Message: "There isn't a variable here.",
+					Offset:  offset,
+				}
+			}
+			tokens = append(tokens, ExpressionToken{
+				Kind:   EXPRESSION_VARIABLE,
+				Text:   name,
+				Offset: offset,
+			})
+			offset = reference.End
+		case character == '_' || unicode.IsLetter(character):
+			name := input[offset : offset+NameLength(input[offset:])]
+			token := ExpressionToken{
+				Kind:   EXPRESSION_NAME,
+				Text:   name,
+				Offset: offset,
+			}
+			if name == "true" || name == "false" {
+				token.Kind = EXPRESSION_VALUE
+				token.Value = BooleanValue(name == "true")
+			}
+			tokens = append(tokens, token)
+			offset += len(name)
+		default:
+			operator_index := slices.IndexFunc(ExpressionOperators,
+				func(operator string) bool {
+					return strings.HasPrefix(input[offset:], operator)
+				})
+			if operator_index < 0 {
+				return nil, &ExpressionError{
+					Message: "The character " + string(character) +
+						" isn't part of an expression.",
+					Offset: offset,
+				}
+			}
+			operator := ExpressionOperators[operator_index]
+			tokens = append(tokens, ExpressionToken{
+				Kind:   EXPRESSION_OPERATOR,
+				Text:   operator,
+				Offset: offset,
+			})
+			offset += len(operator)
+		}
+	}
+	return append(tokens, ExpressionToken{
+		Kind:   EXPRESSION_END,
+		Offset: len(input),
+	}), nil
+}
+
+/*
+Read a number in an expression. Parameters include the expression and where
+the number starts. Returns the token, where the number ends, and an error if
+it isn't a number (eg. 007 or 3rd).
+*/
+func LexNumber(input string, start int) (ExpressionToken, int, error) {
+	end := start
+	is_float := false
+	for end < len(input) {
+		character, size := utf8.DecodeRuneInString(input[end:])
+		if character == '.' && !is_float {
+			is_float = true
+		} else if !unicode.IsDigit(character) {
+			break
+		}
+		end += size
+	}
+	number := input[start:end]
+	// Make sure that the number is followed by something other than a name
+	next, _ := utf8.DecodeRuneInString(input[end:])
+	not_number := &ExpressionError{
+		Message: number + " isn't a number.",
+		Offset:  start,
+	}
+	if end < len(input) && (IsNameCharacter(next) || next == '.') {
+		return ExpressionToken{}, end, not_number
+	}
+	// A number can only start with a 0 if it is 0 or a float (eg. 0.5)
+	if len(number) > 1 && number[0] == '0' && number[1] != '.' {
+		return ExpressionToken{}, end, not_number
+	}
+	token := ExpressionToken{
+		Kind:   EXPRESSION_VALUE,
+		Text:   number,
+		Offset: start,
+	}
+	// An integer that is too big to hold is held as a float
+	if integer, integer_error := strconv.ParseInt(
+		number, 10, 64); integer_error == nil {
+		token.Value = IntegerValue(integer)
+		return token, end, nil
+	}
+	float, float_error := strconv.ParseFloat(number, 64)
+	if float_error != nil || strings.HasSuffix(number, ".") {
+		return ExpressionToken{}, end, not_number
+	}
+	token.Value = FloatValue(float)
+	return token, end, nil
+}
+
+/*
+Read text in quotes in an expression. A backslash escapes the character after
+it (eg. 'It\'s'). Parameters include the expression and where the opening
+quote is. Returns the token, where the text ends, and an error if the text
+isn't closed.
+*/
+func LexText(input string, start int) (ExpressionToken, int, error) {
+	quote := input[start]
+	var text strings.Builder
+	for end := start + 1; end < len(input); end++ {
+		switch input[end] {
+		case quote:
+			return ExpressionToken{
+				Kind:   EXPRESSION_TEXT,
+				Text:   text.String(),
+				Offset: start,
+			}, end + 1, nil
+		case '\\':
+			if end+1 < len(input) {
+				end++
+			}
+		}
+		text.WriteByte(input[end])
+	}
+	return ExpressionToken{}, len(input), &ExpressionError{
+		Message: "The text isn't closed with a " + string(quote) + ".",
+		Offset:  start,
+	}
+}
+
+/*
+The ExpressionNodeKind type houses the kind of a part of an expression.
+*/
+type ExpressionNodeKind int
+
+// The kinds of parts of an expression
+const (
+	// A number or a boolean
+	EXPRESSION_NODE_VALUE ExpressionNodeKind = iota
+	// Text, which has its variables replaced when it is calculated
+	EXPRESSION_NODE_TEXT
+	// A variable
+	EXPRESSION_NODE_VARIABLE
+	// An operator with one operand (eg. -#count)
+	EXPRESSION_NODE_UNARY
+	// An operator with two operands (eg. #count + 1)
+	EXPRESSION_NODE_BINARY
+	// A call to a function (eg. round(#half))
+	EXPRESSION_NODE_CALL
+)
+
+/*
+The ExpressionNode type houses a part of a parsed expression. The structure
+of the node is as follows:
+  - Kind [ExpressionNodeKind]: the kind of part
+  - Text [string]: the text, the name of the variable, the operator, or the
+    name of the function
+  - Value [Value]: the value of a number or a boolean
+  - Operands [[]*ExpressionNode]: the operands of an operator or the
+    arguments of a function
+  - Offset [int]: where the part starts in the expression, in bytes
+*/
+type ExpressionNode struct {
+	Kind     ExpressionNodeKind
+	Text     string
+	Value    Value
+	Operands []*ExpressionNode
+	Offset   int
+}
+
+/*
+The ExpressionParser type houses the state of an expression that is being
+parsed. The structure of the parser is as follows:
+  - tokens [[]ExpressionToken]: the tokens of the expression
+  - index [int]: the index of the next token
+*/
+type ExpressionParser struct {
+	tokens []ExpressionToken
+	index  int
+}
+
+/*
+The operators of each level of binding that sits between two operands, from
+the loosest to the tightest.
+*/
+var ExpressionBinaryOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+/*
+Parse an expression. Parameters include the expression and the variables that
+it can use. Returns the parsed expression and an error if it isn't an
+expression.
+*/
+func ParseExpression(
+	input string,
+	variables map[string]Value,
+) (*ExpressionNode, error) {
+	tokens, lex_error := LexExpression(input, variables)
+	if lex_error != nil {
+		return nil, lex_error
+	}
+	parser := &ExpressionParser{tokens: tokens}
+	node, parse_error := parser.ParseBinary(0)
+	if parse_error != nil {
+		return nil, parse_error
+	}
+	// Anything left over means that this isn't an expression
+	if token := parser.Peek(); token.Kind != EXPRESSION_END {
+		return nil, &ExpressionError{
+			Message: "There's more after the end of the expression.",
+			Offset:  token.Offset,
+		}
+	}
+	return node, nil
+}
+
+/*
+Check whether a value that isn't an expression was still meant to be one,
+which is when it is made up of values, variables, calls to functions, and
+operators, with an operator between any two values, but can't be parsed (eg.
+(1 + 2, #count *, 2 ** 3, or 1 +* 2). Anything else that isn't an expression
+(eg. See (1) or +44 20 7946) is text. Parameters include the value and the
+variables that it can use. Returns true if the value was meant to be an
+expression.
+*/
+func IsMalformedExpression(input string, variables map[string]Value) bool {
+	tokens, lex_error := LexExpression(input, variables)
+	if lex_error != nil || len(tokens) < 2 {
+		return false
+	}
+	has_operand := false
+	has_operator := false
+	// Whether the last token ended a value (eg. a number or a bracket)
+	after_value := false
+	for index, token := range tokens {
+		switch token.Kind {
+		case EXPRESSION_NAME:
+			// A name is only part of an expression when a function is called
+			next := tokens[index+1]
+			_, is_function := ExpressionFunctions()[token.Text]
+			if !is_function || after_value ||
+				next.Kind != EXPRESSION_OPERATOR || next.Text != "(" {
+				return false
+			}
+		case EXPRESSION_VALUE, EXPRESSION_TEXT, EXPRESSION_VARIABLE:
+			// Two values next to each other are words rather than maths
+			if after_value {
+				return false
+			}
+			has_operand = true
+			after_value = true
+		case EXPRESSION_OPERATOR:
+			if token.Text == "(" && after_value {
+				return false
+			}
+			has_operator = true
+			after_value = token.Text == ")"
+		}
+	}
+	return has_operand && has_operator
+}
+
+// Get the next token without moving on. No parameters. Returns the token.
+func (parser *ExpressionParser) Peek() ExpressionToken {
+	return parser.tokens[parser.index]
+}
+
+// Get the next token and move on. No parameters. Returns the token.
+func (parser *ExpressionParser) Next() ExpressionToken {
+	token := parser.tokens[parser.index]
+	if token.Kind != EXPRESSION_END {
+		parser.index++
+	}
+	return token
+}
+
+/*
+Check whether the next token is one of a set of operators. Parameters include
+the operators. Returns true if it is.
+*/
+func (parser *ExpressionParser) IsOperator(operators ...string) bool {
+	token := parser.Peek()
+	return token.Kind == EXPRESSION_OPERATOR &&
+		slices.Contains(operators, token.Text)
+}
+
+/*
+Parse operators that sit between two operands, starting at a level of
+binding and moving to the tighter levels for the operands. Parameters include
+the level (see ExpressionBinaryOperators). Returns the parsed part and an
+error if it isn't an expression.
+*/
+func (parser *ExpressionParser) ParseBinary(level int) (*ExpressionNode, error) {
+	if level == len(ExpressionBinaryOperators) {
+		return parser.ParseUnary()
+	}
+	left, left_error := parser.ParseBinary(level + 1)
+	if left_error != nil {
+		return nil, left_error
+	}
+	for parser.IsOperator(ExpressionBinaryOperators[level]...) {
+		operator := parser.Next()
+		right, right_error := parser.ParseBinary(level + 1)
+		if right_error != nil {
+			return nil, right_error
+		}
+		left = &ExpressionNode{
+			Kind:     EXPRESSION_NODE_BINARY,
+			Text:     operator.Text,
+			Operands: []*ExpressionNode{left, right},
+			Offset:   operator.Offset,
+		}
+	}
+	return left, nil
+}
+
+/*
+Parse an operand that might have a - or a ! in front of it. No parameters.
+Returns the parsed part and an error if it isn't an expression.
+*/
+func (parser *ExpressionParser) ParseUnary() (*ExpressionNode, error) {
+	if !parser.IsOperator("-", "!") {
+		return parser.ParseOperand()
+	}
+	operator := parser.Next()
+	operand, operand_error := parser.ParseUnary()
+	if operand_error != nil {
+		return nil, operand_error
+	}
+	return &ExpressionNode{
+		Kind:     EXPRESSION_NODE_UNARY,
+		Text:     operator.Text,
+		Operands: []*ExpressionNode{operand},
+		Offset:   operator.Offset,
+	}, nil
+}
+
+/*
+Parse an operand, that is, a value, a variable, a call to a function, or an
+expression in brackets. No parameters. Returns the parsed part and an error if
+it isn't an expression.
+*/
+func (parser *ExpressionParser) ParseOperand() (*ExpressionNode, error) {
+	token := parser.Next()
+	switch token.Kind {
+	case EXPRESSION_VALUE:
+		return &ExpressionNode{
+			Kind:   EXPRESSION_NODE_VALUE,
+			Value:  token.Value,
+			Offset: token.Offset,
+		}, nil
+	case EXPRESSION_TEXT:
+		return &ExpressionNode{
+			Kind:   EXPRESSION_NODE_TEXT,
+			Text:   token.Text,
+			Offset: token.Offset,
+		}, nil
+	case EXPRESSION_VARIABLE:
+		return &ExpressionNode{
+			Kind:   EXPRESSION_NODE_VARIABLE,
+			Text:   token.Text,
+			Offset: token.Offset,
+		}, nil
+	case EXPRESSION_NAME:
+		// A name is only a function where the bracket comes right after it
+		open := parser.Peek()
+		if open.Kind != EXPRESSION_OPERATOR || open.Text != "(" ||
+			open.Offset != token.Offset+len(token.Text) {
+			break
+		}
+		parser.Next()
+		call := &ExpressionNode{
+			Kind:   EXPRESSION_NODE_CALL,
+			Text:   token.Text,
+			Offset: token.Offset,
+		}
+		if parser.IsOperator(")") {
+			parser.Next()
+			return call, nil
+		}
+		for {
+			argument, argument_error := parser.ParseBinary(0)
+			if argument_error != nil {
+				return nil, argument_error
+			}
+			call.Operands = append(call.Operands, argument)
+			if !parser.IsOperator(",") {
+				break
+			}
+			parser.Next()
+		}
+		if !parser.IsOperator(")") {
+			return nil, &ExpressionError{
+				Message: "The call to " + token.Text + " isn't closed.",
+				Offset:  parser.Peek().Offset,
+			}
+		}
+		parser.Next()
+		return call, nil
+	case EXPRESSION_OPERATOR:
+		if token.Text != "(" {
+			break
+		}
+		node, node_error := parser.ParseBinary(0)
+		if node_error != nil {
+			return nil, node_error
+		}
+		if !parser.IsOperator(")") {
+			return nil, &ExpressionError{
+				Message: "The bracket isn't closed.",
+				Offset:  token.Offset,
+			}
+		}
+		parser.Next()
+		return node, nil
+	}
+	return nil, &ExpressionError{
+		Message: "A value was expected here.",
+		Offset:  token.Offset,
+	}
+}
+
+/*
+Calculate a parsed expression. Parameters include the variables that it
+uses. Returns the value and an ExpressionError if it can't be calculated (eg.
+it divides by zero).
+*/
+func (node *ExpressionNode) Evaluate(
+	variables map[string]Value) (Value, error) {
+	switch node.Kind {
+	case EXPRESSION_NODE_VALUE:
+		return node.Value, nil
+	case EXPRESSION_NODE_TEXT:
+		text, _ := InterpolateVariables(node.Text, variables)
+		return StringValue(text), nil
+	case EXPRESSION_NODE_VARIABLE:
+		value, exists := variables[node.Text]
+		if !exists {
+			return Value{}, &ExpressionError{
+				Message: "The variable " + node.Text + " hasn't been set.",
+				Offset:  node.Offset,
+			}
+		}
+		return value, nil
+	case EXPRESSION_NODE_CALL:
+		return node.EvaluateCall(variables)
+	}
+
+	// Get the first operand
+	left, left_error := node.Operands[0].Evaluate(variables)
+	if left_error != nil {
+		return Value{}, left_error
+	}
+	var result Value
+	var result_error error
+	switch {
+	case node.Kind == EXPRESSION_NODE_UNARY:
+		result, result_error = ApplyUnaryOperator(node.Text, left)
+	// && and || only calculate the second operand if they need to
+	case node.Text == "&&" || node.Text == "||":
+		if left.Type != VALUE_BOOLEAN {
+			result_error = BooleanOperandError(node.Text, left)
+			break
+		}
+		if left.Boolean == (node.Text == "||") {
+			return left, nil
+		}
+		right, right_error := node.Operands[1].Evaluate(variables)
+		if right_error != nil {
+			return Value{}, right_error
+		}
+		if right.Type != VALUE_BOOLEAN {
+			result_error = BooleanOperandError(node.Text, right)
+			break
+		}
+		result = right
+	default:
+		right, right_error := node.Operands[1].Evaluate(variables)
+		if right_error != nil {
+			return Value{}, right_error
+		}
+		result, result_error = ApplyBinaryOperator(node.Text, left, right)
+	}
+	return result, AtOffset(result_error, node.Offset)
+}
+
+/*
+Calculate a call to a function. Parameters include the variables that the
+arguments use. Returns the value and an ExpressionError if the function
+doesn't exist or can't be called with the arguments.
+*/
+func (node *ExpressionNode) EvaluateCall(
+	variables map[string]Value) (Value, error) {
+	function, exists := ExpressionFunctions()[node.Text]
+	if !exists {
+		return Value{}, &ExpressionError{
+			Message: "There is no function called " + node.Text + ".",
+			Offset:  node.Offset,
+			Hint:    "The functions are:" + ListExpressionFunctions(),
+		}
+	}
+	if len(node.Operands) < function.MinArguments ||
+		len(node.Operands) > function.MaxArguments {
+		return Value{}, &ExpressionError{
+			Message: fmt.Sprintf("The function %s takes %s but was given %d.",
+				node.Text,
+				function.ArgumentCount(),
+				len(node.Operands)),
+			Offset: node.Offset,
+			Hint:   "Use it as " + function.Usage + ".",
+		}
+	}
+	var arguments []Value
+	for _, operand := range node.Operands {
+		argument, argument_error := operand.Evaluate(variables)
+		if argument_error != nil {
+			return Value{}, argument_error
+		}
+		arguments = append(arguments, argument)
+	}
+	result, call_error := function.Call(arguments)
+	if call_error != nil {
+		return Value{}, &ExpressionError{
+			Message: "The function " + node.Text + " " +
+				call_error.Error() + ".",
+			Offset: node.Offset,
+			Hint:   "Use it as " + function.Usage + ".",
+		}
+	}
+	return result, nil
+}
+
+/*
+Place an error from an operator at the operator. Parameters include the error
+and the offset of the operator. Returns the ExpressionError or nil if there
+was no error.
+*/
+func AtOffset(err error, offset int) error {
+	expression_error, is_expression_error := err.(*ExpressionError)
+	if !is_expression_error {
+		return err
+	}
+	expression_error.Offset = offset
+	return expression_error
+}
+
+/*
+Create the error for an operator that can't be used with the types of its
+operands. Parameters include the operator and the operands. Returns the
+error.
+*/
+func OperandTypeError(
+	operator string, left Value, right Value) *ExpressionError {
+	problem := &ExpressionError{
+		Message: "The operator " + operator + " can't be used with " +
+			"a " + left.Type.String() + " and a " + right.Type.String() + ".",
+	}
+	if left.Type == right.Type {
+		problem.Message = "The operator " + operator + " can't be used " +
+			"with two of the type " + left.Type.String() + "."
+	}
+	// Text that holds a number is most likely the answer to an ask statement
+	_, left_number := left.Number()
+	_, right_number := right.Number()
+	if (left.Type == VALUE_STRING && left_number) ||
+		(right.Type == VALUE_STRING && right_number) {
+		problem.Hint = "Text that holds a number needs to be converted " +
+			"before it is used as a number (eg. convert count to integer)."
+	}
+	return problem
+}
+
+/*
+Create the error for && or || where an operand isn't a boolean. Parameters
+include the operator and the operand. Returns the error.
+*/
+func BooleanOperandError(operator string, operand Value) *ExpressionError {
+	return &ExpressionError{
+		Message: "The operator " + operator + " can only be used with " +
+			"booleans but was given a " + operand.Type.String() + ".",
+	}
+}
+
+/*
+Apply an operator with one operand. Parameters include the operator and the
+operand. Returns the result and an error if the operator can't be used with
+the operand.
+*/
+func ApplyUnaryOperator(operator string, operand Value) (Value, error) {
+	switch {
+	case operator == "-" && operand.Type == VALUE_INTEGER:
+		if operand.Integer == math.MinInt64 {
+			return Value{}, &ExpressionError{
+				Message: "The result is too large to hold as an integer.",
+			}
+		}
+		return IntegerValue(-operand.Integer), nil
+	case operator == "-" && operand.Type == VALUE_FLOAT:
+		return FloatValue(-operand.Float), nil
+	case operator == "!" && operand.Type == VALUE_BOOLEAN:
+		return BooleanValue(!operand.Boolean), nil
+	}
+	return Value{}, &ExpressionError{
+		Message: "The operator " + operator + " can't be used with a " +
+			operand.Type.String() + ".",
+	}
+}
+
+/*
+Apply an operator with two operands. Parameters include the operator and the
+operands. Returns the result and an error if the operator can't be used with
+the operands or the result can't be held (eg. dividing by zero).
+*/
+func ApplyBinaryOperator(
+	operator string, left Value, right Value) (Value, error) {
+	is_number := func(value Value) bool {
+		return value.Type == VALUE_INTEGER || value.Type == VALUE_FLOAT
+	}
+	both_integers := left.Type == VALUE_INTEGER && right.Type == VALUE_INTEGER
+	both_numbers := is_number(left) && is_number(right)
+	left_number, _ := left.Number()
+	right_number, _ := right.Number()
+
+	switch operator {
+	case "==":
+		return BooleanValue(left.Equals(right)), nil
+	case "!=":
+		return BooleanValue(!left.Equals(right)), nil
+	case "<", "<=", ">", ">=":
+		var comparison int
+		switch {
+		case both_numbers:
+			comparison = compareNumbers(left_number, right_number)
+		case left.Type == VALUE_STRING && right.Type == VALUE_STRING:
+			comparison = strings.Compare(left.Text, right.Text)
+		default:
+			return Value{}, OperandTypeError(operator, left, right)
+		}
+		return BooleanValue(map[string]bool{
+			"<":  comparison < 0,
+			"<=": comparison <= 0,
+			">":  comparison > 0,
+			">=": comparison >= 0,
+		}[operator]), nil
+	case "+":
+		switch {
+		case left.Type == VALUE_LIST && right.Type == VALUE_LIST:
+			return ListValue(slices.Concat(left.Items, right.Items)), nil
+		case left.Type == VALUE_LIST || right.Type == VALUE_LIST:
+			return Value{}, OperandTypeError(operator, left, right)
+		case left.Type == VALUE_STRING || right.Type == VALUE_STRING:
+			return StringValue(left.String() + right.String()), nil
+		}
+	}
+
+	// Anything else is maths so both operands need to be numbers
+	if !both_numbers {
+		return Value{}, OperandTypeError(operator, left, right)
+	}
+	too_large := &ExpressionError{
+		Message: "The result is too large to hold as a number.",
+	}
+	divide_by_zero := &ExpressionError{Message: "You can't divide by zero."}
+	if both_integers {
+		a, b := left.Integer, right.Integer
+		switch operator {
+		case "+":
+			if (b > 0 && a > math.MaxInt64-b) ||
+				(b < 0 && a < math.MinInt64-b) {
+				return Value{}, too_large
+			}
+			return IntegerValue(a + b), nil
+		case "-":
+			if (b < 0 && a > math.MaxInt64+b) ||
+				(b > 0 && a < math.MinInt64+b) {
+				return Value{}, too_large
+			}
+			return IntegerValue(a - b), nil
+		case "*":
+			product := a * b
+			if a != 0 && (product/a != b ||
+				(a == -1 && b == math.MinInt64)) {
+				return Value{}, too_large
+			}
+			return IntegerValue(product), nil
+		case "/", "%":
+			if b == 0 {
+				return Value{}, divide_by_zero
+			}
+			if operator == "%" {
+				return IntegerValue(a % b), nil
+			}
+			// Only keep an integer where it divides evenly
+			if a%b == 0 && !(a == math.MinInt64 && b == -1) {
+				return IntegerValue(a / b), nil
+			}
+		}
+	}
+
+	var result float64
+	switch operator {
+	case "+":
+		result = left_number + right_number
+	case "-":
+		result = left_number - right_number
+	case "*":
+		result = left_number * right_number
+	case "/":
+		if right_number == 0 {
+			return Value{}, divide_by_zero
+		}
+		result = left_number / right_number
+	case "%":
+		return Value{}, &ExpressionError{
+			Message: "The operator % can only be used with two integers.",
+		}
+	}
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return Value{}, too_large
+	}
+	return FloatValue(result), nil
+}
+
+// Compare two numbers. Returns -1, 0, or 1 as a is less, the same, or more.
+func compareNumbers(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+/*
+The ExpressionFunction type houses a function that can be called in an
+expression. The structure of the function is as follows:
+  - Usage [string]: how the function is written (eg. round(number))
+  - Description [string]: what the function does
+  - MinArguments [int]: the fewest arguments that it can be given
+  - MaxArguments [int]: the most arguments that it can be given
+  - Call [func([]Value) (Value, error)]: the function itself, which is only
+    called with the right number of arguments
+*/
+type ExpressionFunction struct {
+	Usage        string
+	Description  string
+	MinArguments int
+	MaxArguments int
+	Call         func(arguments []Value) (Value, error)
+}
+
+/*
+Describe how many arguments a function takes (eg. 1 or 2 arguments). No
+parameters. Returns the description.
+*/
+func (function ExpressionFunction) ArgumentCount() string {
+	count := strconv.Itoa(function.MinArguments)
+	if function.MaxArguments != function.MinArguments {
+		count += " or " + strconv.Itoa(function.MaxArguments)
+	}
+	if function.MaxArguments == 1 {
+		return count + " argument"
+	}
+	return count + " arguments"
+}
+
+/*
+Get the functions that can be called in an expression. No parameters. Returns
+the functions by their names.
+*/
+func ExpressionFunctions() map[string]ExpressionFunction {
+	return map[string]ExpressionFunction{
+		"length": {
+			Usage:        "length(text or list)",
+			Description:  "the number of characters in text or items in a list",
+			MinArguments: 1,
+			MaxArguments: 1,
+			Call: func(arguments []Value) (Value, error) {
+				switch arguments[0].Type {
+				case VALUE_STRING:
+					return IntegerValue(int64(
+						utf8.RuneCountInString(arguments[0].Text))), nil
+				case VALUE_LIST:
+					return IntegerValue(int64(len(arguments[0].Items))), nil
+				}
+				return Value{}, fmt.Errorf("needs text or a list but was "+
+					"given a %s", arguments[0].Type)
+			},
+		},
+		"lower": {
+			Usage:        "lower(text)",
+			Description:  "the text in lower case",
+			MinArguments: 1,
+			MaxArguments: 1,
+			Call: func(arguments []Value) (Value, error) {
+				if arguments[0].Type != VALUE_STRING {
+					return Value{}, fmt.Errorf("needs text but was given "+
+						"a %s", arguments[0].Type)
+				}
+				return StringValue(strings.ToLower(arguments[0].Text)), nil
+			},
+		},
+		"round": {
+			Usage: "round(number) or round(number, places)",
+			Description: "the number rounded to a whole number or to a " +
+				"number of decimal places",
+			MinArguments: 1,
+			MaxArguments: 2,
+			Call: func(arguments []Value) (Value, error) {
+				if arguments[0].Type == VALUE_INTEGER {
+					return arguments[0], nil
+				}
+				if arguments[0].Type != VALUE_FLOAT {
+					return Value{}, fmt.Errorf("needs a number but was "+
+						"given a %s", arguments[0].Type)
+				}
+				if len(arguments) == 1 {
+					rounded := math.Round(arguments[0].Float)
+					if math.Abs(rounded) >= math.MaxInt64 {
+						return Value{}, errors.New("was given a number that is " +
+							"too large to round to an integer")
+					}
+					return IntegerValue(int64(rounded)), nil
+				}
+				places := arguments[1]
+				if places.Type != VALUE_INTEGER || places.Integer < 0 {
+					return Value{}, fmt.Errorf("needs the number of places to "+
+						"be an integer that is 0 or more but was given %s",
+						places.Literal())
+				}
+				scale := math.Pow(10, float64(places.Integer))
+				return FloatValue(
+					math.Round(arguments[0].Float*scale) / scale), nil
+			},
+		},
+		"upper": {
+			Usage:        "upper(text)",
+			Description:  "the text in upper case",
+			MinArguments: 1,
+			MaxArguments: 1,
+			Call: func(arguments []Value) (Value, error) {
+				if arguments[0].Type != VALUE_STRING {
+					return Value{}, fmt.Errorf("needs text but was given "+
+						"a %s", arguments[0].Type)
+				}
+				return StringValue(strings.ToUpper(arguments[0].Text)), nil
+			},
+		},
+	}
+}
+
+/*
+Create a string list of the functions that can be easily printed if need be.
+No parameters. Returns a string representation of the list of functions.
+*/
+func ListExpressionFunctions() string {
+	var function_list string
+	functions := ExpressionFunctions()
+	for _, name := range slices.Sorted(maps.Keys(functions)) {
+		function_list += "\n\t- " + functions[name].Usage + ": " +
+			functions[name].Description
+	}
+	return function_list
+}
+
+/*
+Report an expression that can't be calculated. The error points at the part
+of the expression that the problem is with where it can be found in the
+token. Parameters include the tokens of the statement, the token that the
+expression is in, the expression, and the problem. Returns the error.
+*/
+func ReportExpressionError(
+	tokens []Token,
+	token Token,
+	expression string,
+	err error,
+) *ScriptError {
+	problem := &ExpressionError{Message: err.Error()}
+	expression_error, is_expression_error := err.(*ExpressionError)
+	if is_expression_error && expression_error.Offset <= len(expression) {
+		problem = expression_error
+	}
+	// Point at the part of the expression, otherwise at the token
+	column, _ := strconv.Atoi(token.TokenPosition)
+	if offset := strings.Index(
+		token.TokenValue, expression); offset >= 0 && column > 0 {
+		column += utf8.RuneCountInString(
+			token.TokenValue[:offset] + expression[:problem.Offset])
+	}
+
+	script_error := Report(
+		"The expression "+utils.ColouriseGreen(expression)+" can't be "+
+			"calculated. "+problem.Message,
+		strconv.Itoa(tokens[0].LineNumber),
+		strconv.Itoa(column),
+		tokens[0].FullLineOfCode,
+	).WithCategory(ERROR_RUNTIME).WithRule("runtime/expression").WithErr(err)
+	if problem.Hint != "" {
+		script_error.WithHint(problem.Hint)
+	}
+	return script_error
+}
@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that expressions are calculated with the right precedence
+and types and that anything that isn't an expression is left as text.
+*/
+func TestCalculateExpression(t *testing.T) {
+	variables := map[string]Value{
+		"count": IntegerValue(4),
+		"half":  FloatValue(0.5),
+		"name":  StringValue("Ada"),
+		"files": ListValue([]Value{StringValue("a.txt")}),
+	}
+	// The values and what they should calculate to
+	cases := map[string]string{
+		"1 + 2 * 3":                       "7",
+		"(1 + 2) * 3":                     "9",
+		"7 / 2":                           "3.5",
+		"6 / 3":                           "2",
+		"7 % 3":                           "1",
+		"-#count + 1":                     "-3",
+		"#count * #half":                  "2",
+		"#{count} >= 4 && !false":         "true",
+		"1 < 2 == true":                   "true",
+		"'Hello ' + #name":                "\"Hello Ada\"",
+		"'Count: ' + #count":              "\"Count: 4\"",
+		"\"#name has \" + length(#files)": "\"Ada has 1\"",
+		"upper(#name) + lower('X')":       "\"ADAx\"",
+		"length('héllo')":                 "5",
+		"round(2.5)":                      "3",
+		"round(3.14159, 2)":               "3.14",
+		"#files + ['b.txt']":              "\"#files + ['b.txt']\"",
+		"Hello World!":                    "\"Hello World!\"",
+		"#b_home/Downloads":               "\"#b_home/Downloads\"",
+		"#names":                          "\"#names\"",
+		"007":                             "\"007\"",
+		"3rd":                             "\"3rd\"",
+		"#count #half":                    "\"#count #half\"",
+		"(1)(2)":                          "\"(1)(2)\"",
+		"See (1)":                         "\"See (1)\"",
+		"+44 20 7946":                     "\"+44 20 7946\"",
+		"/":                               "\"/\"",
+	}
+	for input, expected := range cases {
+		calculated, calculate_error := CalculateValue(input, variables)
+		if calculate_error != nil || calculated.Literal() != expected {
+			t.Errorf("[CalculateValue] %q returned %s (%v), expected %s",
+				input,
+				calculated.Literal(),
+				calculate_error,
+				expected)
+		}
+	}
+}
+
+/*
+Check to make sure that an expression that can't be calculated is an error
+that points at the part of the expression that the problem is with.
+*/
+func TestExpressionErrors(t *testing.T) {
+	variables := map[string]Value{
+		"answer": StringValue("42"),
+	}
+	// The expressions and where their problems are
+	cases := map[string]int{
+		"1 / 0":                   2,
+		"10 % 2.5":                3,
+		"#answer - 1":             8,
+		"'a' * 2":                 4,
+		"1 && true":               2,
+		"lenght(1)":               0,
+		"round(1, 2, 3)":          0,
+		"upper(1)":                0,
+		"9223372036854775807 + 1": 20,
+		"(1 + 2":                  0,
+		"#answer *":               9,
+		"upper('a'":               9,
+		"2 ** 3":                  3,
+		"1 +* 2":                  3,
+		"1 << 2":                  3,
+		"#answer == == 1":         11,
+	}
+	for input, offset := range cases {
+		_, calculate_error := CalculateValue(input, variables)
+		var expression_error *ExpressionError
+		if !errors.As(calculate_error, &expression_error) ||
+			expression_error.Offset != offset {
+			t.Errorf("[CalculateValue] %q returned %v, expected an error "+
+				"at %d",
+				input,
+				calculate_error,
+				offset)
+		}
+	}
+
+	// A number held as text should hint at converting it
+	_, calculate_error := CalculateValue("#answer - 1", variables)
+	var expression_error *ExpressionError
+	if errors.As(calculate_error, &expression_error) &&
+		!strings.Contains(expression_error.Hint, "convert") {
+		t.Errorf("[CalculateValue] Expected a hint to convert, got %q",
+			expression_error.Hint)
+	}
+}
+
+/*
+Check to make sure that the answer to an ask statement is only calculated
+when the statement asks for it and that an expression in a set statement that
+can't be calculated stops the script.
+*/
+func TestAskAndSetExpressions(t *testing.T) {
+	script := "ask \"Sum: \" to \"text\"\n" +
+		"ask \"Sum: \" to \"sum\" as expression\n" +
+		"ask \"Age: \" to \"age\" as integer\n" +
+		"writeln \"#text #sum #age\""
+	var output bytes.Buffer
+	interpreter := New(Options{
+		Stdout: &output,
+		Stdin:  strings.NewReader("1 + 2\n1 + 2\n42\n"),
+	})
+	if run_error := interpreter.RunString(script); run_error != nil {
+		t.Fatalf("[Ask] Expected no error, got %v", run_error)
+	}
+	if !strings.HasSuffix(output.String(), "1 + 2 3 42\n") ||
+		interpreter.Variables["age"].Type != VALUE_INTEGER {
+		t.Errorf("[Ask] Expected %q, got %q",
+			"1 + 2 3 42\n",
+			output.String())
+	}
+
+	// Dividing by zero stops the script and points at the operator
+	interpreter = New(Options{Stdout: &bytes.Buffer{}})
+	run_error := interpreter.RunString("set count = 1\nset x = \"#count / 0\"")
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) {
+		t.Fatalf("[Set] Expected a ScriptError, got %v", run_error)
+	}
+	if script_error.Rule != "runtime/expression" ||
+		script_error.Column != 17 {
+		t.Errorf("[Set] Expected an expression error at column 17, got %s "+
+			"at column %d",
+			script_error.Rule,
+			script_error.Column)
+	}
+
+	/*
+		So does an expression that isn't finished or is otherwise malformed
+		rather than being text
+	*/
+	for _, value := range []string{"(1 + 2", "2 ** 3", "#count +* 2"} {
+		interpreter = New(Options{Stdout: &bytes.Buffer{}})
+		run_error = interpreter.RunString(
+			"set count = 1\nset y = \"" + value + "\"")
+		if !errors.As(run_error, &script_error) ||
+			script_error.Rule != "runtime/expression" {
+			t.Errorf("[Set] Expected an expression error for %q, got %v",
+				value,
+				run_error)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+/*
+This houses how the owner of a file is checked on systems where files have
+owners (eg. Linux and macOS).
+*/
+package parser
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+/*
+Check whether a file belongs to the user that the interpreter is running as.
+Parameters include the information about the file (eg. from os.Lstat()).
+Returns true if it does.
+*/
+func OwnedByUser(info fs.FileInfo) bool {
+	stat, has_stat := info.Sys().(*syscall.Stat_t)
+	return has_stat && int(stat.Uid) == os.Getuid()
+}
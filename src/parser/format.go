@@ -0,0 +1,244 @@
+/*
+The formatter rewrites a script in its canonical form so that scripts look the
+same no matter who wrote them. The canonical form is as follows:
+  - Any shebang line is the first line and any minver statement follows it,
+    with a blank line before the rest of the script
+  - Tokens are seperated by a single space, unless they were written with
+    nothing between them (eg. 1+2), and there is no trailing whitespace
+  - Strings are double quoted
+  - Comments start with the comment symbol and a space
+  - Statements inside of a define block are indented by four spaces
+  - There is never more than one blank line in a row and the script ends with
+    a single new line
+
+Lines that can't be tokenised are left as they are, bar their indentation and
+trailing whitespace, so that formatting never changes what a script does.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The indentation for each define block that a statement is inside of
+const FORMAT_INDENT string = "    "
+
+/*
+Format a script. Parameters include the contents of the script. Returns the
+script in its canonical form.
+*/
+func Format(contents string) string {
+	// Hold the shebang and minver lines, which go at the top
+	shebang_line := ""
+	var minver_lines []string
+	// Hold the formatted lines that make up the rest of the script
+	var body_lines []string
+	// Hold how many define blocks deep the current line is
+	depth := 0
+
+	lines := strings.Split(strings.ReplaceAll(contents, "\r\n", "\n"), "\n")
+	for _, line := range lines {
+		trimmed_line := strings.TrimSpace(line)
+		switch {
+		// Keep blank lines as they are collapsed later
+		case trimmed_line == "":
+			body_lines = append(body_lines, "")
+		// Hold onto the first shebang line so it can go at the top
+		case strings.HasPrefix(trimmed_line, "#!") && shebang_line == "":
+			shebang_line = trimmed_line
+		// Format a comment, indenting it with the block that it is in
+		case strings.HasPrefix(trimmed_line, SYMBOL_COMMENT):
+			body_lines = append(body_lines,
+				strings.Repeat(FORMAT_INDENT, depth)+
+					FormatComment(trimmed_line))
+		default:
+			formatted_line, statement_name, tokens := FormatStatement(
+				trimmed_line)
+			// The end statement is indented with the define that it ends
+			if statement_name == "end" && depth > 0 {
+				depth -= 1
+			}
+			formatted_line = strings.Repeat(FORMAT_INDENT, depth) +
+				formatted_line
+			// Hold onto minver statements so they can go at the top
+			if statement_name == "minver" {
+				minver_lines = append(minver_lines, formatted_line)
+				/*
+					Hold a placeholder in case there is more than one minver
+					statement, in which case they all stay where they are.
+				*/
+				body_lines = append(body_lines, formatted_line)
+				continue
+			}
+			// A define statement without a from starts a block
+			if statement_name == "define" && len(tokens) == 3 {
+				depth += 1
+			}
+			body_lines = append(body_lines, formatted_line)
+		}
+	}
+
+	// Put the shebang and a single minver statement at the top
+	var header_lines []string
+	if shebang_line != "" {
+		header_lines = append(header_lines, shebang_line)
+	}
+	if len(minver_lines) == 1 {
+		header_lines = append(header_lines, strings.TrimSpace(minver_lines[0]))
+		// Take the minver statement out of the rest of the script
+		for index, line := range body_lines {
+			if line == minver_lines[0] {
+				body_lines = append(body_lines[:index], body_lines[index+1:]...)
+				break
+			}
+		}
+	}
+
+	// Collapse blank lines, dropping any at the start and end
+	var formatted_lines []string
+	for _, line := range body_lines {
+		if line == "" && (len(formatted_lines) == 0 ||
+			formatted_lines[len(formatted_lines)-1] == "") {
+			continue
+		}
+		formatted_lines = append(formatted_lines, line)
+	}
+	if len(formatted_lines) > 0 &&
+		formatted_lines[len(formatted_lines)-1] == "" {
+		formatted_lines = formatted_lines[:len(formatted_lines)-1]
+	}
+
+	// Seperate the header from the rest of the script with a blank line
+	if len(header_lines) > 0 && len(formatted_lines) > 0 {
+		header_lines = append(header_lines, "")
+	}
+	formatted_lines = append(header_lines, formatted_lines...)
+	if len(formatted_lines) == 0 {
+		return ""
+	}
+	return strings.Join(formatted_lines, "\n") + "\n"
+}
+
+/*
+Format a comment so that the comment symbol is followed by a space. Comments
+that are made up of comment symbols (eg. a line of dashes) or that already
+start with whitespace are left as they are. Parameters include the comment,
+without any indentation. Returns the formatted comment.
+*/
+func FormatComment(comment string) string {
+	text := strings.TrimPrefix(comment, SYMBOL_COMMENT)
+	first_character, _ := utf8.DecodeRuneInString(text)
+	if text != "" && !unicode.IsSpace(first_character) &&
+		!strings.HasPrefix(text, SYMBOL_COMMENT) {
+		return SYMBOL_COMMENT + " " + text
+	}
+	return comment
+}
+
+/*
+Format a statement. The tokens are joined by a single space where there was
+whitespace between them and anything between them that isn't a token (eg. a
+Go style comment that the tokeniser skips over) is kept. Parameters include
+the line, without any indentation. Returns the formatted line, the name of the
+statement (empty if the line couldn't be tokenised), and the tokens.
+*/
+func FormatStatement(line string) (string, string, []Token) {
+	tokens, tokenise_error := Tokenise(line, 1, 1)
+	// If the line can't be tokenised, leave it as it is
+	if tokenise_error != nil || len(tokens) < 2 {
+		return line, "", nil
+	}
+	// Work in characters as that is how the tokeniser counts positions
+	characters := []rune(line)
+
+	var formatted_line strings.Builder
+	// Hold where the last token ended
+	last_end := 0
+	for _, token := range tokens[1:] {
+		start, _ := strconv.Atoi(token.TokenPosition)
+		start -= 1
+		// Add whatever is between this token and the last
+		formatted_line.WriteString(
+			FormatGap(string(characters[last_end:start]), last_end > 0))
+		formatted_line.WriteString(FormatString(token.TokenValue))
+		last_end = start + utf8.RuneCountInString(token.TokenValue)
+	}
+	// Keep anything after the last token that isn't whitespace
+	formatted_line.WriteString(strings.TrimRightFunc(
+		FormatGap(string(characters[last_end:]), true), unicode.IsSpace))
+	return formatted_line.String(), tokens[1].TokenValue, tokens
+}
+
+/*
+Format what is between two tokens. Parameters include the text between them
+and whether there is a token before it (ie. it isn't the start of the line).
+Returns a single space for whitespace and anything else trimmed with a space
+either side of it where there was whitespace.
+*/
+func FormatGap(gap string, after_token bool) string {
+	trimmed_gap := strings.TrimSpace(gap)
+	// The start of the line is never indented here
+	if !after_token {
+		if trimmed_gap == "" {
+			return ""
+		}
+		return trimmed_gap + " "
+	}
+	if trimmed_gap == "" {
+		if gap == "" {
+			return ""
+		}
+		return " "
+	}
+	// Keep the space either side of what is between the tokens
+	formatted_gap := trimmed_gap
+	if strings.TrimLeftFunc(gap, unicode.IsSpace) != gap {
+		formatted_gap = " " + formatted_gap
+	}
+	if strings.TrimRightFunc(gap, unicode.IsSpace) != gap {
+		formatted_gap += " "
+	}
+	return formatted_gap
+}
+
+/*
+Format a string so that it is double quoted. Raw strings (ie. in backticks)
+and characters (ie. in single quotes) are rewritten with double quotes with
+any double quotes inside of them escaped. Anything that isn't a string is
+left as it is. Parameters include the token. Returns the formatted token.
+*/
+func FormatString(token string) string {
+	if len(token) < 2 {
+		return token
+	}
+	quote := token[0]
+	if (quote != '`' && quote != '\'') || token[len(token)-1] != quote {
+		return token
+	}
+	text := token[1 : len(token)-1]
+	// An escaped single quote doesn't need escaping in double quotes
+	if quote == '\'' {
+		text = strings.ReplaceAll(text, "\\'", "'")
+	}
+	return "\"" + strings.ReplaceAll(text, "\"", "\\\"") + "\""
+}
+
+/*
+Format a script file. Parameters include the name of the file. Returns the
+contents of the file, the formatted contents, and an error if the file
+couldn't be read.
+*/
+func FormatFile(file_name string) (string, string, error) {
+	contents, read_error := os.ReadFile(file_name)
+	if read_error != nil {
+		return "", "", ReportSimple(
+			"Unknown file: " + utils.ColouriseMagenta(file_name) + ".",
+		).WithCategory(ERROR_USAGE).WithErr(read_error)
+	}
+	return string(contents), Format(string(contents)), nil
+}
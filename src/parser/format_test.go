@@ -0,0 +1,42 @@
+package parser
+
+import "testing"
+
+/*
+Check to make sure that scripts are rewritten in their canonical form and that
+formatting a formatted script changes nothing.
+*/
+func TestFormat(t *testing.T) {
+	scripts := map[string]string{
+		// Whitespace is normalised and strings are double quoted
+		"writeln   `Hello \"World\"`   \n\n\n\nset x = 1+2\n": "writeln " +
+			"\"Hello \\\"World\\\"\"\n\nset x = 1+2\n",
+		// The shebang and minver statement go at the top
+		"-about\n#!/usr/bin/appetit\nwriteln \"hi\"\nminver 1": "#!/usr/bin/" +
+			"appetit\nminver 1\n\n- about\nwriteln \"hi\"\n",
+		// Statements in define blocks are indented and comments are kept
+		"define \"greet\"\nwriteln \"hi\" // says hi\n\t-greet\nend\n": "" +
+			"define \"greet\"\n    writeln \"hi\" // says hi\n" +
+			"    - greet\nend\n",
+		// Lines that can't be tokenised are left as they are
+		"writeln \"unterminated   \n": "writeln \"unterminated\n",
+		// Lines of dashes are left as they are
+		"-----\n": "-----\n",
+	}
+
+	for script, expected := range scripts {
+		formatted := Format(script)
+		if formatted != expected {
+			t.Errorf("[format] Expected %q for %q, got %q",
+				expected,
+				script,
+				formatted)
+		}
+		// Formatting again should change nothing
+		if Format(formatted) != formatted {
+			t.Errorf("[format] Expected %q to be unchanged, got %q",
+				formatted,
+				Format(formatted))
+		}
+	}
+}
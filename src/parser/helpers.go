@@ -1,8 +1,8 @@
 /*
 The helpers provides functions that serves as helpful functions to be used
 across the parser including statements. The idea here is that the
-parser "engine" needs to be home only to the three essential functions - Call(),
-Start(), and Tokenise(). In light of that, this provides support
+parser "engine" needs to be home only to the four essential functions - Call(),
+Parse(), Start(), and Tokenise(). In light of that, this provides support
 functions for the parser "engine" that are sometimes used elsewhere (eg. the
 PrepScript function is used by the run statement).
 */
@@ -10,38 +10,39 @@ package parser
 
 import (
 	"appetit/utils"
-	"go/token"
-	"go/types"
+	"errors"
 	"os"
 	"slices"
 	"strings"
+	"unicode"
 )
 
 /*
-Calculate the value variable to see if it's a math expression. Parameters
-include loc, the line of the script (if an error needs to be reported) and
-the value to calculate. Returns the value of the calculated string.
+Calculate a value if it is an expression (see ParseExpression()). The type of
+the value is the type of what it calculates to (eg. 1 + 2 is an integer and
+1 / 2 is a float) and anything that isn't an expression is a string.
+Parameters include the value to calculate and the variables that it can use.
+Returns the calculated value and an ExpressionError if the value is an
+expression that can't be calculated (eg. 1 / 0) or is malformed (eg. (1 + 2
+or 2 ** 3, see IsMalformedExpression()).
 */
-func CalculateValue(loc string, value string) string {
-	// Thanks to https://stackoverflow.com/a/65484336 for the below
-	// Get a file set of tokens
-	expression_tokens := token.NewFileSet()
-	// Get the tokens and evaluate them
-	tv, err := types.Eval(expression_tokens, nil, token.NoPos, value)
-	// If there's an error trying to evaluate the tokens, return the value
-	if err != nil {
-		return value
+func CalculateValue(value string, variables map[string]Value) (Value, error) {
+	expression, parse_error := ParseExpression(value, variables)
+	if parse_error != nil {
+		if IsMalformedExpression(value, variables) {
+			return Value{}, parse_error
+		}
+		return StringValue(value), nil
 	}
-	// Return the calculated value as a string.
-	return tv.Value.ExactString()
+	return expression.Evaluate(variables)
 }
 
 /*
 Prepare a script for execution. Here, open it up and strip the comments. This
 returns a slice of the lines of the script with comments replaced with just
-the comment symbol.
+the comment symbol and an error if the script couldn't be read.
 */
-func PrepScript(file_name string) []string {
+func PrepScript(file_name string) ([]string, error) {
 	// Create a slice for the lines of code
 	var lines []string
 
@@ -50,14 +51,9 @@ func PrepScript(file_name string) []string {
 	// If the file couldn't be opened
 	if err != nil {
 		// Report the error
-		Report(
-			"Unknown file: "+utils.ColouriseMagenta(file_name)+".",
-			"n/a",
-			"n/a",
-			"n/a",
-		)
-		// Exit the script
-		os.Exit(0)
+		return nil, ReportSimple(
+			"Unknown file: " + utils.ColouriseMagenta(file_name) + ".",
+		).WithCategory(ERROR_USAGE).WithErr(err)
 	}
 	// Split the lines of the script by lines and into strings
 	lines = strings.Split(string(script), "\n")
@@ -67,7 +63,7 @@ func PrepScript(file_name string) []string {
 		Return the lines which, by this point, will be a slice of lines where
 		the	comments have been replaced with "blank" comments (ie. "-" only)
 	*/
-	return lines
+	return lines, nil
 }
 
 /*
@@ -146,15 +142,15 @@ Create a string list of the statement names that can be easily printed if
 need be. No parameters. Returns a string representation of the list of
 statements.
 */
-func ListStatements() string {
+func (interpreter *Interpreter) ListStatements() string {
 	// Hold the list of statements
 	var statement_names []string
 
-	// For each statement in STATEMENT_NAMES
-	for stmt := range STATEMENT_NAMES {
+	// For each statement in the interpreter's StatementNames
+	for _, stmt := range interpreter.StatementNames {
 		// Append the statement name to the list of statement_names
 		statement_names = append(
-			statement_names, utils.ColouriseMagenta(STATEMENT_NAMES[stmt]),
+			statement_names, utils.ColouriseMagenta(stmt),
 		)
 	}
 	// Sort the list of statement names
@@ -162,3 +158,62 @@ func ListStatements() string {
 	// Return a joined version of this list
 	return strings.Join(statement_names, ", ")
 }
+
+/*
+Get the usage of a statement, that is, the form that it needs to follow along
+with an example. This is the same text that the statement check reports when
+a statement isn't well formed so it's taken from there by checking the
+statement on its own. Parameters include the name of the statement. Returns
+the usage, stripped of any colour, or an empty string if the name isn't a
+statement.
+*/
+func (interpreter *Interpreter) StatementUsage(statement_name string) string {
+	// If it isn't a statement, there is no usage
+	if !interpreter.CheckIsStatement(statement_name) {
+		return ""
+	}
+	// Check the statement on its own
+	tokens, _ := Tokenise(statement_name, 1, 1)
+	check_error := interpreter.CheckStatement(tokens)
+	var script_error *ScriptError
+	/*
+		If the statement is well formed on its own (eg. exit), it doesn't take
+		any values.
+	*/
+	if !errors.As(check_error, &script_error) {
+		return "The " + statement_name + " statement needs to follow the " +
+			"form " + statement_name + " on a line of its own."
+	}
+	// Get the message without the line of code that some messages end with
+	usage := utils.StripColour(script_error.Message)
+	for _, line_of_code_header := range []string{
+		"\n\nLine of Code:", "\n\nYour line of code",
+	} {
+		usage, _, _ = strings.Cut(usage, line_of_code_header)
+	}
+	return strings.TrimSpace(usage)
+}
+
+/*
+Check whether a character can be part of a name (ie. a statement or variable
+name). Parameters include the character. Returns true if it can.
+*/
+func IsNameCharacter(character rune) bool {
+	return character == '_' || unicode.IsLetter(character) ||
+		unicode.IsDigit(character)
+}
+
+/*
+Get the clauses of a statement that ends with clauses, each a keyword and a
+value, in any order (eg. execute "make" in "build" to "output"). The
+statement is assumed to be well formed. Parameters include the tokens of the
+statement and the index of the first clause. Returns the token of the value
+of each clause by the clause.
+*/
+func ClauseTokens(tokens []Token, start int) map[string]Token {
+	clauses := map[string]Token{}
+	for index := start; index+1 < len(tokens); index += 2 {
+		clauses[tokens[index].TokenValue] = tokens[index+1]
+	}
+	return clauses
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"testing"
 )
 
@@ -18,27 +19,33 @@ func TestCalculateValue(t *testing.T) {
 	expression_three := fmt.Sprintf("%s v%d", LANG_NAME, LANG_VERSION)
 
 	// Calculate those expressions
-	calculated_one := CalculateValue("1", expression_one)
-	calculated_two := CalculateValue("1", expression_two)
-	calculated_three := CalculateValue("1", expression_three)
+	calculated_one, error_one := CalculateValue(expression_one, nil)
+	calculated_two, error_two := CalculateValue(expression_two, nil)
+	calculated_three, error_three := CalculateValue(expression_three, nil)
+	if error_one != nil || error_two != nil || error_three != nil {
+		t.Fatalf("[CalculateValue] Expected no errors, got %v, %v, and %v",
+			error_one,
+			error_two,
+			error_three)
+	}
 
 	// Do the checks and error out if need be
-	if calculated_one != "5" {
+	if calculated_one.Literal() != "5" {
 		t.Errorf("[CalculateValue] Expected %s, got %s",
 			"5",
-			calculated_one)
+			calculated_one.Literal())
 	}
 
-	if calculated_two != "99" {
+	if calculated_two.Literal() != "99" {
 		t.Errorf("[CalculateValue] Expected %s, got %s",
 			"99",
-			calculated_two)
+			calculated_two.Literal())
 	}
 
-	if calculated_three != expression_three {
+	if calculated_three.Literal() != strconv.Quote(expression_three) {
 		t.Errorf("[CalculateValue] Expected %s, got %s",
-			expression_three,
-			calculated_three)
+			strconv.Quote(expression_three),
+			calculated_three.Literal())
 	}
 }
 
@@ -0,0 +1,346 @@
+/*
+The HTTP client houses how the statements that talk to web servers (eg. the
+download statement) make their requests. The settings start with those that
+the interpreter is created with (eg. from the -header, -useragent,
+-httptimeout, -proxy, and -cabundle flags), fall back on the environment
+(see WithDefaults()), and can be changed by the script itself with the header
+and http statements, for example:
+
+	header "Authorization" = "Bearer #env.ARTEFACT_TOKEN"
+	http cabundle = "/etc/ssl/certs/internal.pem"
+	http timeout = 60
+
+A proxy is used where one is set with the http statement or the -proxy flag or
+where the HTTP_PROXY or HTTPS_PROXY environment variables say so, in either
+case unless the host is in the NO_PROXY environment variable (see UseProxy()).
+*/
+package parser
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+How long to wait for a server to connect and to start responding before a
+request is given up on. This doesn't limit how long a download takes once it
+has started.
+*/
+const DEFAULT_HTTP_TIMEOUT = 30 * time.Second
+
+// The settings that the http statement can change
+const (
+	HTTP_SETTING_AUTH       = "auth"
+	HTTP_SETTING_CA_BUNDLE  = "cabundle"
+	HTTP_SETTING_PROXY      = "proxy"
+	HTTP_SETTING_TIMEOUT    = "timeout"
+	HTTP_SETTING_USER_AGENT = "useragent"
+)
+
+/*
+The environment variables that the settings fall back on where they aren't
+passed to the interpreter
+*/
+const (
+	ENVIRONMENT_CA_BUNDLE    = "APPETIT_CA_BUNDLE"
+	ENVIRONMENT_HTTP_TIMEOUT = "APPETIT_HTTP_TIMEOUT"
+	ENVIRONMENT_USER_AGENT   = "APPETIT_USER_AGENT"
+)
+
+/*
+Get the settings that the http statement can change. No parameters. Returns
+the settings.
+*/
+func HTTPSettingNames() []string {
+	return []string{
+		HTTP_SETTING_AUTH,
+		HTTP_SETTING_CA_BUNDLE,
+		HTTP_SETTING_PROXY,
+		HTTP_SETTING_TIMEOUT,
+		HTTP_SETTING_USER_AGENT,
+	}
+}
+
+/*
+Get the User-Agent that requests are made with unless another is set, which
+names the interpreter rather than pretending to be a web browser. No
+parameters. Returns the User-Agent.
+*/
+func DefaultUserAgent() string {
+	return LANG_NAME + "/" + strconv.Itoa(LANG_VERSION) +
+		" (+https://github.com/appetitlang/appetit)"
+}
+
+/*
+The HTTPSettings type houses how requests are made. The structure of the
+settings is as follows:
+  - Headers [map[string]string]: the headers sent with every request by
+    their names
+  - UserAgent [string]: the User-Agent sent with every request, defaults to
+    DefaultUserAgent()
+  - Timeout [time.Duration]: how long to wait for a server to connect and to
+    start responding, defaults to DEFAULT_HTTP_TIMEOUT
+  - Proxy [string]: the URL of the proxy to use, the HTTP_PROXY or
+    HTTPS_PROXY environment variables if there isn't one
+  - CABundle [string]: the path to a file of PEM certificates that are
+    trusted along with those of the system
+  - Auth [string]: the user name and password for basic authentication,
+    written as [user]:[password]
+*/
+type HTTPSettings struct {
+	Headers   map[string]string
+	UserAgent string
+	Timeout   time.Duration
+	Proxy     string
+	CABundle  string
+	Auth      string
+}
+
+/*
+Fill in the settings that haven't been set from the environment and then
+with the defaults. Parameters include the environment. Returns the settings,
+leaving the settings passed as they were.
+*/
+func (settings HTTPSettings) WithDefaults(
+	environment map[string]string) HTTPSettings {
+	settings.Headers = maps.Clone(settings.Headers)
+	if settings.Headers == nil {
+		settings.Headers = map[string]string{}
+	}
+	if settings.UserAgent == "" {
+		settings.UserAgent = environment[ENVIRONMENT_USER_AGENT]
+	}
+	if settings.UserAgent == "" {
+		settings.UserAgent = DefaultUserAgent()
+	}
+	if settings.Timeout <= 0 {
+		seconds, parse_error := strconv.ParseFloat(
+			environment[ENVIRONMENT_HTTP_TIMEOUT], 64)
+		if parse_error == nil && seconds > 0 {
+			settings.Timeout = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = DEFAULT_HTTP_TIMEOUT
+	}
+	if settings.CABundle == "" {
+		settings.CABundle = environment[ENVIRONMENT_CA_BUNDLE]
+	}
+	return settings
+}
+
+/*
+The HeaderList type houses the value of the -header flag, which can be passed
+as many times as need be with a header written as [name]: [value] (eg.
+-header "Authorization: Bearer abc123").
+*/
+type HeaderList map[string]string
+
+/*
+Get the headers in the list as they would be passed to the flag. No
+parameters. Returns the headers.
+*/
+func (list HeaderList) String() string {
+	var headers []string
+	for _, name := range slices.Sorted(maps.Keys(list)) {
+		headers = append(headers, name+": "+list[name])
+	}
+	return strings.Join(headers, ", ")
+}
+
+/*
+Add the value of the flag to the list. Parameters include the value. Returns
+an error if it isn't written as [name]: [value].
+*/
+func (list HeaderList) Set(value string) error {
+	name, header_value, found := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !found || !IsHeaderName(name) {
+		return fmt.Errorf("the header needs to be written as [name]: [value]")
+	}
+	list[http.CanonicalHeaderKey(name)] = strings.TrimSpace(header_value)
+	return nil
+}
+
+/*
+Check whether a name can be the name of a header, that is, that it is made up
+of letters, numbers, and the punctuation that HTTP allows in a name (eg.
+X-Api-Key). Parameters include the name. Returns true if it can.
+*/
+func IsHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, character := range name {
+		if !IsNameCharacter(character) &&
+			!strings.ContainsRune("!#$%&'*+-.^`|~", character) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Check whether a request to a host should go through a proxy given the value
+of the NO_PROXY environment variable, which is a comma separated list of
+hosts (where a host also matches its subdomains), domains that start with a
+dot, IP addresses, and IP ranges (eg. 10.0.0.0/8), any of which can have a
+port. A * on its own matches every host. Requests to the local machine never
+go through a proxy. Parameters include the host (with or without a port) and
+the value of NO_PROXY. Returns true if the request should go through a proxy.
+*/
+func UseProxy(address string, no_proxy string) bool {
+	host, port, split_error := net.SplitHostPort(address)
+	if split_error != nil {
+		host = address
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	host_ip := net.ParseIP(host)
+	if host == "localhost" || (host_ip != nil && host_ip.IsLoopback()) {
+		return false
+	}
+	for _, entry := range strings.Split(strings.ToLower(no_proxy), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "*" {
+			return false
+		}
+		if _, network, cidr_error := net.ParseCIDR(entry); cidr_error == nil {
+			if host_ip != nil && network.Contains(host_ip) {
+				return false
+			}
+			continue
+		}
+		entry_host, entry_port, split_error := net.SplitHostPort(entry)
+		if split_error != nil {
+			entry_host, entry_port = entry, ""
+		}
+		entry_host = strings.TrimPrefix(strings.Trim(entry_host, "[]"), "*")
+		if entry_host == "" || (entry_port != "" && entry_port != port) {
+			continue
+		}
+		if host == strings.TrimPrefix(entry_host, ".") ||
+			strings.HasSuffix(host, "."+strings.TrimPrefix(entry_host, ".")) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Get the value of an environment variable that can be written in upper or
+lower case (eg. HTTP_PROXY or http_proxy), preferring upper case. Parameters
+include the name in upper case. Returns the value.
+*/
+func (interpreter *Interpreter) EnvironmentEither(name string) string {
+	if value := interpreter.Environment[name]; value != "" {
+		return value
+	}
+	return interpreter.Environment[strings.ToLower(name)]
+}
+
+/*
+Get the proxy for a request, which is the one set for the script or passed to
+the interpreter, or otherwise the one in the HTTP_PROXY or HTTPS_PROXY
+environment variable for the scheme of the request. The environment is read
+for each request so that the setenv statement can change it. Parameters
+include the request. Returns the URL of the proxy, nil for none, and an
+error if the URL isn't valid.
+*/
+func (interpreter *Interpreter) ProxyFor(
+	request *http.Request) (*url.URL, error) {
+	if !UseProxy(request.URL.Host, interpreter.EnvironmentEither("NO_PROXY")) {
+		return nil, nil
+	}
+	proxy := interpreter.HTTP.Proxy
+	if proxy == "" && request.URL.Scheme == "https" {
+		proxy = interpreter.EnvironmentEither("HTTPS_PROXY")
+	}
+	if proxy == "" {
+		proxy = interpreter.EnvironmentEither("HTTP_PROXY")
+	}
+	if proxy == "" {
+		return nil, nil
+	}
+	// A proxy without a scheme (eg. proxy.internal:3128) is an HTTP proxy
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	proxy_url, parse_error := url.Parse(proxy)
+	if parse_error != nil {
+		return nil, fmt.Errorf("the proxy %s isn't a valid URL", proxy)
+	}
+	return proxy_url, nil
+}
+
+/*
+Create a client that makes requests with the interpreter's HTTP settings. A
+client is created for each statement that makes requests so that it picks up
+any changes that the script has made to the settings. No parameters. Returns
+the client and an error if the CA bundle couldn't be read.
+*/
+func (interpreter *Interpreter) HTTPClient() (*http.Client, error) {
+	timeout := interpreter.HTTP.Timeout
+	transport := &http.Transport{
+		Proxy: interpreter.ProxyFor,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	// Trust the certificates in the CA bundle along with the system's
+	if interpreter.HTTP.CABundle != "" {
+		certificates, read_error := os.ReadFile(interpreter.HTTP.CABundle)
+		if read_error != nil {
+			return nil, fmt.Errorf("the CA bundle %s couldn't be read: %w",
+				interpreter.HTTP.CABundle, read_error)
+		}
+		pool, pool_error := x509.SystemCertPool()
+		if pool_error != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(certificates) {
+			return nil, fmt.Errorf(
+				"the CA bundle %s doesn't have any PEM certificates in it",
+				interpreter.HTTP.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+/*
+Create a request with the interpreter's headers, User-Agent, and basic
+authentication. Parameters include the method (eg. GET), the URL, and the
+body, nil for none. Returns the request and an error if the URL isn't valid.
+*/
+func (interpreter *Interpreter) NewHTTPRequest(
+	method string, request_url string, body io.Reader) (*http.Request, error) {
+	request, request_error := http.NewRequest(method, request_url, body)
+	if request_error != nil {
+		return nil, request_error
+	}
+	request.Header.Set("User-Agent", interpreter.HTTP.UserAgent)
+	for name, value := range interpreter.HTTP.Headers {
+		request.Header.Set(name, value)
+	}
+	if interpreter.HTTP.Auth != "" {
+		user, password, _ := strings.Cut(interpreter.HTTP.Auth, ":")
+		request.SetBasicAuth(user, password)
+	}
+	return request, nil
+}
@@ -0,0 +1,268 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that the header and http statements change what is sent
+with each request and that the headers passed to the interpreter are sent
+too.
+*/
+func TestHTTPSettings(t *testing.T) {
+	useTestCache(t)
+	var received http.Header
+	var user, password string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			received = request.Header
+			user, password, _ = request.BasicAuth()
+			writer.Write([]byte("Hello"))
+		}))
+	defer server.Close()
+
+	interpreter := New(Options{
+		Stdout: &bytes.Buffer{},
+		HTTP: HTTPSettings{
+			Headers: HeaderList{"X-Team": "backups"},
+		},
+	})
+	interpreter.Variables["token"] = StringValue("abc123")
+	run_error := interpreter.RunString(
+		"header \"x-api-key\" = \"#token\"\n" +
+			"header \"X-Team\" = \"\"\n" +
+			"http auth = \"deploy:secret\"\n" +
+			"http timeout = 5\n" +
+			"download \"" + server.URL + "/file.txt\" to \"" +
+			filepath.Join(t.TempDir(), "file.txt") + "\"")
+	if run_error != nil {
+		t.Fatalf("[http] Expected no error, got %v", run_error)
+	}
+	if received.Get("X-Api-Key") != "abc123" || received.Get("X-Team") != "" ||
+		received.Get("User-Agent") != DefaultUserAgent() {
+		t.Errorf("[header] Expected the script's headers, got %v", received)
+	}
+	if user != "deploy" || password != "secret" {
+		t.Errorf("[http] Expected deploy:secret, got %s:%s", user, password)
+	}
+
+	// Settings that aren't valid are reported along with the statement
+	for _, line := range []string{
+		"header \"Bad Name\" = \"x\"",
+		"http retries = 5",
+		"http proxy = 5",
+		"http timeout = \"abc\"",
+		"http timeout = 0",
+	} {
+		check_error := New(Options{Check: true}).RunString(line)
+		if check_error == nil {
+			t.Errorf("[http] Expected a problem with %q", line)
+		}
+	}
+
+	// A timeout in a variable can only be checked when the statement runs
+	run_error = New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"set seconds = \"abc\"\nhttp timeout = \"#seconds\"")
+	if run_error == nil ||
+		!strings.Contains(run_error.Error(), "number of seconds") {
+		t.Errorf("[http] Expected the timeout to be rejected, got %v",
+			run_error)
+	}
+	check_error := New(Options{Check: true}).RunString(
+		"set seconds = 5\nhttp timeout = \"#seconds\"")
+	if check_error != nil {
+		t.Errorf("[http] Expected no problem with a variable, got %v",
+			check_error)
+	}
+}
+
+/*
+Check to make sure that a proxy is used unless the host is in NO_PROXY and
+that requests to the local machine never go through one.
+*/
+func TestUseProxy(t *testing.T) {
+	no_proxy := "internal.example, .corp, 10.0.0.0/8, build:8080"
+	// The hosts and whether they should go through a proxy
+	cases := map[string]bool{
+		"example.com":                true,
+		"internal.example":           false,
+		"artefacts.internal.example": false,
+		"files.corp:443":             false,
+		"10.1.2.3":                   false,
+		"11.1.2.3":                   true,
+		"build:8080":                 false,
+		"build:9090":                 true,
+		"localhost:8000":             false,
+		"127.0.0.1":                  false,
+		"[::1]:80":                   false,
+	}
+	for host, expected := range cases {
+		if UseProxy(host, no_proxy) != expected {
+			t.Errorf("[UseProxy] Expected %v for %s", expected, host)
+		}
+	}
+	if UseProxy("example.com", "*") {
+		t.Errorf("[UseProxy] Expected * to skip the proxy for every host")
+	}
+
+	// A request to a host that isn't local goes through the proxy
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			proxied = request.URL.String()
+			writer.Write([]byte("Hello"))
+		}))
+	defer proxy.Close()
+	useTestCache(t)
+	interpreter := New(Options{
+		Stdout:      &bytes.Buffer{},
+		Environment: map[string]string{"HTTP_PROXY": proxy.URL},
+	})
+	run_error := interpreter.RunString(
+		"download \"http://artefacts.example/file.txt\" to \"" +
+			filepath.Join(t.TempDir(), "file.txt") + "\"")
+	if run_error != nil || proxied != "http://artefacts.example/file.txt" {
+		t.Errorf("[ProxyFor] Expected the request to be proxied, got %q (%v)",
+			proxied,
+			run_error)
+	}
+}
+
+/*
+Check to make sure that a server with a certificate that the system doesn't
+trust can be downloaded from once its certificate is in the CA bundle.
+*/
+func TestCABundle(t *testing.T) {
+	useTestCache(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			writer.Write([]byte("Hello"))
+		}))
+	defer server.Close()
+	bundle := filepath.Join(t.TempDir(), "bundle.pem")
+	os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0644)
+	download := "download \"" + server.URL + "/file.txt\" to \"" +
+		filepath.Join(t.TempDir(), "file.txt") + "\""
+
+	run_error := New(Options{Stdout: &bytes.Buffer{}}).RunString(download)
+	if run_error == nil {
+		t.Errorf("[cabundle] Expected the certificate not to be trusted")
+	}
+	run_error = New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"http cabundle = \"" + bundle + "\"\n" + download)
+	if run_error != nil {
+		t.Errorf("[cabundle] Expected no error, got %v", run_error)
+	}
+	// The bundle can also come from the environment
+	interpreter := New(Options{
+		Stdout:      &bytes.Buffer{},
+		Environment: map[string]string{ENVIRONMENT_CA_BUNDLE: bundle},
+	})
+	if !strings.HasSuffix(interpreter.HTTP.CABundle, "bundle.pem") {
+		t.Errorf("[cabundle] Expected the bundle from the environment, got %q",
+			interpreter.HTTP.CABundle)
+	}
+}
+
+/*
+Check to make sure that the fetch, post, and put statements save what the
+server responds with, along with its status code and headers, and that an
+error status stops the script.
+*/
+func TestRequestStatements(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			switch request.URL.Path {
+			case "/health":
+				writer.Header().Set("ETag", "\"v2\"")
+				writer.Write([]byte("ok\n"))
+			case "/hook":
+				body, _ := io.ReadAll(request.Body)
+				writer.Header().Set("Content-Type", "application/json")
+				writer.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(writer, "%s %s %s",
+					request.Method,
+					request.Header.Get("Content-Type"),
+					body)
+			default:
+				http.NotFound(writer, request)
+			}
+		}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output})
+	run_error := interpreter.RunString(
+		"fetch \"" + server.URL + "/health\" to \"health\"\n" +
+			"writeln \"#health #b_http_status #b_http_etag\"\n" +
+			"put \"" + server.URL + "/hook\" with \"{\\\"done\\\": true}\" " +
+			"as \"application/json\" to \"reply\"\n" +
+			"writeln \"#b_http_status #b_http_content_type #reply\"")
+	expected := "ok 200 \"v2\"\n" +
+		"201 application/json PUT application/json {\"done\": true}\n"
+	if run_error != nil || output.String() != expected {
+		t.Errorf("[fetch] Expected %q, got %q (%v)",
+			expected,
+			output.String(),
+			run_error)
+	}
+
+	// An error status is saved for the script to check
+	output.Reset()
+	interpreter = New(Options{Stdout: &output})
+	run_error = interpreter.RunString(
+		"post \"" + server.URL + "/missing\" to \"reply\"\n" +
+			"if \"#b_http_status\" is 404 then writeln \"Not found\"\n" +
+			"fetch \"" + server.URL + "/missing\" to \"page\" " +
+			"expect \"2xx, 404\"")
+	if run_error != nil || output.String() != "Not found\n" {
+		t.Errorf("[post] Expected the 404 to be checked, got %q (%v)",
+			output.String(),
+			run_error)
+	}
+
+	// A status that the expect clause doesn't allow stops the script
+	interpreter = New(Options{Stdout: &bytes.Buffer{}})
+	run_error = interpreter.RunString(
+		"post \"" + server.URL + "/missing\" to \"reply\" expect \"2xx\"")
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) ||
+		script_error.Rule != "runtime/http-status" ||
+		interpreter.Variables["b_http_status"].Literal() != "404" {
+		t.Errorf("[post] Expected a 404 to be reported, got %v", run_error)
+	}
+
+	// The form of each statement is checked
+	for line, valid := range map[string]bool{
+		"fetch \"https://example.com\" to \"page\"\nwriteln \"#page\"":  true,
+		"post \"https://example.com\"":                                  true,
+		"fetch \"https://example.com\"":                                 false,
+		"fetch \"https://example.com\" with \"x\" to \"page\"":          false,
+		"put \"https://example.com\" with \"x\" with \"y\"":             false,
+		"fetch \"https://example.com\" to \"page\" expect \"2XX, 404\"": true,
+		"put \"https://example.com\" expect \"#b_http_status\"":         true,
+		"post \"https://example.com\" expect \"20x\"":                   false,
+		"post \"https://example.com\" expect \"600\"":                   false,
+	} {
+		check_error := New(Options{Check: true}).RunString(line)
+		if (check_error == nil) != valid {
+			t.Errorf("[fetch] Expected %q to be valid: %v, got %v",
+				line,
+				valid,
+				check_error)
+		}
+	}
+}
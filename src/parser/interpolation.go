@@ -0,0 +1,366 @@
+/*
+Interpolation replaces the variables in a string with their values. The
+string is read from left to right, once, as follows:
+  - #name is replaced with the value of the longest variable that the name
+    starts with (eg. #name_full is name_full where there is such a variable
+    and #names is name followed by an s where there isn't a names variable)
+  - #{name} is replaced with the value of exactly that variable, which is
+    handy where letters follow the variable (eg. #{name}s)
+  - ## is a literal # (eg. "##ff0000" is #ff0000)
+  - A # that isn't followed by a letter, an underscore, or a { is left as it
+    is (eg. "Item #1")
+  - #env.NAME (or #{env.NAME}) is replaced with the value of the environment
+    variable NAME (see environment.go)
+
+A variable that hasn't been set is an error that stops the script unless the
+interpreter is warning about undefined variables, in which case a warning is
+raised and the variable is left as it was written.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The symbols that wrap the name of a variable (eg. #{name})
+const (
+	SYMBOL_VARIABLE_OPEN  = "{"
+	SYMBOL_VARIABLE_CLOSE = "}"
+)
+
+/*
+The VariableReference type houses a use of a variable in a string. The
+structure of the reference is as follows:
+  - Name [string]: the name as it was written, which for #name is every name
+    character after the # and might only start with the variable, and which
+    is "" for a #{ that isn't closed
+  - Text [string]: the reference as it was written (eg. #{name})
+  - Start [int]: where the reference starts in the string, in bytes
+  - End [int]: where the reference ends in the string, in bytes
+  - Braced [bool]: whether the name was wrapped in braces
+*/
+type VariableReference struct {
+	Name   string
+	Text   string
+	Start  int
+	End    int
+	Braced bool
+}
+
+/*
+Find the uses of variables in a string. Literal hashes (ie. ##) are skipped.
+Parameters include the string. Returns the references in the order that they
+appear.
+*/
+func VariableReferences(input string) []VariableReference {
+	var references []VariableReference
+	for index := 0; index < len(input); index++ {
+		if !strings.HasPrefix(input[index:], SYMBOL_VARIABLE_SUBSTITUTION) {
+			continue
+		}
+		rest := input[index+len(SYMBOL_VARIABLE_SUBSTITUTION):]
+		switch {
+		// Skip over a literal hash
+		case strings.HasPrefix(rest, SYMBOL_VARIABLE_SUBSTITUTION):
+			index += len(SYMBOL_VARIABLE_SUBSTITUTION)
+		// A name in braces runs to the closing brace
+		case strings.HasPrefix(rest, SYMBOL_VARIABLE_OPEN):
+			rest = rest[len(SYMBOL_VARIABLE_OPEN):]
+			name_length := VariableNameLength(rest)
+			reference := VariableReference{Start: index, Braced: true}
+			if name_length > 0 &&
+				strings.HasPrefix(rest[name_length:], SYMBOL_VARIABLE_CLOSE) {
+				reference.Name = rest[:name_length]
+				reference.End = index + len(SYMBOL_VARIABLE_SUBSTITUTION+
+					SYMBOL_VARIABLE_OPEN+SYMBOL_VARIABLE_CLOSE) + name_length
+			} else {
+				// Without a closing brace, the reference is what was written
+				reference.End = index + len(SYMBOL_VARIABLE_SUBSTITUTION+
+					SYMBOL_VARIABLE_OPEN) + name_length
+			}
+			reference.Text = input[reference.Start:reference.End]
+			references = append(references, reference)
+			index = reference.End - 1
+		// A name without braces needs to start with a letter or underscore
+		default:
+			first, _ := utf8.DecodeRuneInString(rest)
+			if first != '_' && !unicode.IsLetter(first) {
+				continue
+			}
+			name_length := VariableNameLength(rest)
+			end := index + len(SYMBOL_VARIABLE_SUBSTITUTION) + name_length
+			references = append(references, VariableReference{
+				Name:  rest[:name_length],
+				Text:  input[index:end],
+				Start: index,
+				End:   end,
+			})
+			index = end - 1
+		}
+	}
+	return references
+}
+
+/*
+Check whether a name is a variable from the text before it, that is, whether
+it follows the variable symbol or the symbol and an opening brace, but not a
+literal hash (ie. ##). Parameters include the text before the name. Returns
+true if the name is a variable.
+*/
+func IsVariableStart(before string) bool {
+	before = strings.TrimSuffix(before, SYMBOL_VARIABLE_OPEN)
+	hashes := len(before) -
+		len(strings.TrimRight(before, SYMBOL_VARIABLE_SUBSTITUTION))
+	return hashes%2 == 1
+}
+
+/*
+Get how long the name at the start of a string is. Parameters include the
+string. Returns the length of the name in bytes.
+*/
+func NameLength(input string) int {
+	length := 0
+	for _, character := range input {
+		if !IsNameCharacter(character) {
+			break
+		}
+		length += utf8.RuneLen(character)
+	}
+	return length
+}
+
+/*
+Get how long the name of a variable at the start of a string is. This is the
+length of the name (see NameLength()) other than for an environment variable
+where the name follows the environment prefix (eg. env.PATH). Parameters
+include the string. Returns the length of the name in bytes.
+*/
+func VariableNameLength(input string) int {
+	if rest, is_environment := strings.CutPrefix(
+		input, SYMBOL_ENVIRONMENT_PREFIX); is_environment {
+		if name_length := NameLength(rest); name_length > 0 {
+			return len(SYMBOL_ENVIRONMENT_PREFIX) + name_length
+		}
+	}
+	return NameLength(input)
+}
+
+/*
+Work out which variable a reference is to. A name in braces needs to be a
+variable. A name without them is the longest variable that it starts with,
+whatever is left over being text that follows the variable. Parameters
+include the reference and the variables. Returns the name of the variable
+and false if there isn't one.
+*/
+func ResolveVariable(
+	reference VariableReference,
+	variables map[string]Value,
+) (string, bool) {
+	if reference.Braced {
+		_, exists := variables[reference.Name]
+		return reference.Name, exists && reference.Name != ""
+	}
+	// Try the whole name, then drop a character at a time
+	for name := reference.Name; name != ""; {
+		if _, exists := variables[name]; exists {
+			return name, true
+		}
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return reference.Name, false
+}
+
+/*
+Replace the variables in a string with the interpreter's values (see
+InterpolateVariables()). Parameters include the string. Returns the string
+with the variables replaced and the references to variables that haven't been
+set.
+*/
+func (interpreter *Interpreter) Interpolate(
+	input string,
+) (string, []VariableReference) {
+	return InterpolateVariables(input, interpreter.VariablesFor(input))
+}
+
+/*
+Replace the variables in a string with their values. Any variable that
+hasn't been set is left as it was written. Parameters include the string and
+the variables. Returns the string with the variables replaced and the
+references to variables that haven't been set.
+*/
+func InterpolateVariables(
+	input string,
+	variables map[string]Value,
+) (string, []VariableReference) {
+	// Return the string as it is if there's nothing to replace
+	if !strings.Contains(input, SYMBOL_VARIABLE_SUBSTITUTION) {
+		return input, nil
+	}
+	var output strings.Builder
+	var undefined []VariableReference
+	last := 0
+	for _, reference := range VariableReferences(input) {
+		// Copy what is before the reference, turning ## into #
+		output.WriteString(strings.ReplaceAll(input[last:reference.Start],
+			SYMBOL_VARIABLE_SUBSTITUTION+SYMBOL_VARIABLE_SUBSTITUTION,
+			SYMBOL_VARIABLE_SUBSTITUTION))
+		last = reference.End
+		name, exists := ResolveVariable(reference, variables)
+		if !exists {
+			undefined = append(undefined, reference)
+			output.WriteString(reference.Text)
+			continue
+		}
+		output.WriteString(variables[name].String())
+		// Keep anything after the variable that was part of the name
+		if !reference.Braced {
+			output.WriteString(reference.Name[len(name):])
+		}
+	}
+	output.WriteString(strings.ReplaceAll(input[last:],
+		SYMBOL_VARIABLE_SUBSTITUTION+SYMBOL_VARIABLE_SUBSTITUTION,
+		SYMBOL_VARIABLE_SUBSTITUTION))
+	return output.String(), undefined
+}
+
+/*
+Get the value of a variable where a string is nothing but that variable (eg.
+"#files" or "#{files}"), in which case the value keeps its type rather than
+being written out as a string. Parameters include the string. Returns the
+value and false if the string isn't a single variable that has been set.
+*/
+func (interpreter *Interpreter) VariableValue(input string) (Value, bool) {
+	references := VariableReferences(input)
+	if len(references) != 1 || references[0].Start != 0 ||
+		references[0].End != len(input) {
+		return Value{}, false
+	}
+	variables := interpreter.VariablesFor(input)
+	name, exists := ResolveVariable(references[0], variables)
+	// Without braces, the whole name needs to be the variable
+	if !exists || name != references[0].Name {
+		return Value{}, false
+	}
+	return variables[name], true
+}
+
+/*
+Replace the variables in a string from a statement, keeping the type of a
+variable where the string is nothing but that variable (see VariableValue()).
+Parameters include the string, the tokens of the statement, and the token
+that the string came from. Returns the value and an error if a variable
+hasn't been set.
+*/
+func (interpreter *Interpreter) TemplateValue(
+	input string,
+	tokens []Token,
+	token Token,
+) (Value, error) {
+	if value, is_variable := interpreter.VariableValue(input); is_variable {
+		return value, nil
+	}
+	output, template_error := interpreter.Template(input, tokens, token)
+	return StringValue(output), template_error
+}
+
+/*
+Replace the variables in a string from a statement. A variable that hasn't
+been set is reported, as an error or, where the interpreter is warning about
+undefined variables, a warning. Parameters include the string, the tokens of
+the statement, and the token that the string came from. Returns the string
+with the variables replaced and an error if a variable hasn't been set.
+*/
+func (interpreter *Interpreter) Template(
+	input string,
+	tokens []Token,
+	token Token,
+) (string, error) {
+	output, undefined := interpreter.Interpolate(input)
+	for _, reference := range undefined {
+		problem := ReportUndefinedVariable(tokens, token, reference)
+		if !interpreter.ModeWarnUndefined {
+			return "", problem
+		}
+		interpreter.Warning(problem)
+	}
+	return output, nil
+}
+
+/*
+Report a variable that hasn't been set. The reference is found in the token,
+as it was written, so that the error points at the variable itself.
+Parameters include the tokens of the statement, the token that the variable
+is in, and the reference. Returns the error.
+*/
+func ReportUndefinedVariable(
+	tokens []Token,
+	token Token,
+	reference VariableReference,
+) *ScriptError {
+	// Point at the variable where it can be found, otherwise at the token
+	column, _ := strconv.Atoi(token.TokenPosition)
+	if offset := strings.Index(
+		token.TokenValue, reference.Text); offset >= 0 && column > 0 {
+		column += utf8.RuneCountInString(token.TokenValue[:offset])
+	}
+
+	var problem *ScriptError
+	if reference.Name == "" {
+		problem = Report(
+			"The variable - "+utils.ColouriseYellow(reference.Text)+
+				" - is missing its closing "+
+				utils.ColouriseMagenta(SYMBOL_VARIABLE_CLOSE)+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			strconv.Itoa(column),
+			tokens[0].FullLineOfCode,
+		).WithHint("Write the variable as " + utils.ColouriseGreen(
+			SYMBOL_VARIABLE_SUBSTITUTION+SYMBOL_VARIABLE_OPEN+"name"+
+				SYMBOL_VARIABLE_CLOSE) + " or use " + utils.ColouriseGreen(
+			SYMBOL_VARIABLE_SUBSTITUTION+SYMBOL_VARIABLE_SUBSTITUTION) +
+			" for a literal " + SYMBOL_VARIABLE_SUBSTITUTION + ".")
+	} else if name, is_environment := strings.CutPrefix(
+		reference.Name, SYMBOL_ENVIRONMENT_PREFIX); is_environment {
+		problem = Report(
+			"The environment variable "+utils.ColouriseYellow(name)+
+				" hasn't been set.",
+			strconv.Itoa(tokens[0].LineNumber),
+			strconv.Itoa(column),
+			tokens[0].FullLineOfCode,
+		).WithHint("Set the environment variable before the script runs, " +
+			"pass it in a file with the " + utils.ColouriseYellow("-env-file") +
+			" flag, or set it before this line (eg. " +
+			utils.ColouriseCyan("setenv") + " " + name + " = " +
+			utils.ColouriseGreen("\"value\"") + ").")
+	} else if IsArgumentVariable(reference.Name) {
+		problem = Report(
+			"The variable "+utils.ColouriseYellow(reference.Name)+
+				" hasn't been set as it wasn't passed to the script.",
+			strconv.Itoa(tokens[0].LineNumber),
+			strconv.Itoa(column),
+			tokens[0].FullLineOfCode,
+		).WithHint("Pass it after the name of the script or declare it " +
+			"with a default at the top of the script (eg. " +
+			utils.ColouriseCyan("params") + " option name = " +
+			utils.ColouriseGreen("\"value\"") + ").")
+	} else {
+		problem = Report(
+			"The variable "+utils.ColouriseYellow(reference.Name)+
+				" hasn't been set.",
+			strconv.Itoa(tokens[0].LineNumber),
+			strconv.Itoa(column),
+			tokens[0].FullLineOfCode,
+		).WithHint("Set the variable before this line (eg. " +
+			utils.ColouriseCyan("set") + " " + reference.Name + " = " +
+			utils.ColouriseGreen("\"value\"") + ") or use " +
+			utils.ColouriseGreen(
+				SYMBOL_VARIABLE_SUBSTITUTION+SYMBOL_VARIABLE_SUBSTITUTION) +
+			" for a literal " + SYMBOL_VARIABLE_SUBSTITUTION + ".")
+	}
+	return problem.WithCategory(ERROR_RUNTIME).WithRule(
+		"runtime/undefined-variable")
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that Interpolate() picks the longest variable that a name
+starts with, whatever order the variables are in, and handles braces and
+literal hashes.
+*/
+func TestInterpolate(t *testing.T) {
+	// Create an interpreter to hold the variables
+	interpreter := New(Options{})
+	interpreter.Variables["name"] = StringValue("Ada")
+	interpreter.Variables["name_full"] = StringValue("Ada Lovelace")
+
+	// The input strings and what they should become
+	cases := map[string]string{
+		"#name_full":         "Ada Lovelace",
+		"#name":              "Ada",
+		"#names":             "Adas",
+		"#{name}_full":       "Ada_full",
+		"##name":             "#name",
+		"###name":            "#Ada",
+		"Item #1":            "Item #1",
+		"Colour: ##ff0000":   "Colour: #ff0000",
+		"#name and #{name}!": "Ada and Ada!",
+	}
+	// Run them a few times as map order used to change the result
+	for range 10 {
+		for input, expected := range cases {
+			output, undefined := interpreter.Interpolate(input)
+			if output != expected || len(undefined) != 0 {
+				t.Errorf("[Interpolate] %q returned %q (%d undefined), "+
+					"expected %q",
+					input,
+					output,
+					len(undefined),
+					expected)
+			}
+		}
+	}
+}
+
+/*
+Check to make sure that Interpolate() leaves a variable that hasn't been set
+as it was written and hands back a reference to it.
+*/
+func TestInterpolateUndefined(t *testing.T) {
+	interpreter := New(Options{})
+
+	// The input strings and the references that should be undefined
+	cases := map[string]string{
+		"Hello #typo":    "#typo",
+		"Hello #{typo}!": "#{typo}",
+		"Hello #{typo":   "#{typo",
+	}
+	for input, text := range cases {
+		output, undefined := interpreter.Interpolate(input)
+		if output != input || len(undefined) != 1 ||
+			undefined[0].Text != text {
+			t.Errorf("[Interpolate] %q returned %q and %v, expected %q "+
+				"to be undefined",
+				input,
+				output,
+				undefined,
+				text)
+		}
+	}
+}
+
+/*
+Check to make sure that using a variable that hasn't been set stops the script
+with an error that points at the variable, unless the interpreter is warning
+about undefined variables.
+*/
+func TestUndefinedVariable(t *testing.T) {
+	// Ask for a name and then use a misspelling of it
+	script := "ask \"Name: \" to \"name\"\nwriteln \"Hi #nmae\""
+
+	// Without the warning mode, the script should stop
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output, Stdin: strings.NewReader("Ada\n")})
+	run_error := interpreter.RunString(script)
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) {
+		t.Fatalf("[Template] Expected a ScriptError, got %v", run_error)
+	}
+	if script_error.Rule != "runtime/undefined-variable" ||
+		script_error.Column != 13 {
+		t.Errorf("[Template] Expected an undefined variable at column 13, "+
+			"got %s at column %d",
+			script_error.Rule,
+			script_error.Column)
+	}
+
+	// With the warning mode, the script should carry on
+	var warning_output bytes.Buffer
+	interpreter = New(Options{
+		WarnUndefined: true,
+		Stdout:        &warning_output,
+		Stdin:         strings.NewReader("Ada\n"),
+		Stderr:        &bytes.Buffer{},
+	})
+	if run_error := interpreter.RunString(script); run_error != nil {
+		t.Fatalf("[Template] Expected no error, got %v", run_error)
+	}
+	if len(interpreter.Warnings) != 1 {
+		t.Errorf("[Template] Expected one warning, got %d",
+			len(interpreter.Warnings))
+	}
+	if warning_output.String() != "Name: Hi #nmae\n" {
+		t.Errorf("[Template] Expected %q, got %q",
+			"Name: Hi #nmae\n",
+			warning_output.String())
+	}
+}
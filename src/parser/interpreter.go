@@ -0,0 +1,331 @@
+/*
+The interpreter houses the state of a running script. Everything that a script
+can change or that changes how a script behaves (eg. its variables, the modes
+that it is running in, and where its input and output go) lives on an
+Interpreter rather than in the package itself. This means that more than one
+script can run in the one process and that Appetit can be embedded in other Go
+tools. A typical use looks like this:
+
+	interpreter := parser.New(parser.Options{Verbose: true})
+	if err := interpreter.RunFile("script.apt"); err != nil {
+		parser.PrintError(os.Stderr, err)
+	}
+*/
+package parser
+
+import (
+	"bufio"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+/*
+The Options type houses the settings that an interpreter is created with. The
+structure of the options is as follows:
+  - AllowExec [bool]: whether the execute statement is allowed
+  - ExecRules [[]ExecRule]: the commands that the execute statement can run
+    (see exec_policy.go), which also allows the execute statement. Without
+    any, AllowExec allows every command.
+  - Check [bool]: whether we are checking the script for problems rather
+    than executing it
+  - Dev [bool]: whether we are in developer mode (ie. print tokens rather than
+    execute statements)
+  - Diagnostics [bool]: whether errors and warnings are reported as
+    diagnostics (see diagnostics.go) rather than printed
+  - DryRun [bool]: whether statements that touch the file system or network
+    print what they would do rather than doing it
+  - Verbose [bool]: whether we are verbose with our output
+  - WarnUndefined [bool]: whether a variable that hasn't been set is a
+    warning rather than an error
+  - MaxIterations [int]: the number of times that a script can loop before
+    it is stopped, defaults to DEFAULT_MAX_ITERATIONS
+  - DownloadRetries [int]: the number of times that a download that stopped
+    is tried again, defaults to none (the -retries flag defaults to
+    DEFAULT_DOWNLOAD_RETRIES)
+  - Environment [map[string]string]: environment variables that are added to
+    (or replace) those of the process (eg. from the -env-file flag)
+  - Arguments [[]string]: the command line arguments passed to the script
+    (see arguments.go)
+  - HTTP [HTTPSettings]: how requests are made (see http_client.go), with
+    anything that isn't set falling back on the environment and defaults
+  - Audit [io.Writer]: where each command that the execute statement is
+    allowed or denied is logged, nil for nowhere
+  - Stdout [io.Writer]: where the output of the script goes, defaults to
+    os.Stdout
+  - Stdin [io.Reader]: where the input for the ask statement comes from,
+    defaults to os.Stdin
+  - Stderr [io.Writer]: where errors go, defaults to os.Stderr
+*/
+type Options struct {
+	AllowExec       bool
+	Check           bool
+	Dev             bool
+	Diagnostics     bool
+	DryRun          bool
+	Verbose         bool
+	WarnUndefined   bool
+	MaxIterations   int
+	DownloadRetries int
+	Environment     map[string]string
+	Arguments       []string
+	HTTP            HTTPSettings
+	ExecRules       []ExecRule
+	Audit           io.Writer
+	Stdout          io.Writer
+	Stdin           io.Reader
+	Stderr          io.Writer
+}
+
+/*
+The Interpreter type houses the state of a running script. The structure of
+the interpreter is as follows:
+  - Variables [map[string]Value]: the variables, prepopulated with the
+    reserved variables
+  - Environment [map[string]string]: the environment variables that the
+    script can read and that the commands it executes are given
+  - Arguments [[]string]: the command line arguments passed to the script
+  - Parameters [[]Parameter]: the parameters that the script declares with
+    params statements, set once the script is parsed
+  - HTTP [HTTPSettings]: how requests are made, which the header and http
+    statements change
+  - TokenTree [[]Token]: a "tree" of every token that has been tokenised,
+    which is a glorified list of tokens
+  - ScriptName [string]: the full path to the script being run
+  - ModeAllowExec [bool]: whether we will allow the execute statements
+  - ExecRules [[]ExecRule]: the commands that the execute statement can run,
+    every command if there are none
+  - Audit [io.Writer]: where the commands that are allowed or denied are
+    logged, nil for nowhere
+  - ModeCheck [bool]: whether we are checking the script for problems
+  - ModeDev [bool]: whether we are in developer mode
+  - ModeDiagnostics [bool]: whether warnings are held for diagnostics rather
+    than printed
+  - ModeDryRun [bool]: whether we are printing a plan rather than touching
+    the file system or network
+  - ModeVerbose [bool]: whether we are verbose with our output
+  - ModeWarnUndefined [bool]: whether a variable that hasn't been set is a
+    warning rather than an error
+  - ShebangPresent [bool]: whether the script has a shebang line. This is
+    necessary for the minver statement.
+  - StatementNames [[]string]: the valid statement names
+  - MaxIterations [int]: the number of times that a script can loop before
+    it is stopped
+  - DownloadRetries [int]: the number of times that a download that stopped
+    is tried again
+  - Procedures [map[string]*Script]: the procedures that have been defined
+    with the define statement
+  - Warnings [[]*ScriptError]: the warnings that have been raised
+  - Debugger [Debugger]: what is told about each statement before it is
+    executed (eg. the debugger for the -debug flag), nil if nothing is
+  - Stdout, Stdin, and Stderr: where output, input, and errors go
+*/
+type Interpreter struct {
+	Variables         map[string]Value
+	Environment       map[string]string
+	Arguments         []string
+	Parameters        []Parameter
+	HTTP              HTTPSettings
+	TokenTree         []Token
+	ScriptName        string
+	ModeAllowExec     bool
+	ExecRules         []ExecRule
+	Audit             io.Writer
+	ModeCheck         bool
+	ModeDev           bool
+	ModeDiagnostics   bool
+	ModeDryRun        bool
+	ModeVerbose       bool
+	ModeWarnUndefined bool
+	ShebangPresent    bool
+	StatementNames    []string
+	MaxIterations     int
+	DownloadRetries   int
+	Procedures        map[string]*Script
+	Warnings          []*ScriptError
+	Debugger          Debugger
+	Stdout            io.Writer
+	Stdin             io.Reader
+	Stderr            io.Writer
+	/*
+		The reader for the ask statement. This is kept for the life of the
+		interpreter so that any input that has been buffered but not yet used
+		isn't lost between ask statements.
+	*/
+	input_reader *bufio.Reader
+	/*
+		Hold the scripts that are currently being parsed. This is used to stop
+		a script that runs itself (directly or otherwise) from being parsed
+		forever.
+	*/
+	scripts_being_parsed []string
+	/*
+		Hold where each procedure was defined (ie. the script and line) so
+		that a procedure that is defined twice can be reported.
+	*/
+	procedure_origins map[string]string
+	/*
+		Hold the call statements that have been parsed, along with the script
+		that each is in, so that, once the whole script is parsed, they can be
+		checked against the procedures.
+	*/
+	calls_to_check []call_to_check
+	// Hold how many procedures deep the script is
+	call_depth int
+	/*
+		Hold how many scripts deep the statement being executed is (ie. the
+		script, then any procedure or script that it runs, and so on)
+	*/
+	execute_depth int
+	// Hold the problems that have been found when checking a script
+	problems []error
+	/*
+		Hold how long to wait before a download is first tried again, which
+		doubles with each attempt (see DownloadRetryDelay())
+	*/
+	download_retry_delay time.Duration
+	/*
+		Hold the environment variables that the interpreter was created with,
+		before the script could change them with setenv, so that the programs
+		that the exec policy allows are found on the PATH that was trusted
+		(see ResolveProgram())
+	*/
+	start_environment map[string]string
+}
+
+/*
+Create a new interpreter. Parameters include the options to create the
+interpreter with. Returns the interpreter.
+*/
+func New(options Options) *Interpreter {
+	// Create the interpreter with its own set of variables
+	interpreter := &Interpreter{
+		Variables:         ReservedVariables(),
+		Environment:       ProcessEnvironment(),
+		Arguments:         options.Arguments,
+		ModeAllowExec:     options.AllowExec || len(options.ExecRules) > 0,
+		ExecRules:         options.ExecRules,
+		Audit:             options.Audit,
+		ModeCheck:         options.Check,
+		ModeDev:           options.Dev,
+		ModeDiagnostics:   options.Diagnostics,
+		ModeDryRun:        options.DryRun,
+		ModeVerbose:       options.Verbose,
+		ModeWarnUndefined: options.WarnUndefined,
+		MaxIterations:     options.MaxIterations,
+		DownloadRetries:   max(options.DownloadRetries, 0),
+		Procedures:        map[string]*Script{},
+		Stdout:            options.Stdout,
+		Stdin:             options.Stdin,
+		Stderr:            options.Stderr,
+
+		procedure_origins:    map[string]string{},
+		download_retry_delay: time.Second,
+	}
+
+	// Add any environment variables that were passed
+	maps.Copy(interpreter.Environment, options.Environment)
+	interpreter.start_environment = maps.Clone(interpreter.Environment)
+	// Fill in the HTTP settings that weren't passed from the environment
+	interpreter.HTTP = options.HTTP.WithDefaults(interpreter.Environment)
+
+	// Default to the standard input and outputs where none were passed
+	if interpreter.Stdout == nil {
+		interpreter.Stdout = os.Stdout
+	}
+	if interpreter.Stdin == nil {
+		interpreter.Stdin = os.Stdin
+	}
+	if interpreter.Stderr == nil {
+		interpreter.Stderr = os.Stderr
+	}
+	// Default to the standard number of iterations where none was passed
+	if interpreter.MaxIterations <= 0 {
+		interpreter.MaxIterations = DEFAULT_MAX_ITERATIONS
+	}
+
+	/* Create a reader to get the input from user. Create a buffer size of
+	65,536 bytes which doesn't seem to be acknowledged by any operating
+	system. See issue #1 on GitHub for more. Leave this as-is though as
+	it does allow for some extra space for input on platforms such as
+	Windows. This is also potentially an issue with stdin limitations on
+	any one given platform.
+	*/
+	interpreter.input_reader = bufio.NewReaderSize(interpreter.Stdin, 65536)
+
+	/*
+		Here, we consolidate the keys from the statement map and set the
+		StatementNames to the sorted list of keys. Thanks to
+		https://stackoverflow.com/q/21362950.
+	*/
+	interpreter.StatementNames = slices.Sorted(
+		maps.Keys(interpreter.StatementMap()),
+	)
+
+	// Return the interpreter
+	return interpreter
+}
+
+/*
+Run a script file. The file name is made absolute and set as the ScriptName so
+that it can be accessed in places such as the reserved variables. Parameters
+include the file name of the script. Returns an error if the script failed.
+*/
+func (interpreter *Interpreter) RunFile(file_name string) error {
+	/*
+		Set the script name, by default, to include the full path to the
+		script.
+	*/
+	interpreter.ScriptName, _ = filepath.Abs(file_name)
+	// Prep the script by opening it and removing the comments
+	contents, prep_error := PrepScript(interpreter.ScriptName)
+	if prep_error != nil {
+		return prep_error
+	}
+	// Start the script
+	return interpreter.Start(contents)
+}
+
+/*
+Run a script held in a string. The ScriptName is left as it is so it can be
+set beforehand where the reserved variables need it. Parameters include the
+contents of the script. Returns an error if the script failed.
+*/
+func (interpreter *Interpreter) RunString(contents string) error {
+	// Split the script into lines and remove the comments
+	lines := RemoveComments(strings.Split(contents, "\n"))
+	// Start the script
+	return interpreter.Start(lines)
+}
+
+/*
+Raise a warning. A warning doesn't stop the script so it is held onto with the
+interpreter's other warnings and, unless they are being reported as
+diagnostics, printed straight away. Parameters include the warning. Returns
+nothing.
+*/
+func (interpreter *Interpreter) Warning(warning *ScriptError) {
+	interpreter.Warnings = append(interpreter.Warnings, warning)
+	if !interpreter.ModeDiagnostics {
+		PrintWarning(interpreter.Stderr, warning)
+	}
+}
+
+/*
+Read a line of input from the same reader that the ask statement uses so that
+input that is read by something else (eg. the REPL) isn't lost to the ask
+statement's buffer or the other way around. No parameters. Returns the line,
+without its line ending, and an error if there was nothing more to read.
+*/
+func (interpreter *Interpreter) ReadLine() (string, error) {
+	line, read_error := interpreter.input_reader.ReadString('\n')
+	// A last line without a new line is still a line
+	if read_error != nil && line != "" {
+		read_error = nil
+	}
+	return strings.TrimRight(line, "\r\n"), read_error
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that RunString() runs a script and that the output is
+written to the writer that the interpreter was created with.
+*/
+func TestRunString(t *testing.T) {
+	// Create an interpreter that writes to a buffer
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output})
+
+	// Run a script that sets a variable and writes it out
+	run_error := interpreter.RunString(
+		"- Greet the world\nset name = \"World\"\nwriteln \"Hello #name!\"",
+	)
+	if run_error != nil {
+		t.Fatalf("[RunString] Expected no error, got %v", run_error)
+	}
+
+	// Check the output
+	if output.String() != "Hello World!\n" {
+		t.Errorf("[RunString] Expected %q, got %q",
+			"Hello World!\n",
+			output.String())
+	}
+}
+
+/*
+Check to make sure that two interpreters don't share any state, that is,
+setting a variable in one doesn't set it in the other.
+*/
+func TestInterpretersAreIndependent(t *testing.T) {
+	// Create two interpreters that write to their own buffers
+	var output_one, output_two bytes.Buffer
+	interpreter_one := New(Options{Stdout: &output_one})
+	interpreter_two := New(Options{Stdout: &output_two})
+
+	// Set a variable in the first interpreter only
+	interpreter_one.RunString("set name = \"One\"\nwriteln \"#name\"")
+	run_error := interpreter_two.RunString("writeln \"#name\"")
+
+	// The first interpreter should have the variable
+	if output_one.String() != "One\n" {
+		t.Errorf("[New] Expected %q, got %q",
+			"One\n",
+			output_one.String())
+	}
+
+	// The second interpreter should not have the variable
+	if _, exists := interpreter_two.Variables["name"]; exists {
+		t.Errorf("[New] Expected the second interpreter not to have %s",
+			"name")
+	}
+	// So using it is an error
+	if run_error == nil || output_two.String() != "" {
+		t.Errorf("[New] Expected an undefined variable error, got %q (%v)",
+			output_two.String(),
+			run_error)
+	}
+}
+
+/*
+Check to make sure that the ask statement reads its input from the reader that
+the interpreter was created with.
+*/
+func TestAskReadsFromStdin(t *testing.T) {
+	// Create an interpreter that reads from a string and writes to a buffer
+	var output bytes.Buffer
+	interpreter := New(Options{
+		Stdout: &output,
+		Stdin:  strings.NewReader("Bob\n"),
+	})
+
+	// Ask for a name and write it out
+	run_error := interpreter.RunString(
+		"ask \"Name: \" to name\nwriteln \"Hello #name!\"",
+	)
+	if run_error != nil {
+		t.Fatalf("[Ask] Expected no error, got %v", run_error)
+	}
+
+	// Check the output
+	if output.String() != "Name: Hello Bob!\n" {
+		t.Errorf("[Ask] Expected %q, got %q",
+			"Name: Hello Bob!\n",
+			output.String())
+	}
+}
@@ -0,0 +1,1857 @@
+/*
+The statement checks house the syntax checks for each statement. These are
+run over the whole script by Parse() before any statement is executed so that
+a malformed line of code is caught before anything has happened. In this way,
+a statement function can assume that the tokens that it is handed are well
+formed and only needs to deal with problems that arise while it is executing.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/*
+Check a tokenised line of code. This checks that the statement is a valid
+statement and then delegates to the statement specific check. Parameters
+include tokens, the tokenised line of code. Returns an error if the line of
+code is malformed.
+*/
+func (interpreter *Interpreter) CheckStatement(tokens []Token) error {
+	// Create a map of statements and their associated checks
+	statement_checks := map[string]func([]Token) error{
+		"ask":             interpreter.CheckAsk,
+		"call":            interpreter.CheckCall,
+		"convert":         interpreter.CheckConvert,
+		"copydirectory":   interpreter.CheckCopyPath,
+		"copyfile":        interpreter.CheckCopyFile,
+		"define":          interpreter.CheckDefine,
+		"deletedirectory": interpreter.CheckDeletePath,
+		"deletefile":      interpreter.CheckDeleteFile,
+		"download":        interpreter.CheckDownload,
+		"downloadall":     interpreter.CheckDownloadAll,
+		"end":             interpreter.CheckEnd,
+		"execute":         interpreter.CheckExecuteCommand,
+		"exit":            interpreter.CheckExit,
+		"fetch":           interpreter.CheckRequest,
+		"goto":            interpreter.CheckGoto,
+		"header":          interpreter.CheckHeader,
+		"http":            interpreter.CheckHTTPSetting,
+		"if":              interpreter.CheckIf,
+		"label":           interpreter.CheckLabel,
+		"log":             interpreter.CheckLog,
+		"makedirectory":   interpreter.CheckCreatePath,
+		"makefile":        interpreter.CheckMakeFile,
+		"minver":          interpreter.CheckMinVer,
+		"movedirectory":   interpreter.CheckMovePath,
+		"movefile":        interpreter.CheckMoveFile,
+		"params":          interpreter.CheckParams,
+		"pause":           interpreter.CheckPause,
+		"post":            interpreter.CheckRequest,
+		"put":             interpreter.CheckRequest,
+		"repeat":          interpreter.CheckRepeat,
+		"run":             interpreter.CheckRun,
+		"set":             interpreter.CheckSet,
+		"setenv":          interpreter.CheckSetEnv,
+		"unsetenv":        interpreter.CheckUnsetEnv,
+		"untar":           interpreter.CheckExtract,
+		"unzip":           interpreter.CheckExtract,
+		"write":           interpreter.CheckWriteln,
+		"writeln":         interpreter.CheckWriteln,
+		"zipdirectory":    interpreter.CheckZipFromPath,
+		"zipfile":         interpreter.CheckZipFromFile,
+	}
+
+	// Get the statement name
+	stmt_name := tokens[1].TokenValue
+	// If the statement isn't valid, report back a list of valid statements
+	if !interpreter.CheckIsStatement(stmt_name) {
+		return Report(
+			"The statement passed - "+utils.ColouriseYellow(stmt_name)+
+				" - is not a valid statement. Valid statements "+
+				"include "+interpreter.ListStatements()+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[1].TokenPosition,
+			tokens[0].FullLineOfCode,
+		).WithRule("syntax/unknown-statement")
+	}
+
+	// Run the statement specific check
+	if check_stmt, exists := statement_checks[stmt_name]; exists {
+		return check_stmt(tokens)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+/*
+A helper to check an action keyword at a particular token index. Parameters
+include the tokens and the index of the action token. Returns an error if the
+action is invalid.
+*/
+func (interpreter *Interpreter) CheckActionToken(
+	tokens []Token, index int) error {
+	// Check the action keyword to ensure that it's valid
+	action_error := CheckAction(
+		strconv.Itoa(tokens[0].LineNumber),
+		tokens[index].TokenValue,
+	)
+	if action_error != nil {
+		return Report(
+			action_error.Error(),
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[index].TokenPosition,
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+/*
+A helper to check the statement that an if or repeat statement runs. The
+label, define, and end statements can't be run this way as they shape the
+script itself and need to be on a line of their own. Neither can the goto
+statement as it has its own condition. Parameters include the tokens and the
+index of the keyword before the statement. Returns an error if the statement
+is malformed.
+*/
+func (interpreter *Interpreter) CheckNestedStatement(
+	tokens []Token, keyword_index int) error {
+	// Get the tokens of the statement
+	nested_tokens := NestedStatementTokens(tokens, keyword_index)
+	// Get the statement name
+	stmt_name := nested_tokens[1].TokenValue
+	// If the statement shapes the script, report an error
+	if stmt_name == "label" || stmt_name == "define" || stmt_name == "end" {
+		return Report(
+			"The "+utils.ColouriseCyan(stmt_name)+" statement can't be "+
+				"run by the "+utils.ColouriseCyan(tokens[1].TokenValue)+
+				" statement. It needs to be on a line of its own.",
+			strconv.Itoa(tokens[0].LineNumber),
+			nested_tokens[1].TokenPosition,
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If the statement is a goto statement, report an error
+	if stmt_name == "goto" {
+		return Report(
+			"The "+utils.ColouriseCyan(stmt_name)+" statement can't be "+
+				"run by the "+utils.ColouriseCyan(tokens[1].TokenValue)+
+				" statement. If you want to go to a label only when a "+
+				"condition is met, use "+utils.ColouriseCyan("goto")+" "+
+				utils.ColouriseGreen("\"[label]\"")+" "+SYMBOL_CONDITION_IF+
+				" "+utils.ColouriseYellow("[condition]")+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			nested_tokens[1].TokenPosition,
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// Check the statement like any other
+	return interpreter.CheckStatement(nested_tokens)
+}
+
+/*
+A helper to check that a variable name is one that can be assigned to, that is,
+it doesn't use the reserved variable prefix and it doesn't conflict with a
+statement name. Parameters include the tokens, the variable name, and the
+index of the token that holds the variable name. Returns an error if the
+variable can't be assigned to.
+*/
+func (interpreter *Interpreter) CheckAssignableVariable(
+	tokens []Token, variable_name string, index int) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+
+	// Hold the (possible) prefix for checking
+	var variable_prefix string
+	// If the length of the variable is less than the RESERVED_VARIABLE_PREFIX
+	if len(variable_name) < len(SYMBOL_RESERVED_VARIABLE_PREFIX) {
+		// Just set the prefix to the variable
+		variable_prefix = string(variable_name)
+	} else {
+		// Otherwise, create a prefix to check against
+		variable_prefix = string(
+			variable_name[0:len(SYMBOL_RESERVED_VARIABLE_PREFIX)],
+		)
+	}
+
+	// Check the variable prefix
+	var_prefix_error := CheckVariablePrefix(
+		loc, variable_prefix, variable_name)
+	if var_prefix_error != nil {
+		return ReportWithFixes(
+			var_prefix_error.Error(),
+			loc,
+			tokens[index].TokenPosition,
+			full_loc,
+		)
+	}
+
+	// Check that the variable name is not one of the statement names
+	if interpreter.CheckIsStatement(variable_name) {
+		return ReportWithFixes(
+			"The variable - "+utils.ColouriseYellow(variable_name)+" - "+
+				"is not a valid variable name as it conflicts with a statement "+
+				"name.",
+			loc,
+			tokens[index].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check an ask statement call.
+func (interpreter *Interpreter) CheckAsk(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Whether the answer is converted or calculated (eg. as integer)
+	is_answer_as := len(tokens) > 6
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	if is_answer_as {
+		_, err = CheckValidNumberOfTokens(tokens, 6)
+	}
+	// If not a valid number of tokens, report an error
+	if err != nil || (is_answer_as && tokens[5].TokenValue != "as") {
+		return Report(
+			"The "+utils.ColouriseCyan("ask")+" statement needs "+
+				"to follow the form:\n\n\t"+utils.ColouriseCyan("ask")+" "+
+				utils.ColouriseGreen("\"[question/prompt]\"")+
+				utils.ColouriseMagenta(" to ")+
+				utils.ColouriseYellow("\"[variable name]\"")+"\n\nThe "+
+				"answer is a string unless the statement ends with "+
+				utils.ColouriseMagenta("as [type]")+" to convert it or "+
+				utils.ColouriseMagenta("as "+ANSWER_EXPRESSION)+" to "+
+				"calculate it.\n\nAn example "+
+				"of a working version check might be:\n\n\t"+
+				utils.ColouriseCyan("ask")+" "+
+				utils.ColouriseGreen("\"What is your name?\"")+
+				utils.ColouriseMagenta(" to ")+
+				utils.ColouriseGreen("\"name\"")+"\n\n"+
+				"Your line of code looks like the following:\n\n\t"+
+				utils.ColouriseRed(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the action keyword
+	action_error := interpreter.CheckActionToken(tokens, 3)
+	if action_error != nil {
+		return action_error
+	}
+	// Check the variable name that the answer will be saved to
+	variable_error := interpreter.CheckAssignableVariable(
+		tokens, FixStringCombined(tokens[4].TokenValue), 4)
+	if variable_error != nil {
+		return variable_error
+	}
+	// Check that the answer is converted to a type or calculated
+	if !is_answer_as || tokens[6].TokenValue == ANSWER_EXPRESSION {
+		return nil
+	}
+	if _, is_type := ValueTypeNamed(tokens[6].TokenValue); !is_type {
+		return Report(
+			"The answer can't be converted to "+
+				utils.ColouriseYellow(tokens[6].TokenValue)+". It can be "+
+				"calculated with "+
+				utils.ColouriseMagenta("as "+ANSWER_EXPRESSION)+" or "+
+				"converted to one of the types:"+ListValueTypes(),
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[6].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a call statement call.
+func (interpreter *Interpreter) CheckCall(tokens []Token) error {
+	// Check the form of the call statement
+	procedure_call, call_error := ParseProcedureCall(tokens)
+	if call_error != nil {
+		return call_error
+	}
+	// Check the variable names of the arguments
+	for _, argument := range procedure_call.Arguments {
+		variable_error := interpreter.CheckAssignableVariable(
+			[]Token{tokens[0], argument.Name},
+			argument.Name.TokenValue, 1)
+		if variable_error != nil {
+			return variable_error
+		}
+	}
+	// Check the variable names of the outputs
+	for _, output := range procedure_call.Returns {
+		variable_error := interpreter.CheckAssignableVariable(
+			[]Token{tokens[0], output}, output.TokenValue, 1)
+		if variable_error != nil {
+			return variable_error
+		}
+	}
+	/*
+		If we've gotten here, the statement is well formed. Whether the
+		procedure exists is checked once the whole script has been parsed.
+	*/
+	return nil
+}
+
+// Check a convert statement call.
+func (interpreter *Interpreter) CheckConvert(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("convert")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("convert")+" "+
+				utils.ColouriseYellow("[variable name]")+" to "+
+				utils.ColouriseMagenta("[type]")+". An example of a "+
+				"working version might be "+utils.ColouriseCyan("convert")+
+				" count to "+utils.ColouriseMagenta("integer")+"\n\n"+
+				"Line of Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the variable name being converted
+	variable_error := interpreter.CheckAssignableVariable(
+		tokens, FixStringCombined(tokens[2].TokenValue), 2)
+	if variable_error != nil {
+		return variable_error
+	}
+	// Check the action keyword
+	action_error := interpreter.CheckActionToken(tokens, 3)
+	if action_error != nil {
+		return action_error
+	}
+	// Check that the type is one that a value can be converted to
+	if _, is_type := ValueTypeNamed(tokens[4].TokenValue); !is_type {
+		return Report(
+			"The type - "+utils.ColouriseYellow(tokens[4].TokenValue)+
+				" - is not a type that a variable can be converted to. The "+
+				"types are:"+ListValueTypes(),
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[4].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a copyfile statement call.
+func (interpreter *Interpreter) CheckCopyFile(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("copyfile")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("copyfile")+" "+
+				utils.ColouriseGreen("\"[path]\"")+" to "+
+				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
+				"use of an inappropriate action symbol ("+
+				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
+				"example of a working version might be "+
+				utils.ColouriseCyan("copyfile")+
+				utils.ColouriseGreen(" \"test.txt\"")+" to "+
+				utils.ColouriseGreen(" \"test_new.txt\"")+"\n\nLine of "+
+				"Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the action keyword
+	return interpreter.CheckActionToken(tokens, 3)
+}
+
+// Check a copydirectory statement call.
+func (interpreter *Interpreter) CheckCopyPath(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, token_err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if token_err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("copydirectory")+
+				" statement needs to follow the form "+
+				utils.ColouriseCyan("copydirectory")+
+				utils.ColouriseGreen(" \"[path]\"")+" to "+
+				utils.ColouriseGreen("\"[path]\"")+". A common issue "+
+				"is the  use of an inappropriate action symbol ("+
+				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
+				"example of a working version might be "+
+				utils.ColouriseCyan("copydirectory")+
+				utils.ColouriseGreen(" \"test_dir\"")+" to "+
+				utils.ColouriseGreen(" \"new_dir\""),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// Check the action keyword
+	return interpreter.CheckActionToken(tokens, 3)
+}
+
+// Check a define statement call.
+func (interpreter *Interpreter) CheckDefine(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// A define statement either has just a name or a name and a file
+	is_block := len(tokens) == 3
+	is_file := len(tokens) == 5 &&
+		tokens[3].TokenValue == SYMBOL_DEFINE_FROM
+	// If not a valid form, report an error
+	if !is_block && !is_file {
+		return Report(
+			"The "+utils.ColouriseCyan("define")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("define")+" "+
+				utils.ColouriseGreen("\"[name]\"")+" "+SYMBOL_DEFINE_FROM+
+				" "+utils.ColouriseGreen("\"[path]\"")+" or start a block "+
+				"of statements that is ended with "+
+				utils.ColouriseCyan("end")+":\n\n\t"+
+				utils.ColouriseCyan("define")+" "+
+				utils.ColouriseGreen("\"[name]\"")+"\n\t\t"+
+				utils.ColouriseYellow("[statements]")+"\n\t"+
+				utils.ColouriseCyan("end")+"\n\nAn example of a working "+
+				"version might be "+utils.ColouriseCyan("define")+
+				utils.ColouriseGreen(" \"backup\"")+" from"+
+				utils.ColouriseGreen(" \"lib/backup.apt\"")+"\n\nLine of "+
+				"Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a makedirectory statement call.
+func (interpreter *Interpreter) CheckCreatePath(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("makedirectory")+" statement "+
+				"needs to follow the form "+
+				utils.ColouriseCyan("makedirectory")+" "+
+				utils.ColouriseYellow("\"[path]\"")+". A common error here "+
+				"is trying to concatenate multiple values into one statement "+
+				"call here. An example of a working version might be "+
+				utils.ColouriseCyan("makedirectory ")+
+				utils.ColouriseGreen("\"test_dir\"")+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a deletefile statement call.
+func (interpreter *Interpreter) CheckDeleteFile(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("deletefile")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("deletefile")+" "+
+				utils.ColouriseGreen("\"[path]\"")+". An example of a working "+
+				"version might be "+utils.ColouriseCyan("deletefile")+
+				utils.ColouriseGreen(" \"test.txt\"")+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a deletedirectory statement call.
+func (interpreter *Interpreter) CheckDeletePath(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("deletedirectory")+" statement "+
+				"needs to follow the form "+
+				utils.ColouriseCyan("deletedirectory")+" "+
+				utils.ColouriseYellow("\"[path]\"")+". A common error here "+
+				"is trying to concatenate multiple values into one statement "+
+				"call here. An example of a working version might be "+
+				utils.ColouriseCyan("deletedirectory ")+
+				utils.ColouriseGreen("\"test_dir\"")+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a download statement call.
+func (interpreter *Interpreter) CheckDownload(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	/*
+		The path can be followed by clauses, each a keyword and a value, in
+		any order (eg. verify "sha256:[hash]" retries 5).
+	*/
+	if len(tokens) < 5 || (len(tokens)-5)%2 != 0 {
+		return Report(
+			"The "+utils.ColouriseCyan("download")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("download")+" "+
+				utils.ColouriseGreen("\"[url]\"")+" to "+
+				utils.ColouriseGreen("\"[path]\"")+" followed by any of "+
+				utils.ColouriseMagenta(DOWNLOAD_CLAUSE_VERIFY)+" "+
+				utils.ColouriseGreen("\"[algorithm]:[hash]\"")+" (or the "+
+				"URL of a checksum file) and "+
+				utils.ColouriseMagenta(DOWNLOAD_CLAUSE_RETRIES)+" "+
+				utils.ColouriseYellow("[number]")+". An example of a "+
+				"working version might be "+utils.ColouriseCyan("download")+
+				utils.ColouriseGreen(" \"http://file.com/file.txt\"")+" to"+
+				utils.ColouriseGreen(" \"#b_home/file.txt\"")+".",
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the action keyword to ensure that it's valid
+	action_error := CheckAction(loc, tokens[3].TokenValue)
+	/* If the action is not a valid action keyword (ie. "to"), report back the
+	error
+	*/
+	if action_error != nil {
+		return ReportWithFixes(
+			action_error.Error(),
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+	// Check the clauses
+	seen_clauses := map[string]bool{}
+	for index := 5; index < len(tokens); index += 2 {
+		clause := tokens[index].TokenValue
+		value := tokens[index+1]
+		switch {
+		case !slices.Contains(DownloadClauses(), clause):
+			return Report(
+				"The clause - "+utils.ColouriseYellow(clause)+" - is not "+
+					"valid after the path. Valid clauses include "+
+					strings.Join(DownloadClauses(), ", ")+".",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case seen_clauses[clause]:
+			return Report(
+				"The clause "+utils.ColouriseMagenta(clause)+" can only be "+
+					"used once.",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case clause == DOWNLOAD_CLAUSE_VERIFY &&
+			!strings.HasPrefix(value.TokenValue, "\""):
+			return Report(
+				"The "+utils.ColouriseMagenta(clause)+" clause needs to be "+
+					"followed by a string in double quotes (eg. "+clause+
+					" "+utils.ColouriseGreen("\"sha256:[hash]\"")+").",
+				loc,
+				value.TokenPosition,
+				full_loc,
+			)
+		case clause == DOWNLOAD_CLAUSE_VERIFY:
+			/*
+				A checksum that is written out in full can be checked now
+				whereas one that uses a variable or is in a checksum file
+				can only be checked once the file is downloaded
+			*/
+			checksum := FixStringCombined(value.TokenValue)
+			if strings.Contains(checksum, SYMBOL_VARIABLE_SUBSTITUTION) ||
+				strings.HasPrefix(checksum, "http://") ||
+				strings.HasPrefix(checksum, "https://") {
+				break
+			}
+			_, _, checksum_error := ParseChecksum(checksum)
+			if checksum_error != nil {
+				return Report(
+					"The checksum "+utils.ColouriseYellow(checksum)+" can't "+
+						"be used as "+checksum_error.Error()+".",
+					loc,
+					value.TokenPosition,
+					full_loc,
+				).WithErr(checksum_error)
+			}
+		case clause == DOWNLOAD_CLAUSE_RETRIES:
+			number_error := CheckWholeNumberClause(
+				tokens, value, 0, "number of retries")
+			if number_error != nil {
+				return number_error
+			}
+		}
+		seen_clauses[clause] = true
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a downloadall statement call.
+func (interpreter *Interpreter) CheckDownloadAll(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	/*
+		The directory can be followed by clauses, each a keyword and a
+		value, in any order (eg. parallel 8 retries 5).
+	*/
+	if len(tokens) < 5 || (len(tokens)-5)%2 != 0 {
+		return Report(
+			"The "+utils.ColouriseCyan("downloadall")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("downloadall")+" "+
+				utils.ColouriseGreen("\"[list file or list variable]\"")+
+				" to "+utils.ColouriseGreen("\"[directory]\"")+" followed "+
+				"by any of "+
+				utils.ColouriseMagenta(DOWNLOADALL_CLAUSE_PARALLEL)+" "+
+				utils.ColouriseYellow("[number]")+" and "+
+				utils.ColouriseMagenta(DOWNLOAD_CLAUSE_RETRIES)+" "+
+				utils.ColouriseYellow("[number]")+". An example of a "+
+				"working version might be "+
+				utils.ColouriseCyan("downloadall")+
+				utils.ColouriseGreen(" \"artefacts.txt\"")+" to"+
+				utils.ColouriseGreen(" \"#b_home/artefacts\"")+" parallel "+
+				utils.ColouriseYellow("4")+".",
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the action keyword to ensure that it's valid
+	action_error := CheckAction(loc, tokens[3].TokenValue)
+	/* If the action is not a valid action keyword (ie. "to"), report back the
+	error
+	*/
+	if action_error != nil {
+		return ReportWithFixes(
+			action_error.Error(),
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+	// Check the clauses
+	seen_clauses := map[string]bool{}
+	for index := 5; index < len(tokens); index += 2 {
+		clause := tokens[index].TokenValue
+		switch {
+		case !slices.Contains(DownloadAllClauses(), clause):
+			return Report(
+				"The clause - "+utils.ColouriseYellow(clause)+" - is not "+
+					"valid after the directory. Valid clauses include "+
+					strings.Join(DownloadAllClauses(), ", ")+".",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case seen_clauses[clause]:
+			return Report(
+				"The clause "+utils.ColouriseMagenta(clause)+" can only be "+
+					"used once.",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case clause == DOWNLOADALL_CLAUSE_PARALLEL:
+			number_error := CheckWholeNumberClause(tokens, tokens[index+1],
+				1, "number of files to download at once")
+			if number_error != nil {
+				return number_error
+			}
+		case clause == DOWNLOAD_CLAUSE_RETRIES:
+			number_error := CheckWholeNumberClause(
+				tokens, tokens[index+1], 0, "number of retries")
+			if number_error != nil {
+				return number_error
+			}
+		}
+		seen_clauses[clause] = true
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check an end statement call.
+func (interpreter *Interpreter) CheckEnd(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 1)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("end")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("end")+
+				" on a line of its own. There are no values that you can or "+
+				"need to pass.\n\nLine of Code: "+
+				utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+	/*
+		If we've gotten here, the statement is well formed. Whether there is
+		a define block to end is checked by Parse().
+	*/
+	return nil
+}
+
+// Check an execute statement call.
+func (interpreter *Interpreter) CheckExecuteCommand(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	/*
+		The command can be followed by clauses, each a keyword and a value, in
+		any order (eg. in "build" to "output" timeout 60).
+	*/
+	if len(tokens) < 3 || (len(tokens)-3)%2 != 0 {
+		return Report(
+			"The "+utils.ColouriseCyan("execute")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("execute")+" "+
+				utils.ColouriseGreen("\"[command]\"")+" followed by any of "+
+				utils.ColouriseMagenta(EXECUTE_CLAUSE_IN)+" "+
+				utils.ColouriseGreen("\"[directory]\"")+", "+
+				utils.ColouriseMagenta(EXECUTE_CLAUSE_TO)+" "+
+				utils.ColouriseYellow("\"[variable name]\"")+", and "+
+				utils.ColouriseMagenta(EXECUTE_CLAUSE_TIMEOUT)+" "+
+				utils.ColouriseYellow("[seconds]")+". A common "+
+				"issue here is excluding a command. An example of a working "+
+				"statement might be "+utils.ColouriseCyan("execute")+
+				utils.ColouriseGreen(" \"git status\"")+" in "+
+				utils.ColouriseGreen("\"#b_home/project\"")+" to "+
+				utils.ColouriseGreen("\"status\"")+"."+
+				"\n\nLine of Code: "+utils.ColouriseMagenta(full_loc),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the clauses
+	seen_clauses := map[string]bool{}
+	for index := 3; index < len(tokens); index += 2 {
+		clause := tokens[index].TokenValue
+		value := tokens[index+1]
+		switch {
+		case !slices.Contains(ExecuteClauses(), clause):
+			return Report(
+				"The clause - "+utils.ColouriseYellow(clause)+" - is not "+
+					"valid after the command. Valid clauses include "+
+					strings.Join(ExecuteClauses(), ", ")+".",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case seen_clauses[clause]:
+			return Report(
+				"The clause "+utils.ColouriseMagenta(clause)+" can only be "+
+					"used once.",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case clause != EXECUTE_CLAUSE_TIMEOUT &&
+			!strings.HasPrefix(value.TokenValue, "\""):
+			return Report(
+				"The "+utils.ColouriseMagenta(clause)+" clause needs to be "+
+					"followed by a string in double quotes (eg. "+clause+
+					" "+utils.ColouriseGreen("\"output\"")+").",
+				loc,
+				value.TokenPosition,
+				full_loc,
+			)
+		case clause == EXECUTE_CLAUSE_TO:
+			// Check the variable name that the output will be saved to
+			variable_error := interpreter.CheckAssignableVariable(
+				tokens, FixStringCombined(value.TokenValue), index+1)
+			if variable_error != nil {
+				return variable_error
+			}
+		case clause == EXECUTE_CLAUSE_TIMEOUT:
+			/*
+				A timeout that is written out in full can be checked now
+				whereas one that uses a variable can only be checked once the
+				statement runs
+			*/
+			seconds, is_literal, value_error := LiteralValue(tokens, value)
+			if value_error != nil {
+				return value_error
+			}
+			if is_literal {
+				_, timeout_error := TimeoutValue(tokens, value, seconds)
+				if timeout_error != nil {
+					return timeout_error
+				}
+			}
+		}
+		seen_clauses[clause] = true
+	}
+
+	/* Check if the -allowexec flag was passed to the app and if not, throw
+	an error. This is done here rather than when the command is executed so
+	that a script isn't left half run because of a missing flag. A dry run
+	doesn't execute anything so there is no need for the flag there.
+	*/
+	if !interpreter.ModeAllowExec && !interpreter.ModeDryRun {
+		return Report(
+			"You are unable to execute system commands.",
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[2].TokenPosition,
+			full_loc,
+		).WithCategory(ERROR_PERMISSION).WithHint(
+			"If you would like to execute system commands, you need to run " +
+				"with the " + utils.ColouriseYellow("-allowexec") + " flag, " +
+				"on its own to allow every command or with the commands to " +
+				"allow (eg. " + utils.ColouriseYellow("-allowexec=git,rsync") +
+				"), or with an exec policy file (eg. " +
+				utils.ColouriseYellow("-execpolicy=policy.txt") + ").",
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check an exit statement call.
+func (interpreter *Interpreter) CheckExit(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 1)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("exit")+" statement needs "+
+				"to follow the form:\n\n\t"+utils.ColouriseCyan("exit")+
+				"\n\nThere are no values that you can or need to pass which "+
+				"is most likely the cause here.\n\n"+
+				"Your line of code looks like the following:\n\n\t"+
+				utils.ColouriseRed(full_loc)+"\n\n",
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a fetch, post, or put statement call.
+func (interpreter *Interpreter) CheckRequest(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Get the statement and the clauses that it can have
+	statement_name := tokens[1].TokenValue
+	allowed_clauses := RequestClauses(RequestMethods()[statement_name])
+	/*
+		The URL can be followed by clauses, each a keyword and a value, in
+		any order (eg. with "[body]" as "application/json" to "response").
+	*/
+	clauses := ClauseTokens(tokens, 3)
+	_, has_to := clauses[REQUEST_CLAUSE_TO]
+	if len(tokens) < 3 || (len(tokens)-3)%2 != 0 ||
+		(statement_name == "fetch" && !has_to) {
+		form := utils.ColouriseCyan("fetch") + " " +
+			utils.ColouriseGreen("\"[url]\"") + " to " +
+			utils.ColouriseYellow("\"[variable name]\"") + ", optionally " +
+			"followed by " + utils.ColouriseMagenta(REQUEST_CLAUSE_EXPECT) +
+			" " + utils.ColouriseGreen("\"[statuses]\"")
+		example := utils.ColouriseCyan("fetch") +
+			utils.ColouriseGreen(" \"https://status.example.com/health\"") +
+			" to " + utils.ColouriseYellow("\"health\"")
+		if statement_name != "fetch" {
+			form = utils.ColouriseCyan(statement_name) + " " +
+				utils.ColouriseGreen("\"[url]\"") + " followed by any of " +
+				utils.ColouriseMagenta(REQUEST_CLAUSE_WITH) + " " +
+				utils.ColouriseGreen("\"[body]\"") + ", " +
+				utils.ColouriseMagenta(REQUEST_CLAUSE_AS) + " " +
+				utils.ColouriseGreen("\"[content type]\"") + ", " +
+				utils.ColouriseMagenta(REQUEST_CLAUSE_TO) + " " +
+				utils.ColouriseYellow("\"[variable name]\"") + ", and " +
+				utils.ColouriseMagenta(REQUEST_CLAUSE_EXPECT) + " " +
+				utils.ColouriseGreen("\"[statuses]\"")
+			example = utils.ColouriseCyan(statement_name) +
+				utils.ColouriseGreen(" \"https://hooks.example.com/backup\"") +
+				" with " + utils.ColouriseGreen("\"{\\\"done\\\": true}\"") +
+				" as " + utils.ColouriseGreen("\"application/json\"")
+		}
+		return Report(
+			"The "+utils.ColouriseCyan(statement_name)+" statement needs "+
+				"to follow the form "+form+". An example of a working "+
+				"version might be "+example+"."+
+				"\n\nLine of Code: "+utils.ColouriseMagenta(full_loc),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the clauses
+	seen_clauses := map[string]bool{}
+	for index := 3; index < len(tokens); index += 2 {
+		clause := tokens[index].TokenValue
+		value := tokens[index+1]
+		switch {
+		case !slices.Contains(allowed_clauses, clause):
+			return Report(
+				"The clause - "+utils.ColouriseYellow(clause)+" - is not "+
+					"valid after the URL. Valid clauses include "+
+					strings.Join(allowed_clauses, ", ")+".",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case seen_clauses[clause]:
+			return Report(
+				"The clause "+utils.ColouriseMagenta(clause)+" can only be "+
+					"used once.",
+				loc,
+				tokens[index].TokenPosition,
+				full_loc,
+			)
+		case !strings.HasPrefix(value.TokenValue, "\""):
+			return Report(
+				"The "+utils.ColouriseMagenta(clause)+" clause needs to be "+
+					"followed by a string in double quotes (eg. "+clause+
+					" "+utils.ColouriseGreen("\"response\"")+").",
+				loc,
+				value.TokenPosition,
+				full_loc,
+			)
+		case clause == REQUEST_CLAUSE_TO:
+			// Check the variable name that the response will be saved to
+			variable_error := interpreter.CheckAssignableVariable(
+				tokens, FixStringCombined(value.TokenValue), index+1)
+			if variable_error != nil {
+				return variable_error
+			}
+		case clause == REQUEST_CLAUSE_EXPECT:
+			// Statuses that use a variable can only be checked once they're set
+			expected := FixStringCombined(value.TokenValue)
+			if strings.Contains(expected, SYMBOL_VARIABLE_SUBSTITUTION) {
+				break
+			}
+			_, status_error := StatusExpected(expected, 0)
+			if status_error != nil {
+				return Report(
+					"The statuses "+utils.ColouriseYellow(expected)+" can't "+
+						"be expected as "+status_error.Error()+".",
+					loc,
+					value.TokenPosition,
+					full_loc,
+				).WithErr(status_error)
+			}
+		}
+		seen_clauses[clause] = true
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check an if statement call.
+func (interpreter *Interpreter) CheckIf(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Find the then keyword that ends the condition
+	then_index := FindConditionEnd(tokens)
+	/*
+		If there is no then keyword, no condition before it, or no statement
+		after it, report an error
+	*/
+	if then_index == -1 || then_index == 2 || then_index == len(tokens)-1 {
+		return Report(
+			"The "+utils.ColouriseCyan("if")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("if")+" "+
+				utils.ColouriseYellow("[condition]")+" then "+
+				utils.ColouriseYellow("[statement]")+". A common issue "+
+				"here is leaving out the "+
+				utils.ColouriseMagenta(SYMBOL_CONDITION_THEN)+" keyword. An "+
+				"example of a working version might be "+
+				utils.ColouriseCyan("if")+" file "+
+				utils.ColouriseGreen("\"test.txt\"")+" exists then "+
+				utils.ColouriseCyan("deletefile")+
+				utils.ColouriseGreen(" \"test.txt\"")+"\n\nLine of Code: "+
+				utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+
+	// Check the condition
+	_, condition_error := ParseCondition(tokens, 2, then_index)
+	if condition_error != nil {
+		return condition_error
+	}
+	// Check the statement that is run if the condition is met
+	return interpreter.CheckNestedStatement(tokens, then_index)
+}
+
+// Check a goto statement call.
+func (interpreter *Interpreter) CheckGoto(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	/*
+		A goto statement either has just a label or a label followed by the
+		if keyword and a condition.
+	*/
+	has_condition := len(tokens) > 4 &&
+		tokens[3].TokenValue == SYMBOL_CONDITION_IF
+	// If not a valid number of tokens, report an error
+	if len(tokens) != 3 && !has_condition {
+		return Report(
+			"The "+utils.ColouriseCyan("goto")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("goto")+" "+
+				utils.ColouriseGreen("\"[label]\"")+" or "+
+				utils.ColouriseCyan("goto")+" "+
+				utils.ColouriseGreen("\"[label]\"")+" "+SYMBOL_CONDITION_IF+
+				" "+utils.ColouriseYellow("[condition]")+". An example of a "+
+				"working version might be "+utils.ColouriseCyan("goto")+
+				utils.ColouriseGreen(" \"wait\"")+" if file "+
+				utils.ColouriseGreen("\"done.txt\"")+" does not exist"+
+				"\n\nLine of Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// If there is a condition, check it
+	if has_condition {
+		_, condition_error := ParseCondition(tokens, 4, len(tokens))
+		if condition_error != nil {
+			return condition_error
+		}
+	}
+	/*
+		If we've gotten here, the statement is well formed. Whether the label
+		exists is checked once the whole script has been parsed.
+	*/
+	return nil
+}
+
+// Check a header statement call.
+func (interpreter *Interpreter) CheckHeader(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("header")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("header")+" "+
+				utils.ColouriseYellow("\"[name]\"")+" = "+
+				utils.ColouriseGreen("\"[value]\"")+". An example of a "+
+				"working version might be "+utils.ColouriseCyan("header")+
+				utils.ColouriseYellow(" \"Authorization\"")+" = "+
+				utils.ColouriseGreen("\"Bearer #token\"")+"\n\n"+
+				"Line of Code: "+utils.ColouriseMagenta(full_loc),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the name of the header
+	header_name := FixStringCombined(tokens[2].TokenValue)
+	if !strings.HasPrefix(tokens[2].TokenValue, "\"") ||
+		!IsHeaderName(header_name) {
+		return Report(
+			"The header name - "+utils.ColouriseYellow(tokens[2].TokenValue)+
+				" - is not valid. The name needs to be in double quotes and "+
+				"be made up of letters, numbers, dashes, and underscores "+
+				"(eg. "+utils.ColouriseYellow("\"X-Api-Key\"")+").",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+	// Check for a valid assignment operator
+	assignment_error := CheckValidAssignment(loc, tokens[3].TokenValue)
+	if assignment_error != nil {
+		return ReportWithFixes(
+			assignment_error.Error(),
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check an http statement call.
+func (interpreter *Interpreter) CheckHTTPSetting(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("http")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("http")+" "+
+				utils.ColouriseYellow("[setting]")+" = "+
+				utils.ColouriseGreen("[value]")+" where the setting is one "+
+				"of "+strings.Join(HTTPSettingNames(), ", ")+". An example "+
+				"of a working version might be "+utils.ColouriseCyan("http")+
+				" timeout = "+utils.ColouriseGreen("60")+"\n\n"+
+				"Line of Code: "+utils.ColouriseMagenta(full_loc),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the setting
+	setting := tokens[2].TokenValue
+	if !slices.Contains(HTTPSettingNames(), setting) {
+		return Report(
+			"The setting - "+utils.ColouriseYellow(setting)+" - is not "+
+				"valid. Valid settings include "+
+				strings.Join(HTTPSettingNames(), ", ")+".",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+	// Check for a valid assignment operator
+	assignment_error := CheckValidAssignment(loc, tokens[3].TokenValue)
+	if assignment_error != nil {
+		return ReportWithFixes(
+			assignment_error.Error(),
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+	// Every setting but the timeout is a string
+	if setting != HTTP_SETTING_TIMEOUT &&
+		!strings.HasPrefix(tokens[4].TokenValue, "\"") {
+		return Report(
+			"The "+utils.ColouriseMagenta(setting)+" setting needs to be "+
+				"a string in double quotes (eg. http "+setting+" = "+
+				utils.ColouriseGreen("\"[value]\"")+").",
+			loc,
+			tokens[4].TokenPosition,
+			full_loc,
+		)
+	}
+	/*
+		A timeout that is written out in full can be checked now whereas one
+		that uses a variable can only be checked once the statement runs
+	*/
+	if setting == HTTP_SETTING_TIMEOUT {
+		seconds, is_literal, value_error := LiteralValue(tokens, tokens[4])
+		if value_error != nil {
+			return value_error
+		}
+		if is_literal {
+			_, timeout_error := TimeoutValue(tokens, tokens[4], seconds)
+			if timeout_error != nil {
+				return timeout_error
+			}
+		}
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a label statement call.
+func (interpreter *Interpreter) CheckLabel(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("label")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("label")+" "+
+				utils.ColouriseGreen("\"[name]\"")+". An example of a working "+
+				"version might be "+utils.ColouriseCyan("label")+
+				utils.ColouriseGreen(" \"wait\"")+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a log statement call.
+func (interpreter *Interpreter) CheckLog(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Check the number of tokens and ensure that it's a proper amount
+	_, num_tokens_error := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if num_tokens_error != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("log")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("log")+" "+
+				utils.ColouriseGreen("\"[message]\"")+" to "+
+				utils.ColouriseGreen("\"[path]\"")+". An example of a "+
+				"working version might be "+utils.ColouriseCyan("log")+
+				utils.ColouriseGreen(" \"The script is done\"")+" to"+
+				utils.ColouriseGreen(" \"script_log\"")+".",
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+
+	// Check the action keyword
+	action := tokens[3].TokenValue
+	if action != SYMBOL_ACTION {
+		return Report(
+			"An inapportiate action symbol is used. You used "+
+				utils.ColouriseMagenta(action)+" when you need to use "+
+				utils.ColouriseMagenta(SYMBOL_ACTION)+".",
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a makefile statement call.
+func (interpreter *Interpreter) CheckMakeFile(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("makefile")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("makefile")+" "+
+				utils.ColouriseGreen("\"[path]\"")+". An example of a working "+
+				"version might be "+utils.ColouriseCyan("makefile")+
+				utils.ColouriseGreen(" \"test.txt\"")+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a minver statement call.
+func (interpreter *Interpreter) CheckMinVer(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Check the number of tokens and ensure that it's a proper amount
+	_, token_number_err := CheckValidNumberOfTokens(tokens, 2)
+
+	// Hold the minimum version as a string and its position
+	min_ver_string := ""
+	min_ver_position := "n/a"
+	// Only pull out the version if there is a token to pull it from
+	if len(tokens) > 2 {
+		min_ver_string = tokens[2].TokenValue
+		min_ver_position = tokens[2].TokenPosition
+	}
+	// Get the minver set by the user as an integer for comparison
+	min_ver, int_conversion_err := strconv.Atoi(min_ver_string)
+	/* If there is an error trying to do the conversion or if the min_ver is
+	less than zero, report an error. This also captures negative integers
+	in particular as the negative sign and the integer are tokenised as
+	seperate tokens.
+	*/
+	if int_conversion_err != nil || min_ver <= 0 || token_number_err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("minver")+" statement needs to "+
+				"include a valid non-zero positive integer. A valid "+
+				utils.ColouriseCyan("minver")+" statement needs to follow the "+
+				"form:\n\t"+utils.ColouriseCyan("minver")+
+				utils.ColouriseYellow(" [version number]")+"\nAn example of a "+
+				"working version check might be:\n\t"+
+				utils.ColouriseCyan("minver")+utils.ColouriseYellow(" 3")+
+				"\nMake sure that you have none of the following for the "+
+				utils.ColouriseCyan("minver")+" statement value:\n\t"+
+				"- Negative number\n\t- Float (ie. decimal number)\n\t"+
+				"- String\n\t- No value\n",
+			loc,
+			min_ver_position,
+			full_loc,
+		)
+	}
+
+	/* Check if the minimum version is greater than or equal to the language
+	version.
+	*/
+	if min_ver > LANG_VERSION {
+		return Report(
+			"The script you're running here requires a newer version of "+
+				"the interpreter. You are running version "+
+				utils.ColouriseYellow(strconv.Itoa(LANG_VERSION))+
+				" but the script requires at least version "+
+				utils.ColouriseYellow(min_ver_string)+".",
+			loc,
+			min_ver_position,
+			full_loc,
+		).WithCategory(ERROR_VERSION).WithHint(
+			"Check to see if a newer version is available.",
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a movefile statement call.
+func (interpreter *Interpreter) CheckMoveFile(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("movefile")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("movefile")+" "+
+				utils.ColouriseGreen("\"[path]\"")+" to "+
+				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
+				"use of an inappropriate action symbol ("+
+				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
+				"example of a working version might be "+
+				utils.ColouriseCyan("movefile")+
+				utils.ColouriseGreen(" \"test.txt\"")+" to "+
+				utils.ColouriseGreen(" \"test_new.txt\"")+".",
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// Check the action keyword
+	return interpreter.CheckActionToken(tokens, 3)
+}
+
+// Check a movedirectory statement call.
+func (interpreter *Interpreter) CheckMovePath(tokens []Token) error {
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("movedirectory")+" statement "+
+				"needs to follow the form "+
+				utils.ColouriseCyan("movedirectory")+" "+
+				utils.ColouriseYellow("\"[path]\"")+" to "+
+				utils.ColouriseYellow("\"[path]\"")+". A common error here "+
+				"is trying to concatenate multiple values into one statement "+
+				"call here. An example of a working version might be "+
+				utils.ColouriseCyan("movedirectory ")+
+				utils.ColouriseGreen("\"test_dir\"")+" to "+
+				utils.ColouriseGreen("\"actual_dir\""),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// Check the action keyword
+	return interpreter.CheckActionToken(tokens, 3)
+}
+
+// Check a params statement call.
+func (interpreter *Interpreter) CheckParams(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	/*
+		The statement is the kind and name of the parameter followed by an
+		optional default (= value) and optional help text in quotes.
+	*/
+	has_default := len(tokens) > 4 && tokens[4].TokenValue == "="
+	help_index := 4
+	if has_default {
+		help_index = 6
+	}
+	is_help := func(index int) bool {
+		return strings.HasPrefix(tokens[index].TokenValue, "\"")
+	}
+	if len(tokens) < 4 || len(tokens) > help_index+1 ||
+		(has_default && len(tokens) < 6) ||
+		(len(tokens) == help_index+1 && !is_help(help_index)) {
+		return Report(
+			"The "+utils.ColouriseCyan("params")+" statement needs to "+
+				"follow the form "+utils.ColouriseCyan("params")+" "+
+				utils.ColouriseYellow("[argument/option/flag] [name]")+
+				" = "+utils.ColouriseGreen("[default]")+" "+
+				utils.ColouriseGreen("\"[help]\"")+" where the default "+
+				"and help are optional. An example of a working version "+
+				"might be "+utils.ColouriseCyan("params")+" option target = "+
+				utils.ColouriseGreen("\"/srv\"")+" "+
+				utils.ColouriseGreen("\"Where to copy to\"")+
+				"\n\nLine of Code: "+utils.ColouriseMagenta(full_loc),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the kind of parameter
+	kind := tokens[2].TokenValue
+	if !slices.Contains(ParameterKinds(), kind) {
+		return Report(
+			"The kind of parameter - "+utils.ColouriseYellow(kind)+" - is "+
+				"not valid. Valid kinds include "+
+				strings.Join(ParameterKinds(), ", ")+".",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+	// Check the name, which becomes part of the name of a variable
+	if !IsEnvironmentName(tokens[3].TokenValue) ||
+		strings.HasPrefix(tokens[3].TokenValue, "_") {
+		return Report(
+			"The parameter name - "+utils.ColouriseYellow(
+				tokens[3].TokenValue)+" - is not valid. The name needs to "+
+				"be made up of letters, numbers, and underscores and start "+
+				"with a letter (eg. "+utils.ColouriseYellow("dry_run")+
+				" for --dry-run).",
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+	// A flag is off unless it is passed so it can't have a default
+	if kind == PARAMETER_FLAG && has_default {
+		return Report(
+			"The flag "+utils.ColouriseYellow(tokens[3].TokenValue)+
+				" can't have a default as a flag is off unless it is passed.",
+			loc,
+			tokens[4].TokenPosition,
+			full_loc,
+		).WithHint("Use an " + utils.ColouriseCyan("option") + " for a " +
+			"parameter that takes a value.")
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a pause statement call.
+func (interpreter *Interpreter) CheckPause(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("pause")+" statement needs to "+
+				"follow the form "+utils.ColouriseCyan("pause")+" "+
+				utils.ColouriseYellow("[number of seconds]")+". A common "+
+				"issue here is excluding the number of seconds or passing "+
+				"them as a string (eg. "+utils.ColouriseGreen("\"3\"")+"). "+
+				"An example of a working version might be "+
+				utils.ColouriseCyan("pause")+utils.ColouriseYellow(" 3"),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+
+	// Get the length of the pause as a string
+	pause_as_string := tokens[2].TokenValue
+	// Create an integer version of the pause length
+	pause_int, err := strconv.Atoi(pause_as_string)
+	/* If there is an error trying to do the conversion or if the pause_int is
+	less than zero, report an error.
+	*/
+	if err != nil || pause_int < 0 {
+		return Report(
+			"The number of seconds "+utils.ColouriseYellow(pause_as_string)+
+				" is not valid. You need to use a positive integer.",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a repeat statement call.
+func (interpreter *Interpreter) CheckRepeat(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	/*
+		If there is no count, no times keyword, or no statement after it,
+		report an error
+	*/
+	if len(tokens) < 5 || tokens[3].TokenValue != SYMBOL_REPEAT_TIMES {
+		return Report(
+			"The "+utils.ColouriseCyan("repeat")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("repeat")+" "+
+				utils.ColouriseYellow("[number of times]")+" "+
+				SYMBOL_REPEAT_TIMES+" "+utils.ColouriseYellow("[statement]")+
+				". An example of a working version might be "+
+				utils.ColouriseCyan("repeat")+utils.ColouriseYellow(" 3")+
+				" times "+utils.ColouriseCyan("writeln")+
+				utils.ColouriseGreen(" \"Hello World\"")+"\n\nLine of Code: "+
+				utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+
+	// Fix the count
+	count := FixStringCombined(tokens[2].TokenValue)
+	/*
+		If the count doesn't rely on a variable, check it now as it won't
+		change between now and when the script is executed.
+	*/
+	if !strings.Contains(count, SYMBOL_VARIABLE_SUBSTITUTION) {
+		_, count_error := interpreter.CheckRepeatCount(tokens, count)
+		if count_error != nil {
+			return count_error
+		}
+	}
+	// Check the statement that is repeated
+	return interpreter.CheckNestedStatement(tokens, 3)
+}
+
+// Check a run statement call.
+func (interpreter *Interpreter) CheckRun(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("run")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("run")+
+				utils.ColouriseGreen(" \"[script]\"")+". An example of a "+
+				"working version check might be "+utils.ColouriseCyan("run")+
+				utils.ColouriseGreen(" \"other_script.apt\"")+"\n\n"+
+				"Line of Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a set statement call.
+func (interpreter *Interpreter) CheckSet(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Whether the value is a list (eg. ["a", "b"])
+	is_list := len(tokens) > 4 && tokens[4].TokenValue == SYMBOL_LIST_OPEN
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil && !is_list {
+		return Report(
+			"The "+utils.ColouriseCyan("set")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("set")+" "+
+				utils.ColouriseYellow("[variable name]")+" = "+
+				utils.ColouriseGreen("\"[value]\"")+". An example of a "+
+				"working version check might be "+utils.ColouriseCyan("set")+
+				" name = "+
+				utils.ColouriseGreen("\""+LANG_NAME+"\"")+"\n\n"+
+				"Line of Code: "+utils.ColouriseMagenta(full_loc),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+
+	// Check the variable name being assigned to
+	variable_error := interpreter.CheckAssignableVariable(
+		tokens, tokens[2].TokenValue, 2)
+	if variable_error != nil {
+		return variable_error
+	}
+
+	// Check for a valid assignment operator
+	assignment_error := CheckValidAssignment(loc, tokens[3].TokenValue)
+	if assignment_error != nil {
+		return ReportWithFixes(
+			assignment_error.Error(),
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+
+	// Check that a list is a list of values seperated by commas
+	if is_list {
+		if _, error_index := ListItemIndexes(tokens, 4); error_index >= 0 {
+			return Report(
+				"The list in this "+utils.ColouriseCyan("set")+" statement "+
+					"needs to be a list of values, seperated by commas, "+
+					"between "+utils.ColouriseMagenta(SYMBOL_LIST_OPEN)+
+					" and "+utils.ColouriseMagenta(SYMBOL_LIST_CLOSE)+". An "+
+					"example of a working version might be "+
+					utils.ColouriseCyan("set")+" files = "+
+					SYMBOL_LIST_OPEN+utils.ColouriseGreen("\"one.txt\"")+
+					SYMBOL_LIST_SEPERATOR+" "+
+					utils.ColouriseGreen("\"two.txt\"")+SYMBOL_LIST_CLOSE+
+					"\n\nLine of Code: "+utils.ColouriseMagenta(full_loc),
+				loc,
+				tokens[error_index].TokenPosition,
+				full_loc,
+			)
+		}
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a setenv statement call.
+func (interpreter *Interpreter) CheckSetEnv(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("setenv")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("setenv")+" "+
+				utils.ColouriseYellow("[NAME]")+" = "+
+				utils.ColouriseGreen("\"[value]\"")+". An example of a "+
+				"working version might be "+utils.ColouriseCyan("setenv")+
+				" GREETING = "+utils.ColouriseGreen("\"Hello\"")+"\n\n"+
+				"Line of Code: "+utils.ColouriseMagenta(full_loc),
+			loc,
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the name of the environment variable
+	name_error := CheckEnvironmentName(tokens, 2)
+	if name_error != nil {
+		return name_error
+	}
+	// Check for a valid assignment operator
+	assignment_error := CheckValidAssignment(loc, tokens[3].TokenValue)
+	if assignment_error != nil {
+		return ReportWithFixes(
+			assignment_error.Error(),
+			loc,
+			tokens[3].TokenPosition,
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check an unsetenv statement call.
+func (interpreter *Interpreter) CheckUnsetEnv(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("unsetenv")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("unsetenv")+" "+
+				utils.ColouriseYellow("[NAME]")+". An example of a working "+
+				"version might be "+utils.ColouriseCyan("unsetenv")+
+				" GREETING\n\nLine of Code: "+
+				utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the name of the environment variable
+	return CheckEnvironmentName(tokens, 2)
+}
+
+/*
+Check the name of an environment variable in a setenv or unsetenv statement
+(see IsEnvironmentName()). Parameters include the tokens and the index of the
+name. Returns an error if the name isn't valid.
+*/
+func CheckEnvironmentName(tokens []Token, index int) error {
+	environment_name := tokens[index].TokenValue
+	if IsEnvironmentName(environment_name) {
+		return nil
+	}
+	return Report(
+		"The environment variable name - "+
+			utils.ColouriseYellow(environment_name)+" - is not valid. The "+
+			"name needs to be made up of letters, numbers, and underscores "+
+			"and can't start with a number (eg. "+
+			utils.ColouriseYellow("API_TOKEN")+").",
+		strconv.Itoa(tokens[0].LineNumber),
+		tokens[index].TokenPosition,
+		tokens[0].FullLineOfCode,
+	)
+}
+
+// Check an unzip or untar statement call.
+func (interpreter *Interpreter) CheckExtract(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the statement name
+	statement_name := tokens[1].TokenValue
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		example := "\"/Users/user/Downloads/tool.zip\""
+		if statement_name == "untar" {
+			example = "\"/Users/user/Downloads/tool.tar.gz\""
+		}
+		return Report(
+			"The "+utils.ColouriseCyan(statement_name)+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan(statement_name)+" "+
+				utils.ColouriseGreen("\"[archive]\"")+" to "+
+				utils.ColouriseGreen("\"[directory]\"")+". An example of a "+
+				"working version might be "+
+				utils.ColouriseCyan(statement_name)+" "+
+				utils.ColouriseGreen(example)+" to "+
+				utils.ColouriseGreen("\"/Users/user/tool\"")+"\n\nLine of "+
+				"Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the action keyword
+	return interpreter.CheckActionToken(tokens, 3)
+}
+
+// Check a write or writeln statement call.
+func (interpreter *Interpreter) CheckWriteln(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 2)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("write/writeln")+" statement "+
+				"needs to follow the form "+
+				utils.ColouriseCyan("write/writeln")+" "+
+				utils.ColouriseYellow("[content to be written]")+". A "+
+				"common error here is trying to concatenate multiple values "+
+				"into one statement call here. An example of a working version "+
+				"might be "+utils.ColouriseCyan("write/writeln ")+
+				utils.ColouriseGreen("\"Hello World\"")+"\n\nLine of Code: "+
+				utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// If we've gotten here, the statement is well formed
+	return nil
+}
+
+// Check a zipfile statement call.
+func (interpreter *Interpreter) CheckZipFromFile(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("zipfile")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("zipfile")+" "+
+				utils.ColouriseGreen("\"[path]\"")+" to "+
+				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
+				"use of an inappropriate action symbol ("+
+				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
+				"example of a working version might be "+
+				utils.ColouriseCyan("zipfile")+
+				utils.ColouriseGreen(" \"/Users/user/test_dir.txt\"")+" to "+
+				utils.ColouriseGreen(" \"test_dir.zip\"")+"\n\nLine of "+
+				"Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the action keyword
+	return interpreter.CheckActionToken(tokens, 3)
+}
+
+// Check a zipdirectory statement call.
+func (interpreter *Interpreter) CheckZipFromPath(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Check the number of tokens and ensure that it's a proper amount
+	_, err := CheckValidNumberOfTokens(tokens, 4)
+	// If not a valid number of tokens, report an error
+	if err != nil {
+		return Report(
+			"The "+utils.ColouriseCyan("zipdirectory")+" statement needs "+
+				"to follow the form "+utils.ColouriseCyan("zipdirectory")+" "+
+				utils.ColouriseGreen("\"[path]\"")+" to "+
+				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
+				"use of an inappropriate action symbol ("+
+				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
+				"example of a working version might be "+
+				utils.ColouriseCyan("zipdirectory")+
+				utils.ColouriseGreen(" \"/Users/user/test_dir\"")+" to "+
+				utils.ColouriseGreen(" \"test_dir.zip\"")+"\n\nLine of "+
+				"Code: "+utils.ColouriseMagenta(full_loc),
+			strconv.Itoa(tokens[0].LineNumber),
+			"n/a",
+			full_loc,
+		)
+	}
+	// Check the action keyword
+	return interpreter.CheckActionToken(tokens, 3)
+}
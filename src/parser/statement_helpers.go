@@ -8,15 +8,89 @@ package parser
 import (
 	"appetit/utils"
 	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/fs"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 )
 
+// ----------------------------------------------------------------------------
+/*
+ask statement helpers
+*/
+
+// The word that asks for an answer to be calculated (eg. as expression)
+const ANSWER_EXPRESSION = "expression"
+
+/*
+Get the answer to an ask statement that ends with as and a type (eg. as
+integer) or as expression. The answer is converted to the type or, for an
+expression, calculated without any of the script's variables so that an
+answer can't reach into the script. Parameters include the tokens and the
+answer. Returns the answer and an error if it can't be converted or
+calculated.
+*/
+func (interpreter *Interpreter) AnswerAs(
+	tokens []Token, answer string) (Value, error) {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+
+	if tokens[6].TokenValue == ANSWER_EXPRESSION {
+		calculated_answer, calculate_error := CalculateValue(answer, nil)
+		if calculate_error != nil {
+			return Value{}, Report(
+				"The answer "+utils.ColouriseGreen(answer)+" can't be "+
+					"calculated. "+calculate_error.Error(),
+				loc,
+				tokens[6].TokenPosition,
+				full_loc,
+			).WithCategory(ERROR_RUNTIME).WithRule(
+				"runtime/expression").WithErr(calculate_error)
+		}
+		return calculated_answer, nil
+	}
+
+	value_type, _ := ValueTypeNamed(tokens[6].TokenValue)
+	converted_answer, convert_error := StringValue(answer).Convert(value_type)
+	if convert_error != nil {
+		return Value{}, Report(
+			"The answer "+utils.ColouriseGreen(answer)+" can't be "+
+				"converted to the type "+
+				utils.ColouriseMagenta(value_type.String())+".",
+			loc,
+			tokens[6].TokenPosition,
+			full_loc,
+		).WithCategory(ERROR_RUNTIME).WithErr(convert_error)
+	}
+	return converted_answer, nil
+}
+
+// ----------------------------------------------------------------------------
+
 // ----------------------------------------------------------------------------
 /*
 copydirectory statement helpers
@@ -27,7 +101,8 @@ This is the function that walks the source directory for a copy path call
 and does the work of copying files. This is called from CopyPath().
 Thanks to https://xojoc.pw/blog/golang-file-tree-traversal
 */
-func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
+func (interpreter *Interpreter) CopyPathWalker(
+	token_info map[string]string) filepath.WalkFunc {
 
 	// Extract values from the token_info passed to the function
 	source := token_info["source"]
@@ -59,9 +134,10 @@ func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
 			/* If verbose mode is set, notify the user that we are making a
 			directory
 			*/
-			if MODE_VERBOSE {
-				fmt.Println(
-					":: Making " + utils.ColouriseGreen(relative_path) +
+			if interpreter.ModeVerbose {
+				fmt.Fprintln(
+					interpreter.Stdout,
+					":: Making "+utils.ColouriseGreen(relative_path)+
 						"...",
 				)
 			}
@@ -74,7 +150,7 @@ func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
 			source_file, source_err := os.Open(file_to_copy)
 			// If there is an error opening the source file, report that
 			if source_err != nil {
-				Report(
+				return Report(
 					"Can't open "+utils.ColouriseYellow(file_to_copy)+
 						". Perhaps you don't have read permissions? "+
 						source_err.Error(),
@@ -89,7 +165,7 @@ func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
 			create, create_err := os.Create(create_path)
 			// If there was an error in creating the new file, report that
 			if create_err != nil {
-				Report(
+				return Report(
 					"Couldn't create the file in "+
 						utils.ColouriseYellow(create_path)+". Check that "+
 						"you have write permissions to write to "+
@@ -104,8 +180,9 @@ func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
 			/* If verbose mode is set, note that we are copying a file and
 			report back the file size
 			*/
-			if MODE_VERBOSE {
-				fmt.Printf(
+			if interpreter.ModeVerbose {
+				fmt.Fprintf(
+					interpreter.Stdout,
 					"    :: Copying %s %s...",
 					utils.ColouriseGreen(info.Name()),
 					utils.ColouriseMagenta(
@@ -119,7 +196,7 @@ func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
 			_, copy_err := io.Copy(create, source_file)
 			// If there's an error, report it
 			if copy_err != nil {
-				Report(
+				return Report(
 					"There was an error doing the copy for "+
 						source_file.Name(),
 					loc,
@@ -128,8 +205,8 @@ func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
 				)
 			}
 			// If verbose mode is set, note that we are done copying the file
-			if MODE_VERBOSE {
-				fmt.Println("done.")
+			if interpreter.ModeVerbose {
+				fmt.Fprintln(interpreter.Stdout, "done.")
 			}
 
 		}
@@ -142,22 +219,233 @@ func CopyPathWalker(token_info map[string]string) filepath.WalkFunc {
 
 // ----------------------------------------------------------------------------
 
+// ----------------------------------------------------------------------------
+/*
+define and call statement helpers
+*/
+
+/*
+Report that an end statement has no define block to end. Parameters include
+the tokens of the end statement. Returns the error.
+*/
+func ReportEndWithoutDefine(tokens []Token) error {
+	return Report(
+		"The "+utils.ColouriseCyan("end")+" statement can only be used to "+
+			"end a "+utils.ColouriseCyan("define")+" block and there isn't "+
+			"one to end here. A define block needs to follow the form:\n\n\t"+
+			utils.ColouriseCyan("define")+" "+
+			utils.ColouriseGreen("\"[name]\"")+"\n\t\t"+
+			utils.ColouriseYellow("[statements]")+"\n\t"+
+			utils.ColouriseCyan("end"),
+		strconv.Itoa(tokens[0].LineNumber),
+		tokens[1].TokenPosition,
+		tokens[0].FullLineOfCode,
+	)
+}
+
+/*
+Report that a define block wasn't closed before the end of the script or
+before another define statement. Parameters include the tokens of the define
+statement that started the block. Returns the error.
+*/
+func ReportUnclosedDefine(tokens []Token) error {
+	return Report(
+		"The "+utils.ColouriseCyan("define")+" block for "+
+			utils.ColouriseYellow(FixStringCombined(tokens[2].TokenValue))+
+			" needs to be closed with an "+utils.ColouriseCyan("end")+
+			" statement before the end of the script or the next "+
+			utils.ColouriseCyan("define")+" statement.",
+		strconv.Itoa(tokens[0].LineNumber),
+		tokens[1].TokenPosition,
+		tokens[0].FullLineOfCode,
+	)
+}
+
+/*
+The ProcedureArgument type houses a single argument passed to a procedure by
+a call statement. The structure of the argument is as follows:
+  - Name [Token]: the name of the variable that the procedure gets
+  - Value [Token]: the value of the variable
+*/
+type ProcedureArgument struct {
+	Name  Token
+	Value Token
+}
+
+/*
+The ProcedureCall type houses a parsed call statement. The structure of the
+call is as follows:
+  - Name [string]: the name of the procedure to call
+  - Arguments [[]ProcedureArgument]: the variables to set for the procedure
+  - Returns [[]Token]: the names of the variables to copy back from the
+    procedure once it's done
+*/
+type ProcedureCall struct {
+	Name      string
+	Arguments []ProcedureArgument
+	Returns   []Token
+}
+
+/*
+Parse a call statement. A call statement names the procedure, optionally
+followed by the with keyword and the arguments, and optionally followed by the
+returning keyword and the outputs. Arguments and outputs are joined with the
+and keyword. Parameters include the tokens of the call statement. Returns the
+call and an error if the call is malformed.
+*/
+func ParseProcedureCall(tokens []Token) (ProcedureCall, error) {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Create the call
+	procedure_call := ProcedureCall{}
+
+	// Create the error for a call statement that isn't well formed
+	malformed_error := Report(
+		"The "+utils.ColouriseCyan("call")+" statement needs to follow the "+
+			"form "+utils.ColouriseCyan("call")+" "+
+			utils.ColouriseGreen("\"[name]\"")+" optionally followed by "+
+			SYMBOL_CALL_WITH+" "+utils.ColouriseYellow("[variable name]")+
+			" = "+utils.ColouriseGreen("\"[value]\"")+" and/or "+
+			SYMBOL_CALL_RETURNING+" "+
+			utils.ColouriseYellow("[variable name]")+". Use "+
+			SYMBOL_CALL_AND+" to pass more than one. An example of a "+
+			"working version might be "+utils.ColouriseCyan("call")+
+			utils.ColouriseGreen(" \"backup\"")+" with source = "+
+			utils.ColouriseGreen("\"#b_home/notes\"")+" returning "+
+			"archive\n\nLine of Code: "+utils.ColouriseMagenta(full_loc),
+		loc,
+		"n/a",
+		full_loc,
+	)
+
+	// A call statement needs at least the name of the procedure
+	if len(tokens) < 3 {
+		return procedure_call, malformed_error
+	}
+	procedure_call.Name = FixStringCombined(tokens[2].TokenValue)
+
+	// Start after the name of the procedure
+	index := 3
+	// If there are arguments, gather them
+	if index < len(tokens) && tokens[index].TokenValue == SYMBOL_CALL_WITH {
+		for {
+			// Each argument is a name, an assignment operator, and a value
+			if index+3 >= len(tokens) {
+				return procedure_call, malformed_error
+			}
+			// Check for a valid assignment operator
+			assignment_error := CheckValidAssignment(
+				loc, tokens[index+2].TokenValue)
+			if assignment_error != nil {
+				return procedure_call, ReportWithFixes(
+					assignment_error.Error(),
+					loc,
+					tokens[index+2].TokenPosition,
+					full_loc,
+				)
+			}
+			procedure_call.Arguments = append(
+				procedure_call.Arguments,
+				ProcedureArgument{
+					Name:  tokens[index+1],
+					Value: tokens[index+3],
+				},
+			)
+			index += 4
+			// Carry on if there is another argument
+			if index >= len(tokens) ||
+				tokens[index].TokenValue != SYMBOL_CALL_AND {
+				break
+			}
+		}
+	}
+
+	// If there are outputs, gather them
+	if index < len(tokens) &&
+		tokens[index].TokenValue == SYMBOL_CALL_RETURNING {
+		for {
+			// Each output is a variable name
+			if index+1 >= len(tokens) {
+				return procedure_call, malformed_error
+			}
+			procedure_call.Returns = append(
+				procedure_call.Returns, tokens[index+1])
+			index += 2
+			// Carry on if there is another output
+			if index >= len(tokens) ||
+				tokens[index].TokenValue != SYMBOL_CALL_AND {
+				break
+			}
+		}
+	}
+
+	// If there is anything left over, the call isn't well formed
+	if index != len(tokens) {
+		return procedure_call, malformed_error
+	}
+	// If we've gotten here, the call is well formed
+	return procedure_call, nil
+}
+
+// ----------------------------------------------------------------------------
+
 // ----------------------------------------------------------------------------
 /*
 download statement helpers
 */
 
 /*
-Hold values of the progress of the writing of downloaded data. This has two
-values: TotalBytes (which holds how many bytes have been downloaded) and
-FileSize (which holds the total number of bytes of the file being
-downloaded). Somewhere down the line, a 64-bit integer is returned as the
-response's content length and so, we are sticking with 64-bit numbers
-throughout.
+Hold values of the progress of the writing of downloaded data. This has
+TotalBytes (which holds how many bytes have been downloaded), FileSize (which
+holds the total number of bytes of the file being downloaded), and Output
+(which is where the progress is written to). Where a number of files are
+downloaded at once, Board is the progress board (see ProgressBoard) that
+shows the progress of each, with Name and Status (eg. Downloading) on its
+line, in which case the progress isn't written to Output itself.
+Somewhere down the line, a 64-bit integer is returned as the response's
+content length and so, we are sticking with 64-bit numbers throughout.
 */
 type WriteProgress struct {
 	TotalBytes float64
 	FileSize   float64
+	Output     io.Writer
+	Name       string
+	Status     string
+	Board      *ProgressBoard
+}
+
+/*
+Start the progress of a download over (eg. for another attempt). Without a
+progress board, the file being downloaded is noted on a line of its own.
+Parameters include what is happening (eg. Downloading or Resuming), the name
+of the file, how much of it has been downloaded, and its size. No returns.
+*/
+func (wp *WriteProgress) Start(
+	action string, name string, offset float64, size float64) {
+	if wp.Board == nil {
+		wp.TotalBytes, wp.FileSize = offset, size
+		fmt.Fprintf(wp.Output, "%s %s\n", action, utils.ColouriseGreen(name))
+		return
+	}
+	wp.Board.Update(func() {
+		wp.Name, wp.Status = name, action
+		wp.TotalBytes, wp.FileSize = offset, size
+	}, true)
+}
+
+/*
+Note what is happening with a download (eg. that it is being tried again).
+Without a progress board, this is written on a line of its own. Parameters
+include the status. No returns.
+*/
+func (wp *WriteProgress) SetStatus(status string) {
+	if wp.Board == nil {
+		fmt.Fprintln(wp.Output, "\n"+status)
+		return
+	}
+	wp.Board.Update(func() { wp.Status = status }, true)
 }
 
 /*
@@ -169,13 +457,18 @@ and returns nil as an error.
 func (wp *WriteProgress) Write(progress []byte) (int, error) {
 	// Get the length of the progress
 	length := len(progress)
+	// The progress board shows the progress when there is one
+	if wp.Board != nil {
+		wp.Board.Update(func() { wp.TotalBytes += float64(length) }, false)
+		return length, nil
+	}
 	// Add the length of the progress byte slice to the total bytes
 	wp.TotalBytes += float64(length)
-	/* Create an output writer that uses stdout as the output. The reason we
+	/* Create an output writer that uses the progress output. The reason we
 	aren't using fmt here is to ensure that text can be flushed from the buffer
 	properly which allows writing over the lines cleanly.
 	*/
-	writer := bufio.NewWriter(os.Stdout)
+	writer := bufio.NewWriter(wp.Output)
 	// Calculate the percentage
 	percentage := wp.TotalBytes / wp.FileSize
 	// Format the progress as a percentage for printing.
@@ -201,4 +494,1764 @@ func (wp *WriteProgress) Write(progress []byte) (int, error) {
 
 }
 
+// How often the progress board is drawn again while files are downloading
+const PROGRESS_BOARD_REFRESH = 100 * time.Millisecond
+
+/*
+The ProgressBoard type houses the progress of a number of downloads that are
+happening at once, which it shows as a line for each download that is drawn
+over as they progress. The structure of the board is as follows:
+  - Output [io.Writer]: where the board is drawn
+  - Bars [[]*WriteProgress]: the progress of each download, in the order that
+    they are shown
+*/
+type ProgressBoard struct {
+	Output io.Writer
+	Bars   []*WriteProgress
+	// Hold the lock that the progress is changed and the board drawn under
+	mutex sync.Mutex
+	// Hold how many lines were drawn last time so that they can be drawn over
+	lines_drawn int
+	// Hold when the board was last drawn
+	last_drawn time.Time
+}
+
+/*
+Create a progress board with a line for each download, each of which is
+waiting to start. Parameters include where the board is drawn and the names
+of the downloads. Returns the board.
+*/
+func NewProgressBoard(output io.Writer, names []string) *ProgressBoard {
+	board := &ProgressBoard{Output: output}
+	for _, name := range names {
+		board.Bars = append(board.Bars, &WriteProgress{
+			Output: output,
+			Name:   name,
+			Status: "Waiting",
+			Board:  board,
+		})
+	}
+	return board
+}
+
+/*
+Change the progress of a download and draw the board again, though no more
+often than every PROGRESS_BOARD_REFRESH unless it is forced (eg. when a
+download finishes). Parameters include the change and whether to force the
+board to be drawn. No returns.
+*/
+func (board *ProgressBoard) Update(change func(), force bool) {
+	board.mutex.Lock()
+	defer board.mutex.Unlock()
+	change()
+	if force || time.Since(board.last_drawn) >= PROGRESS_BOARD_REFRESH {
+		board.Draw()
+	}
+}
+
+/*
+Draw the board, moving back up over what was drawn last time. This needs to
+be called with the board locked (see Update()). No parameters and no returns.
+*/
+func (board *ProgressBoard) Draw() {
+	writer := bufio.NewWriter(board.Output)
+	if board.lines_drawn > 0 {
+		fmt.Fprintf(writer, "\033[%dA", board.lines_drawn)
+	}
+	for _, bar := range board.Bars {
+		writer.WriteString("\r\033[K" + bar.Line() + "\n")
+	}
+	writer.Flush()
+	board.lines_drawn = len(board.Bars)
+	board.last_drawn = time.Now()
+}
+
+/*
+Get the line for a download on a progress board (eg. Downloading file.iso
+42.00% (420 KB of 1,000 KB)). No parameters. Returns the line.
+*/
+func (wp *WriteProgress) Line() string {
+	line := utils.ColouriseBlue(fmt.Sprintf("%-11s", wp.Status)) + " " +
+		utils.ColouriseGreen(wp.Name)
+	if wp.FileSize > 0 {
+		line += fmt.Sprintf(
+			" %s (%s KB of %s KB)",
+			utils.ColouriseMagenta(strconv.FormatFloat(
+				wp.TotalBytes/wp.FileSize*100, 'f', 2, 32)+"%"),
+			utils.CommaSeperator(wp.TotalBytes/1024),
+			utils.CommaSeperator(wp.FileSize/1024),
+		)
+	}
+	return line
+}
+
+// The clauses that can follow the path of a download statement
+const (
+	DOWNLOAD_CLAUSE_VERIFY  = "verify"
+	DOWNLOAD_CLAUSE_RETRIES = "retries"
+)
+
+/*
+The suffix of the file that holds what identifies the version of a file being
+downloaded (ie. its ETag or when it was last modified) next to the partial
+download so that a download is only picked up where it left off if the file
+hasn't changed since.
+*/
+const DOWNLOAD_VALIDATOR_SUFFIX = ".validator"
+
+// The longest that a download waits before it is tried again
+const MAX_DOWNLOAD_RETRY_DELAY = 30 * time.Second
+
+/*
+Get the clauses that can follow the path of a download statement (eg.
+download "[url]" to "[path]" verify "sha256:[hash]" retries 5). No
+parameters. Returns the clauses.
+*/
+func DownloadClauses() []string {
+	return []string{DOWNLOAD_CLAUSE_VERIFY, DOWNLOAD_CLAUSE_RETRIES}
+}
+
+/*
+Get the hash functions that a download can be verified with by their names.
+No parameters. Returns a function that creates each hash by its name.
+*/
+func ChecksumAlgorithms() map[string]func() hash.Hash {
+	return map[string]func() hash.Hash{
+		"md5":    md5.New,
+		"sha1":   sha1.New,
+		"sha256": sha256.New,
+		"sha512": sha512.New,
+	}
+}
+
+/*
+Pull the algorithm and the hash out of a checksum written as
+[algorithm]:[hash] (eg. sha256:9f86d0...). Parameters include the checksum.
+Returns the algorithm, the hash in lower case, and an error if the algorithm
+isn't one that is supported or the hash isn't the right length.
+*/
+func ParseChecksum(checksum string) (string, string, error) {
+	algorithm, digest, found := strings.Cut(checksum, ":")
+	algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	new_hash, is_algorithm := ChecksumAlgorithms()[algorithm]
+	if !found || !is_algorithm {
+		return "", "", fmt.Errorf("the checksum needs to be written as "+
+			"[algorithm]:[hash] where the algorithm is one of %s",
+			strings.Join(slices.Sorted(maps.Keys(ChecksumAlgorithms())), ", "))
+	}
+	_, hex_error := hex.DecodeString(digest)
+	if hex_error != nil || len(digest) != new_hash().Size()*2 {
+		return "", "", fmt.Errorf("a %s hash is %d hexadecimal characters",
+			algorithm, new_hash().Size()*2)
+	}
+	return algorithm, digest, nil
+}
+
+/*
+Pull the checksum for a file out of a checksum file, such as one written by
+sha256sum (eg. 9f86d0...  file.iso). Where the checksum file lists more than
+one file, the line for the file is used. The algorithm is worked out from the
+length of the hash. Parameters include the contents of the checksum file and
+the name of the file. Returns the checksum as [algorithm]:[hash] and an error
+if there isn't one for the file.
+*/
+func ChecksumFromFile(contents string, file_name string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(contents), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// The name is optional where there is only the one file
+		listed_name := ""
+		if len(fields) > 1 {
+			listed_name = path.Base(strings.TrimPrefix(fields[1], "*"))
+		}
+		if len(lines) > 1 && listed_name != file_name {
+			continue
+		}
+		for algorithm, new_hash := range ChecksumAlgorithms() {
+			if len(fields[0]) == new_hash().Size()*2 {
+				return algorithm + ":" + fields[0], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("there is no checksum for %s in it", file_name)
+}
+
+/*
+Work out the checksum of a file. Parameters include the path to the file and
+the algorithm to use. Returns the hash in lower case and an error if the file
+couldn't be read.
+*/
+func FileChecksum(file_path string, algorithm string) (string, error) {
+	file, open_error := os.Open(file_path)
+	if open_error != nil {
+		return "", open_error
+	}
+	defer file.Close()
+	file_hash := ChecksumAlgorithms()[algorithm]()
+	if _, copy_error := io.Copy(file_hash, file); copy_error != nil {
+		return "", copy_error
+	}
+	return hex.EncodeToString(file_hash.Sum(nil)), nil
+}
+
+/*
+Get the directory that downloads are held in until they are finished, making
+it if need be. This is in the user's cache directory (or, where there isn't
+one, the temporary directory) and only the user can get into it so that
+another user can't read a download or change it before it is moved into
+place. No parameters. Returns the directory and an error if it couldn't be
+made or belongs to another user.
+*/
+func PartialDownloadDirectory() (string, error) {
+	directory := filepath.Join(
+		os.TempDir(), "appetit_downloads_"+strconv.Itoa(os.Getuid()))
+	if cache, cache_error := os.UserCacheDir(); cache_error == nil {
+		directory = filepath.Join(cache, "appetit", "downloads")
+	}
+	if make_error := os.MkdirAll(directory, 0700); make_error != nil {
+		return "", make_error
+	}
+	// Don't follow a link or use a directory that another user made
+	info, lstat_error := os.Lstat(directory)
+	if lstat_error != nil {
+		return "", lstat_error
+	}
+	if !info.IsDir() || !OwnedByUser(info) {
+		return "", fmt.Errorf("%s isn't a directory of your own", directory)
+	}
+	// Windows doesn't have permissions like these
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0700 {
+		if chmod_error := os.Chmod(directory, 0700); chmod_error != nil {
+			return "", chmod_error
+		}
+	}
+	return directory, nil
+}
+
+/*
+Get where a download is held until it is finished. This is the same for each
+attempt at downloading a URL so that a download that stopped part of the way
+through can be picked up where it left off, even by a later run of the
+script. Parameters include the URL. Returns the path and an error if the
+directory that it is in couldn't be made (see PartialDownloadDirectory()).
+*/
+func PartialDownloadPath(url string) (string, error) {
+	directory, directory_error := PartialDownloadDirectory()
+	if directory_error != nil {
+		return "", directory_error
+	}
+	url_hash := sha256.Sum256([]byte(url))
+	return filepath.Join(directory,
+		hex.EncodeToString(url_hash[:8])+".part"), nil
+}
+
+/*
+Get how long to wait before a download is tried again. The wait doubles with
+each attempt up to MAX_DOWNLOAD_RETRY_DELAY. Parameters include the number of
+attempts that have failed so far, less one. Returns the wait.
+*/
+func (interpreter *Interpreter) DownloadRetryDelay(attempt int) time.Duration {
+	delay := interpreter.download_retry_delay << attempt
+	if delay <= 0 || delay > MAX_DOWNLOAD_RETRY_DELAY {
+		delay = MAX_DOWNLOAD_RETRY_DELAY
+	}
+	return delay
+}
+
+/*
+Make an attempt at downloading a URL to the partial download, picking up
+where an earlier attempt left off if the server allows it (ie. with an HTTP
+Range request). Parameters include the client to use, the URL, the path of
+the partial download, and where the progress is written. Returns the name of
+the remote file, whether it is worth trying again, and an error if the
+download didn't finish.
+*/
+func (interpreter *Interpreter) DownloadAttempt(
+	client *http.Client,
+	url string,
+	partial_path string,
+	progress *WriteProgress) (string, bool, error) {
+	// Set up the GET request
+	request, request_error := interpreter.NewHTTPRequest("GET", url, nil)
+	if request_error != nil {
+		return "", false, request_error
+	}
+
+	/*
+		Ask for the rest of the file if some of it has been downloaded. If
+		the file has changed since (going by its validator), the server sends
+		all of it instead.
+	*/
+	var offset int64
+	if info, lstat_error := os.Lstat(partial_path); lstat_error == nil {
+		// Only pick up a partial download that is a file of the user's own
+		if info.Mode().IsRegular() && OwnedByUser(info) {
+			offset = info.Size()
+		} else if remove_error := os.Remove(partial_path); remove_error != nil {
+			return "", false, remove_error
+		}
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		validator, read_error := os.ReadFile(
+			partial_path + DOWNLOAD_VALIDATOR_SUFFIX)
+		if read_error == nil {
+			request.Header.Set("If-Range", string(validator))
+		}
+	}
+
+	// Do the request itself
+	response, response_error := client.Do(request)
+	if response_error != nil {
+		return "", true, response_error
+	}
+	defer response.Body.Close()
+	/* Get the remote file name absent much of the URL so that we can report
+	back which file we are downloading.
+	*/
+	remote_file_name := path.Base(response.Request.URL.Path)
+
+	// Work out whether to add to the partial download or start it over
+	open_flags := os.O_CREATE | os.O_WRONLY
+	range_start, range_total := ContentRange(response)
+	switch {
+	case response.StatusCode == http.StatusPartialContent &&
+		offset > 0 && range_start == offset:
+		open_flags |= os.O_APPEND
+	case response.StatusCode == http.StatusOK:
+		open_flags |= os.O_TRUNC
+		offset = 0
+	// Where the partial download is the whole file, there's nothing to get
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable &&
+		offset > 0 && range_total == offset:
+		return remote_file_name, false, nil
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable ||
+		response.StatusCode == http.StatusPartialContent:
+		// The partial download is no use so start over on the next attempt
+		os.Remove(partial_path)
+		return remote_file_name, true, errors.New(
+			"the server couldn't pick up where the download left off")
+	default:
+		// A problem with the server (or too many requests) can pass
+		return remote_file_name,
+			response.StatusCode >= 500 ||
+				response.StatusCode == http.StatusTooManyRequests,
+			fmt.Errorf("the server responded with %s", response.Status)
+	}
+
+	// Note what identifies this version of the file when starting over
+	if offset == 0 {
+		validator := response.Header.Get("ETag")
+		if validator == "" {
+			validator = response.Header.Get("Last-Modified")
+		}
+		os.Remove(partial_path + DOWNLOAD_VALIDATOR_SUFFIX)
+		if validator != "" {
+			os.WriteFile(
+				partial_path+DOWNLOAD_VALIDATOR_SUFFIX, []byte(validator), 0600)
+		}
+	}
+
+	partial_file, open_error := os.OpenFile(partial_path, open_flags, 0600)
+	if open_error != nil {
+		return remote_file_name, false, open_error
+	}
+	defer partial_file.Close()
+
+	// Note which file we are downloading and set its size for the progress
+	action := "Downloading"
+	if offset > 0 {
+		action = "Resuming"
+	}
+	progress.Start(action, remote_file_name, float64(offset),
+		float64(offset+response.ContentLength))
+	/* Copy the chunk downloaded to the partial download. Here, the source is
+	set as a TeeReader which returns a reader that reads the body of the
+	response and writes, via the WriteProgress type, the size of what has
+	been downloaded.
+	*/
+	_, copy_error := io.Copy(
+		partial_file, io.TeeReader(response.Body, progress))
+	if copy_error != nil {
+		return remote_file_name, true, copy_error
+	}
+	return remote_file_name, false, partial_file.Close()
+}
+
+/*
+Download a URL to the partial download, trying again (see
+DownloadRetryDelay()) where an attempt stopped for a reason that may pass.
+Parameters include the client to use, the URL, the path of the partial
+download, the number of times to try again, and where the progress is
+written. Returns the name of the remote file and an error if the download
+didn't finish.
+*/
+func (interpreter *Interpreter) DownloadWithRetries(
+	client *http.Client,
+	url string,
+	partial_path string,
+	retries int,
+	progress *WriteProgress) (string, error) {
+	var remote_file_name string
+	var download_error error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			delay := interpreter.DownloadRetryDelay(attempt - 1)
+			// A progress board only has room for a short status
+			if progress.Board != nil {
+				progress.SetStatus(fmt.Sprintf("Retry %d/%d", attempt, retries))
+			} else {
+				progress.SetStatus(fmt.Sprintf(
+					"The download stopped (%s). Trying again in %s (%d of %d).",
+					download_error.Error(),
+					delay,
+					attempt,
+					retries,
+				))
+			}
+			time.Sleep(delay)
+		}
+		var retry bool
+		remote_file_name, retry, download_error = interpreter.DownloadAttempt(
+			client, url, partial_path, progress)
+		if download_error == nil || !retry {
+			break
+		}
+	}
+	return remote_file_name, download_error
+}
+
+/*
+Get where the part of a file in a response starts and the size of the whole
+file from the Content-Range header (eg. bytes 100-199/200). The start of a
+response that doesn't hold part of the file (eg. bytes [asterisk]/200) isn't
+known.
+Parameters include the response. Returns the start and the size, -1 for
+either that isn't known.
+*/
+func ContentRange(response *http.Response) (int64, int64) {
+	content_range, found := strings.CutPrefix(
+		response.Header.Get("Content-Range"), "bytes ")
+	if !found {
+		return -1, -1
+	}
+	span, total_text, _ := strings.Cut(content_range, "/")
+	start_text, _, _ := strings.Cut(span, "-")
+	start, start_error := strconv.ParseInt(start_text, 10, 64)
+	if start_error != nil {
+		start = -1
+	}
+	total, total_error := strconv.ParseInt(total_text, 10, 64)
+	if total_error != nil {
+		total = -1
+	}
+	return start, total
+}
+
+/*
+Check that a download matches its checksum. The checksum is either written
+as [algorithm]:[hash] or is the URL of a checksum file (see
+ChecksumFromFile()). Parameters include the tokens of the download
+statement, the token of the checksum, the client to get a checksum file
+with, the checksum, the path of the download, and the name of the remote
+file. Returns an error if the download doesn't match or the checksum can't be
+worked out.
+*/
+func (interpreter *Interpreter) VerifyDownload(
+	tokens []Token,
+	checksum_token Token,
+	client *http.Client,
+	checksum string,
+	file_path string,
+	remote_file_name string) error {
+	// Get the line and full line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	full_loc := tokens[0].FullLineOfCode
+	report := func(message string, err error) *ScriptError {
+		return Report(
+			message, loc, checksum_token.TokenPosition, full_loc,
+		).WithRule("runtime/download-checksum").WithErr(err)
+	}
+
+	// Get the checksum from the checksum file if there is one
+	if strings.HasPrefix(checksum, "http://") ||
+		strings.HasPrefix(checksum, "https://") {
+		checksum_url := checksum
+		request, response_error := interpreter.NewHTTPRequest(
+			"GET", checksum_url, nil)
+		var response *http.Response
+		if response_error == nil {
+			response, response_error = client.Do(request)
+		}
+		if response_error == nil {
+			defer response.Body.Close()
+			if response.StatusCode != http.StatusOK {
+				response_error = fmt.Errorf(
+					"the server responded with %s", response.Status)
+			}
+		}
+		var contents []byte
+		if response_error == nil {
+			// A checksum file is small so don't read more than a megabyte
+			contents, response_error = io.ReadAll(
+				io.LimitReader(response.Body, 1<<20))
+		}
+		if response_error == nil {
+			checksum, response_error = ChecksumFromFile(
+				string(contents), remote_file_name)
+		}
+		if response_error != nil {
+			return report(
+				"The checksum file "+utils.ColouriseYellow(checksum_url)+
+					" couldn't be read as "+response_error.Error()+".",
+				response_error)
+		}
+	}
+
+	algorithm, expected, parse_error := ParseChecksum(checksum)
+	if parse_error != nil {
+		return report(
+			"The checksum "+utils.ColouriseYellow(checksum)+" can't be "+
+				"used as "+parse_error.Error()+".",
+			parse_error)
+	}
+	actual, checksum_error := FileChecksum(file_path, algorithm)
+	if checksum_error != nil {
+		return report(
+			"The checksum of the download couldn't be worked out.",
+			checksum_error)
+	}
+	if actual != expected {
+		return report(
+			"The download of "+utils.ColouriseYellow(remote_file_name)+
+				" doesn't match its checksum so it has been removed. It "+
+				"should be "+utils.ColouriseGreen(algorithm+":"+expected)+
+				" but is "+utils.ColouriseRed(algorithm+":"+actual)+".",
+			nil,
+		).WithHint("The file may have been damaged on the way or changed " +
+			"on the server. Run the script again to download it again.")
+	}
+	return nil
+}
+
+/*
+Get the value of a token that is written out in full, that is, one that
+doesn't use a variable, so that it can be checked before the script runs.
+Parameters include the tokens of the statement and the token of the value.
+Returns the value, whether it is written out in full, and an error if it is
+an expression that can't be calculated.
+*/
+func LiteralValue(tokens []Token, token Token) (Value, bool, error) {
+	value := FixStringCombined(token.TokenValue)
+	if strings.Contains(value, SYMBOL_VARIABLE_SUBSTITUTION) {
+		return Value{}, false, nil
+	}
+	calculated_value, calculate_error := CalculateValue(value, nil)
+	if calculate_error != nil {
+		return Value{}, true, ReportExpressionError(
+			tokens, token, value, calculate_error)
+	}
+	return calculated_value, true, nil
+}
+
+/*
+Get the value of a clause that is a whole number (eg. retries 5). Parameters
+include the tokens of the statement, the token of the value, the smallest
+number that is allowed, and what the number is (for errors). Returns the
+number and an error if the value isn't a whole number or is too small.
+*/
+func (interpreter *Interpreter) WholeNumberClause(
+	tokens []Token,
+	token Token,
+	minimum int,
+	description string) (int, error) {
+	value, value_error := interpreter.EvaluateValue(tokens, token)
+	if value_error != nil {
+		return 0, value_error
+	}
+	return WholeNumberValue(tokens, token, value, minimum, description)
+}
+
+/*
+Check the value of a clause that is a whole number (eg. retries 5) when a
+script is checked. A value that is written out in full can be checked now
+whereas one that uses a variable can only be checked once the statement runs.
+Parameters include the tokens of the statement, the token of the value, the
+smallest number that is allowed, and what the number is (for errors). Returns
+an error if the value is written out in full and isn't a whole number or is
+too small.
+*/
+func CheckWholeNumberClause(
+	tokens []Token,
+	token Token,
+	minimum int,
+	description string) error {
+	value, is_literal, value_error := LiteralValue(tokens, token)
+	if value_error != nil || !is_literal {
+		return value_error
+	}
+	_, number_error := WholeNumberValue(
+		tokens, token, value, minimum, description)
+	return number_error
+}
+
+/*
+Check that the value of a clause is a whole number. This is used both when a
+script is checked, for a value that is written out in full, and when the
+statement runs. Parameters include the tokens of the statement, the token of
+the value, the value, the smallest number that is allowed, and what the
+number is (for errors). Returns the number and an error if the value isn't a
+whole number or is too small.
+*/
+func WholeNumberValue(
+	tokens []Token,
+	token Token,
+	value Value,
+	minimum int,
+	description string) (int, error) {
+	number, is_number := value.Number()
+	if !is_number || number < float64(minimum) ||
+		number != math.Trunc(number) {
+		return 0, Report(
+			"The "+description+" "+utils.ColouriseYellow(value.String())+
+				" needs to be a whole number that is "+
+				strconv.Itoa(minimum)+" or more.",
+			strconv.Itoa(tokens[0].LineNumber),
+			token.TokenPosition,
+			tokens[0].FullLineOfCode,
+		).WithHint(
+			"Use a whole number (eg. " +
+				utils.ColouriseYellow(strconv.Itoa(minimum+2)) + ") or a " +
+				"variable that holds one (eg. " +
+				utils.ColouriseYellow("\"#count\"") + ").",
+		)
+	}
+	return int(number), nil
+}
+
+/*
+Check that a timeout is a number of seconds above zero. This is used both
+when a script is checked, for a value that is written out in full, and when
+the statement runs. Parameters include the tokens of the statement, the token
+of the value, and the value. Returns the timeout and an error if the value
+isn't a number of seconds above zero.
+*/
+func TimeoutValue(
+	tokens []Token, token Token, value Value) (time.Duration, error) {
+	seconds, is_number := value.Number()
+	if !is_number || seconds <= 0 {
+		return 0, Report(
+			"The timeout "+utils.ColouriseYellow(value.String())+
+				" needs to be a number of seconds above zero.",
+			strconv.Itoa(tokens[0].LineNumber),
+			token.TokenPosition,
+			tokens[0].FullLineOfCode,
+		).WithHint(
+			"Use a number of seconds (eg. " + utils.ColouriseYellow("60") +
+				" or " + utils.ColouriseYellow("2.5") + ") or a variable " +
+				"that holds one (eg. " +
+				utils.ColouriseYellow("\"#seconds\"") + ").",
+		)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// The clause that sets how many files a downloadall statement gets at once
+const DOWNLOADALL_CLAUSE_PARALLEL = "parallel"
+
+// The number of files that a downloadall statement gets at once by default
+const DEFAULT_DOWNLOAD_PARALLEL = 4
+
+/*
+Get the clauses that can follow the directory of a downloadall statement (eg.
+downloadall "list.txt" to "[directory]" parallel 8 retries 5). No
+parameters. Returns the clauses.
+*/
+func DownloadAllClauses() []string {
+	return []string{DOWNLOADALL_CLAUSE_PARALLEL, DOWNLOAD_CLAUSE_RETRIES}
+}
+
+/*
+The DownloadJob type houses one of the files of a downloadall statement. The
+structure of the job is as follows:
+  - URL [string]: the URL of the file
+  - Checksum [string]: what the file is verified against (see
+    VerifyDownload()), empty for nothing
+  - SaveName [string]: where the file is saved
+  - Error [error]: why the file couldn't be downloaded, nil if it was
+*/
+type DownloadJob struct {
+	URL      string
+	Checksum string
+	SaveName string
+	Error    error
+}
+
+/*
+Read a line of a list of files for a downloadall statement, which is a URL
+optionally followed by a checksum (eg. https://example.com/a.iso
+sha256:[hash]). Parameters include the line. Returns the job, its URL set to
+an empty string for a blank line or one that starts with a #, and an error if
+there is more on the line than that.
+*/
+func ParseDownloadLine(line string) (DownloadJob, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return DownloadJob{}, nil
+	}
+	if len(fields) > 2 {
+		return DownloadJob{}, fmt.Errorf(
+			"%s isn't a URL optionally followed by a checksum", line)
+	}
+	job := DownloadJob{URL: fields[0]}
+	if len(fields) == 2 {
+		job.Checksum = fields[1]
+	}
+	return job, nil
+}
+
+/*
+The name that a file of a downloadall statement is saved under where its URL
+doesn't end with one (eg. https://example.com/)
+*/
+const DEFAULT_DOWNLOAD_FILE_NAME = "index.html"
+
+/*
+Get the name that a file of a downloadall statement is saved under, which is
+the last part of the path of its URL. Where that isn't a single name that
+stays in the directory (eg. it is empty or ..), the file is saved as
+DEFAULT_DOWNLOAD_FILE_NAME. Parameters include the URL. Returns the name and
+an error if the URL can't be parsed.
+*/
+func DownloadFileName(download_url string) (string, error) {
+	parsed_url, parse_error := url.Parse(download_url)
+	if parse_error != nil {
+		return "", parse_error
+	}
+	name := path.Base(parsed_url.Path)
+	if name == "." || !filepath.IsLocal(name) || filepath.Base(name) != name {
+		return DEFAULT_DOWNLOAD_FILE_NAME, nil
+	}
+	return name, nil
+}
+
+/*
+Move a finished download to where it is to be saved, copying it where it
+can't be moved (eg. because the temporary directory is on another drive).
+Parameters include the path of the partial download and where it is to be
+saved. Returns an error if it couldn't be moved or copied.
+*/
+func PlaceDownload(partial_path string, save_name string) error {
+	if os.Rename(partial_path, save_name) != nil {
+		source, open_error := os.Open(partial_path)
+		if open_error != nil {
+			return open_error
+		}
+		defer source.Close()
+		destination, create_error := os.Create(save_name)
+		if create_error != nil {
+			return create_error
+		}
+		_, copy_error := io.Copy(destination, source)
+		if close_error := destination.Close(); copy_error == nil {
+			copy_error = close_error
+		}
+		if copy_error != nil {
+			return copy_error
+		}
+		source.Close()
+		os.Remove(partial_path)
+	}
+	// See the download statement for why macOS needs this
+	if runtime.GOOS == "darwin" {
+		exec.Command("chflags", "nohidden", save_name).Run()
+	}
+	return nil
+}
+
+/*
+Download one of the files of a downloadall statement, checking it against its
+checksum, if it has one, before it is moved into place. This is run for a
+number of files at once so it only touches the job and its progress.
+Parameters include the tokens of the statement, the client to use, the job,
+the number of times to try again, and where the progress is written. Returns
+an error if the file couldn't be downloaded.
+*/
+func (interpreter *Interpreter) RunDownloadJob(
+	tokens []Token,
+	client *http.Client,
+	job *DownloadJob,
+	retries int,
+	progress *WriteProgress) error {
+	partial_path, partial_error := PartialDownloadPath(job.URL)
+	if partial_error != nil {
+		return partial_error
+	}
+	remote_file_name, download_error := interpreter.DownloadWithRetries(
+		client, job.URL, partial_path, retries, progress)
+	if download_error != nil {
+		return download_error
+	}
+	if job.Checksum != "" {
+		progress.SetStatus("Verifying")
+		verify_error := interpreter.VerifyDownload(tokens, tokens[2],
+			client, job.Checksum, partial_path, remote_file_name)
+		if verify_error != nil {
+			os.Remove(partial_path)
+			os.Remove(partial_path + DOWNLOAD_VALIDATOR_SUFFIX)
+			return verify_error
+		}
+	}
+	os.Remove(partial_path + DOWNLOAD_VALIDATOR_SUFFIX)
+	return PlaceDownload(partial_path, job.SaveName)
+}
+
+/*
+Download the files of a downloadall statement, no more than a number of them
+at once, showing the progress of each on a progress board. A file that
+couldn't be downloaded doesn't stop the others. Parameters include the tokens
+of the statement, the client to use, the jobs (each of which has its error
+set), the number of files to download at once, and the number of times to
+try each again. No returns.
+*/
+func (interpreter *Interpreter) RunDownloadJobs(
+	tokens []Token,
+	client *http.Client,
+	jobs []DownloadJob,
+	parallel int,
+	retries int) {
+	var names []string
+	for _, job := range jobs {
+		names = append(names, filepath.Base(job.SaveName))
+	}
+	board := NewProgressBoard(interpreter.Stdout, names)
+	// Hold a place for each download that is running
+	running := make(chan struct{}, parallel)
+	var wait_group sync.WaitGroup
+	for index := range jobs {
+		wait_group.Add(1)
+		go func(job *DownloadJob, progress *WriteProgress) {
+			defer wait_group.Done()
+			running <- struct{}{}
+			defer func() { <-running }()
+			job.Error = interpreter.RunDownloadJob(
+				tokens, client, job, retries, progress)
+			if job.Error != nil {
+				progress.SetStatus("Failed")
+			} else {
+				progress.SetStatus("Done")
+			}
+		}(&jobs[index], board.Bars[index])
+	}
+	wait_group.Wait()
+}
+
+// ----------------------------------------------------------------------------
+
+// ----------------------------------------------------------------------------
+/*
+dry run helpers
+*/
+
+/*
+Summarise what lives at a path so that a plan line can show how much would be
+touched. Parameters include the path to summarise. Returns a summary such as
+"(3 files, 12 KB)" or "(does not exist)" if there is nothing at the path.
+*/
+func PathSummary(path string) string {
+	// If there's nothing at the path, say as much
+	if _, stat_err := os.Stat(path); stat_err != nil {
+		return "(does not exist)"
+	}
+	// Hold the number of files and how many bytes they hold between them
+	file_count := 0
+	var total_bytes int64
+	/* Walk the path, counting each file along the way. A file that can't be
+	read is skipped as it doesn't change what the plan would be.
+	*/
+	filepath.WalkDir(
+		path, func(file_path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			info, info_err := entry.Info()
+			if info_err != nil {
+				return nil
+			}
+			file_count += 1
+			total_bytes += info.Size()
+			return nil
+		})
+
+	return FileCountSummary(file_count, total_bytes)
+}
+
+/*
+Summarise a number of files and how many bytes they hold between them.
+Parameters include the number of files and the number of bytes. Returns a
+summary such as "(3 files, 12 KB)".
+*/
+func FileCountSummary(file_count int, total_bytes int64) string {
+	// Use the singular where there is only the one file
+	files := "files"
+	if file_count == 1 {
+		files = "file"
+	}
+	// Round up so that a small file doesn't come across as being empty
+	kilobytes := math.Ceil(float64(total_bytes) / 1024)
+	return fmt.Sprintf(
+		"(%s %s, %s KB)",
+		utils.CommaSeperator(float64(file_count)),
+		files,
+		utils.CommaSeperator(kilobytes),
+	)
+}
+
+/*
+Print a plan line for a statement that is being skipped because we are in dry
+run mode (eg. "would delete /home/x/foo (3 files, 12 KB)"). Parameters include
+the action (eg. "delete") and what the action would be done to. Returns
+nothing.
+*/
+func (interpreter *Interpreter) PrintPlan(action string, details string) {
+	fmt.Fprintln(
+		interpreter.Stdout,
+		utils.ColouriseBlue("would "+action),
+		details,
+	)
+}
+
+// ----------------------------------------------------------------------------
+
+// ----------------------------------------------------------------------------
+/*
+execute statement helpers
+*/
+
+/*
+The exit codes that a command is given when it couldn't be run to the end.
+These are the ones that shells use.
+*/
+const (
+	EXIT_CODE_TIMEOUT   = 124
+	EXIT_CODE_NOT_RUN   = 126
+	EXIT_CODE_NOT_FOUND = 127
+)
+
+// The clauses that can follow the command of an execute statement
+const (
+	EXECUTE_CLAUSE_IN      = "in"
+	EXECUTE_CLAUSE_TO      = "to"
+	EXECUTE_CLAUSE_TIMEOUT = "timeout"
+)
+
+/*
+Get the clauses that can follow the command of an execute statement (eg.
+execute "make" in "build" to "output" timeout 60). No parameters. Returns the
+clauses.
+*/
+func ExecuteClauses() []string {
+	return []string{EXECUTE_CLAUSE_IN, EXECUTE_CLAUSE_TO, EXECUTE_CLAUSE_TIMEOUT}
+}
+
+/*
+Split a command into the program and its arguments much as a shell would.
+Arguments are split on whitespace, quotes (single or double) keep whitespace
+in an argument, and a backslash keeps the quote or whitespace after it as it
+is. A backslash before anything else is left as it is so that Windows paths
+(eg. C:\Tools\app.exe) don't need escaping. Nothing else is expanded (ie.
+there are no globs, pipes, or $VARIABLES). Parameters include the command.
+Returns the program and its arguments and an error if a quote isn't closed.
+*/
+func SplitCommand(command string) ([]string, error) {
+	var parts []string
+	var part strings.Builder
+	// Whether there is a part, which can be empty (eg. '')
+	in_part := false
+	// The quote that the lexer is in, zero if it isn't in one
+	var quote rune
+	characters := []rune(command)
+	for index := 0; index < len(characters); index++ {
+		character := characters[index]
+		// Get the character after this one, if there is one
+		var next rune
+		if index+1 < len(characters) {
+			next = characters[index+1]
+		}
+		switch {
+		case quote == '\'' && character == '\'',
+			quote == '"' && character == '"':
+			quote = 0
+		case quote == '"' && character == '\\' && next == '"',
+			quote == 0 && character == '\\' &&
+				(next == '"' || next == '\'' || unicode.IsSpace(next)):
+			part.WriteRune(next)
+			index++
+		case quote != 0:
+			part.WriteRune(character)
+		case character == '\'' || character == '"':
+			quote = character
+			in_part = true
+		case unicode.IsSpace(character):
+			if in_part {
+				parts = append(parts, part.String())
+				part.Reset()
+				in_part = false
+			}
+		default:
+			part.WriteRune(character)
+			in_part = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("the %c quote isn't closed", quote)
+	}
+	if in_part {
+		parts = append(parts, part.String())
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("there is no command to execute")
+	}
+	return parts, nil
+}
+
+/*
+Join the program and its arguments back into a command, wrapping those that
+are empty or have whitespace or quotes in them in double quotes so that the
+command splits the same way again (see SplitCommand()). Parameters include
+the program and its arguments. Returns the command.
+*/
+func JoinCommand(parts []string) string {
+	quoted := make([]string, len(parts))
+	for index, part := range parts {
+		quoted[index] = part
+		if part == "" || strings.ContainsAny(part, " \t\r\n'\"") {
+			quoted[index] = "\"" + strings.ReplaceAll(part, "\"", "\\\"") + "\""
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+/*
+Report a command that couldn't be run or that failed. Parameters include the
+tokens of the execute statement, the program, the error from running it, and
+how long it was given (zero if there was no timeout). Returns the report and
+the exit code that it is given.
+*/
+func ReportExecuteError(
+	tokens []Token,
+	program string,
+	err error,
+	timeout time.Duration) (*ScriptError, int) {
+	// Get the line and full line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	full_loc := tokens[0].FullLineOfCode
+	report := func(message string) *ScriptError {
+		return Report(
+			message, loc, tokens[2].TokenPosition, full_loc).WithErr(err)
+	}
+
+	var exit_error *exec.ExitError
+	switch {
+	case timeout > 0 && errors.Is(err, context.DeadlineExceeded):
+		return report(
+				"The command " + utils.ColouriseYellow(program) + " didn't " +
+					"finish within " + timeout.String() + " so it was stopped.",
+			).WithRule("runtime/execute-timeout").WithHint(
+				"Give it longer with a larger " +
+					utils.ColouriseMagenta(EXECUTE_CLAUSE_TIMEOUT) + "."),
+			EXIT_CODE_TIMEOUT
+	case errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist):
+		return report(
+			"The command " + utils.ColouriseYellow(program) + " was not " +
+				"found. Perhaps it was a typo?",
+		).WithRule("runtime/execute-not-found").WithHint(
+			"Make sure that it is installed and on the PATH or use the " +
+				"full path to it."), EXIT_CODE_NOT_FOUND
+	case errors.Is(err, fs.ErrPermission):
+		return report(
+				"The command " + utils.ColouriseYellow(program) + " can't be " +
+					"run as permission was denied.",
+			).WithRule("runtime/execute-permission").WithHint(
+				"Make sure that it is executable by " +
+					utils.ColouriseMagenta(SYMBOL_VARIABLE_SUBSTITUTION+
+						SYMBOL_RESERVED_VARIABLE_PREFIX+"user") + "."),
+			EXIT_CODE_NOT_RUN
+	case errors.As(err, &exit_error) && exit_error.ExitCode() >= 0:
+		exit_code := exit_error.ExitCode()
+		return report(
+			"The command " + utils.ColouriseYellow(program) + " failed " +
+				"with the exit code " +
+				utils.ColouriseMagenta(strconv.Itoa(exit_code)) + ".",
+		).WithRule("runtime/execute-exit"), exit_code
+	case errors.As(err, &exit_error):
+		return report(
+			"The command " + utils.ColouriseYellow(program) + " was " +
+				"stopped before it finished (" + exit_error.String() + ").",
+		).WithRule("runtime/execute-exit"), EXIT_CODE_NOT_RUN
+	}
+	return report(
+		"The command " + utils.ColouriseYellow(program) + " couldn't be run.",
+	).WithRule("runtime/execute"), EXIT_CODE_NOT_RUN
+}
+
+// ----------------------------------------------------------------------------
+
+// ----------------------------------------------------------------------------
+/*
+fetch, post, and put statement helpers
+*/
+
+// The clauses that can follow the URL of a fetch, post, or put statement
+const (
+	REQUEST_CLAUSE_WITH   = "with"
+	REQUEST_CLAUSE_AS     = "as"
+	REQUEST_CLAUSE_TO     = "to"
+	REQUEST_CLAUSE_EXPECT = "expect"
+)
+
+/*
+The most that is read from a response into a variable. Anything bigger is
+better off downloaded to a file.
+*/
+const MAX_RESPONSE_SIZE = 10 << 20
+
+// The content type of a body that isn't given one with the as clause
+const DEFAULT_CONTENT_TYPE = "text/plain; charset=utf-8"
+
+/*
+Get the statements that make a request and the method that each uses. No
+parameters. Returns the methods by the statement names.
+*/
+func RequestMethods() map[string]string {
+	return map[string]string{
+		"fetch": http.MethodGet,
+		"post":  http.MethodPost,
+		"put":   http.MethodPut,
+	}
+}
+
+/*
+Get the clauses that can follow the URL of a statement that makes a request
+(eg. post "[url]" with "[body]" as "application/json" to "response"). A fetch
+statement doesn't send a body so it only has the to and expect clauses.
+Parameters include the method. Returns the clauses.
+*/
+func RequestClauses(method string) []string {
+	if method == http.MethodGet {
+		return []string{REQUEST_CLAUSE_TO, REQUEST_CLAUSE_EXPECT}
+	}
+	return []string{
+		REQUEST_CLAUSE_WITH,
+		REQUEST_CLAUSE_AS,
+		REQUEST_CLAUSE_TO,
+		REQUEST_CLAUSE_EXPECT,
+	}
+}
+
+/*
+Check whether a status code is one that the expect clause of a request
+allows (eg. expect "2xx" or expect "200, 404"). Parameters include the
+statuses, separated by commas, each a status code or a class of them such as
+2xx, and the status code. Returns true if the status code is allowed and an
+error if the statuses aren't valid.
+*/
+func StatusExpected(expected string, status int) (bool, error) {
+	code := strconv.Itoa(status)
+	is_expected := false
+	for _, part := range strings.Split(expected, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		_, number_error := strconv.Atoi(part)
+		is_class := len(part) == 3 && strings.HasSuffix(part, "xx")
+		if len(part) != 3 || part[0] < '1' || part[0] > '5' ||
+			(number_error != nil && !is_class) {
+			return false, fmt.Errorf("%q isn't a status code or a class of "+
+				"them such as 2xx", part)
+		}
+		if part == code || (is_class && part[0] == code[0]) {
+			is_expected = true
+		}
+	}
+	return is_expected, nil
+}
+
+/*
+Save the status code and the response headers that scripts most often need
+to the reserved variables (ie. b_http_status, b_http_content_type,
+b_http_location, and b_http_etag). Parameters include the response, nil to
+clear them before a request is made. No returns.
+*/
+func (interpreter *Interpreter) SetResponseVariables(response *http.Response) {
+	status := 0
+	headers := http.Header{}
+	if response != nil {
+		status = response.StatusCode
+		headers = response.Header
+	}
+	interpreter.Variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"http_status"] =
+		IntegerValue(int64(status))
+	for variable, header := range map[string]string{
+		"http_content_type": "Content-Type",
+		"http_location":     "Location",
+		"http_etag":         "ETag",
+	} {
+		interpreter.Variables[SYMBOL_RESERVED_VARIABLE_PREFIX+variable] =
+			StringValue(headers.Get(header))
+	}
+}
+
+/*
+Report a request that couldn't be made or that the server responded to with
+a status code that the expect clause doesn't allow. Parameters include the
+tokens of the statement, the URL, the response, nil if there wasn't one, and
+the error, nil if there wasn't one. Returns the error to report.
+*/
+func ReportRequestError(
+	tokens []Token,
+	request_url string,
+	response *http.Response,
+	err error) *ScriptError {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	if response == nil {
+		return Report(
+			"The request to "+utils.ColouriseCyan(request_url)+" couldn't "+
+				"be made: "+err.Error()+".",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		).WithRule("runtime/http-request").WithHint(
+			"Make sure that the URL is valid and that the server can be " +
+				"reached (see the " + utils.ColouriseMagenta("http") +
+				" statement for proxies and timeouts).",
+		).WithErr(err)
+	}
+	return Report(
+		"The server at "+utils.ColouriseCyan(request_url)+" responded "+
+			"with "+utils.ColouriseRed(response.Status)+".",
+		loc,
+		tokens[2].TokenPosition,
+		full_loc,
+	).WithRule("runtime/http-status").WithHint(
+		"The status code isn't one that the " +
+			utils.ColouriseMagenta(REQUEST_CLAUSE_EXPECT) + " clause " +
+			"allows. It is saved to " +
+			utils.ColouriseYellow("#b_http_status") + " and what the " +
+			"server responded with to the variable in the " +
+			utils.ColouriseMagenta(REQUEST_CLAUSE_TO) + " clause, if " +
+			"there is one.",
+	).WithErr(err)
+}
+
+// ----------------------------------------------------------------------------
+
+// ----------------------------------------------------------------------------
+/*
+goto and repeat statement helpers
+*/
+
+/*
+Report that a script has looped more times than the interpreter allows.
+Parameters include the tokens of the statement that would have looped again.
+Returns the error.
+*/
+func (interpreter *Interpreter) ReportTooManyIterations(tokens []Token) error {
+	// Get the maximum number of iterations as a string
+	max_iterations := strconv.Itoa(interpreter.MaxIterations)
+	return Report(
+		"This would make the script loop more than "+
+			utils.ColouriseYellow(max_iterations)+" times so it has been "+
+			"stopped. This usually means that the script would have looped "+
+			"forever (eg. a "+utils.ColouriseCyan("goto")+" statement "+
+			"whose condition is never met).",
+		strconv.Itoa(tokens[0].LineNumber),
+		tokens[1].TokenPosition,
+		tokens[0].FullLineOfCode,
+	).WithHint(
+		"If the script needs to loop more than this, run it with a higher " +
+			utils.ColouriseYellow("-maxiterations") + " value.",
+	)
+}
+
+/*
+Check the number of times that a repeat statement repeats its statement. This
+is called both when the statement is parsed (for counts that don't rely on a
+variable) and when it is executed. Parameters include the tokens of the repeat
+statement and the count to check. Returns the count as an integer and an error
+if the count isn't valid.
+*/
+func (interpreter *Interpreter) CheckRepeatCount(
+	tokens []Token, count string) (int, error) {
+	// Create an integer version of the count
+	count_int, count_error := strconv.Atoi(count)
+	/* If there is an error trying to do the conversion or if the count is
+	less than zero, report an error.
+	*/
+	if count_error != nil || count_int < 0 {
+		return 0, Report(
+			"The number of times to repeat - "+
+				utils.ColouriseYellow(count)+" - is not valid. You need to "+
+				"use a positive integer.",
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[2].TokenPosition,
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// If the count is more than the script is allowed to loop, stop here
+	if count_int > interpreter.MaxIterations {
+		return 0, interpreter.ReportTooManyIterations(tokens)
+	}
+	// If we've gotten here, the count is valid
+	return count_int, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// ----------------------------------------------------------------------------
+/*
+if statement helpers
+*/
+
+/*
+The Condition type houses the condition of an if statement. The structure of
+the condition is as follows:
+  - Subject [string]: what the condition checks, one of "file", "directory",
+    or "variable", or empty where two values are being compared
+  - Operator [string]: the words that make up the check (eg. "is greater
+    than" or "exists")
+  - Left [Token]: the value on the left of a comparison or, otherwise, the
+    subject itself (eg. the path of a file)
+  - Right [Token]: the value on the right of a comparison. This is empty for
+    every other condition.
+*/
+type Condition struct {
+	Subject  string
+	Operator string
+	Left     Token
+	Right    Token
+}
+
+/*
+Create a map of the subjects of conditions and the operators that they can be
+used with. The empty subject is used for comparing two values. No parameters.
+Returns the map of subjects to operators.
+*/
+func ConditionOperators() map[string][]string {
+	return map[string][]string{
+		"": {
+			"is",
+			"is not",
+			"is greater than",
+			"is less than",
+			"is at least",
+			"is at most",
+		},
+		"directory": {"exists", "does not exist"},
+		"file":      {"exists", "does not exist"},
+		"variable":  {"is set", "is not set"},
+	}
+}
+
+/*
+Create a string list of the conditions that can be easily printed if need be.
+No parameters. Returns a string representation of the list of conditions.
+*/
+func ListConditions() string {
+	// Hold the list of conditions
+	var conditions []string
+	// Hold the subjects in a set order so that the list is always the same
+	subjects := []string{"", "file", "directory", "variable"}
+	for _, subject := range subjects {
+		for _, operator := range ConditionOperators()[subject] {
+			// Comparisons have a value on either side of the operator
+			if subject == "" {
+				conditions = append(conditions,
+					utils.ColouriseGreen("\"[value]\"")+" "+operator+" "+
+						utils.ColouriseGreen("\"[value]\""))
+				// Variables are checked by name
+			} else if subject == "variable" {
+				conditions = append(conditions,
+					subject+" "+utils.ColouriseGreen("\"[name]\"")+" "+
+						operator)
+				// Files and directories are checked by path
+			} else {
+				conditions = append(conditions,
+					subject+" "+utils.ColouriseGreen("\"[path]\"")+" "+
+						operator)
+			}
+		}
+	}
+
+	var condition_list string
+	for _, condition := range conditions {
+		condition_list += "\n\t- " + condition
+	}
+	return condition_list
+}
+
+/*
+Join the values of a set of tokens back into the words that they were written
+as. This is used to print out conditions in verbose mode. Parameters include
+the tokens. Returns the joined values.
+*/
+func JoinTokenValues(tokens []Token) string {
+	// Hold the values of the tokens
+	var token_values []string
+	for _, token := range tokens {
+		token_values = append(token_values, token.TokenValue)
+	}
+	return strings.Join(token_values, " ")
+}
+
+/*
+Find the then keyword in an if statement. Parameters include the tokens of the
+if statement. Returns the index of the then keyword or -1 if there isn't one.
+*/
+func FindConditionEnd(tokens []Token) int {
+	// Start after the statement name and look for the first then keyword
+	for index := 2; index < len(tokens); index++ {
+		if tokens[index].TokenValue == SYMBOL_CONDITION_THEN {
+			return index
+		}
+	}
+	// If we've gotten here, there is no then keyword
+	return -1
+}
+
+/*
+Create the tokens for the statement that an if statement runs. The line of
+code token is kept at the start so that the statement can be checked and
+called like any other and so that errors still point to the full line of
+code. Parameters include the tokens of the if statement and the index of the
+then keyword. Returns the tokens of the statement.
+*/
+func NestedStatementTokens(tokens []Token, then_index int) []Token {
+	// Start with the line of code token
+	nested_tokens := []Token{tokens[0]}
+	// Add everything after the then keyword
+	return append(nested_tokens, tokens[then_index+1:]...)
+}
+
+/*
+Parse the condition of an if or goto statement. Any value that doesn't rely on
+a variable is checked here as well so that, for instance, comparing a word to a
+number is caught before the script is executed. Parameters include the tokens
+of the statement and the index of the first token of the condition and the
+index after the last. Returns the condition and an error if the condition is
+malformed.
+*/
+func ParseCondition(
+	tokens []Token,
+	condition_start int, condition_end int) (Condition, error) {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Hold the condition and the tokens that make up the condition
+	var condition Condition
+	condition_tokens := tokens[condition_start:condition_end]
+	// Hold the tokens that make up the operator
+	var operator_tokens []Token
+
+	// Get the valid operators for each subject
+	condition_operators := ConditionOperators()
+	/*
+		A condition needs at least three tokens, be it a subject, a value, and
+		an operator or a value, an operator, and a value.
+	*/
+	if len(condition_tokens) >= 3 {
+		// Check to see if the first token is a subject (eg. file)
+		subject := condition_tokens[0].TokenValue
+		if _, is_subject := condition_operators[subject]; is_subject {
+			// The subject is followed by its value and then the operator
+			condition.Subject = subject
+			condition.Left = condition_tokens[1]
+			operator_tokens = condition_tokens[2:]
+		} else {
+			// Otherwise, the operator sits between two values
+			last_index := len(condition_tokens) - 1
+			condition.Left = condition_tokens[0]
+			condition.Right = condition_tokens[last_index]
+			operator_tokens = condition_tokens[1:last_index]
+		}
+	}
+
+	// Join the operator tokens into a single operator
+	var operator_words []string
+	for _, operator_token := range operator_tokens {
+		operator_words = append(operator_words, operator_token.TokenValue)
+	}
+	condition.Operator = strings.Join(operator_words, " ")
+
+	// If the operator isn't valid for the subject, report an error
+	if !slices.Contains(
+		condition_operators[condition.Subject], condition.Operator) {
+		// Give an example of the statement that the condition is in
+		example := utils.ColouriseCyan("if") + " " +
+			utils.ColouriseGreen("\"#b_os\"") + " is " +
+			utils.ColouriseGreen("\"linux\"") + " then " +
+			utils.ColouriseCyan("writeln") + " " +
+			utils.ColouriseGreen("\"Hello Linux!\"")
+		if tokens[1].TokenValue == "goto" {
+			example = utils.ColouriseCyan("goto") + " " +
+				utils.ColouriseGreen("\"linux\"") + " " +
+				SYMBOL_CONDITION_IF + " " +
+				utils.ColouriseGreen("\"#b_os\"") + " is " +
+				utils.ColouriseGreen("\"linux\"")
+		}
+		return condition, Report(
+			"The condition in this "+utils.ColouriseCyan(tokens[1].TokenValue)+
+				" statement isn't one that can be checked. A condition "+
+				"needs to take one of the following forms:\n"+
+				ListConditions()+"\n\nAn example of a working version "+
+				"might be "+example+".",
+			loc,
+			tokens[condition_start].TokenPosition,
+			full_loc,
+		)
+	}
+
+	/*
+		Numbers can only be compared with the operators after is and is not.
+		Check any values that don't rely on a variable now as they won't
+		change between now and when the script is executed.
+	*/
+	if condition.Subject == "" && condition.Operator != "is" &&
+		condition.Operator != "is not" {
+		for _, value_token := range []Token{condition.Left, condition.Right} {
+			// Fix the value
+			value := FixStringCombined(value_token.TokenValue)
+			// Skip any value that relies on a variable
+			if strings.Contains(value, SYMBOL_VARIABLE_SUBSTITUTION) {
+				continue
+			}
+			// If the value isn't a number, report an error
+			_, number_error := strconv.ParseFloat(value, 64)
+			if number_error != nil {
+				return condition, ReportNotANumber(tokens, value_token, value)
+			}
+		}
+	}
+
+	// If we've gotten here, the condition is well formed
+	return condition, nil
+}
+
+/*
+Report that a value in a comparison isn't a number. Parameters include the
+tokens of the statement, the token of the value, and the value itself.
+Returns the error.
+*/
+func ReportNotANumber(tokens []Token, value_token Token, value string) error {
+	return Report(
+		"The value "+utils.ColouriseYellow(value)+" is not a number. "+
+			"Only numbers can be compared using "+
+			utils.ColouriseMagenta("is greater than")+", "+
+			utils.ColouriseMagenta("is less than")+", "+
+			utils.ColouriseMagenta("is at least")+", and "+
+			utils.ColouriseMagenta("is at most")+". If you want to check "+
+			"that two values are the same, use "+
+			utils.ColouriseMagenta("is")+" or "+
+			utils.ColouriseMagenta("is not")+".",
+		strconv.Itoa(tokens[0].LineNumber),
+		value_token.TokenPosition,
+		tokens[0].FullLineOfCode,
+	)
+}
+
+/*
+Evaluate the condition of an if or goto statement. Any variables in the values
+are substituted first. Parameters include the tokens of the statement and the
+condition. Returns whether the condition is met and an error if it couldn't be
+checked.
+*/
+func (interpreter *Interpreter) EvaluateCondition(
+	tokens []Token, condition Condition) (bool, error) {
+	// Fix the value on the left
+	left := FixStringCombined(condition.Left.TokenValue)
+	// Whether the operator is a negated one (eg. is not set)
+	negated := strings.Contains(condition.Operator, "not")
+
+	switch condition.Subject {
+	case "file", "directory":
+		// Get a templated value of the path
+		path, template_error := interpreter.Template(
+			left, tokens, condition.Left)
+		if template_error != nil {
+			return false, template_error
+		}
+		// See if there is anything at the path
+		path_info, path_error := os.Stat(path)
+		// Check that it is the right kind of thing
+		exists := path_error == nil &&
+			path_info.IsDir() == (condition.Subject == "directory")
+		return exists != negated, nil
+	case "variable":
+		/*
+			Check the variable by its name. This is not templated, much like
+			the variable name in an ask statement.
+		*/
+		exists, _ := interpreter.CheckVariableExistence(left)
+		return exists != negated, nil
+	}
+
+	// Get templated values of either side of the comparison
+	left_value, template_error := interpreter.TemplateValue(
+		left, tokens, condition.Left)
+	if template_error != nil {
+		return false, template_error
+	}
+	right_value, template_error := interpreter.TemplateValue(
+		FixStringCombined(condition.Right.TokenValue),
+		tokens,
+		condition.Right)
+	if template_error != nil {
+		return false, template_error
+	}
+
+	// The is and is not operators compare the values as they are
+	if condition.Operator == "is" || condition.Operator == "is not" {
+		return left_value.Equals(right_value) != negated, nil
+	}
+
+	// Every other operator compares the values as numbers
+	left_number, left_is_number := left_value.Number()
+	if !left_is_number {
+		return false, ReportNotANumber(
+			tokens, condition.Left, left_value.String())
+	}
+	right_number, right_is_number := right_value.Number()
+	if !right_is_number {
+		return false, ReportNotANumber(
+			tokens, condition.Right, right_value.String())
+	}
+
+	switch condition.Operator {
+	case "is greater than":
+		return left_number > right_number, nil
+	case "is less than":
+		return left_number < right_number, nil
+	case "is at least":
+		return left_number >= right_number, nil
+	default:
+		return left_number <= right_number, nil
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// ----------------------------------------------------------------------------
+/*
+list helpers
+*/
+
+/*
+Find the items of a list in a statement (eg. ["a.txt", "#name"]). The list
+runs from its opening bracket to the end of the statement and each item is a
+single value with a comma between it and the next. Parameters include the
+tokens of the statement and the index of the opening bracket. Returns the
+indexes of the items and the index of the token where the list stops being
+well formed, which is -1 if it is well formed.
+*/
+func ListItemIndexes(tokens []Token, open_index int) ([]int, int) {
+	item_indexes := []int{}
+	last_index := len(tokens) - 1
+	// The list needs to end with a closing bracket
+	if tokens[last_index].TokenValue != SYMBOL_LIST_CLOSE ||
+		last_index == open_index {
+		return nil, last_index
+	}
+	for index := open_index + 1; index < last_index; index += 2 {
+		// An item can't be a symbol of the list itself
+		item := tokens[index].TokenValue
+		if item == SYMBOL_LIST_OPEN || item == SYMBOL_LIST_CLOSE ||
+			item == SYMBOL_LIST_SEPERATOR {
+			return nil, index
+		}
+		item_indexes = append(item_indexes, index)
+		// Every item bar the last needs a comma after it
+		if index+1 < last_index &&
+			tokens[index+1].TokenValue != SYMBOL_LIST_SEPERATOR {
+			return nil, index + 1
+		}
+	}
+	// A comma can't be the last thing in the list
+	if tokens[last_index-1].TokenValue == SYMBOL_LIST_SEPERATOR {
+		return nil, last_index - 1
+	}
+	return item_indexes, -1
+}
+
+/*
+Run a statement once for each item of a list where one of its values is a
+list variable (eg. copyfile "#files" to "backup/"). While the statement runs
+for an item, the variable holds that item in place of the list so that the
+statement can treat it like any other value. Parameters include the tokens of
+the statement, the index of the value, and the statement. Returns whether the
+value is a list, in which case the statement has been run for each item, and
+an error if the statement failed for an item.
+*/
+func (interpreter *Interpreter) ForEachItem(
+	tokens []Token,
+	index int,
+	statement func([]Token) error,
+) (bool, error) {
+	// Get the variable, if the value is nothing but a variable
+	value := FixStringCombined(tokens[index].TokenValue)
+	list, is_variable := interpreter.VariableValue(value)
+	if !is_variable || list.Type != VALUE_LIST {
+		return false, nil
+	}
+	name, _ := ResolveVariable(
+		VariableReferences(value)[0], interpreter.Variables)
+
+	// Put the list back once every item has been run
+	defer func() {
+		interpreter.Variables[name] = list
+	}()
+	for _, item := range list.Items {
+		interpreter.Variables[name] = item
+		if item_error := statement(tokens); item_error != nil {
+			return true, item_error
+		}
+	}
+	return true, nil
+}
+
+// ----------------------------------------------------------------------------
+
+// ----------------------------------------------------------------------------
+/*
+run statement helpers
+*/
+
+/*
+Check that the script that a run or define statement points to exists,
+reporting an error if it does not. This is called both when the statement is
+parsed and when it is executed. Parameters include the tokens of the
+statement, the index of the token that holds the script, and the templated name
+of the script. Returns an error if the script doesn't exist.
+*/
+func RunTargetExists(
+	tokens []Token, path_index int, script_name string) error {
+	// If the script doesn't exist, report it
+	if !CheckFileExists(script_name) {
+		return Report(
+			"The script - "+utils.ColouriseYellow(script_name)+" - does "+
+				"not exist and/or can't be accessed. Double check to verify "+
+				"that the script exists.",
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[path_index].TokenPosition,
+			tokens[0].FullLineOfCode,
+		)
+	}
+	return nil
+}
+
 // ----------------------------------------------------------------------------
@@ -0,0 +1,459 @@
+/*
+This file contains the statement tree, the structure that a script is parsed
+into before any of it is executed. Parse() builds the tree and Execute() walks
+it. Splitting the two means that the whole script, including any scripts that
+are reached via the run statement, is checked before anything is executed.
+*/
+package parser
+
+import (
+	"appetit/utils"
+	"errors"
+	"slices"
+	"strconv"
+)
+
+/*
+The Statement type houses a single checked statement call in the statement
+tree. The structure of the statement is as follows:
+  - Name [string]: the name of the statement (eg. writeln)
+  - Tokens [[]Token]: the tokenised line of code inclusive of the line of
+    code token at the start
+  - Script [*Script]: for a run statement, the parsed script that will be
+    run and, for a define statement, the procedure that it defines. This is
+    nil for every other statement and for run statements where the script
+    can only be located at runtime (eg. it relies on a variable set by the
+    script).
+*/
+type Statement struct {
+	Name   string
+	Tokens []Token
+	Script *Script
+}
+
+/*
+The Script type houses a parsed script. The structure of the script is as
+follows:
+  - Name [string]: the path to the script
+  - Statements [[]Statement]: the statements in the script in the order in
+    which they appear
+  - Labels [map[string]int]: the labels in the script and the index of the
+    label statement in Statements
+  - Parameters [[]Parameter]: the parameters declared with params statements
+    at the top of the script (see arguments.go)
+*/
+type Script struct {
+	Name       string
+	Statements []Statement
+	Labels     map[string]int
+	Parameters []Parameter
+}
+
+/*
+Add a label to the script. The label points to the next statement to be added
+to the script, that is, the label statement itself. Parameters include the
+tokens of the label statement. Returns an error if the label is already in the
+script.
+*/
+func (script *Script) AddLabel(tokens []Token) error {
+	// Get the name of the label
+	label := FixStringCombined(tokens[2].TokenValue)
+	// Create the labels if this is the first one
+	if script.Labels == nil {
+		script.Labels = map[string]int{}
+	}
+	// If the label is already in the script, report an error
+	if index, exists := script.Labels[label]; exists {
+		first_line := script.Statements[index].Tokens[0].LineNumber
+		return Report(
+			"The label "+utils.ColouriseYellow(label)+" is already used "+
+				"on line "+utils.ColouriseYellow(strconv.Itoa(first_line))+
+				". Each label in a script needs to have its own name so that "+
+				"a "+utils.ColouriseCyan("goto")+" statement knows where to "+
+				"go.",
+			strconv.Itoa(tokens[0].LineNumber),
+			tokens[2].TokenPosition,
+			tokens[0].FullLineOfCode,
+		)
+	}
+	// Point the label at the next statement
+	script.Labels[label] = len(script.Statements)
+	return nil
+}
+
+/*
+Check that every goto statement in the script goes to a label in the same
+script. This is done once the whole script is parsed so that a goto statement
+can go to a label further down the script. No parameters. Returns an error for
+each goto statement that goes to a label that doesn't exist.
+*/
+func (script *Script) CheckGotoLabels() []error {
+	// Hold an error for each goto statement with nowhere to go
+	var goto_errors []error
+	// Loop over the statements, looking for goto statements
+	for _, statement := range script.Statements {
+		if statement.Name != "goto" {
+			continue
+		}
+		// Get the name of the label
+		label := FixStringCombined(statement.Tokens[2].TokenValue)
+		/*
+			If the label doesn't exist, report an error. This is checked as
+			the script is parsed so the error is a syntax error.
+		*/
+		if _, exists := script.Labels[label]; !exists {
+			goto_errors = append(goto_errors, AnnotateError(Report(
+				"There is no label called "+utils.ColouriseYellow(label)+
+					" in this script. Make sure that there is a "+
+					utils.ColouriseCyan("label")+" statement with the same "+
+					"name (eg. "+utils.ColouriseCyan("label")+" "+
+					utils.ColouriseGreen("\""+label+"\"")+").",
+				strconv.Itoa(statement.Tokens[0].LineNumber),
+				statement.Tokens[2].TokenPosition,
+				statement.Tokens[0].FullLineOfCode,
+			), statement.Tokens, ERROR_SYNTAX))
+		}
+	}
+	// Return the errors, nil if every goto statement has somewhere to go
+	return goto_errors
+}
+
+/*
+Parse the target of a run statement so that it is checked along with the
+script that runs it. Parameters include the tokens of the run statement and
+the name of the script that it is in. Returns the parsed script or nil if the
+target can't be known until runtime and an error if the target doesn't exist
+or doesn't parse.
+*/
+func (interpreter *Interpreter) ParseRunTarget(
+	tokens []Token, parent_name string) (*Script, error) {
+	// Get the name of the script to run and template it
+	script_name, undefined := interpreter.Interpolate(
+		FixStringCombined(tokens[2].TokenValue))
+
+	/*
+		If there is still a variable in the name, it must be one that the
+		script sets so we can't know the target until the script is running.
+		Run() will parse the target when it gets there instead. If we are only
+		checking the script, the target will never be reached so warn that it
+		wasn't checked.
+	*/
+	if len(undefined) > 0 {
+		if interpreter.ModeCheck {
+			warning := Report(
+				"The script that this "+utils.ColouriseCyan("run")+
+					" statement runs - "+utils.ColouriseYellow(script_name)+
+					" - can only be found once the script is running so it "+
+					"hasn't been checked.",
+				strconv.Itoa(tokens[0].LineNumber),
+				tokens[2].TokenPosition,
+				tokens[0].FullLineOfCode,
+			).WithRule("check/unchecked-run-target")
+			warning.Statement = "run"
+			warning.Script = parent_name
+			interpreter.Warning(warning)
+		}
+		return nil, nil
+	}
+
+	// If this script is already being parsed, leave it until runtime
+	if slices.Contains(interpreter.scripts_being_parsed, script_name) {
+		return nil, nil
+	}
+
+	// Parse the script
+	return interpreter.ParseFile(tokens, 2, script_name)
+}
+
+/*
+Parse a script that a run or define statement points to. Parameters include
+the tokens of the statement, the index of the token that holds the script, and
+the templated name of the script. Returns the parsed script and an error if the
+script doesn't exist or doesn't parse.
+*/
+func (interpreter *Interpreter) ParseFile(
+	tokens []Token, path_index int, script_name string) (*Script, error) {
+	// Check that the script exists before we try to parse it
+	exists_error := RunTargetExists(tokens, path_index, script_name)
+	if exists_error != nil {
+		return nil, exists_error
+	}
+
+	// Prep the script by opening it and removing the comments
+	contents, prep_error := PrepScript(script_name)
+	if prep_error != nil {
+		return nil, prep_error
+	}
+
+	// Note that we are parsing this script and remove it once we're done
+	interpreter.scripts_being_parsed = append(
+		interpreter.scripts_being_parsed, script_name)
+	defer func() {
+		parsed_count := len(interpreter.scripts_being_parsed)
+		interpreter.scripts_being_parsed =
+			interpreter.scripts_being_parsed[:parsed_count-1]
+	}()
+
+	// Parse the script
+	return interpreter.Parse(contents, script_name)
+}
+
+/*
+Parse the procedure of a define statement and add it to the interpreter's
+procedures. The file form is parsed much like the target of a run statement
+while the block form starts out empty so that Parse() can add the statements
+of the block to it. Parameters include the tokens of the define statement and
+the name of the script that it is in. Returns the procedure and an error if it
+couldn't be parsed or has already been defined elsewhere.
+*/
+func (interpreter *Interpreter) ParseDefine(
+	tokens []Token, script_name string) (*Script, error) {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Get the name of the procedure
+	procedure_name := FixStringCombined(tokens[2].TokenValue)
+
+	/*
+		Note where the procedure is defined. A script that is parsed more
+		than once (eg. a script that is run twice) defines its procedures
+		again so that is fine but defining a procedure elsewhere is not.
+	*/
+	origin := script_name + ":" + loc
+	existing_origin, exists := interpreter.procedure_origins[procedure_name]
+	if exists && existing_origin != origin {
+		return nil, Report(
+			"The procedure "+utils.ColouriseYellow(procedure_name)+" is "+
+				"already defined ("+utils.ColouriseYellow(existing_origin)+
+				"). Each procedure needs to have its own name so that a "+
+				utils.ColouriseCyan("call")+" statement knows which one to "+
+				"call.",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+
+	// Start with an empty procedure for the block form
+	procedure := &Script{Name: script_name}
+	// If this is the file form, parse the file
+	if len(tokens) > 3 {
+		// Get the name of the file and template it
+		file_name, undefined := interpreter.Interpolate(
+			FixStringCombined(tokens[4].TokenValue))
+		/*
+			The procedure needs to be known before the script runs so that
+			call statements can be checked so the file can only rely on the
+			reserved variables.
+		*/
+		if len(undefined) > 0 {
+			return nil, Report(
+				"The file - "+utils.ColouriseYellow(file_name)+" - can't "+
+					"be found before the script runs. The file of a "+
+					utils.ColouriseCyan("define")+" statement can only use "+
+					"reserved variables (eg. "+
+					utils.ColouriseGreen("\"#b_home/backup.apt\"")+").",
+				loc,
+				tokens[4].TokenPosition,
+				full_loc,
+			)
+		}
+		// A file that defines itself would never finish being parsed
+		if slices.Contains(interpreter.scripts_being_parsed, file_name) {
+			return nil, Report(
+				"The file - "+utils.ColouriseYellow(file_name)+" - is "+
+					"already being parsed so it can't be used to define the "+
+					"procedure "+utils.ColouriseYellow(procedure_name)+". "+
+					"Check that the file doesn't define itself.",
+				loc,
+				tokens[4].TokenPosition,
+				full_loc,
+			)
+		}
+		// Parse the file
+		file_procedure, file_error := interpreter.ParseFile(
+			tokens, 4, file_name)
+		if file_error != nil {
+			return nil, file_error
+		}
+		procedure = file_procedure
+	}
+
+	// Add the procedure to the interpreter
+	interpreter.Procedures[procedure_name] = procedure
+	interpreter.procedure_origins[procedure_name] = origin
+	return procedure, nil
+}
+
+/*
+The call_to_check type houses a call statement that is waiting to be checked
+against the procedures once the whole script is parsed. The structure of the
+call is as follows:
+  - tokens [[]Token]: the tokens of the call statement
+  - script_name [string]: the name of the script that the call statement is
+    in
+*/
+type call_to_check struct {
+	tokens      []Token
+	script_name string
+}
+
+/*
+Get the tokens of the statement that will actually be called, that is, the
+statement inside of any if or repeat statements. Parameters include the tokens
+of the statement. Returns the tokens of the innermost statement.
+*/
+func InnermostStatementTokens(tokens []Token) []Token {
+	// Keep going until the statement isn't an if or repeat statement
+	for len(tokens) > 1 {
+		switch tokens[1].TokenValue {
+		case "if":
+			tokens = NestedStatementTokens(tokens, FindConditionEnd(tokens))
+		case "repeat":
+			tokens = NestedStatementTokens(tokens, 3)
+		default:
+			return tokens
+		}
+	}
+	return tokens
+}
+
+/*
+Check that every call statement that has been parsed calls a procedure that
+has been defined. This is done once the whole script, inclusive of any scripts
+that it runs or defines procedures from, is parsed so that a procedure can be
+called before it is defined. No parameters. Returns an error for each call
+statement that calls a procedure that doesn't exist.
+*/
+func (interpreter *Interpreter) CheckCalls() []error {
+	// Hold an error for each call statement with nothing to call
+	var call_errors []error
+	// Hold the calls to check and start afresh for the next script
+	calls_to_check := interpreter.calls_to_check
+	interpreter.calls_to_check = nil
+	// Loop over the call statements
+	for _, call := range calls_to_check {
+		// Get the tokens of the call statement
+		tokens := call.tokens
+		// Get the name of the procedure
+		procedure_name := FixStringCombined(tokens[2].TokenValue)
+		// If the procedure doesn't exist, report an error
+		if _, exists := interpreter.Procedures[procedure_name]; !exists {
+			call_error := Report(
+				"There is no procedure called "+
+					utils.ColouriseYellow(procedure_name)+". Make sure that "+
+					"there is a "+utils.ColouriseCyan("define")+" statement "+
+					"with the same name (eg. "+utils.ColouriseCyan("define")+
+					" "+utils.ColouriseGreen("\""+procedure_name+"\"")+").",
+				strconv.Itoa(tokens[0].LineNumber),
+				tokens[2].TokenPosition,
+				tokens[0].FullLineOfCode,
+			)
+			// Note which script the call statement is in
+			call_error.Script = call.script_name
+			call_errors = append(call_errors,
+				AnnotateError(call_error, tokens, ERROR_SYNTAX))
+		}
+	}
+	// Return the errors, nil if every call statement has something to call
+	return call_errors
+}
+
+/*
+The Debugger interface is for anything that needs to see each statement
+before it is executed (eg. the debugger for the -debug flag). The statement,
+the script that it is in, and how many scripts deep it is (1 for the script
+itself, 2 for a procedure or script that it runs, and so on) are passed.
+Returning an error stops the script with that error.
+*/
+type Debugger interface {
+	BeforeStatement(statement Statement, script *Script, depth int) error
+}
+
+/*
+Execute a parsed script statement by statement, stopping at the first
+statement that fails. The statements are tracked by their index so that a goto
+statement can jump to a label. Parameters include the parsed script. Returns an
+error if a statement failed or if the script looped more than the interpreter
+allows.
+*/
+func (interpreter *Interpreter) Execute(script *Script) error {
+	// Note that we are a script deeper until this script is done
+	interpreter.execute_depth += 1
+	defer func() { interpreter.execute_depth -= 1 }()
+	// Count the number of times that the script has jumped back
+	iterations := 0
+	// Loop over the statements in the order that they were parsed
+	for index := 0; index < len(script.Statements); index++ {
+		// Get the statement
+		statement := script.Statements[index]
+		// Let any debugger see the statement before it is executed
+		if interpreter.Debugger != nil {
+			debugger_error := interpreter.Debugger.BeforeStatement(
+				statement, script, interpreter.execute_depth)
+			if debugger_error != nil {
+				return debugger_error
+			}
+		}
+		/*
+			If this is a run statement whose target was parsed with the rest
+			of the script, execute that directly rather than having Run()
+			open and parse the script again.
+		*/
+		if statement.Name == "run" && statement.Script != nil {
+			execute_error := interpreter.Execute(statement.Script)
+			if execute_error != nil {
+				return execute_error
+			}
+			continue
+		}
+		// Otherwise, delegate to the statement itself
+		call_error := interpreter.Call(statement.Tokens)
+
+		/*
+			If the statement is a goto statement, jump to the label. The loop
+			moves past the label statement itself and onto the statement after
+			it.
+		*/
+		var goto_error *GotoError
+		if errors.As(call_error, &goto_error) {
+			label_index := script.Labels[goto_error.Label]
+			/*
+				Jumping back is what makes a loop so count those jumps and stop
+				the script if it has looped too many times.
+			*/
+			if label_index <= index {
+				iterations += 1
+				if iterations > interpreter.MaxIterations {
+					return interpreter.ReportTooManyIterations(
+						goto_error.Tokens)
+				}
+			}
+			index = label_index
+			continue
+		}
+		if call_error != nil {
+			return call_error
+		}
+	}
+	// If we've gotten here, every statement was executed
+	return nil
+}
+
+/*
+Print out the tokens of a parsed script, inclusive of any scripts reached via
+the run statement. This is used by the -dev flag. Parameters include the parsed
+script. Returns nothing.
+*/
+func (interpreter *Interpreter) PrintScriptTokens(script *Script) {
+	// Loop over the statements in the script
+	for _, statement := range script.Statements {
+		// Print the tokens
+		PrintTokenInfo(statement.Tokens)
+		// If the statement runs another script, print its tokens too
+		if statement.Script != nil {
+			interpreter.PrintScriptTokens(statement.Script)
+		}
+	}
+}
@@ -7,13 +7,16 @@ package parser
 import (
 	"appetit/utils"
 	"archive/zip"
-	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -23,55 +26,25 @@ import (
 /*
 ask statement
 
-Set a variable. Parameters include the tokens. Returns the final value of
-the variable.
+Set a variable to the user's answer. The answer is a string unless the
+statement ends with as and a type to convert it to or as expression to
+calculate it. Parameters include the tokens. Returns an error if the input
+couldn't be read or the answer couldn't be converted or calculated.
 */
-func Ask(tokens []Token) string {
+func (interpreter *Interpreter) Ask(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("ask")+" statement needs "+
-				"to follow the form:\n\n\t"+utils.ColouriseCyan("ask")+" "+
-				utils.ColouriseGreen("\"[question/prompt]\"")+
-				utils.ColouriseMagenta(" to ")+
-				utils.ColouriseYellow("\"[variable name]\"")+"\n\nAn example "+
-				"of a working version check might be:\n\n\t"+
-				utils.ColouriseCyan("ask")+" "+
-				utils.ColouriseGreen("\"What is your name?\"")+
-				utils.ColouriseMagenta(" to ")+
-				utils.ColouriseGreen("\"name\"")+"\n\n"+
-				"Your line of code looks like the following:\n\n\t"+
-				utils.ColouriseRed(full_loc),
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 
 	/* Fix the prompt to ensure that quotation marks and escapes are handled
 	properly.
 	*/
 	prompt := FixStringCombined(tokens[2].TokenValue)
 	// Get a templated value for the prompt
-	prompt = VariableTemplater(prompt)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	if action_error != nil {
-		Report(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	prompt, template_error := interpreter.Template(prompt, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
 
 	/* Fix the variable name to ensure that quotation marks and escapes are
@@ -79,51 +52,10 @@ func Ask(tokens []Token) string {
 	*/
 	variable_name := FixStringCombined(tokens[4].TokenValue)
 
-	/* Get the prefix of the variable so that we can check that it isn't
-	reserved
-	*/
-	// Hold the (possible) prefix for checking
-	var variable_prefix string
-	// If the length of the variable is less than the RESERVED_VARIABLE_PREFIX
-	if len(variable_name) < len(SYMBOL_RESERVED_VARIABLE_PREFIX) {
-		// Just set the prefix to the variable
-		variable_prefix = string(variable_name)
-	} else {
-		// Otherwise, create a prefix to check against
-		variable_prefix = string(
-			variable_name[0:len(SYMBOL_RESERVED_VARIABLE_PREFIX)],
-		)
-	}
-
-	// Check the variable prefix
-	var_prefix_error := CheckVariablePrefix(
-		loc, variable_prefix, variable_name)
-	if var_prefix_error != nil {
-		ReportWithFixes(
-			var_prefix_error.Error(),
-			loc,
-			tokens[4].TokenPosition,
-			full_loc,
-		)
-	}
-
-	// Check that the variable name is not one of the statement names
-	statement := CheckIsStatement(variable_name)
-	// If it is a statement
-	if statement {
-		ReportWithFixes(
-			"The variable - "+utils.ColouriseYellow(variable_name)+" - "+
-				"is not a valid variable name as it conflicts with a statement "+
-				"name.",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
-	}
-
 	// If verbose mode is set
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s user \"%s\" and saving to variable %s...\n",
 			utils.ColouriseBlue("Asking"),
 			utils.ColouriseGreen(prompt),
@@ -131,26 +63,17 @@ func Ask(tokens []Token) string {
 		)
 	}
 
-	/* Create a reader to get the input from user. Create a buffer size of
-	65,536 bytes which doesn't seem to be acknowledged by any operating
-	system. See issue #1 on GitHub for more. Leave this as-is though as
-	it does allow for some extra space for input on platforms such as
-	Windows. This is also potentially an issue with stdin limitations on
-	any one given platform.
-	*/
-	input_reader := bufio.NewReaderSize(os.Stdin, 65536)
 	// Prompt as per the prompt provided by the script
-	fmt.Print(prompt)
-	/* Read in the line while looking for the new line character as the
-	delimiter
+	fmt.Fprint(interpreter.Stdout, prompt)
+	/* Read in the line from the interpreter's input reader while looking for
+	the new line character as the delimiter
 	*/
-	//user_input, user_input_error := input_reader.ReadString('\n')
-	user_input, user_input_error := input_reader.ReadString('\n')
+	user_input, user_input_error := interpreter.input_reader.ReadString('\n')
 	// Convert the user_input_bytes to a string
 	user_input = strings.TrimSuffix(user_input, "\n")
 
 	if user_input_error != nil {
-		Report(
+		return Report(
 			"There was an error getting the user input. Please report the "+
 				"following error in yellow to the project's GitHub repository "+
 				"and a copy of the script:\n\n"+
@@ -161,71 +84,211 @@ func Ask(tokens []Token) string {
 		)
 	}
 
-	/* Get the final variable value here by checking to see if the value is
-	a math expression
+	/* The answer is a string unless the statement asks for it to be
+	calculated or converted (eg. ask "Age? " to "age" as integer)
 	*/
-	final_variable_value := CalculateValue(loc, user_input)
+	final_variable_value := StringValue(user_input)
+	if len(tokens) > 6 {
+		final_variable_value, user_input_error = interpreter.AnswerAs(
+			tokens, user_input)
+		if user_input_error != nil {
+			return user_input_error
+		}
+	}
 
 	// Set the variable
-	VARIABLES[variable_name] = final_variable_value
+	interpreter.Variables[variable_name] = final_variable_value
 
-	// Return the final value of the variable
-	return final_variable_value
+	return nil
 }
 
 /*
-copyfile statement
+call statement
+
+Call a procedure that was defined with the define statement. The procedure
+runs with its own copy of the variables so that anything it sets stays with it
+unless it is asked to return it. Any arguments are set in that copy before the
+procedure runs and any outputs are copied back once it is done. Parameters
+include the tokens. Returns an error if the procedure failed or didn't set an
+output.
+*/
+func (interpreter *Interpreter) CallProcedure(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
 
-Copy a file from an origin to a destination. The tokens are passed to get
-the origin, destination, and to ensure that the 'action' is appropriate.
-Returns nothing. Thanks to https://www.kelche.co/blog/go/golang-file-
-handling/.
+	// Get the call
+	procedure_call, call_error := ParseProcedureCall(tokens)
+	if call_error != nil {
+		return call_error
+	}
+	// Get the procedure
+	procedure, exists := interpreter.Procedures[procedure_call.Name]
+	if !exists {
+		return Report(
+			"There is no procedure called "+
+				utils.ColouriseYellow(procedure_call.Name)+".",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+
+	/*
+		A procedure that calls itself forever would never stop so count how
+		deep we are and stop if it's deeper than the script can loop.
+	*/
+	interpreter.call_depth += 1
+	defer func() {
+		interpreter.call_depth -= 1
+	}()
+	if interpreter.call_depth > interpreter.MaxIterations {
+		return interpreter.ReportTooManyIterations(tokens)
+	}
+
+	// Create a copy of the variables for the procedure
+	procedure_variables := maps.Clone(interpreter.Variables)
+	// Set the arguments in the copy
+	for _, argument := range procedure_call.Arguments {
+		/* Get the final variable value, that is, one where any variables
+		have been substituted and any math expression calculated
+		*/
+		argument_value, value_error := interpreter.EvaluateValue(
+			tokens, argument.Value)
+		if value_error != nil {
+			return value_error
+		}
+		procedure_variables[argument.Name.TokenValue] = argument_value
+	}
+
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s %s...\n",
+			utils.ColouriseBlue("Calling"),
+			utils.ColouriseYellow(procedure_call.Name),
+		)
+	}
+
+	// Run the procedure with its own variables and then put ours back
+	caller_variables := interpreter.Variables
+	interpreter.Variables = procedure_variables
+	execute_error := interpreter.Execute(procedure)
+	interpreter.Variables = caller_variables
+	if execute_error != nil {
+		return execute_error
+	}
+
+	// Copy the outputs back
+	for _, output := range procedure_call.Returns {
+		output_value, output_set := procedure_variables[output.TokenValue]
+		if !output_set {
+			return Report(
+				"The procedure "+utils.ColouriseYellow(procedure_call.Name)+
+					" was asked to return "+
+					utils.ColouriseYellow(output.TokenValue)+" but it never "+
+					"set it. Make sure that the procedure has a "+
+					utils.ColouriseCyan("set")+" or "+
+					utils.ColouriseCyan("ask")+" statement for it.",
+				loc,
+				output.TokenPosition,
+				full_loc,
+			)
+		}
+		interpreter.Variables[output.TokenValue] = output_value
+	}
+	return nil
+}
+
+/*
+convert statement
+
+Convert a variable to another type (eg. convert count to integer). Parameters
+include the tokens. Returns an error if the variable hasn't been set or its
+value can't be converted.
 */
-func CopyFile(tokens []Token) {
+func (interpreter *Interpreter) Convert(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("copyfile")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("copyfile")+" "+
-				utils.ColouriseGreen("\"[path]\"")+" to "+
-				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
-				"use of an inappropriate action symbol ("+
-				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
-				"example of a working version might be "+
-				utils.ColouriseCyan("copyfile")+
-				utils.ColouriseGreen(" \"test.txt\"")+" to "+
-				utils.ColouriseGreen(" \"test_new.txt\"")+"\n\nLine of "+
-				"Code: "+utils.ColouriseMagenta(full_loc),
+
+	// Get the variable name and the type to convert it to
+	variable_name := FixStringCombined(tokens[2].TokenValue)
+	value_type, _ := ValueTypeNamed(tokens[4].TokenValue)
+
+	// Get the variable
+	value, exists := interpreter.Variables[variable_name]
+	if !exists {
+		return Report(
+			"The variable "+utils.ColouriseYellow(variable_name)+
+				" hasn't been set so it can't be converted.",
 			loc,
-			"n/a",
+			tokens[2].TokenPosition,
+			full_loc,
+		).WithRule("runtime/undefined-variable")
+	}
+
+	// Convert the value
+	converted_value, convert_error := value.Convert(value_type)
+	if convert_error != nil {
+		return Report(
+			"The value of the variable "+
+				utils.ColouriseYellow(variable_name)+" - "+
+				utils.ColouriseGreen(value.Literal())+" - can't be "+
+				"converted to the type "+
+				utils.ColouriseMagenta(value_type.String())+".",
+			loc,
+			tokens[4].TokenPosition,
 			full_loc,
+		).WithErr(convert_error)
+	}
+
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s %s to the type %s...\n",
+			utils.ColouriseBlue("Converting"),
+			utils.ColouriseYellow(variable_name),
+			utils.ColouriseMagenta(value_type.String()),
 		)
 	}
+	// Set the variable
+	interpreter.Variables[variable_name] = converted_value
+
+	return nil
+}
+
+/*
+copyfile statement
+
+Copy a file from an origin to a destination. The tokens are passed to get
+the origin, destination, and to ensure that the 'action' is appropriate.
+Returns an error if the statement failed. Thanks to
+https://www.kelche.co/blog/go/golang-file-handling/.
+*/
+func (interpreter *Interpreter) CopyFile(tokens []Token) error {
+	// If the source is a list, copy each file in it
+	if is_list, list_error := interpreter.ForEachItem(
+		tokens, 2, interpreter.CopyFile); is_list {
+		return list_error
+	}
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
 
 	// Fix the source string
 	source := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	source = VariableTemplater(source)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	if action_error != nil {
-		Report(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	source, template_error := interpreter.Template(source, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
 
 	// Fix the destination string
@@ -233,7 +296,11 @@ func CopyFile(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	destination = VariableTemplater(destination)
+	destination, template_error = interpreter.Template(
+		destination, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
 
 	/* Split the origin by the os path separator so that we can get the
 	file name in case we need to append it
@@ -249,8 +316,20 @@ func CopyFile(tokens []Token) {
 		destination = destination + filename
 	}
 
-	if MODE_VERBOSE {
-		fmt.Printf(
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"copy",
+			utils.ColouriseGreen(source)+" to "+
+				utils.ColouriseGreen(destination)+" "+
+				utils.ColouriseMagenta(PathSummary(source)),
+		)
+		return nil
+	}
+
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s to %s...",
 			utils.ColouriseBlue("Copying"),
 			utils.ColouriseGreen(source),
@@ -260,7 +339,7 @@ func CopyFile(tokens []Token) {
 
 	source_file, err := os.Open(source)
 	if err != nil {
-		Report(
+		return Report(
 			"Can't open "+utils.ColouriseYellow(source)+
 				"! Are you sure that the file exists?",
 			loc,
@@ -271,7 +350,7 @@ func CopyFile(tokens []Token) {
 
 	destination_file, err := os.Create(destination)
 	if err != nil {
-		Report(
+		return Report(
 			"The destination - "+utils.ColouriseYellow(destination)+
 				" - is invalid. Are you source that the destination exists? If "+
 				"you're trying to copy to a directory, make sure to put in a "+
@@ -283,7 +362,7 @@ func CopyFile(tokens []Token) {
 	}
 	bytes, err := io.Copy(destination_file, source_file)
 	if err != nil {
-		Report(
+		return Report(
 			"There was an error doing the file copy/move. Check to ensure that "+
 				"source - "+source+" - and the destination - "+destination+
 				" - are valid.",
@@ -293,8 +372,9 @@ func CopyFile(tokens []Token) {
 		)
 	}
 
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			"done! "+
 				utils.ColouriseMagenta(
 					"[%s bytes written]\n",
@@ -302,40 +382,25 @@ func CopyFile(tokens []Token) {
 			strconv.FormatInt(bytes, 10),
 		)
 	}
+	return nil
 }
 
 /*
 copydirectory statement
 
 Copy a directory from one place to another. The parameters are the
-conventional set of tokens. Returns nothing.
+conventional set of tokens. Returns an error if the statement failed.
 */
-func CopyPath(tokens []Token) {
+func (interpreter *Interpreter) CopyPath(tokens []Token) error {
+	// If the source is a list, copy each directory in it
+	if is_list, list_error := interpreter.ForEachItem(
+		tokens, 2, interpreter.CopyPath); is_list {
+		return list_error
+	}
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, token_err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if token_err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("copydirectory")+
-				"statement needs to follow the form "+
-				utils.ColouriseCyan("copydirectory")+
-				utils.ColouriseGreen(" \"[path]\"")+" to "+
-				utils.ColouriseGreen("\"[path]\"")+". A common issue "+
-				"is the  use of an inappropriate action symbol ("+
-				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
-				"example of a working version might be "+
-				utils.ColouriseCyan("copyfcopydirectoryile")+
-				utils.ColouriseGreen(" \"test_dir\"")+" to "+
-				utils.ColouriseGreen(" \"new_dir\""),
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 	// Get the source folder to copy and fix the string where need be
 	source_path := FixStringCombined(tokens[2].TokenValue)
 	// Fix the path seperators to ensure that the last character is a seperator
@@ -343,20 +408,12 @@ func CopyPath(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have
 	been substituted.
 	*/
-	source_path = VariableTemplater(source_path)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	if action_error != nil {
-		Report(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	source_path, template_error := interpreter.Template(
+		source_path, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
+
 	// Get the source folder to copy and fix the string where need be
 	dest_path := FixStringCombined(tokens[4].TokenValue)
 	// Fix the path seperators to ensure that the last character is a seperator
@@ -364,7 +421,22 @@ func CopyPath(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have
 	been substituted.
 	*/
-	dest_path = VariableTemplater(dest_path)
+	dest_path, template_error = interpreter.Template(
+		dest_path, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"copy",
+			utils.ColouriseGreen(source_path)+" to "+
+				utils.ColouriseGreen(dest_path)+" "+
+				utils.ColouriseMagenta(PathSummary(source_path)),
+		)
+		return nil
+	}
 
 	// Set up a map of values to be passed to the file walker
 	walker_values := make(map[string]string)
@@ -376,44 +448,30 @@ func CopyPath(tokens []Token) {
 	walker_values["dest_position"] = tokens[4].TokenPosition
 
 	// Walk the files are start copying
-	filepath.Walk(source_path, CopyPathWalker(walker_values))
+	return filepath.Walk(
+		source_path, interpreter.CopyPathWalker(walker_values))
 }
 
 /*
 makedirectory statement
 
 Make a directory. The tokens are passed to get the file that will be moved.
-Returns nothing.
+Returns an error if the statement failed.
 */
-func CreatePath(tokens []Token) {
+func (interpreter *Interpreter) CreatePath(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("makedirectory")+" statement "+
-				"needs to follow the form "+
-				utils.ColouriseCyan("makedirectory")+" "+
-				utils.ColouriseYellow("\"[path]\"")+". A common error here "+
-				"is trying to concatenate multiple values into one statement "+
-				"call here. An example of a working version might be "+
-				utils.ColouriseCyan("makedirectory ")+
-				utils.ColouriseGreen("\"test_dir\"")+".",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 	// Fix up the path string
 	path := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	path = VariableTemplater(path)
+	path, template_error := interpreter.Template(path, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
 
 	// Get the last character
 	last_char := path[len(path)-1:]
@@ -423,8 +481,15 @@ func CreatePath(tokens []Token) {
 		path = path + string(os.PathSeparator)
 	}
 
-	if MODE_VERBOSE {
-		fmt.Printf(
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan("make directory", utils.ColouriseGreen(path))
+		return nil
+	}
+
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s...",
 			utils.ColouriseBlue("Making"),
 			utils.ColouriseGreen(path),
@@ -433,7 +498,7 @@ func CreatePath(tokens []Token) {
 
 	mk_err := os.MkdirAll(path, 0750)
 	if mk_err != nil {
-		Report(
+		return Report(
 			"Error creating the directory "+utils.ColouriseYellow(path)+
 				". Check to make sure that you have the right permissions to "+
 				"the parent directory.",
@@ -442,51 +507,71 @@ func CreatePath(tokens []Token) {
 			full_loc,
 		)
 	}
-	if MODE_VERBOSE {
-		fmt.Println("done!")
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, "done!")
 	}
+	return nil
+}
+
+/*
+define statement
+
+Define a procedure that can be called with the call statement. The procedures
+are gathered when the script is parsed so there is nothing to do here.
+Parameters include the tokens. Returns nil as a definition can't fail.
+*/
+func (interpreter *Interpreter) Define(tokens []Token) error {
+	return nil
 }
 
 /*
 deletefile statement
 
 Delete a file. The tokens are passed to get the file that will be deleted
-and the full line of code is passed for error reporting. Returns nothing.
+and the full line of code is passed for error reporting. Returns an error if
+the statement failed.
 */
-func DeleteFile(tokens []Token) {
+func (interpreter *Interpreter) DeleteFile(tokens []Token) error {
+	// If the source is a list, delete each file in it
+	if is_list, list_error := interpreter.ForEachItem(
+		tokens, 2, interpreter.DeleteFile); is_list {
+		return list_error
+	}
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("deletefile")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("deletefile")+" "+
-				utils.ColouriseGreen("\"[path]\"")+". An example of a working "+
-				"version might be "+utils.ColouriseCyan("deletefile")+
-				utils.ColouriseGreen(" \"test.txt\"")+".",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 
 	// Fix the source string
 	source := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	source = VariableTemplater(source)
+	source, template_error := interpreter.Template(source, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
+
+	/* If we're in dry run mode, say what we would do and leave it there. This
+	is done before checking that the file exists as an earlier statement that
+	was skipped might have been the one to create it.
+	*/
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"delete",
+			utils.ColouriseGreen(source)+" "+
+				utils.ColouriseMagenta(PathSummary(source)),
+		)
+		return nil
+	}
+
 	// Check to see if the file exists
 	file_exists := CheckFileExists(source)
 
 	// If the file doesn't exist, error out
 	if !file_exists {
 		// Report the error
-		Report(
+		return Report(
 			utils.ColouriseMagenta(source)+" does not exist.",
 			loc,
 			tokens[2].TokenPosition,
@@ -495,8 +580,12 @@ func DeleteFile(tokens []Token) {
 		// If it does exist, remove it
 	} else {
 		// If we're in verbose mode, report back some more info
-		if MODE_VERBOSE {
-			fmt.Printf(":: Deleting %s...", utils.ColouriseMagenta(source))
+		if interpreter.ModeVerbose {
+			fmt.Fprintf(
+				interpreter.Stdout,
+				":: Deleting %s...",
+				utils.ColouriseMagenta(source),
+			)
 		}
 		err := os.Remove(source)
 		if err != nil {
@@ -507,7 +596,7 @@ func DeleteFile(tokens []Token) {
 			that
 			*/
 			if info_err != nil {
-				Report(
+				return Report(
 					"So, I'm having difficulties removing the file and even "+
 						"getting some information on the file to help you "+
 						"understand why.",
@@ -517,7 +606,7 @@ func DeleteFile(tokens []Token) {
 				)
 			}
 			// Report back some info about the permissions on the file
-			Report(
+			return Report(
 				"There was an error deleting the file: "+
 					utils.ColouriseMagenta(source)+". It looks like the "+
 					"permissions on the file are "+info.Mode().Perm().String()+
@@ -529,53 +618,54 @@ func DeleteFile(tokens []Token) {
 			)
 		}
 		// If we're in verbose mode, report back some more info
-		if MODE_VERBOSE {
-			fmt.Println("done!")
+		if interpreter.ModeVerbose {
+			fmt.Fprintln(interpreter.Stdout, "done!")
 		}
 
 	}
-
+	return nil
 }
 
 /*
 deletedirectory statement
 
 Delete a directory. The parameters are the conventional set of tokens.
-Returns nothing.
+Returns an error if the statement failed.
 */
-func DeletePath(tokens []Token) {
+func (interpreter *Interpreter) DeletePath(tokens []Token) error {
+	// If the source is a list, delete each directory in it
+	if is_list, list_error := interpreter.ForEachItem(
+		tokens, 2, interpreter.DeletePath); is_list {
+		return list_error
+	}
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("deletedirectory")+" statement "+
-				"needs to follow the form "+
-				utils.ColouriseCyan("deletedirectory")+" "+
-				utils.ColouriseYellow("\"[path]\"")+". A common error here "+
-				"is trying to concatenate multiple values into one statement "+
-				"call here. An example of a working version might be "+
-				utils.ColouriseCyan("deletedirectory ")+
-				utils.ColouriseGreen("\"test_dir\"")+".",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 	// Fix up the path string
 	path := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	path = VariableTemplater(path)
+	path, template_error := interpreter.Template(path, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"delete",
+			utils.ColouriseGreen(path)+" "+
+				utils.ColouriseMagenta(PathSummary(path)),
+		)
+		return nil
+	}
 
 	// If verbose mode is set, print out what's happening
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s...",
 			utils.ColouriseBlue("Deleting"),
 			utils.ColouriseGreen(path),
@@ -584,7 +674,7 @@ func DeletePath(tokens []Token) {
 
 	remove_err := os.RemoveAll(path)
 	if remove_err != nil {
-		Report(
+		return Report(
 			"There was an error removing "+
 				utils.ColouriseMagenta(path)+". The path does not exist.",
 			loc,
@@ -593,188 +683,153 @@ func DeletePath(tokens []Token) {
 		)
 	}
 
-	if MODE_VERBOSE {
-		fmt.Println("done!")
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, "done!")
 	}
+	return nil
 }
 
 /*
 download statement
 
 This function deals with the download itself. It takes in the conventional
-set of tokens as the parameter. There is no return here as there is little
-reason to have one.
+set of tokens as the parameter. Returns an error if the download failed.
 */
-func Download(tokens []Token) {
+func (interpreter *Interpreter) Download(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
 
-	// Check the number of tokens and ensure that it's a proper amount
-	_, num_tokens_error := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if num_tokens_error != nil {
-		Report(
-			"The "+utils.ColouriseCyan("download")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("download")+" "+
-				utils.ColouriseGreen("\"[url]\"")+"to"+
-				utils.ColouriseGreen("\"[path]\"")+". An example of a "+
-				"working version might be "+utils.ColouriseCyan("download")+
-				utils.ColouriseGreen(" \"http://file.com/file.txt\"")+"to"+
-				utils.ColouriseGreen(" \"#b_home/file.txt\"")+".",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
-
-	// Create a temp file to hold the download before it is moved into place
-	temp_file, temp_file_err := os.CreateTemp("", "appetit_dl_temp")
-	// Hold the file temporarily
-	temp_loc := temp_file.Name()
-
-	if temp_file_err != nil {
-		Report(
-			"Issue with creating a temporary file to store the download. "+
-				"The temp file that I tried to make was "+temp_loc+
-				". Check to make sure that "+utils.ColouriseCyan(os.TempDir())+
-				" is writeable.",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
-
 	// Fix the remote file name
 	file_to_get := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	file_to_get = VariableTemplater(file_to_get)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	/* If the action is not a valid action keyword (ie. "to"), report back the
-	error
-	*/
-	if action_error != nil {
-		ReportWithFixes(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	file_to_get, template_error := interpreter.Template(
+		file_to_get, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
+
 	// Fix the local save file name
 	save_name := FixStringCombined(tokens[4].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	save_name = VariableTemplater(save_name)
-
-	// If verbose mode is set, notify the user of what is happening
-	if MODE_VERBOSE {
-		fmt.Println(
-			":: Creating a temp file - " + temp_loc + " - to store the " +
-				"download before it's moved to its final home: " + save_name +
-				".",
-		)
+	save_name, template_error = interpreter.Template(
+		save_name, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// Get the checksum and the number of retries if either was passed
+	clauses := ClauseTokens(tokens, 5)
+	checksum := ""
+	checksum_token, verify := clauses[DOWNLOAD_CLAUSE_VERIFY]
+	if verify {
+		checksum, template_error = interpreter.Template(
+			FixStringCombined(checksum_token.TokenValue), tokens, checksum_token)
+		if template_error != nil {
+			return template_error
+		}
 	}
-
-	/* If the user is not using Windows, we can defer the file close. Below,
-	you'll see that we explicitly close the handler for Windows users.
-	*/
-	if VARIABLES["b_os"] != "windows" {
-		// Defer the file close.
-		defer temp_file.Close()
+	retries := interpreter.DownloadRetries
+	if token, exists := clauses[DOWNLOAD_CLAUSE_RETRIES]; exists {
+		var retries_error error
+		retries, retries_error = interpreter.WholeNumberClause(
+			tokens, token, 0, "number of retries")
+		if retries_error != nil {
+			return retries_error
+		}
 	}
 
-	/* Set up a client with some default transport options. Thanks to https://
-	www.zenrows.com/blog/golang-net-http-user-agent#customize-ua for this
-	one.
-	*/
-	client := &http.Client{
-		Transport: &http.Transport{},
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		details := utils.ColouriseGreen(file_to_get) + " to " +
+			utils.ColouriseGreen(save_name)
+		if verify {
+			details += " verified against " + utils.ColouriseGreen(checksum)
+		}
+		interpreter.PrintPlan("download", details)
+		return nil
 	}
 
-	// Set up the GET request
-	request, err := http.NewRequest("GET", file_to_get, nil)
-	if err != nil {
-		Report(
-			"There was an error initiating the request to "+
-				utils.ColouriseCyan(file_to_get)+". Make sure that the URL "+
-				"is valid.",
+	/* Hold the download in a partial file until it is finished and has been
+	verified. The partial file is the same for each run so that a download
+	that stopped part of the way through is picked up where it left off.
+	*/
+	temp_loc, partial_error := PartialDownloadPath(file_to_get)
+	if partial_error != nil {
+		return Report(
+			"There's nowhere to hold the download until it is finished as "+
+				partial_error.Error()+".",
 			loc,
-			tokens[2].TokenPosition,
+			"n/a",
 			full_loc,
-		)
+		).WithErr(partial_error)
 	}
 
-	/* This is here to ensure that the request going through looks like it is
-	coming from a web browser and not Go. Some suggestions online point to
-	the default Go user agent being flagged as something that might trigger
-	a 403 response.
-	*/
-	request.Header.Set(
-		"User-Agent",
-		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 "+
-			"(KHTML, like Gecko) Chrome/109.0.0.0 Safari/537.36",
-	)
-
-	// Do the request itself
-	response, err := client.Do(request)
-	if err != nil {
-		Report(
-			"There was an error getting the file - "+
-				utils.ColouriseCyan(file_to_get)+". Make sure that the URL "+
-				"is valid.",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
+	// If verbose mode is set, notify the user of what is happening
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(
+			interpreter.Stdout,
+			":: Using a temp file - "+temp_loc+" - to store the "+
+				"download before it's moved to its final home: "+save_name+
+				".",
 		)
 	}
-	defer response.Body.Close()
 
-	/* Get the remote file name absent much of the URL so that we can report
-	back which file we are downloading.
-	*/
-	remote_file_name := path.Base(response.Request.URL.Path)
-	// Note which file we are downloading
-	fmt.Printf("Downloading %s\n", utils.ColouriseGreen(remote_file_name))
-	// Set the file size in the WriteProgress struct
-	size_counter := &WriteProgress{
-		FileSize: float64(response.ContentLength),
-	}
-	/* Copy the chunk downloaded to our temp file. Here, we're copying to the
-	temp_file and the source is set as a TeeReader which returns a reader
-	that reads the body of the response and writes, via the WriteProgress
-	type, the size of what has been downloaded.
-	*/
-	_, io_err := io.Copy(temp_file, io.TeeReader(response.Body, size_counter))
-	// If there is an error in the saving of the chunk, report it
-	if io_err != nil {
-		Report(
-			"There is an error saving the downloaded chunk: "+io_err.Error(),
+	// Set up a client with the script's HTTP settings
+	client, client_error := interpreter.HTTPClient()
+	if client_error != nil {
+		return Report(
+			"The request couldn't be set up as "+client_error.Error()+".",
 			loc,
-			tokens[1].TokenPosition,
+			"n/a",
 			full_loc,
-		)
-	}
-
-	/* Windows, it would seem, does not like deferred file closing so we need
-	to do it manually here. This was noted above so this is handled here.
-	Thanks to https://www.reddit.com/r/golang/comments/g5ftg0/
-	osrename_on_windows/ for that one. As much as the implicit goal of this
-	code is not to have OS specific bits, sometimes "Windows gonna Windows"
-	and its idiosyncracies need to be accounted for.
-	*/
-	if VARIABLES["b_os"] == "windows" {
-		// Close the file handler.
-		temp_file.Close()
+		).WithErr(client_error)
+	}
+
+	// Try the download, waiting longer between each attempt
+	remote_file_name, download_error := interpreter.DownloadWithRetries(
+		client, file_to_get, temp_loc, retries,
+		&WriteProgress{Output: interpreter.Stdout})
+	if download_error != nil {
+		if _, is_url_error := download_error.(*url.Error); is_url_error {
+			return Report(
+				"There was an error getting the file - "+
+					utils.ColouriseCyan(file_to_get)+". Make sure that the URL "+
+					"is valid.",
+				loc,
+				tokens[2].TokenPosition,
+				full_loc,
+			).WithErr(download_error)
+		}
+		return Report(
+			"The file - "+utils.ColouriseCyan(file_to_get)+" - couldn't be "+
+				"downloaded: "+download_error.Error()+".",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		).WithHint(
+			"What has been downloaded so far is kept, so running the " +
+				"script again will pick up where the download left off.",
+		).WithErr(download_error)
+	}
+
+	// Check the download against its checksum before it is moved into place
+	if verify {
+		verify_error := interpreter.VerifyDownload(tokens, checksum_token,
+			client, checksum, temp_loc, remote_file_name)
+		if verify_error != nil {
+			os.Remove(temp_loc)
+			os.Remove(temp_loc + DOWNLOAD_VALIDATOR_SUFFIX)
+			return verify_error
+		}
 	}
+	// The download is finished so what identifies its version isn't needed
+	os.Remove(temp_loc + DOWNLOAD_VALIDATOR_SUFFIX)
 
 	// Check if the save name is a directory
 	info, info_err := os.Stat(save_name)
@@ -800,33 +855,23 @@ func Download(tokens []Token) {
 	}
 
 	// Move the temp file to where the user wants it
-	rename_err := os.Rename(temp_loc, save_name)
-	// If there was an error renaming the file, report that
-	if rename_err != nil {
-		/* Make the second token value set to the temp location so that we
-		can pass the tokens to the copyfile function. We also need to
-		fix the value of the destination token to include the proper name.
-		*/
-		tokens[2].TokenValue = temp_loc
-		tokens[4].TokenValue = save_name
-		// Copy the file
-		CopyFile(tokens)
-		// Remove the temp file
-		remove_err := os.Remove(temp_loc)
-		if remove_err != nil {
-			Report(
-				"There was an error removing the temp file: "+
-					utils.ColouriseYellow(save_name)+". It will be worth "+
-					"trying to remove it manually.",
-				loc,
-				tokens[4].TokenPosition,
-				full_loc,
-			)
-		}
+	if place_error := PlaceDownload(temp_loc, save_name); place_error != nil {
+		return Report(
+			"The download couldn't be moved to "+
+				utils.ColouriseYellow(save_name)+". Check that you can "+
+				"write to that path.",
+			loc,
+			tokens[4].TokenPosition,
+			full_loc,
+		).WithErr(place_error)
 	}
 
 	// Report that the file is downloaded
-	fmt.Printf("\nFile downloaded to %s\n", utils.ColouriseGreen(save_name))
+	fmt.Fprintf(
+		interpreter.Stdout,
+		"\nFile downloaded to %s\n",
+		utils.ColouriseGreen(save_name),
+	)
 
 	/* This is a macOS specific fix to accommodate the fact that macOS seems to
 	make the file hidden when the file is moved. Here, "macOS gonna macOS"
@@ -835,7 +880,7 @@ func Download(tokens []Token) {
 	useless for those preferring the graphical user interface for file
 	management.
 	*/
-	if VARIABLES["b_os"] == "darwin" {
+	if interpreter.Variables["b_os"].String() == "darwin" {
 		// Unhide the file
 		macos_unhide := exec.Command("chflags", "nohidden", save_name)
 		/* Capture the output and suppress it as there isn't any but we may
@@ -852,7 +897,7 @@ func Download(tokens []Token) {
 				utils.ColouriseGreen("Shift"),
 				utils.ColouriseGreen("."),
 			)
-			Report(
+			return Report(
 				"On macOS, the file is hidden by the operating system by "+
 					"default. There was an attempt to unhide it but it failed. "+
 					"You will want to enable the showing of hidden files in "+
@@ -865,161 +910,838 @@ func Download(tokens []Token) {
 			)
 		}
 	}
-
+	return nil
 }
 
 /*
-execute statement
-
-Execute a system command. Parameters include the tokens. Returns nothing.
+downloadall statement
+
+Download a number of files to a directory, a few at once, showing the
+progress of each. The files are either listed in a file, a URL on each line
+optionally followed by a checksum (see ParseDownloadLine()), or in a list
+variable. A file that couldn't be downloaded doesn't stop the others and each
+failure is listed once they have all finished. Parameters include the
+tokens. Returns an error if the list couldn't be read or any of the files
+couldn't be downloaded.
 */
-func ExecuteCommand(tokens []Token) {
+func (interpreter *Interpreter) DownloadAll(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("execute")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("execute")+" "+
-				utils.ColouriseGreen("\"[command]\"")+". A common "+
-				"issue here is excluding a command. An example of a working "+
-				"statement might be "+utils.ColouriseCyan("execute")+
-				utils.ColouriseGreen(" \"ls\"")+"."+"\n\nLine of Code: "+
-				utils.ColouriseMagenta(full_loc),
+
+	// Get the files from the list variable or the list file
+	var jobs []DownloadJob
+	var line_errors []string
+	source := FixStringCombined(tokens[2].TokenValue)
+	if list, is_variable := interpreter.VariableValue(source); is_variable &&
+		list.Type == VALUE_LIST {
+		for _, item := range list.Items {
+			job, line_error := ParseDownloadLine(item.String())
+			if line_error != nil {
+				line_errors = append(line_errors, line_error.Error())
+			} else if job.URL != "" {
+				jobs = append(jobs, job)
+			}
+		}
+	} else {
+		list_file, template_error := interpreter.Template(
+			source, tokens, tokens[2])
+		if template_error != nil {
+			return template_error
+		}
+		contents, read_error := os.ReadFile(list_file)
+		if read_error != nil {
+			return Report(
+				"The list of files - "+utils.ColouriseYellow(list_file)+
+					" - couldn't be read.",
+				loc,
+				tokens[2].TokenPosition,
+				full_loc,
+			).WithErr(read_error)
+		}
+		for line_number, line := range strings.Split(string(contents), "\n") {
+			job, line_error := ParseDownloadLine(line)
+			if line_error != nil {
+				line_errors = append(line_errors, "line "+
+					strconv.Itoa(line_number+1)+": "+line_error.Error())
+			} else if job.URL != "" {
+				jobs = append(jobs, job)
+			}
+		}
+	}
+	if len(line_errors) > 0 {
+		return Report(
+			"The list of files has entries that can't be downloaded:\n  "+
+				strings.Join(line_errors, "\n  "),
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		)
+	}
+
+	// Get the directory to save the files to
+	directory, template_error := interpreter.Template(
+		FixStringCombined(tokens[4].TokenValue), tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+	// Work out where each file is saved, making sure that no two collide
+	saved_by := map[string]string{}
+	for index, job := range jobs {
+		name, name_error := DownloadFileName(job.URL)
+		if name_error == nil && saved_by[name] != "" {
+			name_error = fmt.Errorf("%s and %s would both be saved as %s",
+				saved_by[name], job.URL, name)
+		}
+		if name_error != nil {
+			return Report(
+				"The files can't be downloaded as "+name_error.Error()+".",
+				loc,
+				tokens[2].TokenPosition,
+				full_loc,
+			).WithErr(name_error)
+		}
+		saved_by[name] = job.URL
+		jobs[index].SaveName = filepath.Join(directory, name)
+	}
+
+	// Get the number of files to get at once and the number of retries
+	clauses := ClauseTokens(tokens, 5)
+	parallel := DEFAULT_DOWNLOAD_PARALLEL
+	retries := interpreter.DownloadRetries
+	if token, exists := clauses[DOWNLOADALL_CLAUSE_PARALLEL]; exists {
+		var parallel_error error
+		parallel, parallel_error = interpreter.WholeNumberClause(
+			tokens, token, 1, "number of files to download at once")
+		if parallel_error != nil {
+			return parallel_error
+		}
+	}
+	if token, exists := clauses[DOWNLOAD_CLAUSE_RETRIES]; exists {
+		var retries_error error
+		retries, retries_error = interpreter.WholeNumberClause(
+			tokens, token, 0, "number of retries")
+		if retries_error != nil {
+			return retries_error
+		}
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		for _, job := range jobs {
+			details := utils.ColouriseGreen(job.URL) + " to " +
+				utils.ColouriseGreen(job.SaveName)
+			if job.Checksum != "" {
+				details += " verified against " +
+					utils.ColouriseGreen(job.Checksum)
+			}
+			interpreter.PrintPlan("download", details)
+		}
+		return nil
+	}
+	if len(jobs) == 0 {
+		fmt.Fprintln(interpreter.Stdout, "There are no files to download.")
+		return nil
+	}
+
+	// Make the directory if it isn't there yet
+	if make_error := os.MkdirAll(directory, 0750); make_error != nil {
+		return Report(
+			"The directory - "+utils.ColouriseYellow(directory)+" - "+
+				"couldn't be made to save the files to.",
+			loc,
+			tokens[4].TokenPosition,
+			full_loc,
+		).WithErr(make_error)
+	}
+	// Set up a client with the script's HTTP settings
+	client, client_error := interpreter.HTTPClient()
+	if client_error != nil {
+		return Report(
+			"The request couldn't be set up as "+client_error.Error()+".",
+			loc,
+			"n/a",
+			full_loc,
+		).WithErr(client_error)
+	}
+
+	// If verbose mode is set, notify the user of what is happening
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s %d files to %s, %d at once...\n",
+			utils.ColouriseBlue("Downloading"),
+			len(jobs),
+			utils.ColouriseGreen(directory),
+			parallel,
+		)
+	}
+	interpreter.RunDownloadJobs(tokens, client, jobs, parallel, retries)
+
+	// Sum up what was downloaded and what wasn't
+	var failures []string
+	for _, job := range jobs {
+		if job.Error == nil {
+			continue
+		}
+		message := job.Error.Error()
+		var script_error *ScriptError
+		if errors.As(job.Error, &script_error) {
+			message = utils.StripColour(script_error.Message)
+		}
+		failures = append(failures,
+			filepath.Base(job.SaveName)+" ("+job.URL+"): "+message)
+	}
+	fmt.Fprintf(
+		interpreter.Stdout,
+		"\nDownloaded %d of %d files to %s\n",
+		len(jobs)-len(failures),
+		len(jobs),
+		utils.ColouriseGreen(directory),
+	)
+	if len(failures) > 0 {
+		return Report(
+			strconv.Itoa(len(failures))+" of the "+strconv.Itoa(len(jobs))+
+				" files couldn't be downloaded:\n  "+
+				strings.Join(failures, "\n  "),
 			loc,
 			tokens[2].TokenPosition,
 			full_loc,
+		).WithRule("runtime/downloadall").WithHint(
+			"What has been downloaded of those files is kept, so running " +
+				"the script again will pick up where they left off.",
 		)
 	}
+	return nil
+}
+
+/*
+end statement
 
-	// Get the command and fix the string
-	command := FixStringCombined(tokens[2].TokenValue)
+End a define block. The define blocks are gathered when the script is parsed
+so this is never called but is here so that end is a statement like any other.
+Parameters include the tokens. Returns nil as ending a block can't fail.
+*/
+func (interpreter *Interpreter) End(tokens []Token) error {
+	return nil
+}
 
-	/* Check if the -allowexec flag was passed to the app and if not, throw
-	an error
+/*
+execute statement
+
+Execute a system command (eg. execute "git commit -m 'Backed up'"). The
+command is split into the program and its arguments much as a shell would
+split it (see SplitCommand()) and its output is shown as it is written. The
+command can be followed by in "[directory]" to run it in a directory, to
+"[variable]" to save its output to a variable rather than showing it, and
+timeout [seconds] to stop it if it runs for too long. The program is found
+on the PATH and the command is only run if the exec policy allows it (see
+exec_policy.go). The exit code of the command is saved to b_exit_code.
+Parameters include the tokens. Returns an error if the command couldn't be
+run or failed.
+*/
+func (interpreter *Interpreter) ExecuteCommand(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+
+	/*
+		Split the command into the program and its arguments before the
+		variables are replaced so that the value of a variable is always one
+		argument, whatever spaces or quotes it has in it.
 	*/
-	if !MODE_ALLOW_EXEC {
-		Report(
-			"You are unable to execute system commands. If you would like "+
-				"to do so, you need to run with the "+
-				utils.ColouriseYellow("-allowexec")+" flag.",
+	raw_command := FixStringCombined(tokens[2].TokenValue)
+	command_parts, split_error := SplitCommand(raw_command)
+	if split_error != nil {
+		return Report(
+			"The command "+utils.ColouriseYellow(raw_command)+" can't be "+
+				"split into its arguments as "+split_error.Error()+".",
+			loc,
+			tokens[2].TokenPosition,
+			full_loc,
+		).WithHint("Wrap an argument with spaces in single quotes (eg. " +
+			utils.ColouriseGreen("\"git commit -m 'Backed up'\"") + ").")
+	}
+	var template_error error
+	for index, part := range command_parts {
+		command_parts[index], template_error = interpreter.Template(
+			part, tokens, tokens[2])
+		if template_error != nil {
+			return template_error
+		}
+	}
+	if command_parts[0] == "" {
+		return Report(
+			"The command "+utils.ColouriseYellow(raw_command)+" doesn't "+
+				"have a program to execute.",
 			loc,
 			tokens[2].TokenPosition,
 			full_loc,
 		)
 	}
+	// Get the command as it is shown (eg. in a plan)
+	command := JoinCommand(command_parts)
+
+	// Get the directory, variable, and timeout from the clauses
+	clauses := ClauseTokens(tokens, 3)
+	var directory, variable_name string
+	var timeout time.Duration
+	if token, exists := clauses[EXECUTE_CLAUSE_IN]; exists {
+		directory, template_error = interpreter.Template(
+			FixStringCombined(token.TokenValue), tokens, token)
+		if template_error != nil {
+			return template_error
+		}
+		if info, stat_error := os.Stat(directory); stat_error != nil ||
+			!info.IsDir() {
+			return Report(
+				"The directory "+utils.ColouriseYellow(directory)+" that "+
+					"the command is to be run in doesn't exist.",
+				loc,
+				token.TokenPosition,
+				full_loc,
+			)
+		}
+	}
+	if token, exists := clauses[EXECUTE_CLAUSE_TO]; exists {
+		variable_name = FixStringCombined(token.TokenValue)
+	}
+	if token, exists := clauses[EXECUTE_CLAUSE_TIMEOUT]; exists {
+		seconds, value_error := interpreter.EvaluateValue(tokens, token)
+		if value_error != nil {
+			return value_error
+		}
+		var timeout_error error
+		timeout, timeout_error = TimeoutValue(tokens, token, seconds)
+		if timeout_error != nil {
+			return timeout_error
+		}
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		plan := utils.ColouriseYellow(command)
+		if directory != "" {
+			plan += " in " + utils.ColouriseGreen(directory)
+		}
+		interpreter.PrintPlan("execute", plan)
+		// The output is still set so that the rest of the script can use it
+		if variable_name != "" {
+			interpreter.Variables[variable_name] = StringValue("")
+		}
+		return nil
+	}
 
 	// If verbose mode is set
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s...\n",
 			utils.ColouriseBlue("Executing"),
 			utils.ColouriseYellow(command),
 		)
 	}
 
-	// Split the command into parts, needed below to account for arguments.
-	cmd_split := strings.Split(command, " ")
+	/*
+		Find the program and check that the exec policy allows the command
+		before it is run.
+	*/
+	program_path, resolve_error := interpreter.ResolveProgram(
+		command_parts[0], directory)
+	if resolve_error != nil {
+		execute_error, exit_code := ReportExecuteError(
+			tokens, command_parts[0], resolve_error, timeout)
+		interpreter.Variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"exit_code"] =
+			IntegerValue(int64(exit_code))
+		return execute_error
+	}
+	authorise_error := interpreter.AuthoriseCommand(
+		tokens, command_parts, program_path, directory)
+	if authorise_error != nil {
+		interpreter.Variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"exit_code"] =
+			IntegerValue(EXIT_CODE_NOT_RUN)
+		return authorise_error
+	}
+
+	// Stop the command once the timeout is up, if there is one
+	context_to_run, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		context_to_run, cancel = context.WithTimeout(
+			context.Background(), timeout)
+	}
+	defer cancel()
+	execute_command := exec.CommandContext(
+		context_to_run, program_path, command_parts[1:]...)
+	execute_command.Args[0] = command_parts[0]
+	// The command gets the script's environment (see setenv)
+	execute_command.Env = interpreter.EnvironmentList()
+	execute_command.Dir = directory
+	/*
+		Show the output as it is written unless it is being saved to a
+		variable. Errors are always shown.
+	*/
+	var output bytes.Buffer
+	execute_command.Stdout = interpreter.Stdout
+	if variable_name != "" {
+		execute_command.Stdout = &output
+	}
+	execute_command.Stderr = interpreter.Stderr
+	/*
+		Don't wait forever on output from anything that the command left
+		running once it has been stopped.
+	*/
+	execute_command.WaitDelay = time.Second
+	run_error := execute_command.Run()
+	if run_error != nil && context_to_run.Err() != nil {
+		run_error = errors.Join(context_to_run.Err(), run_error)
+	}
+
+	// Save the exit code, whether or not the command succeeded
+	exit_code := 0
+	var execute_error *ScriptError
+	if run_error != nil {
+		execute_error, exit_code = ReportExecuteError(
+			tokens, command_parts[0], run_error, timeout)
+	}
+	interpreter.Variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"exit_code"] =
+		IntegerValue(int64(exit_code))
+	if execute_error != nil {
+		return execute_error
+	}
+	// Save the output without the new line that most commands end with
+	if variable_name != "" {
+		interpreter.Variables[variable_name] = StringValue(
+			strings.TrimRight(output.String(), "\r\n"))
+	}
+	return nil
+}
+
+/*
+exit statement
 
-	/* Get the output and an error from executing the command and capturing
-	the output. Thanks to https://stackoverflow.com/a/23724092 for the
-	argument passing here.
+Handle an exit statement call. This one is very basic and doesn't require
+much of the end user other than the statement call itself. Returns
+ErrScriptExit to stop the script.
+*/
+func (interpreter *Interpreter) Exit(tokens []Token) error {
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, ":: Exiting...")
+	}
+	/*
+		Finally, exit. Returning ErrScriptExit stops the script without it
+		being treated as a failure.
 	*/
-	output, err := exec.Command(cmd_split[0], cmd_split[1:]...).Output()
-	// If the error isn't nil, throw an err
-	if err != nil {
-		Report(
-			"The application "+utils.ColouriseYellow(command)+
-				" was not found. Perhaps it was a typo?",
+	return ErrScriptExit
+}
+
+/*
+fetch, post, and put statement
+
+Make a request and save what the server responds with to a variable. This
+handles the fetch statement (a GET request), which needs the to clause, and
+the post and put statements, which send a body (eg. post "[url]" with
+"[body]" as "application/json" to "response"). The status code and some of
+the response headers are saved to the reserved variables (see
+SetResponseVariables()) whatever the status code is, so the script can check
+it. A request with the expect clause (eg. expect "2xx") stops the script if
+the status code isn't one that it allows. Parameters include the tokens.
+Returns an error if the request couldn't be made or the status code isn't
+expected.
+*/
+func (interpreter *Interpreter) Request(tokens []Token) error {
+	// Get the full line of code
+	full_loc := tokens[0].FullLineOfCode
+	// Get the line of code
+	loc := strconv.Itoa(tokens[0].LineNumber)
+	// Get the method from the statement (eg. POST for the post statement)
+	statement_name := tokens[1].TokenValue
+	method := RequestMethods()[statement_name]
+
+	// Get the URL and template it
+	request_url, template_error := interpreter.Template(
+		FixStringCombined(tokens[2].TokenValue), tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
+	// Get the body, content type, and variable from the clauses
+	clauses := ClauseTokens(tokens, 3)
+	var body, content_type, variable_name string
+	if token, exists := clauses[REQUEST_CLAUSE_WITH]; exists {
+		body, template_error = interpreter.Template(
+			FixStringCombined(token.TokenValue), tokens, token)
+		if template_error != nil {
+			return template_error
+		}
+		content_type = DEFAULT_CONTENT_TYPE
+	}
+	if token, exists := clauses[REQUEST_CLAUSE_AS]; exists {
+		content_type, template_error = interpreter.Template(
+			FixStringCombined(token.TokenValue), tokens, token)
+		if template_error != nil {
+			return template_error
+		}
+	}
+	if token, exists := clauses[REQUEST_CLAUSE_TO]; exists {
+		variable_name = FixStringCombined(token.TokenValue)
+	}
+	// Get the statuses that the request expects, if it says
+	expect_token, has_expect := clauses[REQUEST_CLAUSE_EXPECT]
+	var expected string
+	if has_expect {
+		expected, template_error = interpreter.Template(
+			FixStringCombined(expect_token.TokenValue), tokens, expect_token)
+		if template_error != nil {
+			return template_error
+		}
+		if _, status_error := StatusExpected(expected, 0); status_error != nil {
+			return Report(
+				"The statuses "+utils.ColouriseYellow(expected)+" can't be "+
+					"expected as "+status_error.Error()+".",
+				loc,
+				expect_token.TokenPosition,
+				full_loc,
+			).WithErr(status_error)
+		}
+	}
+	// Clear what an earlier request left behind
+	interpreter.SetResponseVariables(nil)
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		plan := utils.ColouriseGreen(request_url)
+		if body != "" {
+			plan += " with " + utils.CommaSeperator(float64(len(body))) +
+				" bytes of " + utils.ColouriseYellow(content_type)
+		}
+		interpreter.PrintPlan(statement_name, plan)
+		// The response is still set so that the rest of the script can use it
+		if variable_name != "" {
+			interpreter.Variables[variable_name] = StringValue("")
+		}
+		return nil
+	}
+
+	// Set up the request with the script's HTTP settings
+	client, client_error := interpreter.HTTPClient()
+	if client_error != nil {
+		return ReportRequestError(tokens, request_url, nil, client_error)
+	}
+	request, request_error := interpreter.NewHTTPRequest(
+		method, request_url, strings.NewReader(body))
+	if request_error != nil {
+		return ReportRequestError(tokens, request_url, nil, request_error)
+	}
+	if content_type != "" {
+		request.Header.Set("Content-Type", content_type)
+	}
+
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s %s...\n",
+			utils.ColouriseBlue("Sending a "+method+" request to"),
+			utils.ColouriseGreen(request_url),
+		)
+	}
+
+	// Make the request and read the response, up to MAX_RESPONSE_SIZE
+	response, response_error := client.Do(request)
+	if response_error != nil {
+		return ReportRequestError(tokens, request_url, nil, response_error)
+	}
+	defer response.Body.Close()
+	response_body, read_error := io.ReadAll(
+		io.LimitReader(response.Body, MAX_RESPONSE_SIZE+1))
+	if read_error != nil {
+		return ReportRequestError(tokens, request_url, nil, read_error)
+	}
+	if len(response_body) > MAX_RESPONSE_SIZE {
+		return Report(
+			"The response from "+utils.ColouriseCyan(request_url)+" is "+
+				"bigger than "+
+				utils.CommaSeperator(float64(MAX_RESPONSE_SIZE/1024))+
+				" KB, which is more than a variable can hold.",
 			loc,
 			tokens[2].TokenPosition,
 			full_loc,
+		).WithRule("runtime/http-response-size").WithHint(
+			"Use the " + utils.ColouriseCyan("download") + " statement to " +
+				"save it to a file instead.",
+		)
+	}
+
+	// Save the response, whether or not the server responded with an error
+	interpreter.SetResponseVariables(response)
+	if variable_name != "" {
+		interpreter.Variables[variable_name] = StringValue(
+			strings.TrimRight(string(response_body), "\r\n"))
+	}
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: The server responded with %s\n",
+			utils.ColouriseMagenta(response.Status),
 		)
 	}
-	// Output the results of the command
-	fmt.Println(string(output))
+	if has_expect {
+		is_expected, _ := StatusExpected(expected, response.StatusCode)
+		if !is_expected {
+			return ReportRequestError(tokens, request_url, response, nil)
+		}
+	}
+	return nil
 }
 
 /*
-exit statement
+goto statement
 
-Handle an exit statement call. This one is very basic and doesn't require
-much of the end user other than the statement call itself.
+Go to a label in the script. If the statement has a condition, it only goes to
+the label if the condition is met. The jump itself is made by Execute() which
+is handed a GotoError with the name of the label. Parameters include the
+tokens. Returns a GotoError to jump to the label, nil if the condition isn't
+met, or an error if the condition couldn't be checked.
 */
-func Exit(tokens []Token) {
+func (interpreter *Interpreter) Goto(tokens []Token) error {
+	// Get the name of the label
+	label := FixStringCombined(tokens[2].TokenValue)
+
+	// If there is a condition, check whether it is met
+	if len(tokens) > 3 {
+		// Get the condition
+		condition, condition_error := ParseCondition(tokens, 4, len(tokens))
+		if condition_error != nil {
+			return condition_error
+		}
+
+		// If verbose mode is set
+		if interpreter.ModeVerbose {
+			fmt.Fprintf(
+				interpreter.Stdout,
+				":: %s if %s...",
+				utils.ColouriseBlue("Checking"),
+				utils.ColouriseMagenta(JoinTokenValues(tokens[4:])),
+			)
+		}
+
+		// Check whether the condition is met
+		condition_met, evaluate_error := interpreter.EvaluateCondition(
+			tokens, condition)
+		if evaluate_error != nil {
+			return evaluate_error
+		}
+
+		// If verbose mode is set, report what the condition came to
+		if interpreter.ModeVerbose {
+			fmt.Fprintln(interpreter.Stdout, condition_met)
+		}
+
+		// If the condition isn't met, carry on with the next statement
+		if !condition_met {
+			return nil
+		}
+	}
+
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s to %s...\n",
+			utils.ColouriseBlue("Going"),
+			utils.ColouriseYellow(label),
+		)
+	}
+	// Hand the label to Execute() to make the jump
+	return &GotoError{Label: label, Tokens: tokens}
+}
+
+/*
+header statement
+
+Set a header that is sent with every request that the script makes from here
+on (eg. header "Authorization" = "Bearer #token"). An empty value removes the
+header. Parameters include the tokens. Returns an error if the value uses a
+variable that hasn't been set.
+*/
+func (interpreter *Interpreter) Header(tokens []Token) error {
+	// Get the name of the header in its canonical form (eg. X-Api-Key)
+	header_name := http.CanonicalHeaderKey(
+		FixStringCombined(tokens[2].TokenValue))
+	// Get a templated value
+	value, template_error := interpreter.Template(
+		FixStringCombined(tokens[4].TokenValue), tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// If verbose mode is set, leaving the value out as it may be a secret
+	if interpreter.ModeVerbose {
+		action := "Setting"
+		if value == "" {
+			action = "Removing"
+		}
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s the header %s...\n",
+			utils.ColouriseBlue(action),
+			utils.ColouriseYellow(header_name),
+		)
+	}
+	if value == "" {
+		delete(interpreter.HTTP.Headers, header_name)
+		return nil
+	}
+	interpreter.HTTP.Headers[header_name] = value
+	return nil
+}
+
+/*
+http statement
+
+Change how the requests that the script makes from here on are made (eg.
+http timeout = 60 or http proxy = "http://proxy.internal:3128"). An empty
+value puts the setting back to its default. See HTTPSettings for the
+settings. Parameters include the tokens. Returns an error if the value isn't
+valid for the setting.
+*/
+func (interpreter *Interpreter) HTTPSetting(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 1)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("exit")+" statement needs "+
-				"to follow the form:\n\n\t"+utils.ColouriseCyan("exit")+
-				"\n\nThere are no values that you can or need to pass which "+
-				"is most likely the cause here.\n\n"+
-				"Your line of code looks like the following:\n\n\t"+
-				utils.ColouriseRed(full_loc)+"\n\n",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
+	// Get the setting and its value
+	setting := tokens[2].TokenValue
+	value, value_error := interpreter.EvaluateValue(tokens, tokens[4])
+	if value_error != nil {
+		return value_error
+	}
+	setting_value := value.String()
+
+	switch setting {
+	case HTTP_SETTING_AUTH:
+		if setting_value != "" && !strings.Contains(setting_value, ":") {
+			return Report(
+				"The "+utils.ColouriseMagenta(setting)+" setting needs to "+
+					"be written as "+
+					utils.ColouriseGreen("\"[user]:[password]\"")+".",
+				loc,
+				tokens[4].TokenPosition,
+				full_loc,
+			)
+		}
+		interpreter.HTTP.Auth = setting_value
+	case HTTP_SETTING_CA_BUNDLE:
+		if _, stat_error := os.Stat(setting_value); setting_value != "" &&
+			stat_error != nil {
+			return Report(
+				"The CA bundle - "+utils.ColouriseYellow(setting_value)+
+					" - doesn't exist.",
+				loc,
+				tokens[4].TokenPosition,
+				full_loc,
+			).WithErr(stat_error)
+		}
+		interpreter.HTTP.CABundle = setting_value
+	case HTTP_SETTING_PROXY:
+		interpreter.HTTP.Proxy = setting_value
+	case HTTP_SETTING_TIMEOUT:
+		timeout, timeout_error := TimeoutValue(tokens, tokens[4], value)
+		if timeout_error != nil {
+			return timeout_error
+		}
+		interpreter.HTTP.Timeout = timeout
+	case HTTP_SETTING_USER_AGENT:
+		if setting_value == "" {
+			setting_value = DefaultUserAgent()
+		}
+		interpreter.HTTP.UserAgent = setting_value
+	}
+
+	// If verbose mode is set, leaving the value out as it may be a secret
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s the HTTP setting %s...\n",
+			utils.ColouriseBlue("Setting"),
+			utils.ColouriseYellow(setting),
+		)
+	}
+	return nil
+}
+
+/*
+if statement
+
+Run a statement only if a condition is met. The condition sits between the
+statement name and the then keyword and the statement to run sits after it. The
+statement is handed back to Call() as if it were on a line of its own.
+Parameters include the tokens. Returns an error if the condition couldn't be
+checked or the statement failed.
+*/
+func (interpreter *Interpreter) If(tokens []Token) error {
+	// Find the then keyword that ends the condition
+	then_index := FindConditionEnd(tokens)
+	// Get the condition
+	condition, condition_error := ParseCondition(tokens, 2, then_index)
+	if condition_error != nil {
+		return condition_error
+	}
+
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s if %s...",
+			utils.ColouriseBlue("Checking"),
+			utils.ColouriseMagenta(JoinTokenValues(tokens[2:then_index])),
 		)
 	}
 
-	// If verbose mode is set
-	if MODE_VERBOSE {
-		fmt.Println(":: Exiting...")
+	// Check whether the condition is met
+	condition_met, evaluate_error := interpreter.EvaluateCondition(
+		tokens, condition)
+	if evaluate_error != nil {
+		return evaluate_error
+	}
+
+	// If verbose mode is set, report what the condition came to
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, condition_met)
+	}
+
+	// If the condition isn't met, there is nothing to do
+	if !condition_met {
+		return nil
 	}
-	// Finally, exit
-	os.Exit(0)
+	// Otherwise, call the statement
+	return interpreter.Call(NestedStatementTokens(tokens, then_index))
+}
+
+/*
+label statement
+
+Mark a place in the script that a goto statement can go to. The labels are
+gathered when the script is parsed so there is nothing to do here. Parameters
+include the tokens. Returns nil as a label can't fail.
+*/
+func (interpreter *Interpreter) Label(tokens []Token) error {
+	return nil
 }
 
 /*
 log statement
 
 This will log a string to a file of the user's choosing as a helpful shorthand
-for tracking executions of a script. Returns nothing.
+for tracking executions of a script. Returns an error if the statement failed.
 */
-func Log(tokens []Token) {
+func (interpreter *Interpreter) Log(tokens []Token) error {
 	full_loc := tokens[0].FullLineOfCode
 
 	loc := strconv.Itoa(tokens[0].LineNumber)
 
-	_, num_tokens_error := CheckValidNumberOfTokens(tokens, 4)
-
-	if num_tokens_error != nil {
-		Report(
-			"The "+utils.ColouriseCyan("log")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("log")+" "+
-				utils.ColouriseGreen("\"[path]\"")+"to"+
-				utils.ColouriseGreen("\"[path]\"")+". An example of a "+
-				"working version might be "+utils.ColouriseCyan("log")+
-				utils.ColouriseGreen(" \"The script is done\"")+"to"+
-				utils.ColouriseGreen(" \"script_log\"")+".",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
-
-	action := tokens[3].TokenValue
-	if action != SYMBOL_ACTION {
-		Report(
-			"An inapportiate action symbol is used. You used "+
-				utils.ColouriseMagenta(action)+" when you need to use "+
-				utils.ColouriseMagenta(SYMBOL_ACTION)+".",
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
-	}
-
 	/* Get the output string, that is, the text that will be output to the log.
 	Further, fix the string.
 	*/
@@ -1027,20 +1749,39 @@ func Log(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	output_string = VariableTemplater(output_string)
+	output_string, template_error := interpreter.Template(
+		output_string, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
 
 	// Get the file name
 	file_name := FixStringCombined(tokens[4].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	file_name = VariableTemplater(file_name)
+	file_name, template_error = interpreter.Template(
+		file_name, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"log",
+			utils.ColouriseGreen(output_string)+" to "+
+				utils.ColouriseGreen(file_name+".log"),
+		)
+		return nil
+	}
+
 	// Open the log file and create it if it doesn't exist
 	file_handler, file_handler_error := os.OpenFile(
 		file_name+".log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	// If there's an error opening or creating the file...
 	if file_handler_error != nil {
-		Report(
+		return Report(
 			"There was an error opening the file "+
 				utils.ColouriseCyan(file_name)+". Make sure that you can "+
 				"create a log file in this directory.",
@@ -1055,7 +1796,7 @@ func Log(tokens []Token) {
 	// Write the log message with a trailing new line
 	_, write_handler_error := file_handler.WriteString(output_string + "\n")
 	if write_handler_error != nil {
-		Report(
+		return Report(
 			"There was an error writing to the file "+
 				utils.ColouriseCyan(file_name)+". Make sure that you can "+
 				"write to files in this directory.",
@@ -1065,51 +1806,52 @@ func Log(tokens []Token) {
 		)
 	}
 
-	if MODE_VERBOSE {
-		fmt.Println("Wrote log file to " + file_name + ".log.")
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, "Wrote log file to "+file_name+".log.")
 	}
+	return nil
 }
 
 /*
 makefile statement
 
 Create a file. The tokens are passed to get the file that will be deleted
-and the full line of code is passed for error reporting. Returns nothing.
+and the full line of code is passed for error reporting. Returns an error if
+the statement failed.
 */
-func MakeFile(tokens []Token) {
+func (interpreter *Interpreter) MakeFile(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("makefile")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("create")+" "+
-				utils.ColouriseGreen("\"[path]\"")+". An example of a working "+
-				"version might be "+utils.ColouriseCyan("makefile")+
-				utils.ColouriseGreen(" \"test.txt\"")+".",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 
 	// Fix the file name string
 	file_name := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	file_name = VariableTemplater(file_name)
+	file_name, template_error := interpreter.Template(
+		file_name, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
+
+	/* If we're in dry run mode, say what we would do and leave it there. This
+	is done before checking that the file exists as an earlier statement that
+	was skipped might have been the one to remove it.
+	*/
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan("make file", utils.ColouriseGreen(file_name))
+		return nil
+	}
+
 	// Check to see if the file exists
 	file_exists := CheckFileExists(file_name)
 
 	// If the file exists already exist, error out
 	if file_exists {
 		// Report the error
-		Report(
+		return Report(
 			utils.ColouriseMagenta(file_name)+" exists already.",
 			loc,
 			tokens[2].TokenPosition,
@@ -1117,15 +1859,19 @@ func MakeFile(tokens []Token) {
 		)
 	}
 	// If we're in verbose mode, report back some more info
-	if MODE_VERBOSE {
-		fmt.Printf(":: Making %s...", utils.ColouriseMagenta(file_name))
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: Making %s...",
+			utils.ColouriseMagenta(file_name),
+		)
 	}
 	// Create the file
 	_, create_err := os.Create(file_name)
 	// If there is an issue with creating the file...
 	if create_err != nil {
 		// Report the error
-		Report(
+		return Report(
 			utils.ColouriseMagenta(file_name)+" could not be created: "+
 				create_err.Error(),
 			loc,
@@ -1134,73 +1880,27 @@ func MakeFile(tokens []Token) {
 		)
 	}
 	// If we're in verbose mode, report back some more info
-	if MODE_VERBOSE {
-		fmt.Println("done!")
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, "done!")
 	}
+	return nil
 }
 
 /*
 minver statement
 
 Check the minimum version required to run the script. Parameters include
-the tokens. Returns nothing.
+the tokens. Returns nil as Parse() has already checked the version.
 */
-func MinVer(tokens []Token) int {
-	// Get the full line of code
-	full_loc := tokens[0].FullLineOfCode
-	// Get the line of code
-	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, token_number_err := CheckValidNumberOfTokens(tokens, 2)
-	/* Get the minimum version as a string. As we check whether this is an
-	integer above, we should be good to go here.
-	*/
-	min_ver_string := tokens[2].TokenValue
-	// Get the minver set by the user as an integer for comparison
-	min_ver, int_conversion_err := strconv.Atoi(min_ver_string)
-	/* If there is an error trying to do the conversion or if the min_ver is
-	less than zero, report an error. This also captures negative integers
-	in particular as the negative sign and the integer are tokenised as
-	seperate tokens.
-	*/
-	if int_conversion_err != nil || min_ver <= 0 || token_number_err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("minver")+" statement needs to "+
-				"include a valid non-zero positive integer. A valid "+
-				utils.ColouriseCyan("minver")+" statement needs to follow the "+
-				"form:\n\t"+utils.ColouriseCyan("minver")+
-				utils.ColouriseYellow(" [version number]")+"\nAn example of a "+
-				"working version check might be:\n\t"+
-				utils.ColouriseCyan("minver")+utils.ColouriseYellow(" 3")+
-				"\nMake sure that you have none of the following for the "+
-				utils.ColouriseCyan("minver")+" statement value:\n\t"+
-				"- Negative number\n\t- Float (ie. decimal number)\n\t"+
-				"- String\n\t- No value\n",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
-	}
-
-	/* Check if the minimum version is greater than or equal to the language
-	version.
+func (interpreter *Interpreter) MinVer(tokens []Token) error {
+	/* Get the minimum version as an integer. As Parse() checks whether this
+	is a valid integer before execution starts, we can discard the error.
 	*/
-	if min_ver > LANG_VERSION {
-		Report(
-			"The script you're running here requires a newer version of "+
-				"the interpreter. You are running version "+
-				utils.ColouriseYellow(strconv.Itoa(LANG_VERSION))+
-				" but the script requires at least version "+
-				utils.ColouriseYellow(min_ver_string)+". Check to see if "+
-				"a newer version is available. ",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
-	}
+	min_ver, _ := strconv.Atoi(tokens[2].TokenValue)
 
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: Setting the minimum version of Appetit (%s) required to "+
 				"run this script to %s\n",
 			utils.ColouriseBlue("minver"),
@@ -1208,8 +1908,7 @@ func MinVer(tokens []Token) int {
 		)
 	}
 
-	// Return the minimum version required
-	return min_ver
+	return nil
 }
 
 /*
@@ -1217,52 +1916,27 @@ movefile statement
 
 Move a file from an origin to a destination. The tokens are passed to get
 the origin, destination, and to ensure that the 'action' is appropriate.
-Returns nothing.
+Returns an error if the statement failed.
 */
-func MoveFile(tokens []Token) {
+func (interpreter *Interpreter) MoveFile(tokens []Token) error {
+	// If the source is a list, move each file in it
+	if is_list, list_error := interpreter.ForEachItem(
+		tokens, 2, interpreter.MoveFile); is_list {
+		return list_error
+	}
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("movefile")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("movefile")+" "+
-				utils.ColouriseGreen("\"[path]\"")+" to "+
-				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
-				"use of an inappropriate action symbol ("+
-				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
-				"example of a working version might be "+
-				utils.ColouriseCyan("movefile")+
-				utils.ColouriseGreen(" \"test.txt\"")+" to "+
-				utils.ColouriseGreen(" \"test_new.txt\"")+".",
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 
 	// Fix the source string
 	source := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	source = VariableTemplater(source)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	if action_error != nil {
-		Report(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	source, template_error := interpreter.Template(source, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
 
 	// Fix the destination string
@@ -1270,7 +1944,11 @@ func MoveFile(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	destination = VariableTemplater(destination)
+	destination, template_error = interpreter.Template(
+		destination, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
 
 	/* Split the origin by the os path separator so that we can get the
 	file name in case we need to append it
@@ -1286,8 +1964,20 @@ func MoveFile(tokens []Token) {
 		destination = destination + filename
 	}
 
-	if MODE_VERBOSE {
-		fmt.Printf(
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"move",
+			utils.ColouriseGreen(source)+" to "+
+				utils.ColouriseGreen(destination)+" "+
+				utils.ColouriseMagenta(PathSummary(source)),
+		)
+		return nil
+	}
+
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s to %s...",
 			utils.ColouriseBlue("Moving"),
 			utils.ColouriseGreen(source),
@@ -1303,10 +1993,12 @@ func MoveFile(tokens []Token) {
 		/* If there's an error renaming the file, copy it instead and delete
 		the original
 		*/
-		CopyFile(tokens)
+		if copy_error := interpreter.CopyFile(tokens); copy_error != nil {
+			return copy_error
+		}
 		remove_err := os.Remove(source)
 		if remove_err != nil {
-			Report(
+			return Report(
 				"There was an error removing the source file: "+
 					utils.ColouriseYellow(source)+". It will be worth "+
 					"trying to remove it manually.",
@@ -1317,41 +2009,23 @@ func MoveFile(tokens []Token) {
 		}
 	}
 
-	if MODE_VERBOSE {
-		fmt.Println("done!")
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, "done!")
 	}
+	return nil
 }
 
 /*
 movedirectory statement
 Move a directory. The parameters are the conventional set of tokens.
-Returns nothing.
+Returns an error if the statement failed.
 */
-func MovePath(tokens []Token) {
-	// Get the full line of code
-	full_loc := tokens[0].FullLineOfCode
-	// Get the line of code
-	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("movedirectory")+" statement "+
-				"needs to follow the form "+
-				utils.ColouriseCyan("movedirectory")+" "+
-				utils.ColouriseYellow("\"[path]\"")+". A common error here "+
-				"is trying to concatenate multiple values into one statement "+
-				"call here. An example of a working version might be "+
-				utils.ColouriseCyan("movedirectory ")+
-				utils.ColouriseGreen("\"test_dir\"")+" to "+
-				utils.ColouriseGreen("\"actual_dir\""),
-			loc,
-			"n/a",
-			full_loc,
-		)
+func (interpreter *Interpreter) MovePath(tokens []Token) error {
+	// If the source is a list, move each directory in it
+	if is_list, list_error := interpreter.ForEachItem(
+		tokens, 2, interpreter.MovePath); is_list {
+		return list_error
 	}
-
 	// Get the source folder to copy and fix the strings
 	old_path := FixStringCombined(tokens[2].TokenValue)
 	// Fix the path seperators to ensure that the last character is a seperator
@@ -1359,20 +2033,12 @@ func MovePath(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have
 	been substituted.
 	*/
-	old_path = VariableTemplater(old_path)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	if action_error != nil {
-		Report(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	old_path, template_error := interpreter.Template(
+		old_path, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
+
 	// Get the destination folder to copy and fix the strings
 	new_path := FixStringCombined(tokens[4].TokenValue)
 	// Fix the path seperators to ensure that the last character is a seperator
@@ -1380,10 +2046,25 @@ func MovePath(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have
 	been substituted.
 	*/
-	new_path = VariableTemplater(new_path)
+	new_path, template_error = interpreter.Template(new_path, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"move",
+			utils.ColouriseGreen(old_path)+" to "+
+				utils.ColouriseGreen(new_path)+" "+
+				utils.ColouriseMagenta(PathSummary(old_path)),
+		)
+		return nil
+	}
 
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s to %s...",
 			utils.ColouriseBlue("Moving"),
 			utils.ColouriseGreen(old_path),
@@ -1401,7 +2082,9 @@ func MovePath(tokens []Token) {
 		copying them.
 		*/
 		// Give copying a go here instead.
-		CopyPath(tokens)
+		if copy_error := interpreter.CopyPath(tokens); copy_error != nil {
+			return copy_error
+		}
 		/*Report(
 			"There was an error moving the directory. Check to ensure that " +
 			"the source - " + utils.ColouriseYellow(old_path) + " - and the " +
@@ -1414,9 +2097,23 @@ func MovePath(tokens []Token) {
 		)*/
 	}
 
-	if MODE_VERBOSE {
-		fmt.Println("done!")
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, "done!")
 	}
+	return nil
+}
+
+/*
+params statement
+
+Declare a parameter of the script (eg. params option target = "/srv" "Where
+to copy to"). The parameters are gathered when the script is parsed and given
+their values before it starts (see arguments.go) so this is never called but
+is here so that params is a statement like any other. Parameters include the
+tokens. Returns nil as declaring a parameter can't fail.
+*/
+func (interpreter *Interpreter) Params(tokens []Token) error {
+	return nil
 }
 
 /*
@@ -1425,56 +2122,69 @@ pause statement
 Pause the execution of the script. Parameters include the tokens. Returns
 nothing.
 */
-func Pause(tokens []Token) {
-	// Get the full line of code
-	full_loc := tokens[0].FullLineOfCode
-	// Get the line of code
-	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("pause")+" statement needs to "+
-				"follow the form "+utils.ColouriseCyan("pause")+" "+
-				utils.ColouriseYellow("[version number]")+". A common "+
-				"issue here is excluding a version number or passing one as a "+
-				"string (eg. "+utils.ColouriseGreen("\"3\"")+"). An "+
-				"example of a working version check might be "+
-				utils.ColouriseCyan("pause")+utils.ColouriseYellow(" 3"),
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
-	}
-
-	/* Convert the loc to a simple number to keep things simple. We can discard
-	the line number as this will always be an integer.
-	*/
+func (interpreter *Interpreter) Pause(tokens []Token) error {
+	// Get the length of the pause as a string
 	pause_as_string := tokens[2].TokenValue
-
-	// Create an integer version of the pause length
-	pause_int, err := strconv.Atoi(pause_as_string)
-	/* If there is an error trying to do the conversion or if the pause_int is
-	less than zero, report an error.
+	/* Create an integer version of the pause length. As Parse() checks that
+	this is a valid integer before execution starts, we can discard the error.
 	*/
-	if err != nil || pause_int < 0 {
-		Report(
-			"The version number "+utils.ColouriseYellow(pause_as_string)+
-				" is not a valid version. You need to use a positive non-zero "+
-				"integer.",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
-	}
+	pause_int, _ := strconv.Atoi(pause_as_string)
 
 	// If verbose mode is set...
-	if MODE_VERBOSE {
-		fmt.Printf(":: Pausing for %s seconds...", pause_as_string)
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: Pausing for %s seconds...",
+			pause_as_string,
+		)
 	}
 	// Pause execution by sleeping for the required number of seconds
 	time.Sleep(time.Duration(pause_int) * time.Second)
+	return nil
+}
+
+/*
+repeat statement
+
+Run a statement a number of times. The count sits between the statement name
+and the times keyword and the statement to run sits after it. The statement is
+handed back to Call() as if it were on a line of its own. Parameters include
+the tokens. Returns an error if the count isn't valid or the statement failed.
+*/
+func (interpreter *Interpreter) Repeat(tokens []Token) error {
+	// Fix the count
+	count := FixStringCombined(tokens[2].TokenValue)
+	// Get a templated value for the count
+	count, template_error := interpreter.Template(count, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
+	// Check the count now that any variables have been substituted
+	count_int, count_error := interpreter.CheckRepeatCount(tokens, count)
+	if count_error != nil {
+		return count_error
+	}
+
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s %s times...\n",
+			utils.ColouriseBlue("Repeating"),
+			utils.ColouriseYellow(count),
+		)
+	}
+
+	// Get the tokens of the statement to repeat
+	nested_tokens := NestedStatementTokens(tokens, 3)
+	// Call the statement the number of times asked for
+	for repetition := 0; repetition < count_int; repetition++ {
+		call_error := interpreter.Call(nested_tokens)
+		if call_error != nil {
+			return call_error
+		}
+	}
+	return nil
 }
 
 /*
@@ -1483,227 +2193,299 @@ run statement
 Run a script from elsewhere. Parameters include the tokens. Returns
 nothing.
 */
-func Run(tokens []Token) {
-	// Get the full line of code
-	full_loc := tokens[0].FullLineOfCode
-	// Get the line of code
-	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("run")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("run")+
-				utils.ColouriseGreen(" \"[script]\"")+". An example of a "+
-				"working version check might be "+utils.ColouriseCyan("run")+
-				utils.ColouriseGreen("\"other_script.apt\"")+"\n\n"+
-				"Line of Code: "+utils.ColouriseMagenta(full_loc),
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
-
+func (interpreter *Interpreter) Run(tokens []Token) error {
 	/* Set the path name for the script to be run, fixing any issues with the
 	string.
 	*/
 	script_name := FixStringCombined(tokens[2].TokenValue)
 	// Replace any variables in the output string
-	script_name = VariableTemplater(script_name)
+	script_name, template_error := interpreter.Template(
+		script_name, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
 
-	file_exists := CheckFileExists(script_name)
+	// Check that the script exists
+	exists_error := RunTargetExists(tokens, 2, script_name)
+	if exists_error != nil {
+		return exists_error
+	}
 
-	if !file_exists {
-		Report(
-			"The script - "+utils.ColouriseYellow(script_name)+" - does "+
-				"not exist and/or can't be accessed. Double check to verify "+
-				"that the script exists.",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
+	// Prep the script by opening it and removing the comments
+	contents, prep_error := PrepScript(script_name)
+	if prep_error != nil {
+		return prep_error
 	}
 
-	contents := PrepScript(script_name)
+	/*
+		If we've gotten here, the script couldn't be parsed along with the
+		rest of the script (eg. the name relies on a variable set by the
+		script) so parse it now, before any of it is executed.
+	*/
+	script, parse_error := interpreter.Parse(contents, script_name)
+	if parse_error != nil {
+		return parse_error
+	}
 
-	if MODE_DEV {
+	if interpreter.ModeDev {
 		// Start printing out the tokens
-		fmt.Println(utils.ColouriseYellow("\nTokens"))
-		Start(contents, true)
+		fmt.Fprintln(interpreter.Stdout, utils.ColouriseYellow("\nTokens"))
+		interpreter.PrintScriptTokens(script)
 	} else {
-		Start(contents, false)
+		return interpreter.Execute(script)
 	}
+	return nil
 }
 
 /*
 set statement
 
-Set a variable. Parameters include the tokens. Returns the variable value.
+Set a variable. Parameters include the tokens. Returns an error if the value
+uses a variable that hasn't been set.
 */
-func Set(tokens []Token) string {
-	// Get the full line of code
-	full_loc := tokens[0].FullLineOfCode
-	// Get the line of code
-	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("set")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("set")+" "+
-				utils.ColouriseYellow("[variable name]")+" = "+
-				utils.ColouriseGreen("\"[value]\"")+". An example of a "+
-				"working version check might be "+utils.ColouriseCyan("set")+
-				" name = "+
-				utils.ColouriseGreen("\""+LANG_NAME+"\"")+"\n\n"+
-				"Line of Code: "+utils.ColouriseMagenta(full_loc),
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
-
+func (interpreter *Interpreter) Set(tokens []Token) error {
 	// Set the variable name
 	variable_name := tokens[2].TokenValue
-	// The assignment operator
-	assignment_operator := tokens[3].TokenValue
-	// The variable value with fixes that need to be made
-	variable_value := FixStringCombined(tokens[4].TokenValue)
 
-	/* Get the prefix of the variable so that we can check that it isn't
-	reserved
+	/* Get the final variable value, either a list or a value where any
+	variables have been substituted and any math expression calculated
 	*/
-	// Hold the (possible) prefix for checking
-	var variable_prefix string
-	// If the length of the variable is less than the RESERVED_VARIABLE_PREFIX
-	if len(variable_name) < len(SYMBOL_RESERVED_VARIABLE_PREFIX) {
-		// Just set the prefix to the variable
-		variable_prefix = string(variable_name)
+	var final_variable_value Value
+	var value_error error
+	if tokens[4].TokenValue == SYMBOL_LIST_OPEN {
+		final_variable_value, value_error = interpreter.EvaluateList(tokens, 4)
 	} else {
-		// Otherwise, create a prefix to check against
-		variable_prefix = string(variable_name[0:2])
-	}
-
-	// Check the variable prefix
-	var_prefix_error := CheckVariablePrefix(
-		loc, variable_prefix, variable_name)
-	if var_prefix_error != nil {
-		ReportWithFixes(
-			var_prefix_error.Error(),
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
-	}
-
-	// Check that the variable name is not one of the statement names
-	statement := CheckIsStatement(variable_name)
-	// If it is a statement
-	if statement {
-		ReportWithFixes(
-			"The variable - "+utils.ColouriseYellow(variable_name)+" - "+
-				"is not a valid variable name as it conflicts with a statement "+
-				"name.",
-			loc,
-			tokens[2].TokenPosition,
-			full_loc,
-		)
+		final_variable_value, value_error = interpreter.EvaluateValue(
+			tokens, tokens[4])
 	}
-
-	// Check for a valid assignment operator
-	assignment_error := CheckValidAssignment(loc, assignment_operator)
-	if assignment_error != nil {
-		ReportWithFixes(
-			assignment_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	if value_error != nil {
+		return value_error
 	}
 
-	/* Get a templated value, that is, a variable where values have been
-	substituted
-	*/
-	templated_variable := VariableTemplater(variable_value)
-
-	/* Get the final variable value here by checking to see if the value is
-	a math expression
-	*/
-	final_variable_value := CalculateValue(loc, templated_variable)
-
 	// If verbose mode is set...
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s to %s...",
 			utils.ColouriseBlue("Setting"),
 			utils.ColouriseYellow(variable_name),
-			utils.ColouriseGreen(final_variable_value),
+			utils.ColouriseGreen(final_variable_value.Literal()),
 		)
 	}
 	// Set the variable
-	VARIABLES[variable_name] = final_variable_value
+	interpreter.Variables[variable_name] = final_variable_value
 
 	// If verbose mode is set, report that things are done.
-	if MODE_VERBOSE {
-		fmt.Println("done!")
+	if interpreter.ModeVerbose {
+		fmt.Fprintln(interpreter.Stdout, "done!")
 	}
 
-	return final_variable_value
+	return nil
+}
+
+/*
+setenv statement
+
+Set an environment variable. The variable can be read by the script (eg.
+#env.NAME) and is given to any command that the script executes. Parameters
+include the tokens. Returns an error if the value uses a variable that hasn't
+been set.
+*/
+func (interpreter *Interpreter) SetEnv(tokens []Token) error {
+	// Get the name of the environment variable
+	environment_name := tokens[2].TokenValue
+	// Get a templated value
+	value, template_error := interpreter.Template(
+		FixStringCombined(tokens[4].TokenValue), tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
 
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s the environment variable %s to %s...\n",
+			utils.ColouriseBlue("Setting"),
+			utils.ColouriseYellow(environment_name),
+			utils.ColouriseGreen(value),
+		)
+	}
+	// Set the environment variable
+	interpreter.Environment[environment_name] = value
+	return nil
 }
 
 /*
-write and writeln statement
+unsetenv statement
 
-Write output to its own line or on one line. This handles both the write
-and writeln statement. Parameters include the tokens, and newline as a bool
-for whether output needs to add a new line (writeln) or leave the line
-without a newline character at the end. Returns nothing.
+Remove an environment variable so that neither the script nor the commands
+that it executes can see it. Parameters include the tokens. Returns nil as
+removing a variable that isn't set does nothing.
+*/
+func (interpreter *Interpreter) UnsetEnv(tokens []Token) error {
+	// Get the name of the environment variable
+	environment_name := tokens[2].TokenValue
+
+	// If verbose mode is set
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s the environment variable %s...\n",
+			utils.ColouriseBlue("Removing"),
+			utils.ColouriseYellow(environment_name),
+		)
+	}
+	// Remove the environment variable
+	delete(interpreter.Environment, environment_name)
+	return nil
+}
+
+/*
+unzip and untar statement
+
+Unpack an archive into a directory, making the directory if it isn't there
+yet. This handles the unzip statement and the untar statement, which unpacks
+tar archives that are uncompressed or compressed with gzip, bzip2, or xz. An
+archive with an entry that would be unpacked outside of the directory (eg.
+../../.bashrc) is stopped at that entry. Parameters include the tokens.
+Returns an error if the archive couldn't be unpacked.
 */
-func Writeln(tokens []Token, newline bool) string {
+func (interpreter *Interpreter) Extract(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 2)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("write/writeln")+" statement "+
-				"needs to follow the form "+
-				utils.ColouriseCyan("write/writeln")+" "+
-				utils.ColouriseYellow("[content to be written]")+". A "+
-				"common error here is trying to concatenate multiple values "+
-				"into one statement call here. An example of a working version "+
-				"might be "+utils.ColouriseCyan("write/writeln ")+
-				utils.ColouriseGreen("\"Hello World\"")+"\n\nLine of Code: "+
-				utils.ColouriseMagenta(full_loc),
+	// Get the statement name and the reader for its archives
+	statement_name := tokens[1].TokenValue
+	reader := ArchiveReaders()[statement_name]
+
+	// Get the archive and the directory to unpack it to
+	source, template_error := interpreter.Template(
+		FixStringCombined(tokens[2].TokenValue), tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
+	destination, template_error := interpreter.Template(
+		FixStringCombined(tokens[4].TokenValue), tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// Report an archive that couldn't be unpacked along with why
+	report_extract_error := func(extract_error error) error {
+		if errors.Is(extract_error, ErrUnsafeArchivePath) {
+			return Report(
+				"The archive - "+utils.ColouriseYellow(source)+" - has an "+
+					"entry that would be unpacked outside of "+
+					utils.ColouriseYellow(destination)+": "+
+					extract_error.Error()+".",
+				loc,
+				tokens[2].TokenPosition,
+				full_loc,
+			).WithRule("runtime/archive-path").WithHint(
+				"Nothing is written outside of the directory. The entries " +
+					"before this one have been unpacked. Only unpack " +
+					"archives from sources that you trust.",
+			).WithErr(extract_error)
+		}
+		return Report(
+			"The archive - "+utils.ColouriseYellow(source)+" - couldn't "+
+				"be unpacked to "+utils.ColouriseYellow(destination)+": "+
+				extract_error.Error()+".",
 			loc,
-			"n/a",
+			tokens[2].TokenPosition,
+			full_loc,
+		).WithRule("runtime/extract").WithErr(extract_error)
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		summary, summary_error := ArchiveSummary(reader, source)
+		if summary_error != nil {
+			return report_extract_error(summary_error)
+		}
+		interpreter.PrintPlan(
+			statement_name,
+			utils.ColouriseGreen(source)+" to "+
+				utils.ColouriseGreen(destination)+" "+
+				utils.ColouriseMagenta(summary),
+		)
+		return nil
+	}
+
+	// If verbose mode is set, note each entry as it is unpacked
+	var output io.Writer
+	if interpreter.ModeVerbose {
+		output = interpreter.Stdout
+		fmt.Fprintf(
+			interpreter.Stdout,
+			":: %s %s to %s...\n",
+			utils.ColouriseBlue("Unpacking"),
+			utils.ColouriseGreen(source),
+			utils.ColouriseGreen(destination),
+		)
+	}
+	extractor, extractor_error := NewArchiveExtractor(destination, output)
+	if extractor_error != nil {
+		return Report(
+			"The directory - "+utils.ColouriseYellow(destination)+" - "+
+				"couldn't be made to unpack the archive to.",
+			loc,
+			tokens[4].TokenPosition,
 			full_loc,
+		).WithErr(extractor_error)
+	}
+	extract_error := reader(source, extractor.Extract)
+	// Set the directories as they should be even if not everything unpacked
+	extract_error = errors.Join(extract_error, extractor.Finish())
+	if extract_error != nil {
+		return report_extract_error(extract_error)
+	}
+
+	/* If verbose mode is set, report back that we're done along with how many
+	files and bytes were written
+	*/
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
+			"done! "+utils.ColouriseMagenta("[%d files, %s bytes written]\n"),
+			extractor.Files,
+			strconv.FormatInt(extractor.Bytes, 10),
 		)
 	}
+	return nil
+}
+
+/*
+write and writeln statement
 
+Write output to its own line or on one line. This handles both the write
+and writeln statement. Parameters include the tokens, and newline as a bool
+for whether output needs to add a new line (writeln) or leave the line
+without a newline character at the end. Returns nil as writing can't fail.
+*/
+func (interpreter *Interpreter) Writeln(tokens []Token, newline bool) error {
 	// Fix the string to be printed
 	trimmed_output := FixStringCombined(tokens[2].TokenValue)
 	// Replace any variables in the output string
-	trimmed_output = VariableTemplater(trimmed_output)
+	trimmed_output, template_error := interpreter.Template(
+		trimmed_output, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
+	}
 
 	/* If newline is true, we are parsing a writeln, otherwise, we are parsing
 	a write
 	*/
 	if newline {
 		// Print out the output with a newline as we are parsing a writeln
-		fmt.Printf("%s\n", trimmed_output)
-		return fmt.Sprintf("%s\n", trimmed_output)
+		fmt.Fprintf(interpreter.Stdout, "%s\n", trimmed_output)
 	} else {
 		// Print out the output with a newline as we are parsing a write
-		fmt.Print(trimmed_output)
-		return trimmed_output
+		fmt.Fprint(interpreter.Stdout, trimmed_output)
 	}
+	return nil
 }
 
 /*
@@ -1711,53 +2493,22 @@ zipfile statement
 
 Make a zip archive of a file. The tokens are passed to get
 the origin, destination, and to ensure that the 'action' is appropriate.
-Returns nothing. Thanks to https://earthly.dev/blog/golang-zip-files/
+Returns an error if the statement failed. Thanks to
+https://earthly.dev/blog/golang-zip-files/
 */
-func ZipFromFile(tokens []Token) {
+func (interpreter *Interpreter) ZipFromFile(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("zipfile")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("zipfile")+" "+
-				utils.ColouriseGreen("\"[path]\"")+" to "+
-				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
-				"use of an inappropriate action symbol ("+
-				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
-				"example of a working version might be "+
-				utils.ColouriseCyan("zipfile")+
-				utils.ColouriseGreen(" \"/Users/user/test_dir.txt\"")+" to "+
-				utils.ColouriseGreen(" \"test_dir.zip\"")+"\n\nLine of "+
-				"Code: "+utils.ColouriseMagenta(full_loc),
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 	// Fix up the source string
 	source := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	source = VariableTemplater(source)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	// If there's an error in the action keyword, report it
-	if action_error != nil {
-		Report(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	source, template_error := interpreter.Template(source, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
 
 	// Fix up the destination string
@@ -1765,11 +2516,27 @@ func ZipFromFile(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	destination = VariableTemplater(destination)
+	destination, template_error = interpreter.Template(
+		destination, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"zip",
+			utils.ColouriseGreen(source)+" to "+
+				utils.ColouriseGreen(destination)+" "+
+				utils.ColouriseMagenta(PathSummary(source)),
+		)
+		return nil
+	}
 
 	// If verbose mode is set, note that we're zipping a file
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s to %s...",
 			utils.ColouriseBlue("Zipping"),
 			utils.ColouriseGreen(source),
@@ -1781,7 +2548,7 @@ func ZipFromFile(tokens []Token) {
 	archive_name, archive_name_error := os.Create(destination)
 	// If there's an error with creating the archive, report it
 	if archive_name_error != nil {
-		Report(
+		return Report(
 			"The archive name you provided - "+
 				utils.ColouriseYellow(destination)+" - could "+
 				" not be created. Is it possible that you can't write to that "+
@@ -1801,7 +2568,7 @@ func ZipFromFile(tokens []Token) {
 	file_to_zip, file_to_zip_error := os.Open(source)
 	// If there's an error opening up the file that will be zipped, report it
 	if file_to_zip_error != nil {
-		Report(
+		return Report(
 			"Couldn't open "+utils.ColouriseYellow(source)+"! Is it "+
 				"possible that this file doesn't exist?",
 			loc,
@@ -1823,7 +2590,7 @@ func ZipFromFile(tokens []Token) {
 	add_file, add_file_error := zip_writer.Create(filename)
 	// If there was an error creating the file in the zip archive, report it
 	if add_file_error != nil {
-		Report(
+		return Report(
 			"Couldn't add "+utils.ColouriseYellow(source)+" to "+
 				utils.ColouriseYellow(destination)+". Something went wrong "+
 				"with adding the file to the archive.",
@@ -1841,7 +2608,7 @@ func ZipFromFile(tokens []Token) {
 	archive, report it
 	*/
 	if copy_file_err != nil {
-		Report(
+		return Report(
 			"Couldn't copy data from "+source+" to "+destination+
 				"Check to make sure that the original file can be read.",
 			loc,
@@ -1853,8 +2620,9 @@ func ZipFromFile(tokens []Token) {
 	/* If verbose mode is set, report back that we're done along with how many
 	bytes were written
 	*/
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			"done! "+
 				utils.ColouriseMagenta(
 					"[%s bytes written]\n",
@@ -1865,6 +2633,7 @@ func ZipFromFile(tokens []Token) {
 
 	// Close the zip writer
 	zip_writer.Close()
+	return nil
 }
 
 /*
@@ -1874,53 +2643,21 @@ Make a zip archive of a file. The tokens are passed to get
 the origin, destination, and to ensure that the 'action' is appropriate. This
 is a modified version of this function (consider it version 2) as it migrates
 from a file path walker to the os.DirFS and zip writer AddFS() functions.
-Returns nothing.
+Returns an error if the statement failed.
 */
-func ZipFromPath(tokens []Token) {
+func (interpreter *Interpreter) ZipFromPath(tokens []Token) error {
 	// Get the full line of code
 	full_loc := tokens[0].FullLineOfCode
 	// Get the line of code
 	loc := strconv.Itoa(tokens[0].LineNumber)
-	// Check the number of tokens and ensure that it's a proper amount
-	_, err := CheckValidNumberOfTokens(tokens, 4)
-	// If not a valid number of tokens, report an error
-	if err != nil {
-		Report(
-			"The "+utils.ColouriseCyan("zipfile")+" statement needs "+
-				"to follow the form "+utils.ColouriseCyan("zipfile")+" "+
-				utils.ColouriseGreen("\"[path]\"")+" to "+
-				utils.ColouriseGreen("\"[path]\"")+". A common issue is the "+
-				"use of an inappropriate action symbol ("+
-				utils.ColouriseMagenta(SYMBOL_ACTION)+"). An "+
-				"example of a working version might be "+
-				utils.ColouriseCyan("zipfile")+
-				utils.ColouriseGreen(" \"/Users/user/test_dir.txt\"")+" to "+
-				utils.ColouriseGreen(" \"test_dir.zip\"")+"\n\nLine of "+
-				"Code: "+utils.ColouriseMagenta(full_loc),
-			loc,
-			"n/a",
-			full_loc,
-		)
-	}
 	// Fix up the source string
 	source := FixStringCombined(tokens[2].TokenValue)
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	source = VariableTemplater(source)
-
-	// Get the action to ensure that it can be checked
-	action := tokens[3].TokenValue
-	// Check the action keyword to ensure that it's valid
-	action_error := CheckAction(loc, action)
-	// If there's an error in the action keyword, report it
-	if action_error != nil {
-		Report(
-			action_error.Error(),
-			loc,
-			tokens[3].TokenPosition,
-			full_loc,
-		)
+	source, template_error := interpreter.Template(source, tokens, tokens[2])
+	if template_error != nil {
+		return template_error
 	}
 
 	// Fix up the destination string
@@ -1928,11 +2665,27 @@ func ZipFromPath(tokens []Token) {
 	/* Get a templated value, that is, a variable where values have been
 	substituted
 	*/
-	destination = VariableTemplater(destination)
+	destination, template_error = interpreter.Template(
+		destination, tokens, tokens[4])
+	if template_error != nil {
+		return template_error
+	}
+
+	// If we're in dry run mode, say what we would do and leave it there
+	if interpreter.ModeDryRun {
+		interpreter.PrintPlan(
+			"zip",
+			utils.ColouriseGreen(source)+" to "+
+				utils.ColouriseGreen(destination)+" "+
+				utils.ColouriseMagenta(PathSummary(source)),
+		)
+		return nil
+	}
 
 	// If verbose mode is set, note that we're zipping a file
-	if MODE_VERBOSE {
-		fmt.Printf(
+	if interpreter.ModeVerbose {
+		fmt.Fprintf(
+			interpreter.Stdout,
 			":: %s %s to %s...",
 			utils.ColouriseBlue("Zipping"),
 			utils.ColouriseGreen(source),
@@ -1943,7 +2696,7 @@ func ZipFromPath(tokens []Token) {
 	// Create the archive file
 	archive_file, archive_file_error := os.Create(destination)
 	if archive_file_error != nil {
-		Report(
+		return Report(
 			"Error creating the archive at "+destination,
 			loc,
 			tokens[4].TokenPosition,
@@ -1961,11 +2714,12 @@ func ZipFromPath(tokens []Token) {
 	// Ad the filesystem path to the archive
 	archive_fs_error := archive_writer.AddFS(archive_path)
 	if archive_fs_error != nil {
-		Report(
+		return Report(
 			"Error adding path to the archive",
 			loc,
 			tokens[2].TokenPosition,
 			full_loc,
 		)
 	}
+	return nil
 }
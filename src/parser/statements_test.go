@@ -4,12 +4,26 @@ Tests for the engine, largely for the Tokenise() function for each statement.
 package parser
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestValidAskCall(t *testing.T) {
-	results := Tokenise("ask \"Greeting: \" to greeting", 1, 1)
+	results, _ := Tokenise("ask \"Greeting: \" to greeting", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_ASK)
 
 	if !tokenisation_equal {
@@ -22,7 +36,7 @@ func TestValidAskCall(t *testing.T) {
 }
 
 func TestValidCopyDirCall(t *testing.T) {
-	results := Tokenise("copydirectory \"/home/user/test\" to \"/home/user/test2\"", 1, 1)
+	results, _ := Tokenise("copydirectory \"/home/user/test\" to \"/home/user/test2\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_COPYDIR)
 
 	if !tokenisation_equal {
@@ -35,7 +49,7 @@ func TestValidCopyDirCall(t *testing.T) {
 }
 
 func TestValidCopyFileCall(t *testing.T) {
-	results := Tokenise("copyfile \"/home/user/test.txt\" to \"/home/user/test2.txt\"", 1, 1)
+	results, _ := Tokenise("copyfile \"/home/user/test.txt\" to \"/home/user/test2.txt\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_COPYFILE)
 
 	if !tokenisation_equal {
@@ -48,7 +62,7 @@ func TestValidCopyFileCall(t *testing.T) {
 }
 
 func TestValidDeleteDirCall(t *testing.T) {
-	results := Tokenise("deletedirectory \"/home/user/test/\"", 1, 1)
+	results, _ := Tokenise("deletedirectory \"/home/user/test/\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_DELETEDIR)
 
 	if !tokenisation_equal {
@@ -61,7 +75,7 @@ func TestValidDeleteDirCall(t *testing.T) {
 }
 
 func TestValidDeleteFileCall(t *testing.T) {
-	results := Tokenise("deletefile \"/home/user/test.txt\"", 1, 1)
+	results, _ := Tokenise("deletefile \"/home/user/test.txt\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_DELETEFILE)
 
 	if !tokenisation_equal {
@@ -74,7 +88,7 @@ func TestValidDeleteFileCall(t *testing.T) {
 }
 
 func TestValidDownloadCall(t *testing.T) {
-	results := Tokenise(
+	results, _ := Tokenise(
 		"download \"http://upload.wikimedia.org/wikipedia/commons/0/02/"+
 			"La_Libert%C3%A9_guidant_le_peuple_-_Eug%C3%A8ne_Delacroix_-_Mus%C3"+
 			"%A9e_du_Louvre_Peintures_RF_129_-_apr%C3%A8s_restauration_2024."+
@@ -91,7 +105,7 @@ func TestValidDownloadCall(t *testing.T) {
 }
 
 func TestValidExecuteCall(t *testing.T) {
-	results := Tokenise("execute \"ls -l\"", 1, 1)
+	results, _ := Tokenise("execute \"ls -l\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_EXECUTE)
 
 	if !tokenisation_equal {
@@ -104,7 +118,7 @@ func TestValidExecuteCall(t *testing.T) {
 }
 
 func TestExit(t *testing.T) {
-	results := Tokenise("exit", 1, 1)
+	results, _ := Tokenise("exit", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_EXIT)
 
 	if !tokenisation_equal {
@@ -117,7 +131,7 @@ func TestExit(t *testing.T) {
 }
 
 func TestValidMakeDirCall(t *testing.T) {
-	results := Tokenise("makedirectory \"#b_home/Downloads/testdir2\"", 1, 1)
+	results, _ := Tokenise("makedirectory \"#b_home/Downloads/testdir2\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_MAKEDIR)
 
 	if !tokenisation_equal {
@@ -130,7 +144,7 @@ func TestValidMakeDirCall(t *testing.T) {
 }
 
 func TestValidMakeFileCall(t *testing.T) {
-	results := Tokenise("makefile \"#b_home/Downloads/testdir2.txt\"", 1, 1)
+	results, _ := Tokenise("makefile \"#b_home/Downloads/testdir2.txt\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_MAKEFILE)
 
 	if !tokenisation_equal {
@@ -143,7 +157,7 @@ func TestValidMakeFileCall(t *testing.T) {
 }
 
 func TestValidMinVerCall(t *testing.T) {
-	results := Tokenise("minver 1", 1, 1)
+	results, _ := Tokenise("minver 1", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_MINVER)
 
 	if !tokenisation_equal {
@@ -211,7 +225,7 @@ func TestValidMinverCalls(t *testing.T) {
 }
 
 func TestValidMoveDirCall(t *testing.T) {
-	results := Tokenise("movedirectory \"/home/user/test\" to \"/home/user/test2\"", 1, 1)
+	results, _ := Tokenise("movedirectory \"/home/user/test\" to \"/home/user/test2\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_MOVEDIR)
 
 	if !tokenisation_equal {
@@ -224,7 +238,7 @@ func TestValidMoveDirCall(t *testing.T) {
 }
 
 func TestValidMoveFileCall(t *testing.T) {
-	results := Tokenise("movefile \"/home/user/test.txt\" to \"/home/user/test\"", 1, 1)
+	results, _ := Tokenise("movefile \"/home/user/test.txt\" to \"/home/user/test\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_MOVEFILE)
 
 	if !tokenisation_equal {
@@ -237,7 +251,7 @@ func TestValidMoveFileCall(t *testing.T) {
 }
 
 func TestValidPauseCall(t *testing.T) {
-	results := Tokenise("pause 3", 1, 1)
+	results, _ := Tokenise("pause 3", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_PAUSE)
 
 	if !tokenisation_equal {
@@ -250,7 +264,7 @@ func TestValidPauseCall(t *testing.T) {
 }
 
 func TestValidRunCall(t *testing.T) {
-	results := Tokenise("run \"../samples/write.apt\"", 1, 1)
+	results, _ := Tokenise("run \"../samples/write.apt\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_RUN)
 
 	if !tokenisation_equal {
@@ -263,7 +277,7 @@ func TestValidRunCall(t *testing.T) {
 }
 
 func TestValidSetCall(t *testing.T) {
-	results := Tokenise("set name = \"Hello World!\"", 1, 1)
+	results, _ := Tokenise("set name = \"Hello World!\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_SET)
 
 	if !tokenisation_equal {
@@ -276,7 +290,7 @@ func TestValidSetCall(t *testing.T) {
 }
 
 func TestValidWriteCall(t *testing.T) {
-	results := Tokenise("write \"Hello World!\"", 1, 1)
+	results, _ := Tokenise("write \"Hello World!\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_WRITE)
 
 	if !tokenisation_equal {
@@ -289,7 +303,7 @@ func TestValidWriteCall(t *testing.T) {
 }
 
 func TestValidWriteLnCall(t *testing.T) {
-	results := Tokenise("writeln \"Hello World!\"", 1, 1)
+	results, _ := Tokenise("writeln \"Hello World!\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_WRITELN)
 
 	if !tokenisation_equal {
@@ -302,7 +316,7 @@ func TestValidWriteLnCall(t *testing.T) {
 }
 
 func TestValidZipDirCall(t *testing.T) {
-	results := Tokenise("zipdirectory \"/home/user/test\" to \"/home/user/test2.zip\"", 1, 1)
+	results, _ := Tokenise("zipdirectory \"/home/user/test\" to \"/home/user/test2.zip\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_ZIPDIR)
 
 	if !tokenisation_equal {
@@ -315,7 +329,7 @@ func TestValidZipDirCall(t *testing.T) {
 }
 
 func TestValidZipFileCall(t *testing.T) {
-	results := Tokenise("zipfile \"/home/user/test.txt\" to \"/home/user/test2.zip\"", 1, 1)
+	results, _ := Tokenise("zipfile \"/home/user/test.txt\" to \"/home/user/test2.zip\"", 1, 1)
 	tokenisation_equal := reflect.DeepEqual(results, TEST_ZIPFILE)
 
 	if !tokenisation_equal {
@@ -326,3 +340,942 @@ func TestValidZipFileCall(t *testing.T) {
 		)
 	}
 }
+
+/*
+Check to make sure that an if statement only runs its statement when the
+condition is met for each kind of condition.
+*/
+func TestIfStatement(t *testing.T) {
+	// Create a directory and file to check against
+	directory := t.TempDir()
+	file_name := filepath.Join(directory, "test.txt")
+	os.WriteFile(file_name, []byte("test"), 0644)
+
+	// Each condition and whether it should be met
+	conditions := map[string]bool{
+		"\"#name\" is \"Appetit\"":                   true,
+		"\"#name\" is \"appetit\"":                   false,
+		"\"#name\" is not \"appetit\"":               true,
+		"\"#count\" is greater than 3":               true,
+		"\"#count\" is greater than 5":               false,
+		"\"#count\" is less than 5.5":                true,
+		"\"#count\" is at least 5":                   true,
+		"\"#count\" is at most 4":                    false,
+		"file \"" + file_name + "\" exists":          true,
+		"file \"" + directory + "\" exists":          false,
+		"directory \"" + directory + "\" exists":     true,
+		"file \"" + file_name + "2\" does not exist": true,
+		"variable \"name\" is set":                   true,
+		"variable \"missing\" is set":                false,
+		"variable \"missing\" is not set":            true,
+	}
+
+	for condition, expected := range conditions {
+		// Create an interpreter that writes to a buffer
+		var output bytes.Buffer
+		interpreter := New(Options{Stdout: &output})
+		run_error := interpreter.RunString(
+			"set name = \"Appetit\"\nset count = 5\n" +
+				"if " + condition + " then write \"met\"",
+		)
+		if run_error != nil {
+			t.Fatalf("[if stmt] Expected no error for %s, got %v",
+				condition,
+				run_error)
+		}
+		// Check whether the statement was run
+		if (output.String() == "met") != expected {
+			t.Errorf("[if stmt] Expected %s to be %v, got output %q",
+				condition,
+				expected,
+				output.String())
+		}
+	}
+}
+
+/*
+Check to make sure that malformed if statements are caught before anything is
+executed, inclusive of the statement that would be run.
+*/
+func TestIfStatementChecks(t *testing.T) {
+	malformed_statements := []string{
+		"if \"a\" is \"a\" writeln \"missing then\"",
+		"if then writeln \"missing condition\"",
+		"if \"a\" is \"a\" then",
+		"if \"a\" is bigger than \"b\" then writeln \"bad operator\"",
+		"if \"a\" is greater than 3 then writeln \"not a number\"",
+		"if file \"test.txt\" is set then writeln \"bad operator\"",
+		"if \"a\" is \"a\" then writeln",
+		"if \"a\" is \"a\" then notastatement \"a\"",
+	}
+
+	for _, statement := range malformed_statements {
+		// Create an interpreter that writes to a buffer
+		var output bytes.Buffer
+		interpreter := New(Options{Stdout: &output})
+		run_error := interpreter.RunString(
+			"writeln \"before\"\n" + statement)
+		// Check that the error is a syntax error
+		if ExitCode(run_error) != int(ERROR_SYNTAX) {
+			t.Errorf("[if stmt] Expected a syntax error for %s, got %v",
+				statement,
+				run_error)
+		}
+		// Check that nothing was executed
+		if output.Len() != 0 {
+			t.Errorf("[if stmt] Expected no output for %s, got %q",
+				statement,
+				output.String())
+		}
+	}
+}
+
+/*
+Check to make sure that goto statements jump to their labels, both forwards
+and backwards, and only when their condition is met.
+*/
+func TestGotoStatement(t *testing.T) {
+	// Create an interpreter that writes to a buffer
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output})
+
+	// Loop five times and then skip over a statement
+	run_error := interpreter.RunString(
+		"set i = 0\nlabel \"top\"\nset i = \"#i + 1\"\nwrite \"#i\"\n" +
+			"goto \"top\" if \"#i\" is less than 5\ngoto \"end\"\n" +
+			"write \"skipped\"\nlabel \"end\"\nwrite \" done\"",
+	)
+	if run_error != nil {
+		t.Fatalf("[goto stmt] Expected no error, got %v", run_error)
+	}
+
+	// Check the output
+	if output.String() != "12345 done" {
+		t.Errorf("[goto stmt] Expected %q, got %q",
+			"12345 done",
+			output.String())
+	}
+}
+
+/*
+Check to make sure that repeat statements run their statement the number of
+times asked for, inclusive of a number held in a variable.
+*/
+func TestRepeatStatement(t *testing.T) {
+	// Create an interpreter that writes to a buffer
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output})
+
+	run_error := interpreter.RunString(
+		"repeat 3 times write \"a\"\nset count = 2\n" +
+			"repeat \"#count\" times write \"b\"\nrepeat 0 times write \"c\"",
+	)
+	if run_error != nil {
+		t.Fatalf("[repeat stmt] Expected no error, got %v", run_error)
+	}
+
+	// Check the output
+	if output.String() != "aaabb" {
+		t.Errorf("[repeat stmt] Expected %q, got %q",
+			"aaabb",
+			output.String())
+	}
+}
+
+/*
+Check to make sure that a script that loops more than the interpreter allows
+is stopped and that errors after a jump report the right line.
+*/
+func TestMaxIterations(t *testing.T) {
+	// Create an interpreter that can only loop three times
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output, MaxIterations: 3})
+
+	// A goto statement that loops forever should be stopped
+	run_error := interpreter.RunString(
+		"label \"top\"\nwrite \"a\"\ngoto \"top\"")
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) || script_error.Line != 3 {
+		t.Errorf("[goto stmt] Expected an error on line 3, got %v",
+			run_error)
+	}
+	// The statement should have run once and then looped three times
+	if output.String() != "aaaa" {
+		t.Errorf("[goto stmt] Expected %q, got %q",
+			"aaaa",
+			output.String())
+	}
+
+	// A repeat statement asking for more than that should be stopped too
+	run_error = interpreter.RunString("repeat 4 times write \"a\"")
+	if ExitCode(run_error) != int(ERROR_SYNTAX) {
+		t.Errorf("[repeat stmt] Expected a syntax error, got %v", run_error)
+	}
+
+	// An error after a jump should report the line that it happened on
+	run_error = interpreter.RunString(
+		"set i = 0\nlabel \"top\"\nset i = \"#i + 1\"\n" +
+			"goto \"top\" if \"#i\" is less than 3\n" +
+			"if \"#i\" is greater than \"x#i\" then write \"never\"",
+	)
+	if !errors.As(run_error, &script_error) || script_error.Line != 5 {
+		t.Errorf("[goto stmt] Expected an error on line 5, got %v",
+			run_error)
+	}
+}
+
+/*
+Check to make sure that malformed label, goto, and repeat statements are
+caught before anything is executed.
+*/
+func TestLoopStatementChecks(t *testing.T) {
+	malformed_statements := []string{
+		"label",
+		"label \"a\"\nlabel \"a\"",
+		"goto \"nowhere\"",
+		"goto \"a\" if\nlabel \"a\"",
+		"goto \"a\" unless \"a\" is \"b\"\nlabel \"a\"",
+		"if \"a\" is \"a\" then goto \"a\"\nlabel \"a\"",
+		"repeat 3 times label \"a\"",
+		"repeat 3 writeln \"missing times\"",
+		"repeat -1 times writeln \"negative\"",
+		"repeat 3 times",
+		"repeat 3 times writeln",
+	}
+
+	for _, statement := range malformed_statements {
+		// Create an interpreter that writes to a buffer
+		var output bytes.Buffer
+		interpreter := New(Options{Stdout: &output})
+		run_error := interpreter.RunString(
+			"writeln \"before\"\n" + statement)
+		// Check that the error is a syntax error
+		if ExitCode(run_error) != int(ERROR_SYNTAX) {
+			t.Errorf("[loop stmt] Expected a syntax error for %s, got %v",
+				statement,
+				run_error)
+		}
+		// Check that nothing was executed
+		if output.Len() != 0 {
+			t.Errorf("[loop stmt] Expected no output for %s, got %q",
+				statement,
+				output.String())
+		}
+	}
+
+	/*
+		A condition that can't be checked is shown with an example of a goto
+		statement, leaving the line of code to the report itself
+	*/
+	run_error := New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"goto \"a\" if \"x\" is about \"y\"\nlabel \"a\"")
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) ||
+		strings.Contains(script_error.Message, "writeln") ||
+		strings.Contains(script_error.Message, "Line of Code") {
+		t.Errorf("[loop stmt] Expected a goto example, got %v", run_error)
+	}
+}
+
+/*
+Check to make sure that procedures can be defined in a block and from a file
+and that they run with their own copy of the variables.
+*/
+func TestDefineAndCallStatements(t *testing.T) {
+	// Create a procedure in a file
+	procedure_file := filepath.Join(t.TempDir(), "greet.apt")
+	os.WriteFile(
+		procedure_file,
+		[]byte("write \"Hello #who! \"\nset greeting = \"Hi #who\""),
+		0644,
+	)
+
+	// Create an interpreter that writes to a buffer
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output})
+
+	// Call each procedure, the block one before it is defined
+	run_error := interpreter.RunString(
+		"define \"greet\" from \"" + procedure_file + "\"\n" +
+			"call \"greet\" with who = \"World\" returning greeting\n" +
+			"write \"#greeting \"\n" +
+			"call \"add\" with a = 2 and b = 3 returning total\n" +
+			"write \"#total\"\n" +
+			"define \"add\"\n\tset total = \"#a + #b\"\n\tset leaked = 1\nend",
+	)
+	if run_error != nil {
+		t.Fatalf("[call stmt] Expected no error, got %v", run_error)
+	}
+
+	// Check the output
+	if output.String() != "Hello World! Hi World 5" {
+		t.Errorf("[call stmt] Expected %q, got %q",
+			"Hello World! Hi World 5",
+			output.String())
+	}
+
+	// Check that only the outputs made their way back
+	for _, variable_name := range []string{"who", "a", "b", "leaked"} {
+		if _, exists := interpreter.Variables[variable_name]; exists {
+			t.Errorf("[call stmt] Expected %s not to be set", variable_name)
+		}
+	}
+}
+
+/*
+Check to make sure that malformed define, end, and call statements are caught
+before anything is executed.
+*/
+func TestProcedureStatementChecks(t *testing.T) {
+	malformed_statements := []string{
+		"call \"missing\"",
+		"call \"a\" with x 1\ndefine \"a\"\nend",
+		"call \"a\" with x =\ndefine \"a\"\nend",
+		"call \"a\" returning\ndefine \"a\"\nend",
+		"call \"a\" with b_x = 1\ndefine \"a\"\nend",
+		"define",
+		"define \"a\" to \"a.apt\"",
+		"define \"a\" from \"missing.apt\"",
+		"define \"a\"\nwriteln \"never ended\"",
+		"define \"a\"\ndefine \"b\"\nend\nend",
+		"define \"a\"\nend\ndefine \"a\"\nend",
+		"end",
+		"if \"a\" is \"a\" then define \"a\"",
+	}
+
+	for _, statement := range malformed_statements {
+		// Create an interpreter that writes to a buffer
+		var output bytes.Buffer
+		interpreter := New(Options{Stdout: &output})
+		run_error := interpreter.RunString(
+			"writeln \"before\"\n" + statement)
+		// Check that the error is a syntax error
+		if ExitCode(run_error) != int(ERROR_SYNTAX) {
+			t.Errorf("[call stmt] Expected a syntax error for %s, got %v",
+				statement,
+				run_error)
+		}
+		// Check that nothing was executed
+		if output.Len() != 0 {
+			t.Errorf("[call stmt] Expected no output for %s, got %q",
+				statement,
+				output.String())
+		}
+	}
+}
+
+/*
+Check to make sure that a dry run prints a plan for each statement that would
+touch the file system or network without touching anything.
+*/
+func TestDryRun(t *testing.T) {
+	// Create a directory holding three 4 KB files
+	temp_dir := t.TempDir()
+	data_dir := filepath.Join(temp_dir, "data")
+	os.Mkdir(data_dir, 0750)
+	for _, file_name := range []string{"a.txt", "b.txt", "c.txt"} {
+		os.WriteFile(
+			filepath.Join(data_dir, file_name),
+			bytes.Repeat([]byte("a"), 4096),
+			0644,
+		)
+	}
+
+	// Create an interpreter that writes to a buffer and is in dry run mode
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output, DryRun: true})
+	interpreter.Variables["dir"] = StringValue(temp_dir)
+
+	statements := []string{
+		"deletedirectory \"#dir/data\"",
+		"deletefile \"#dir/data/a.txt\"",
+		"copyfile \"#dir/data/a.txt\" to \"#dir/copy.txt\"",
+		"copydirectory \"#dir/data\" to \"#dir/copy\"",
+		"movefile \"#dir/data/b.txt\" to \"#dir/moved.txt\"",
+		"movedirectory \"#dir/data\" to \"#dir/moved\"",
+		"makefile \"#dir/new.txt\"",
+		"makedirectory \"#dir/new\"",
+		"log \"hello\" to \"#dir/log\"",
+		"zipfile \"#dir/data/c.txt\" to \"#dir/file.zip\"",
+		"zipdirectory \"#dir/data\" to \"#dir/dir.zip\"",
+		"download \"http://127.0.0.1:1/file\" to \"#dir/download\"",
+		"execute \"rm -rf #dir\"",
+	}
+	run_error := interpreter.RunString(strings.Join(statements, "\n"))
+	if run_error != nil {
+		t.Fatalf("[dry run] Expected no error, got %v", run_error)
+	}
+
+	// Check that there is a plan line for each statement
+	plan := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(plan) != len(statements) {
+		t.Fatalf("[dry run] Expected %d plan lines, got %q",
+			len(statements),
+			output.String())
+	}
+	for index, line := range plan {
+		if !strings.Contains(line, "would ") {
+			t.Errorf("[dry run] Expected a plan line for %s, got %q",
+				statements[index],
+				line)
+		}
+	}
+	// Check that the size of the directory is included
+	if !strings.Contains(plan[0], "(3 files, 12 KB)") {
+		t.Errorf("[dry run] Expected the directory size, got %q", plan[0])
+	}
+
+	// Check that nothing was touched
+	entries, _ := os.ReadDir(temp_dir)
+	if len(entries) != 1 {
+		t.Errorf("[dry run] Expected only the data directory, got %v",
+			entries)
+	}
+	entries, _ = os.ReadDir(data_dir)
+	if len(entries) != 3 {
+		t.Errorf("[dry run] Expected the three files to remain, got %v",
+			entries)
+	}
+}
+
+/*
+Check to make sure that the set statement keeps the type of a value, that the
+convert statement changes it, and that a list of files can be copied and
+deleted with one statement each.
+*/
+func TestTypedValues(t *testing.T) {
+	// Create two files to copy and delete
+	temp_dir := t.TempDir()
+	for _, file_name := range []string{"a.txt", "b.txt"} {
+		os.WriteFile(filepath.Join(temp_dir, file_name), []byte("a"), 0644)
+	}
+
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output})
+	interpreter.Variables["dir"] = StringValue(temp_dir)
+	run_error := interpreter.RunString(strings.Join([]string{
+		"set count = \"2 + 3\"",
+		"set half = \"1.0 / 2\"",
+		"set files = [\"#dir/a.txt\", \"#dir/b.txt\"]",
+		"set copies = \"#files\"",
+		"set number = \"42\"",
+		"convert number to float",
+		"set quoted = \"7\"",
+		"convert \"quoted\" to integer",
+		"makedirectory \"#dir/copies/\"",
+		"copyfile \"#files\" to \"#dir/copies/\"",
+		"deletefile \"#files\"",
+		"writeln \"#count #half #number\"",
+	}, "\n"))
+	if run_error != nil {
+		t.Fatalf("[set] Expected no error, got %v", run_error)
+	}
+
+	// Check the types of the variables
+	types := map[string]ValueType{
+		"count":  VALUE_INTEGER,
+		"half":   VALUE_FLOAT,
+		"files":  VALUE_LIST,
+		"copies": VALUE_LIST,
+		"number": VALUE_FLOAT,
+		"quoted": VALUE_INTEGER,
+	}
+	for variable_name, value_type := range types {
+		if interpreter.Variables[variable_name].Type != value_type {
+			t.Errorf("[set] Expected %s to be a %s, got %s",
+				variable_name,
+				value_type,
+				interpreter.Variables[variable_name].Literal())
+		}
+	}
+	if output.String() != "5 0.5 42\n" {
+		t.Errorf("[set] Expected %q, got %q", "5 0.5 42\n", output.String())
+	}
+
+	// Check that the files were copied and then deleted
+	copies, _ := os.ReadDir(filepath.Join(temp_dir, "copies"))
+	if len(copies) != 2 {
+		t.Errorf("[copyfile] Expected two copies, got %v", copies)
+	}
+	for _, file_name := range []string{"a.txt", "b.txt"} {
+		if CheckFileExists(filepath.Join(temp_dir, file_name)) {
+			t.Errorf("[deletefile] Expected %s to be deleted", file_name)
+		}
+	}
+	// The list should be put back once each file is done
+	if interpreter.Variables["files"].Type != VALUE_LIST {
+		t.Errorf("[deletefile] Expected files to still be a list, got %s",
+			interpreter.Variables["files"].Literal())
+	}
+
+	// Either spelling of the variable name is checked as the same variable
+	for _, script := range []string{
+		"set n = \"5\"\nconvert n to integer",
+		"set n = \"5\"\nconvert \"n\" to integer",
+	} {
+		if check_error := New(Options{Check: true}).RunString(
+			script); check_error != nil {
+			t.Errorf("[convert] Expected no problems with %q, got %v",
+				script,
+				check_error)
+		}
+	}
+}
+
+/*
+Check to make sure that a malformed list and a value that can't be converted
+are reported.
+*/
+func TestTypedValueErrors(t *testing.T) {
+	scripts := []string{
+		"set files = [\"a.txt\" \"b.txt\"]",
+		"set files = [\"a.txt\",]",
+		"set name = \"abc\"\nconvert name to integer",
+		"set name = \"abc\"\nconvert name to number",
+	}
+	for _, script := range scripts {
+		interpreter := New(Options{Stdout: &bytes.Buffer{}})
+		if run_error := interpreter.RunString(script); run_error == nil {
+			t.Errorf("[set] Expected an error for %q", script)
+		}
+	}
+}
+
+/*
+Check to make sure that a command is split into its arguments much as a shell
+would split it.
+*/
+func TestSplitCommand(t *testing.T) {
+	// The commands and what they should be split into
+	cases := map[string][]string{
+		"ls -l":                        {"ls", "-l"},
+		"  git   status ":              {"git", "status"},
+		"git commit -m 'Backed up'":    {"git", "commit", "-m", "Backed up"},
+		"echo \"a \\\" b\" it\\'s":     {"echo", "a \" b", "it's"},
+		"printf ''":                    {"printf", ""},
+		"C:\\Tools\\app.exe a\\ b":     {"C:\\Tools\\app.exe", "a b"},
+		"echo pre'quoted'\"and more\"": {"echo", "prequotedand more"},
+	}
+	for command, expected := range cases {
+		parts, split_error := SplitCommand(command)
+		if split_error != nil || !slices.Equal(parts, expected) {
+			t.Errorf("[SplitCommand] %q returned %q (%v), expected %q",
+				command,
+				parts,
+				split_error,
+				expected)
+		}
+	}
+	for _, command := range []string{"echo 'open", "echo \"open", "  "} {
+		if _, split_error := SplitCommand(command); split_error == nil {
+			t.Errorf("[SplitCommand] Expected an error for %q", command)
+		}
+	}
+}
+
+/*
+Check to make sure that the output of a command can be saved to a variable,
+that the exit code is saved, and that the ways in which a command can fail
+are told apart.
+*/
+func TestExecuteStatement(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The commands used are Unix commands")
+	}
+	temp_dir := t.TempDir()
+	script := "execute \"sh -c 'echo Hello; exit 0'\" to \"greeting\"\n" +
+		"execute \"pwd\" in \"" + temp_dir + "\" to \"where\"\n" +
+		"execute \"echo streamed\"\n" +
+		"writeln \"#greeting #b_exit_code\""
+	var output bytes.Buffer
+	interpreter := New(Options{Stdout: &output, AllowExec: true})
+	if run_error := interpreter.RunString(script); run_error != nil {
+		t.Fatalf("[execute] Expected no error, got %v", run_error)
+	}
+	if output.String() != "streamed\nHello 0\n" {
+		t.Errorf("[execute] Expected %q, got %q",
+			"streamed\nHello 0\n",
+			output.String())
+	}
+	// The temporary directory can be behind a symlink (eg. on macOS)
+	where, _ := filepath.EvalSymlinks(interpreter.Variables["where"].String())
+	if resolved, _ := filepath.EvalSymlinks(temp_dir); where != resolved {
+		t.Errorf("[execute] Expected to run in %s, got %s", resolved, where)
+	}
+
+	/*
+		The value of a variable is one argument, whatever spaces or quotes
+		are in it, so that it can't add arguments of its own
+	*/
+	output.Reset()
+	interpreter = New(Options{Stdout: &output, AllowExec: true})
+	interpreter.Variables["f"] = StringValue("my file.txt")
+	interpreter.Variables["g"] = StringValue("x' '/etc")
+	run_error := interpreter.RunString(
+		"execute \"printf [%s]\\\\n #f '#g'\"")
+	expected := "[my file.txt]\n[x' '/etc]\n"
+	if run_error != nil || output.String() != expected {
+		t.Errorf("[execute] Expected %q, got %q (%v)",
+			expected,
+			output.String(),
+			run_error)
+	}
+
+	// The commands, the rule that they fail with, and the exit code
+	cases := []struct {
+		command   string
+		rule      string
+		exit_code int64
+	}{
+		{"execute \"appetit-no-such-command\"",
+			"runtime/execute-not-found", EXIT_CODE_NOT_FOUND},
+		{"execute \"sh -c 'exit 3'\"", "runtime/execute-exit", 3},
+		{"execute \"sleep 5\" timeout 0.1",
+			"runtime/execute-timeout", EXIT_CODE_TIMEOUT},
+	}
+	for _, test_case := range cases {
+		interpreter := New(Options{Stdout: &bytes.Buffer{}, AllowExec: true})
+		run_error := interpreter.RunString(test_case.command)
+		var script_error *ScriptError
+		if !errors.As(run_error, &script_error) ||
+			script_error.Rule != test_case.rule {
+			t.Errorf("[execute] Expected %s for %q, got %v",
+				test_case.rule,
+				test_case.command,
+				run_error)
+		}
+		exit_code := interpreter.Variables["b_exit_code"]
+		if exit_code.Literal() != strconv.FormatInt(test_case.exit_code, 10) {
+			t.Errorf("[execute] Expected the exit code %d for %q, got %s",
+				test_case.exit_code,
+				test_case.command,
+				exit_code.Literal())
+		}
+	}
+
+	/*
+		A timeout that is written out in full is checked before the script
+		runs whereas one in a variable is checked when the statement runs
+	*/
+	for _, script := range []string{
+		"writeln \"started\"\nexecute \"ls\" timeout \"abc\"",
+		"writeln \"started\"\nexecute \"ls\" timeout 0",
+	} {
+		output.Reset()
+		interpreter := New(Options{Stdout: &output, AllowExec: true})
+		run_error := interpreter.RunString(script)
+		var script_error *ScriptError
+		if !errors.As(run_error, &script_error) ||
+			script_error.Hint == "" || output.Len() != 0 {
+			t.Errorf("[execute] Expected %q to be rejected before it runs, "+
+				"got %q (%v)",
+				script,
+				output.String(),
+				run_error)
+		}
+	}
+	output.Reset()
+	interpreter = New(Options{Stdout: &output, AllowExec: true})
+	run_error = interpreter.RunString(
+		"set seconds = \"abc\"\nexecute \"ls\" timeout \"#seconds\"")
+	if run_error == nil ||
+		!strings.Contains(run_error.Error(), "number of seconds") {
+		t.Errorf("[execute] Expected the timeout to be rejected, got %v",
+			run_error)
+	}
+}
+
+/*
+Keep the partial downloads of a test away from any that are already there by
+giving it a cache directory of its own.
+*/
+func useTestCache(t *testing.T) string {
+	cache := t.TempDir()
+	for _, name := range []string{"XDG_CACHE_HOME", "HOME", "LocalAppData"} {
+		t.Setenv(name, cache)
+	}
+	return cache
+}
+
+/*
+Check to make sure that a download that stops part of the way through is
+picked up where it left off and checked against its checksum file.
+*/
+func TestDownloadStatement(t *testing.T) {
+	useTestCache(t)
+	content := bytes.Repeat([]byte("appetit "), 4096)
+	checksum := sha256.Sum256(content)
+	var requests int
+	var resumed_range string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			if strings.HasSuffix(request.URL.Path, ".sha256") {
+				fmt.Fprintf(writer, "%x  file.txt\n", checksum)
+				return
+			}
+			requests++
+			writer.Header().Set("ETag", "\"v1\"")
+			// Stop part of the way through the first time
+			if requests == 1 {
+				writer.Header().Set(
+					"Content-Length", strconv.Itoa(len(content)))
+				writer.Write(content[:len(content)/2])
+				return
+			}
+			resumed_range = request.Header.Get("Range")
+			http.ServeContent(writer, request, "file.txt", time.Time{},
+				bytes.NewReader(content))
+		}))
+	defer server.Close()
+
+	// A # in the name is written as ## so that it isn't taken as a variable
+	save_directory := t.TempDir()
+	save_name := filepath.Join(save_directory, "file#1.txt")
+	interpreter := New(Options{Stdout: &bytes.Buffer{}, DownloadRetries: 1})
+	interpreter.download_retry_delay = time.Millisecond
+	run_error := interpreter.RunString("download \"" + server.URL +
+		"/file.txt\" to \"" + save_directory + "/file##1.txt\" verify \"" +
+		server.URL +
+		"/file.txt.sha256\"")
+	if run_error != nil {
+		t.Fatalf("[download] Expected no error, got %v", run_error)
+	}
+	saved, _ := os.ReadFile(save_name)
+	if !bytes.Equal(saved, content) || requests != 2 ||
+		resumed_range != "bytes="+strconv.Itoa(len(content)/2)+"-" {
+		t.Errorf("[download] Expected the download to be resumed, got %d "+
+			"bytes after %d requests (%q)",
+			len(saved),
+			requests,
+			resumed_range)
+	}
+	partial_path, _ := PartialDownloadPath(server.URL + "/file.txt")
+	if _, stat_error := os.Stat(partial_path); stat_error == nil {
+		t.Errorf("[download] Expected the partial download to be removed")
+	}
+
+	// A download that doesn't match its checksum isn't kept
+	other_name := filepath.Join(t.TempDir(), "other.txt")
+	run_error = New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"download \"" + server.URL + "/file.txt\" to \"" + other_name +
+			"\" verify \"sha256:" + strings.Repeat("0", 64) + "\"")
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) ||
+		script_error.Rule != "runtime/download-checksum" {
+		t.Errorf("[download] Expected a checksum error, got %v", run_error)
+	}
+	if _, stat_error := os.Stat(other_name); stat_error == nil {
+		t.Errorf("[download] Expected the download not to be kept")
+	}
+
+	// A checksum that is written out is checked along with the statement
+	check_error := New(Options{Check: true}).RunString(
+		"download \"" + server.URL + "/file.txt\" to \"" + other_name +
+			"\" verify \"sha256:abc\"")
+	if check_error == nil ||
+		!strings.Contains(check_error.Error(), "64 hexadecimal") {
+		t.Errorf("[download] Expected the checksum to be reported, got %v",
+			check_error)
+	}
+}
+
+/*
+Check to make sure that downloads are held where only the user can get at
+them and that a link left where a download is held isn't written through.
+*/
+func TestPartialDownloadPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The permissions and links checked are Unix ones")
+	}
+	useTestCache(t)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			writer.Write([]byte("downloaded"))
+		}))
+	defer server.Close()
+
+	partial_path, partial_error := PartialDownloadPath(server.URL + "/a.txt")
+	if partial_error != nil {
+		t.Fatalf("[PartialDownloadPath] Expected no error, got %v",
+			partial_error)
+	}
+	info, _ := os.Lstat(filepath.Dir(partial_path))
+	if info == nil || info.Mode().Perm() != 0700 {
+		t.Errorf("[PartialDownloadPath] Expected a private directory, got %v",
+			info)
+	}
+
+	// Another file is left where the download is held
+	target := filepath.Join(t.TempDir(), "target.txt")
+	os.WriteFile(target, []byte("untouched"), 0644)
+	os.Symlink(target, partial_path)
+	save_name := filepath.Join(t.TempDir(), "a.txt")
+	run_error := New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"download \"" + server.URL + "/a.txt\" to \"" + save_name + "\"")
+	saved, _ := os.ReadFile(save_name)
+	untouched, _ := os.ReadFile(target)
+	if run_error != nil || string(saved) != "downloaded" ||
+		string(untouched) != "untouched" {
+		t.Errorf("[download] Expected the link not to be followed, got %q "+
+			"and %q (%v)",
+			saved,
+			untouched,
+			run_error)
+	}
+}
+
+// Check to make sure that a checksum is found in a checksum file.
+func TestChecksumFromFile(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	contents := strings.Repeat("cd", 32) + "  other.iso\n" + hash +
+		" *file.iso\n"
+	checksum, checksum_error := ChecksumFromFile(contents, "file.iso")
+	if checksum_error != nil || checksum != "sha256:"+hash {
+		t.Errorf("[ChecksumFromFile] Expected sha256:%s, got %s (%v)",
+			hash,
+			checksum,
+			checksum_error)
+	}
+	// A file with the one checksum doesn't need to name the file
+	checksum, _ = ChecksumFromFile(strings.Repeat("ef", 16), "file.iso")
+	if checksum != "md5:"+strings.Repeat("ef", 16) {
+		t.Errorf("[ChecksumFromFile] Expected an md5 checksum, got %s",
+			checksum)
+	}
+	_, checksum_error = ChecksumFromFile(contents, "x.iso")
+	if checksum_error == nil {
+		t.Errorf("[ChecksumFromFile] Expected an error for a missing file")
+	}
+}
+
+/*
+Check to make sure that a file of a downloadall statement is saved under the
+last part of the path of its URL, and under the default name where that
+would put it outside of the directory.
+*/
+func TestDownloadFileName(t *testing.T) {
+	// The URLs and the names that they are saved under
+	cases := map[string]string{
+		"https://example.com/files/tool.tar.gz": "tool.tar.gz",
+		"https://example.com/files/a.txt?v=2":   "a.txt",
+		"https://example.com/":                  DEFAULT_DOWNLOAD_FILE_NAME,
+		"https://example.com":                   DEFAULT_DOWNLOAD_FILE_NAME,
+		"https://example.com/files/..":          DEFAULT_DOWNLOAD_FILE_NAME,
+		"https://example.com/files/%2E%2E":      DEFAULT_DOWNLOAD_FILE_NAME,
+		"https://example.com/files/.":           DEFAULT_DOWNLOAD_FILE_NAME,
+	}
+	for download_url, expected := range cases {
+		name, name_error := DownloadFileName(download_url)
+		if name_error != nil || name != expected {
+			t.Errorf("[DownloadFileName] Expected %s for %s, got %s (%v)",
+				expected,
+				download_url,
+				name,
+				name_error)
+		}
+	}
+}
+
+/*
+Check to make sure that the downloadall statement gets every file that it
+can, no more than a few at once, and lists those that it couldn't.
+*/
+func TestDownloadAllStatement(t *testing.T) {
+	useTestCache(t)
+	var running, most_running atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			now_running := running.Add(1)
+			defer running.Add(-1)
+			if now_running > most_running.Load() {
+				most_running.Store(now_running)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if request.URL.Path == "/missing.txt" {
+				http.NotFound(writer, request)
+				return
+			}
+			writer.Write([]byte("Contents of " + request.URL.Path))
+		}))
+	defer server.Close()
+
+	checksum := sha256.Sum256([]byte("Contents of /b.txt"))
+	list_file := filepath.Join(t.TempDir(), "list.txt")
+	os.WriteFile(list_file, []byte("# Artefacts\n"+
+		server.URL+"/a.txt\n"+
+		server.URL+"/b.txt sha256:"+fmt.Sprintf("%x", checksum)+"\n\n"+
+		server.URL+"/missing.txt\n"+
+		server.URL+"/c.txt\n"), 0644)
+	directory := filepath.Join(t.TempDir(), "artefacts")
+
+	var output bytes.Buffer
+	run_error := New(Options{Stdout: &output}).RunString(
+		"downloadall \"" + list_file + "\" to \"" + directory +
+			"\" parallel 2")
+	var script_error *ScriptError
+	if !errors.As(run_error, &script_error) ||
+		script_error.Rule != "runtime/downloadall" ||
+		!strings.Contains(script_error.Message, "missing.txt") {
+		t.Fatalf("[downloadall] Expected missing.txt to fail, got %v",
+			run_error)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		contents, _ := os.ReadFile(filepath.Join(directory, name))
+		if string(contents) != "Contents of /"+name {
+			t.Errorf("[downloadall] Expected %s to be downloaded, got %q",
+				name,
+				contents)
+		}
+	}
+	if most_running.Load() > 2 ||
+		!strings.Contains(output.String(), "Downloaded 3 of 4 files") {
+		t.Errorf("[downloadall] Expected 2 at once and a summary, got %d "+
+			"and %q",
+			most_running.Load(),
+			output.String())
+	}
+
+	// The files can also be in a list variable
+	other_directory := t.TempDir()
+	run_error = New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"set files = [\"" + server.URL + "/a.txt\", \"" + server.URL +
+			"/c.txt\"]\ndownloadall \"#files\" to \"" + other_directory + "\"")
+	entries, _ := os.ReadDir(other_directory)
+	if run_error != nil || len(entries) != 2 {
+		t.Errorf("[downloadall] Expected two files, got %d (%v)",
+			len(entries),
+			run_error)
+	}
+
+	/*
+		A number that is written out in full is checked before the script
+		runs whereas one in a variable is checked when the statement runs
+	*/
+	for _, statement := range []string{
+		"download \"" + server.URL + "/a.txt\" to \"" + other_directory +
+			"\" retries \"abc\"",
+		"downloadall \"" + list_file + "\" to \"" + other_directory +
+			"\" parallel 0",
+		"downloadall \"" + list_file + "\" to \"" + other_directory +
+			"\" retries 1.5",
+	} {
+		output.Reset()
+		run_error := New(Options{Stdout: &output}).RunString(
+			"writeln \"started\"\n" + statement)
+		if !errors.As(run_error, &script_error) ||
+			script_error.Hint == "" || output.Len() != 0 {
+			t.Errorf("[downloadall] Expected %q to be rejected before it "+
+				"runs, got %q (%v)",
+				statement,
+				output.String(),
+				run_error)
+		}
+	}
+	run_error = New(Options{Stdout: &bytes.Buffer{}}).RunString(
+		"set count = \"abc\"\ndownloadall \"" + list_file + "\" to \"" +
+			other_directory + "\" parallel \"#count\"")
+	if run_error == nil || !strings.Contains(run_error.Error(), "whole") {
+		t.Errorf("[downloadall] Expected the number to be rejected, got %v",
+			run_error)
+	}
+}
@@ -0,0 +1,309 @@
+/*
+This houses the values that variables hold. A value is a string, an integer, a
+float, a boolean, or a list of values. Wherever a value is written out (eg.
+in a writeln statement), it is written as a string (see Value.String()).
+*/
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/*
+The ValueType type houses the type of a value.
+*/
+type ValueType int
+
+// The types of values
+const (
+	// Text (eg. "Appetit")
+	VALUE_STRING ValueType = iota
+	// A whole number (eg. 42)
+	VALUE_INTEGER
+	// A number with a fractional part (eg. 0.5)
+	VALUE_FLOAT
+	// Either true or false
+	VALUE_BOOLEAN
+	// A list of other values (eg. ["one.txt", "two.txt"])
+	VALUE_LIST
+)
+
+// The symbols that wrap a list and seperate its items (eg. ["a", "b"])
+const (
+	SYMBOL_LIST_OPEN      = "["
+	SYMBOL_LIST_CLOSE     = "]"
+	SYMBOL_LIST_SEPERATOR = ","
+)
+
+/*
+Return the name of the type as it is written in a convert statement (eg.
+integer). No parameters. Returns the name of the type.
+*/
+func (value_type ValueType) String() string {
+	switch value_type {
+	case VALUE_STRING:
+		return "string"
+	case VALUE_INTEGER:
+		return "integer"
+	case VALUE_FLOAT:
+		return "float"
+	case VALUE_BOOLEAN:
+		return "boolean"
+	case VALUE_LIST:
+		return "list"
+	}
+	return "unknown"
+}
+
+/*
+Get a type from its name. Parameters include the name of the type. Returns
+the type and false if there is no type with that name.
+*/
+func ValueTypeNamed(name string) (ValueType, bool) {
+	for _, value_type := range []ValueType{
+		VALUE_STRING, VALUE_INTEGER, VALUE_FLOAT, VALUE_BOOLEAN, VALUE_LIST,
+	} {
+		if value_type.String() == name {
+			return value_type, true
+		}
+	}
+	return VALUE_STRING, false
+}
+
+/*
+Create a string list of the types that can be easily printed if need be. No
+parameters. Returns a string representation of the list of types.
+*/
+func ListValueTypes() string {
+	var type_list string
+	for _, value_type := range []ValueType{
+		VALUE_STRING, VALUE_INTEGER, VALUE_FLOAT, VALUE_BOOLEAN, VALUE_LIST,
+	} {
+		type_list += "\n\t- " + value_type.String()
+	}
+	return type_list
+}
+
+/*
+The Value type houses the value of a variable. Only the field for the type of
+the value is used. The structure of the value is as follows:
+  - Type [ValueType]: the type of the value
+  - Text [string]: the value of a string
+  - Integer [int64]: the value of an integer
+  - Float [float64]: the value of a float
+  - Boolean [bool]: the value of a boolean
+  - Items [[]Value]: the items of a list
+*/
+type Value struct {
+	Type    ValueType
+	Text    string
+	Integer int64
+	Float   float64
+	Boolean bool
+	Items   []Value
+}
+
+// Create a string value. Parameters include the text. Returns the value.
+func StringValue(text string) Value {
+	return Value{Type: VALUE_STRING, Text: text}
+}
+
+// Create an integer value. Parameters include the number. Returns the value.
+func IntegerValue(integer int64) Value {
+	return Value{Type: VALUE_INTEGER, Integer: integer}
+}
+
+// Create a float value. Parameters include the number. Returns the value.
+func FloatValue(float float64) Value {
+	return Value{Type: VALUE_FLOAT, Float: float}
+}
+
+// Create a boolean value. Parameters include the boolean. Returns the value.
+func BooleanValue(boolean bool) Value {
+	return Value{Type: VALUE_BOOLEAN, Boolean: boolean}
+}
+
+// Create a list value. Parameters include the items. Returns the value.
+func ListValue(items []Value) Value {
+	return Value{Type: VALUE_LIST, Items: items}
+}
+
+/*
+Get the value as it is written out, that is, as it is put in place of a
+variable in a string. Lists are written out as their items seperated by a
+comma and a space. No parameters. Returns the value as a string.
+*/
+func (value Value) String() string {
+	switch value.Type {
+	case VALUE_INTEGER:
+		return strconv.FormatInt(value.Integer, 10)
+	case VALUE_FLOAT:
+		return strconv.FormatFloat(value.Float, 'f', -1, 64)
+	case VALUE_BOOLEAN:
+		return strconv.FormatBool(value.Boolean)
+	case VALUE_LIST:
+		var items []string
+		for _, item := range value.Items {
+			items = append(items, item.String())
+		}
+		return strings.Join(items, SYMBOL_LIST_SEPERATOR+" ")
+	}
+	return value.Text
+}
+
+/*
+Get the value as it would be written in a set statement (eg. strings are
+quoted and lists are wrapped in brackets). This is used wherever a variable
+is shown with its value (eg. the debugger) so that its type can be seen. No
+parameters. Returns the value as it would be written.
+*/
+func (value Value) Literal() string {
+	switch value.Type {
+	case VALUE_STRING:
+		return strconv.Quote(value.Text)
+	case VALUE_LIST:
+		var items []string
+		for _, item := range value.Items {
+			items = append(items, item.Literal())
+		}
+		return SYMBOL_LIST_OPEN +
+			strings.Join(items, SYMBOL_LIST_SEPERATOR+" ") + SYMBOL_LIST_CLOSE
+	}
+	return value.String()
+}
+
+/*
+Get the value as a number. Integers and floats are numbers, as is a string
+that holds a number (eg. "42") as the user's input is a string. No
+parameters. Returns the number and false if the value isn't a number.
+*/
+func (value Value) Number() (float64, bool) {
+	switch value.Type {
+	case VALUE_INTEGER:
+		return float64(value.Integer), true
+	case VALUE_FLOAT:
+		return value.Float, true
+	case VALUE_STRING:
+		number, number_error := strconv.ParseFloat(
+			strings.TrimSpace(value.Text), 64)
+		return number, number_error == nil
+	}
+	return 0, false
+}
+
+/*
+Check whether two values are the same. Two numbers are compared as numbers
+(eg. 2 is 2.0) and two lists are compared item by item. Anything else is
+compared as it is written out, which is how values were compared before they
+had types (eg. 42 is "42"). Parameters include the other value. Returns true
+if the values are the same.
+*/
+func (value Value) Equals(other Value) bool {
+	is_number := func(value Value) bool {
+		return value.Type == VALUE_INTEGER || value.Type == VALUE_FLOAT
+	}
+	if is_number(value) && is_number(other) {
+		if value.Type == VALUE_INTEGER && other.Type == VALUE_INTEGER {
+			return value.Integer == other.Integer
+		}
+		value_number, _ := value.Number()
+		other_number, _ := other.Number()
+		return value_number == other_number
+	}
+	if value.Type == VALUE_LIST && other.Type == VALUE_LIST {
+		if len(value.Items) != len(other.Items) {
+			return false
+		}
+		for index := range value.Items {
+			if !value.Items[index].Equals(other.Items[index]) {
+				return false
+			}
+		}
+		return true
+	}
+	return value.String() == other.String()
+}
+
+/*
+Convert a value to another type. A string is converted by reading it (eg.
+"42" is 42 and "true" is true), a float is converted to an integer by
+dropping its fractional part, a number is true as a boolean if it isn't zero,
+and a string is converted to a list by splitting it where there are commas.
+Anything else that is converted to a list is the only item in the list.
+Parameters include the type to convert to. Returns the converted value and an
+error if the value can't be converted.
+*/
+func (value Value) Convert(value_type ValueType) (Value, error) {
+	// There's nothing to do if the value is already of the type
+	if value.Type == value_type {
+		return value, nil
+	}
+	switch value_type {
+	case VALUE_STRING:
+		return StringValue(value.String()), nil
+	case VALUE_INTEGER:
+		switch value.Type {
+		case VALUE_BOOLEAN:
+			if value.Boolean {
+				return IntegerValue(1), nil
+			}
+			return IntegerValue(0), nil
+		case VALUE_FLOAT:
+			if math.IsNaN(value.Float) || math.IsInf(value.Float, 0) {
+				break
+			}
+			return IntegerValue(int64(value.Float)), nil
+		case VALUE_STRING:
+			text := strings.TrimSpace(value.Text)
+			if integer, integer_error := strconv.ParseInt(
+				text, 10, 64); integer_error == nil {
+				return IntegerValue(integer), nil
+			}
+			if float, float_error := strconv.ParseFloat(
+				text, 64); float_error == nil {
+				return FloatValue(float).Convert(VALUE_INTEGER)
+			}
+		}
+	case VALUE_FLOAT:
+		switch value.Type {
+		case VALUE_BOOLEAN:
+			integer, _ := value.Convert(VALUE_INTEGER)
+			return FloatValue(float64(integer.Integer)), nil
+		case VALUE_INTEGER, VALUE_STRING:
+			if float, is_number := value.Number(); is_number {
+				return FloatValue(float), nil
+			}
+		}
+	case VALUE_BOOLEAN:
+		switch value.Type {
+		case VALUE_INTEGER, VALUE_FLOAT:
+			number, _ := value.Number()
+			return BooleanValue(number != 0), nil
+		case VALUE_STRING:
+			boolean, boolean_error := strconv.ParseBool(
+				strings.TrimSpace(value.Text))
+			if boolean_error == nil {
+				return BooleanValue(boolean), nil
+			}
+		}
+	case VALUE_LIST:
+		if value.Type != VALUE_STRING {
+			return ListValue([]Value{value}), nil
+		}
+		// An empty string is an empty list
+		items := []Value{}
+		if strings.TrimSpace(value.Text) == "" {
+			return ListValue(items), nil
+		}
+		for _, item := range strings.Split(
+			value.Text, SYMBOL_LIST_SEPERATOR) {
+			items = append(items, StringValue(strings.TrimSpace(item)))
+		}
+		return ListValue(items), nil
+	}
+	return value, fmt.Errorf("the %s %s can't be converted to the type %s",
+		value.Type, value.Literal(), value_type)
+}
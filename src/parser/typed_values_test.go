@@ -0,0 +1,77 @@
+package parser
+
+import "testing"
+
+/*
+Check to make sure that Convert() converts values between types and refuses
+to convert a value that isn't of the type.
+*/
+func TestConvert(t *testing.T) {
+	// The values, the types to convert them to, and what they should become
+	cases := []struct {
+		value    Value
+		to       ValueType
+		expected string
+	}{
+		{StringValue("42"), VALUE_INTEGER, "42"},
+		{StringValue("2.5"), VALUE_INTEGER, "2"},
+		{StringValue("2.5"), VALUE_FLOAT, "2.5"},
+		{StringValue("true"), VALUE_BOOLEAN, "true"},
+		{IntegerValue(0), VALUE_BOOLEAN, "false"},
+		{FloatValue(0.5), VALUE_STRING, "\"0.5\""},
+		{BooleanValue(true), VALUE_INTEGER, "1"},
+		{StringValue("a.txt, b.txt"), VALUE_LIST, "[\"a.txt\", \"b.txt\"]"},
+		{StringValue(""), VALUE_LIST, "[]"},
+		{IntegerValue(7), VALUE_LIST, "[7]"},
+	}
+	for _, test_case := range cases {
+		converted, convert_error := test_case.value.Convert(test_case.to)
+		if convert_error != nil || converted.Type != test_case.to ||
+			converted.Literal() != test_case.expected {
+			t.Errorf("[Convert] %s to %s returned %s (%v), expected %s",
+				test_case.value.Literal(),
+				test_case.to,
+				converted.Literal(),
+				convert_error,
+				test_case.expected)
+		}
+	}
+
+	// Values that can't be converted
+	for _, value := range []Value{StringValue("abc"), ListValue(nil)} {
+		_, convert_error := value.Convert(VALUE_INTEGER)
+		if convert_error == nil {
+			t.Errorf("[Convert] Expected %s not to convert to an integer",
+				value.Literal())
+		}
+	}
+}
+
+/*
+Check to make sure that Equals() compares numbers as numbers, lists item by
+item, and anything else as it is written out.
+*/
+func TestEquals(t *testing.T) {
+	list := ListValue([]Value{StringValue("a"), IntegerValue(1)})
+	cases := []struct {
+		left     Value
+		right    Value
+		expected bool
+	}{
+		{IntegerValue(2), FloatValue(2), true},
+		{IntegerValue(42), StringValue("42"), true},
+		{BooleanValue(true), StringValue("true"), true},
+		{StringValue("a"), StringValue("b"), false},
+		{list, ListValue([]Value{StringValue("a"), FloatValue(1)}), true},
+		{list, ListValue([]Value{StringValue("a")}), false},
+	}
+	for _, test_case := range cases {
+		if test_case.left.Equals(test_case.right) != test_case.expected {
+			t.Errorf("[Equals] %s is %s returned %t, expected %t",
+				test_case.left.Literal(),
+				test_case.right.Literal(),
+				!test_case.expected,
+				test_case.expected)
+		}
+	}
+}
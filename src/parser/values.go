@@ -3,9 +3,7 @@ This holds a collection of values that are referenced throughout the
 parser. This includes:
 - Language name and version values (as consts)
 - Symbols and non-statement keywords used in the language
-- Mode settings (eg. are we running in dev mode?)
-- Token and variable information
-- Some other values that are necessary to track across the parser
+- The reserved variables that each interpreter starts with
 */
 package parser
 
@@ -13,7 +11,6 @@ import (
 	"fmt"
 	"os"
 	"runtime"
-	"strconv"
 )
 
 /*
@@ -44,8 +41,20 @@ order or pattern. Currently, this isn't in use.
 */
 const LANG_CODENAME = "Canberra"
 
-// Hold the name of the script
-var SCRIPT_NAME string
+/*
+The number of times that a script can loop (ie. jump back with a goto
+statement or repeat a statement) before it is stopped. This stops a script
+with a mistake in it from running forever. This can be changed with the
+-maxiterations flag.
+*/
+const DEFAULT_MAX_ITERATIONS int = 10000
+
+/*
+The number of times that a download that stopped part of the way through is
+tried again before it is given up on. This can be changed with the -retries
+flag or for a download with its retries clause.
+*/
+const DEFAULT_DOWNLOAD_RETRIES int = 3
 
 /*
 	This section houses symbols and conjoining words in statements and for the
@@ -56,6 +65,27 @@ var SCRIPT_NAME string
 // The action symbol.
 const SYMBOL_ACTION string = "to"
 
+// The keyword that joins the arguments and outputs of a call statement
+const SYMBOL_CALL_AND string = "and"
+
+// The keyword that starts the outputs of a call statement
+const SYMBOL_CALL_RETURNING string = "returning"
+
+// The keyword that starts the arguments of a call statement
+const SYMBOL_CALL_WITH string = "with"
+
+// The keyword that starts the condition of a goto statement
+const SYMBOL_CONDITION_IF string = "if"
+
+// The keyword that seperates an if statement's condition from its statement
+const SYMBOL_CONDITION_THEN string = "then"
+
+// The keyword that seperates a repeat statement's count from its statement
+const SYMBOL_REPEAT_TIMES string = "times"
+
+// The keyword that seperates a define statement's name from its file
+const SYMBOL_DEFINE_FROM string = "from"
+
 // Comment symbol.
 const SYMBOL_COMMENT string = "-"
 
@@ -69,103 +99,96 @@ const SYMBOL_RESERVED_VARIABLE_PREFIX = "b_"
 const SYMBOL_VARIABLE_SUBSTITUTION = "#"
 
 /*
-	This section houses the state of any modes that we might be running, set
-	via flags passed to the parameter.
+Create the variables that each interpreter starts with. This is prepopulated
+with the reserved variables. You will notice that some of these reserved
+variables are empty. They are constructed in the BuildReservedVariables()
+method. The reason that this is using string formatting throughout is to allow
+an easy change to the RESERVED_VARIABLE_PREFIX if need be. No parameters.
+Returns a new map of variables.
 */
-// Whether we will allow the execute statements
-var MODE_ALLOW_EXEC bool = false
-
-// Whether we are in developer mode
-var MODE_DEV bool = false
-
-// Whether we are verbose with our output
-var MODE_VERBOSE bool = false
-
-/*
-Do we have a shebang line? If so, set this to true. This is necessary for
-the minver statement
-*/
-var SHEBANG_PRESENT bool = false
-
-// Valid statement names
-var STATEMENT_NAMES []string
-
-/*
-The TOKEN_TREE holds the tokens in a "tree" which is a glorified list of
-tokens.
-*/
-var TOKEN_TREE []Token
-
-/*
-	This houses the variables. This is prepopulated with the reserved
-	variables. You will notice that some of these reserved variables are empty.
-	They are constructed in the BuildReservedVariables() function. The reason
-	that this is using string formatting throughout is to allow an easy change
-	to the RESERVED_VARIABLE_PREFIX if need be.
-*/
-
-var VARIABLES = map[string]string{
-	fmt.Sprintf(
-		"%sarch",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): runtime.GOARCH,
-	fmt.Sprintf(
-		"%scpu",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): strconv.Itoa(runtime.NumCPU()),
-	fmt.Sprintf(
-		"%sdate_dmy",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%sdate_day",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%sdate_month",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%sdate_year",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%sdate_ymd",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%shome",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%shostname",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%sipv4",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%slogstamp",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%sos",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): runtime.GOOS,
-	fmt.Sprintf(
-		"%sscriptname_full",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%stempdir",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): os.TempDir(),
-	fmt.Sprintf(
-		"%stime_full",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%stime_hour",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%stime_minute",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%stime_seconds",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%szone",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%suser",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
-	fmt.Sprintf(
-		"%swd",
-		SYMBOL_RESERVED_VARIABLE_PREFIX): "",
+func ReservedVariables() map[string]Value {
+	return map[string]Value{
+		fmt.Sprintf(
+			"%sarg_count",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): IntegerValue(0),
+		fmt.Sprintf(
+			"%sarch",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(runtime.GOARCH),
+		fmt.Sprintf(
+			"%scpu",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): IntegerValue(
+			int64(runtime.NumCPU())),
+		fmt.Sprintf(
+			"%sdate_dmy",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%sdate_day",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%sdate_month",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%sdate_year",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%sdate_ymd",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%sexit_code",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): IntegerValue(0),
+		fmt.Sprintf(
+			"%shome",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%shostname",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%shttp_content_type",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%shttp_etag",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%shttp_location",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%shttp_status",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): IntegerValue(0),
+		fmt.Sprintf(
+			"%sipv4",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%slogstamp",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%sos",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(runtime.GOOS),
+		fmt.Sprintf(
+			"%sscriptname_full",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%stempdir",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(os.TempDir()),
+		fmt.Sprintf(
+			"%stime_full",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%stime_hour",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%stime_minute",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%stime_seconds",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%szone",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%suser",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+		fmt.Sprintf(
+			"%swd",
+			SYMBOL_RESERVED_VARIABLE_PREFIX): StringValue(""),
+	}
 }
@@ -12,7 +12,6 @@ import (
 	"os/user"
 	"path/filepath"
 	"slices"
-	"strings"
 	"syscall"
 	"time"
 )
@@ -31,11 +30,12 @@ a value. This pair of booleans can only return three combinations:
 There is no "false, true" pair here - you can't have a variable that doesn't
 exist have a value.
 */
-func CheckVariableExistence(var_name string) (bool, bool) {
-	// This conditional will be met if the VARIABLE exists
-	if value, ok := VARIABLES[var_name]; ok {
+func (interpreter *Interpreter) CheckVariableExistence(
+	var_name string) (bool, bool) {
+	// This conditional will be met if the variable exists
+	if value, ok := interpreter.Variables[var_name]; ok {
 		// If the value is nothing, we have a variable but no value
-		if value == "" {
+		if value.String() == "" {
 			return true, false
 			// If the variable is present and has a value, return true for both
 		} else {
@@ -47,17 +47,95 @@ func CheckVariableExistence(var_name string) (bool, bool) {
 	}
 }
 
+/*
+Evaluate a value in a statement (eg. the value in a set statement). A value
+that is nothing but a variable (eg. "#files") is that variable's value, type
+and all. A value that is an expression is calculated (see CalculateValue())
+and any other value has its variables replaced. Parameters include the tokens
+of the statement and the token of the value. Returns the value and an error
+if a variable hasn't been set or the expression can't be calculated.
+*/
+func (interpreter *Interpreter) EvaluateValue(
+	tokens []Token, token Token) (Value, error) {
+	// Fix the value
+	value := FixStringCombined(token.TokenValue)
+	// If the value is a variable, keep its type
+	if variable_value, is_variable := interpreter.VariableValue(
+		value); is_variable {
+		return variable_value, nil
+	}
+	/* Get a templated value, that is, a variable where values have been
+	substituted
+	*/
+	templated_value, template_error := interpreter.Template(
+		value, tokens, token)
+	if template_error != nil {
+		return Value{}, template_error
+	}
+	/* If the value isn't an expression (eg. it's text or uses a variable that
+	hasn't been set), it's the templated value, unless it was meant to be one
+	but is malformed (eg. (1 + 2 or 2 ** 3)
+	*/
+	variables := interpreter.VariablesFor(value)
+	expression, parse_error := ParseExpression(value, variables)
+	if parse_error != nil {
+		if IsMalformedExpression(value, variables) {
+			return Value{}, ReportExpressionError(
+				tokens, token, value, parse_error)
+		}
+		return StringValue(templated_value), nil
+	}
+	// Otherwise, calculate it
+	calculated_value, calculate_error := expression.Evaluate(variables)
+	if calculate_error != nil {
+		return Value{}, ReportExpressionError(
+			tokens, token, value, calculate_error)
+	}
+	return calculated_value, nil
+}
+
+/*
+Evaluate a list in a statement (eg. ["a.txt", "#name.txt"]). Each item is
+evaluated like any other value and a list in the list is added item by item
+so that lists can be joined (eg. ["#some", "#more"]). Parameters include the
+tokens of the statement and the index of the opening bracket. Returns the
+list and an error if a variable hasn't been set.
+*/
+func (interpreter *Interpreter) EvaluateList(
+	tokens []Token, open_index int) (Value, error) {
+	items := []Value{}
+	item_indexes, _ := ListItemIndexes(tokens, open_index)
+	for _, item_index := range item_indexes {
+		item, item_error := interpreter.EvaluateValue(
+			tokens, tokens[item_index])
+		if item_error != nil {
+			return Value{}, item_error
+		}
+		if item.Type == VALUE_LIST {
+			items = append(items, item.Items...)
+		} else {
+			items = append(items, item)
+		}
+	}
+	return ListValue(items), nil
+}
+
 /*
 Create any values for built in reserved variables that require building.
-This addresses the empty ones in the VARIABLES map and updates those that
-require specific values for each statement call. This does not update each
-variable's value each call, however, as checks are made to see if those values
-that won't change during runtime already have values. No parameters and no
-returns.
+This addresses the empty ones in the interpreter's Variables and updates those
+that require specific values for each statement call. This does not update
+each variable's value each call, however, as checks are made to see if those
+values that won't change during runtime already have values. No parameters and
+no returns.
 */
-func BuildReservedVariables() {
+func (interpreter *Interpreter) BuildReservedVariables() {
+	// Hold the interpreter's variables for easy reference
+	variables := interpreter.Variables
+	// Hold the name of the script for easy reference
+	script_name := interpreter.ScriptName
+
 	// Check to see if the user reserved variable has a value
-	_, cur_user_value := CheckVariableExistence(
+	_, cur_user_value := interpreter.CheckVariableExistence(
 		SYMBOL_RESERVED_VARIABLE_PREFIX + "user")
 	/*
 		If it doesn't, add one. If it doesn't, this condition won't be met so
@@ -68,9 +146,11 @@ func BuildReservedVariables() {
 		cur_user, cur_user_error := user.Current()
 		// Assuming that there was no issue getting the current user, assign it
 		if cur_user_error != nil {
-			VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"user"] = ""
+			variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"user"] = StringValue(
+				"")
 		} else {
-			VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"user"] = cur_user.Username
+			variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"user"] = StringValue(
+				cur_user.Username)
 		}
 	}
 
@@ -93,90 +173,112 @@ func BuildReservedVariables() {
 		Get the date in dd-mm-yyyy format. This should be re-generated every
 		run of Call().
 	*/
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_dmy"] = fmt.Sprintf(
-		"%s-%s-%s", date_day, date_month, date_year,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_dmy"] = StringValue(
+		fmt.Sprintf(
+			"%s-%s-%s", date_day, date_month, date_year,
+		),
 	)
 
 	/*
 		Get the date in yyyy-mm-dd format. This should be re-generated every
 		run of Call().
 	*/
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_ymd"] = fmt.Sprintf(
-		"%s-%s-%s", date_year, date_month, date_day,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_ymd"] = StringValue(
+		fmt.Sprintf(
+			"%s-%s-%s", date_year, date_month, date_day,
+		),
 	)
 
 	// Set the date_day
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_day"] = fmt.Sprintf(
-		"%s", date_day,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_day"] = StringValue(
+		fmt.Sprintf(
+			"%s", date_day,
+		),
 	)
 
 	// Set the date_month
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_month"] = fmt.Sprintf(
-		"%s", date_month,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_month"] = StringValue(
+		fmt.Sprintf(
+			"%s", date_month,
+		),
 	)
 
 	// Set the date_year
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_year"] = fmt.Sprintf(
-		"%s", date_year,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"date_year"] = StringValue(
+		fmt.Sprintf(
+			"%s", date_year,
+		),
 	)
 
 	/*
 		Get the time in hh-mm-ss in 24 hour format. This should be re-generated
 		every run of Call().
 	*/
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_full"] = fmt.Sprintf(
-		"%s-%s-%s", time_hour, time_minute, time_seconds,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_full"] = StringValue(
+		fmt.Sprintf(
+			"%s-%s-%s", time_hour, time_minute, time_seconds,
+		),
 	)
 
 	// Set the date_day
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_hour"] = fmt.Sprintf(
-		"%s", time_hour,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_hour"] = StringValue(
+		fmt.Sprintf(
+			"%s", time_hour,
+		),
 	)
 
 	// Set the date_month
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_minute"] = fmt.Sprintf(
-		"%s", time_minute,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_minute"] = StringValue(
+		fmt.Sprintf(
+			"%s", time_minute,
+		),
 	)
 
 	// Set the date_year
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_seconds"] = fmt.Sprintf(
-		"%s", time_seconds,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"time_seconds"] = StringValue(
+		fmt.Sprintf(
+			"%s", time_seconds,
+		),
 	)
 
 	/*
 		Create the logstamp by combining the date_ymd and time reserved
 		variables.
 	*/
-	VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"logstamp"] = fmt.Sprintf(
-		"%s/%s/%s, %s:%s:%s",
-		date_year,
-		date_month,
-		date_day,
-		time_hour,
-		time_minute,
-		time_seconds,
+	variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"logstamp"] = StringValue(
+		fmt.Sprintf(
+			"%s/%s/%s, %s:%s:%s",
+			date_year,
+			date_month,
+			date_day,
+			time_hour,
+			time_minute,
+			time_seconds,
+		),
 	)
 
 	/*
 		Check to see if we've got the b_scriptname_full which also serves to
 		check if we have the b_scriptname_only variable set.
 	*/
-	_, cur_script_names := CheckVariableExistence(
+	_, cur_script_names := interpreter.CheckVariableExistence(
 		SYMBOL_RESERVED_VARIABLE_PREFIX + "scriptname_full")
 
 	if !cur_script_names {
 		/*
 			This needs to be set here as it can't be set in the creation of the
-			VARIABLE map because that map is created before.
+			Variables map because that map is created before.
 		*/
-		VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"scriptname_full"] = SCRIPT_NAME
+		variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"scriptname_full"] = StringValue(
+			script_name)
 		// Get just the file name
-		_, name_only := filepath.Split(SCRIPT_NAME)
-		VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"scriptname_only"] = name_only
+		_, name_only := filepath.Split(script_name)
+		variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"scriptname_only"] = StringValue(
+			name_only)
 	}
 
-	_, cur_time_zone := CheckVariableExistence(
-		VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"zone"],
+	_, cur_time_zone := interpreter.CheckVariableExistence(
+		variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"zone"].String(),
 	)
 
 	if !cur_time_zone {
@@ -190,21 +292,23 @@ func BuildReservedVariables() {
 		time_zone, _ := date_time.Zone()
 
 		// Get the timezone
-		VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"zone"] = time_zone
+		variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"zone"] = StringValue(
+			time_zone)
 	}
 
 	/*
 		Check to see if the hostname is set. This doesn't need to be
 		re-generated each call of the function.
 	*/
-	_, host_value := CheckVariableExistence(
+	_, host_value := interpreter.CheckVariableExistence(
 		SYMBOL_RESERVED_VARIABLE_PREFIX + "hostname")
 	// Get the hostname
 	if !host_value {
 		host, err := os.Hostname()
 		// If there's no error, set the b_host to the hostname.
 		if err == nil {
-			VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"hostname"] = host
+			variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"hostname"] = StringValue(
+				host)
 		}
 	}
 
@@ -212,7 +316,7 @@ func BuildReservedVariables() {
 		Check if the user's home directory is already set. This won't need to
 		be re-set each time as this won't change.
 	*/
-	_, home_dir_value := CheckVariableExistence(
+	_, home_dir_value := interpreter.CheckVariableExistence(
 		SYMBOL_RESERVED_VARIABLE_PREFIX + "home")
 
 	if !home_dir_value {
@@ -220,7 +324,8 @@ func BuildReservedVariables() {
 		home, err := os.UserHomeDir()
 		// If there's no error, set the b_home to the home directory.
 		if err == nil {
-			VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"home"] = home
+			variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"home"] = StringValue(
+				home)
 		}
 	}
 
@@ -230,7 +335,7 @@ func BuildReservedVariables() {
 		the working directory, this isn't true yet so we don't need to update
 		this each time.
 	*/
-	_, work_dir_value := CheckVariableExistence(
+	_, work_dir_value := interpreter.CheckVariableExistence(
 		SYMBOL_RESERVED_VARIABLE_PREFIX + "wd")
 
 	if !work_dir_value {
@@ -238,7 +343,8 @@ func BuildReservedVariables() {
 		wd, err := syscall.Getwd()
 		// If there's no error, set the b_wd to the working directory.
 		if err == nil {
-			VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"wd"] = wd
+			variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"wd"] = StringValue(
+				wd)
 		}
 	}
 
@@ -252,7 +358,8 @@ func BuildReservedVariables() {
 	ipaddrs, ipaddrs_err := net.InterfaceAddrs()
 	// If we can't, abandon ship and save n/a to the ipv4 reserved variable
 	if ipaddrs_err != nil {
-		VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"ipv4"] = "n/a"
+		variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"ipv4"] = StringValue(
+			"n/a")
 	}
 
 	// Iterate over the addresses
@@ -265,7 +372,8 @@ func BuildReservedVariables() {
 				// Get the IP address as a string
 				ipv4_addr := ip.IP.String()
 				// Set the IPv4 address reserved variable
-				VARIABLES[SYMBOL_RESERVED_VARIABLE_PREFIX+"ipv4"] = ipv4_addr
+				variables[SYMBOL_RESERVED_VARIABLE_PREFIX+"ipv4"] = StringValue(
+					ipv4_addr)
 			}
 		}
 	}
@@ -279,8 +387,8 @@ reserved variables.
 func ListReservedVariables() string {
 	// Hold the list of reserved variables
 	var reserved_var []string
-	// For each variable in the VARIABLES map
-	for vars := range VARIABLES {
+	// For each variable in a fresh set of variables
+	for vars := range ReservedVariables() {
 		/* If the prefix -- signified by the string from 0 to the length of
 		the RESERVED_VARIABLE_PREFIX -- is the RESERVED_VARIABLE_PREFIX
 		*/
@@ -307,28 +415,13 @@ func ListReservedVariables() string {
 /*
 Replace variables inside of a string. In short, this takes a string such as
 "Your name is #name" and converts it to "Your name is Appetit" (where #name
-is "Appetit"). Parameters include the input line of code to fix. Returns a
-templated string where variables have been fixed.
+is "Appetit"). See interpolation.go for how variables are written. Any
+variable that hasn't been set is left as it was written, so this is for
+where there is no statement to report it against (eg. the debugger).
+Statements use Template() instead. Parameters include the input line of code
+to fix. Returns a templated string where variables have been fixed.
 */
-func VariableTemplater(input string) string {
-	// For each key-value pair in the map of variables
-	for key, value := range VARIABLES {
-		// Get the string value of the variable
-		value = string(value)
-		/*
-			Replace the value in the string if the value is found in the
-			string prepended by the variable replacement symbol. The
-			SYMBOL_VARIABLE_SUBSTITUTION+key checks that the variable symbol
-			precedes the key to ensure that words that happen to have similar
-			names don't also get replaced. In other words, '#name' is
-			completely different than 'name'.
-		*/
-		input = strings.ReplaceAll(
-			input,
-			SYMBOL_VARIABLE_SUBSTITUTION+key,
-			value,
-		)
-	}
-	// Return the substituted string
-	return input
+func (interpreter *Interpreter) VariableTemplater(input string) string {
+	output, _ := interpreter.Interpolate(input)
+	return output
 }
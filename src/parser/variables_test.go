@@ -18,19 +18,24 @@ CheckVariableExistence() function should return three values:
 We check to make sure that each of those is occurring here.
 */
 func TestCheckVariableExistence(t *testing.T) {
+	// Create an interpreter to hold the variables
+	interpreter := New(Options{})
 	/*
 		Create some fictional variables to test against here. We have a
 		variable with no value and one with a value.
 	*/
-	VARIABLES["novalue"] = ""
-	VARIABLES["yesvalue"] = "yes"
+	interpreter.Variables["novalue"] = StringValue("")
+	interpreter.Variables["yesvalue"] = StringValue("yes")
 
 	// Check condition 1 (ie. true, false)
-	true_false_exist, true_false_value := CheckVariableExistence("novalue")
+	true_false_exist, true_false_value :=
+		interpreter.CheckVariableExistence("novalue")
 	// Check condition 2 (ie. true, true)
-	true_true_exist, true_true_value := CheckVariableExistence("yesvalue")
+	true_true_exist, true_true_value :=
+		interpreter.CheckVariableExistence("yesvalue")
 	// Check condition 3 (ie. false, false)
-	false_false_exist, false_false_value := CheckVariableExistence("value")
+	false_false_exist, false_false_value :=
+		interpreter.CheckVariableExistence("value")
 
 	// Condition 1 - throw an error if the exist is false or the value is true
 	if !true_false_exist || true_false_value {
@@ -66,21 +71,23 @@ Check to make sure that the removal of comments does what it should - replace
 the comments with empty comment strings.
 */
 func TestVariableTemplater(t *testing.T) {
+	// Create an interpreter to hold the variables
+	interpreter := New(Options{})
 	// Set up some dummy variables for the variable templater
-	VARIABLES["lang"] = "TestLang"
-	VARIABLES["version"] = "4"
-	VARIABLES["codename"] = "CityName"
+	interpreter.Variables["lang"] = StringValue("TestLang")
+	interpreter.Variables["version"] = StringValue("4")
+	interpreter.Variables["codename"] = StringValue("CityName")
 
 	// A simple example
 	lang_and_ver := "App: #lang, Version: #version"
 	// A string formatted example of the string from above
 	lang_and_ver_correct := fmt.Sprintf(
 		"App: %s, Version: %s",
-		VARIABLES["lang"],
-		VARIABLES["version"],
+		interpreter.Variables["lang"].String(),
+		interpreter.Variables["version"].String(),
 	)
 	// A templated version
-	lang_and_ver_templated := VariableTemplater(lang_and_ver)
+	lang_and_ver_templated := interpreter.VariableTemplater(lang_and_ver)
 
 	// A second simple example
 	lang_ver_codename := "App=#lang and Version=#version " +
@@ -88,12 +95,13 @@ func TestVariableTemplater(t *testing.T) {
 	// A string formatted example of the string from above
 	lang_ver_codename_correct := fmt.Sprintf(
 		"App=%s and Version=%s (Code Name: %s)",
-		VARIABLES["lang"],
-		VARIABLES["version"],
-		VARIABLES["codename"],
+		interpreter.Variables["lang"].String(),
+		interpreter.Variables["version"].String(),
+		interpreter.Variables["codename"].String(),
 	)
 	// A templated version of the second example
-	lang_ver_codename_templated := VariableTemplater(lang_ver_codename)
+	lang_ver_codename_templated := interpreter.VariableTemplater(
+		lang_ver_codename)
 
 	if lang_and_ver_templated != lang_and_ver_correct {
 		t.Errorf(
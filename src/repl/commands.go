@@ -0,0 +1,182 @@
+/*
+The REPL commands are those that start with a colon and are for the REPL
+itself rather than the language (eg. listing the variables or saving the
+session as a script). This also houses the tab completion as it completes
+the commands along with statements and variables.
+*/
+package repl
+
+import (
+	"appetit/parser"
+	"appetit/utils"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/*
+Get the REPL commands along with what each does. No parameters. Returns a map
+of the names of the commands, without the colon, to their descriptions.
+*/
+func Commands() map[string]string {
+	return map[string]string{
+		"help":     "Show this help.",
+		"quit":     "Leave the REPL (as does Ctrl-D).",
+		"reserved": "List the reserved variables.",
+		"save":     "Save the session as a script (eg. :save script.apt).",
+		"vars":     "List the variables that have been set and their values.",
+	}
+}
+
+/*
+Run a REPL command. Parameters include the line with the command. Returns
+true if the REPL should be left.
+*/
+func (repl *REPL) Command(line string) bool {
+	output := repl.Interpreter.Stdout
+	// Split the command from its argument
+	command, argument, _ := strings.Cut(
+		strings.TrimPrefix(line, SYMBOL_COMMAND), " ")
+	argument = strings.TrimSpace(argument)
+
+	switch command {
+	case "help":
+		fmt.Fprintln(output, "Type a statement to run it. The commands are:")
+		commands := Commands()
+		for _, name := range slices.Sorted(maps.Keys(commands)) {
+			fmt.Fprintf(output, "\t%s\t%s\n",
+				utils.ColouriseMagenta(SYMBOL_COMMAND+name),
+				commands[name])
+		}
+	case "quit", "exit":
+		return true
+	case "reserved":
+		fmt.Fprintln(output, "The reserved variables are:"+
+			parser.ListReservedVariables())
+	case "save":
+		save_error := repl.Save(argument)
+		if save_error != nil {
+			parser.PrintError(repl.Interpreter.Stderr, save_error)
+			break
+		}
+		fmt.Fprintln(output, utils.ColouriseMagenta(
+			"Saved the session to "+argument+"."))
+	case "vars":
+		repl.PrintVariables()
+	default:
+		parser.PrintError(repl.Interpreter.Stderr, parser.ReportSimple(
+			"The command - "+utils.ColouriseYellow(line)+" - isn't a REPL "+
+				"command. Type "+utils.ColouriseMagenta(SYMBOL_COMMAND+"help")+
+				" to see the commands.",
+		).WithCategory(parser.ERROR_USAGE))
+	}
+	return false
+}
+
+/*
+Print the variables that have been set, leaving out the reserved variables.
+No parameters. Returns nothing.
+*/
+func (repl *REPL) PrintVariables() {
+	output := repl.Interpreter.Stdout
+	// Get the names of the variables that aren't reserved
+	var variable_names []string
+	for variable_name := range repl.Interpreter.Variables {
+		if !strings.HasPrefix(
+			variable_name, parser.SYMBOL_RESERVED_VARIABLE_PREFIX) {
+			variable_names = append(variable_names, variable_name)
+		}
+	}
+	if len(variable_names) == 0 {
+		fmt.Fprintln(output, "No variables have been set.")
+		return
+	}
+	slices.Sort(variable_names)
+	for _, variable_name := range variable_names {
+		fmt.Fprintf(output, "%s = %s\n",
+			utils.ColouriseMagenta(variable_name),
+			utils.ColouriseGreen(
+				repl.Interpreter.Variables[variable_name].Literal()))
+	}
+}
+
+/*
+Save the session as a script. The lines that ran without an error are saved,
+after a minver statement for the running version, in their canonical format.
+Parameters include the name of the file. Returns an error if there is no
+file name or the script couldn't be written.
+*/
+func (repl *REPL) Save(file_name string) error {
+	if file_name == "" {
+		return parser.ReportSimple(
+			"The " + utils.ColouriseMagenta(SYMBOL_COMMAND+"save") +
+				" command needs a file name (eg. " +
+				utils.ColouriseMagenta(SYMBOL_COMMAND+"save") +
+				utils.ColouriseGreen(" script.apt") + ").",
+		).WithCategory(parser.ERROR_USAGE)
+	}
+	// Leave out any minver statement that was typed as the script gets one
+	lines := []string{"minver " + strconv.Itoa(parser.LANG_VERSION)}
+	for _, line := range repl.Session {
+		if strings.Fields(line)[0] != "minver" {
+			lines = append(lines, line)
+		}
+	}
+	script := parser.Format(strings.Join(lines, "\n"))
+	if write_error := os.WriteFile(
+		file_name, []byte(script), 0644); write_error != nil {
+		return parser.ReportSimple(
+			"The session couldn't be saved to " +
+				utils.ColouriseYellow(file_name) + ".",
+		).WithErr(write_error)
+	}
+	return nil
+}
+
+/*
+Get the completions for the word before the cursor. After the variable
+symbol, the variables (inclusive of the reserved variables) are offered. At
+the start of a line (or after the then or times keywords), the statements are
+offered, or the REPL commands if the line starts with the command symbol. A
+statement or command is followed by a space but a variable, which is usually
+inside of a string, isn't. Parameters include the line and the cursor.
+Returns where the word starts and the completions.
+*/
+func (repl *REPL) Complete(line []rune, cursor int) (int, []string) {
+	// Find the start of the word before the cursor
+	start := cursor
+	for start > 0 && parser.IsNameCharacter(line[start-1]) {
+		start -= 1
+	}
+	typed := string(line[start:cursor])
+	before := string(line[:start])
+	previous_words := strings.Fields(before)
+
+	// Get the names that could be completed to
+	var names []string
+	suffix := " "
+	switch {
+	case parser.IsVariableStart(before):
+		names = slices.Sorted(maps.Keys(repl.Interpreter.Variables))
+		suffix = ""
+	case strings.TrimSpace(before) == SYMBOL_COMMAND:
+		names = slices.Sorted(maps.Keys(Commands()))
+	case len(previous_words) == 0 ||
+		previous_words[len(previous_words)-1] ==
+			parser.SYMBOL_CONDITION_THEN ||
+		previous_words[len(previous_words)-1] == parser.SYMBOL_REPEAT_TIMES:
+		names = repl.Interpreter.StatementNames
+	}
+
+	// Keep those that start with what has been typed
+	var completions []string
+	for _, name := range names {
+		if strings.HasPrefix(name, typed) {
+			completions = append(completions, name+suffix)
+		}
+	}
+	return start, completions
+}
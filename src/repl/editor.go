@@ -0,0 +1,379 @@
+/*
+The line editor reads a line from the terminal a key at a time so that the
+REPL can offer history (the up and down keys) and tab completion. The keys
+that are supported are as follows:
+  - Left, Right, Home, End, Ctrl-A, Ctrl-B, Ctrl-E, and Ctrl-F to move
+  - Backspace, Delete, Ctrl-K, Ctrl-U, and Ctrl-W to delete
+  - Up, Down, Ctrl-P, and Ctrl-N to go through the history
+  - Tab to complete the word being typed
+  - Ctrl-C to abandon the line and Ctrl-D on an empty line to leave
+*/
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// The keys that the line editor acts on
+const (
+	KEY_CTRL_A    byte = 1
+	KEY_CTRL_B    byte = 2
+	KEY_CTRL_C    byte = 3
+	KEY_CTRL_D    byte = 4
+	KEY_CTRL_E    byte = 5
+	KEY_CTRL_F    byte = 6
+	KEY_BACKSPACE byte = 8
+	KEY_TAB       byte = 9
+	KEY_NEW_LINE  byte = 10
+	KEY_CTRL_K    byte = 11
+	KEY_CTRL_L    byte = 12
+	KEY_ENTER     byte = 13
+	KEY_CTRL_N    byte = 14
+	KEY_CTRL_P    byte = 16
+	KEY_CTRL_U    byte = 21
+	KEY_CTRL_W    byte = 23
+	KEY_ESCAPE    byte = 27
+	KEY_DELETE    byte = 127
+)
+
+// Returned by ReadLine() when the line was abandoned with Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// Returned by ReadLine() when the terminal couldn't be put into raw mode.
+var ErrNoRawMode = errors.New("the terminal couldn't be put into raw mode")
+
+/*
+The LineEditor type houses what is needed to read a line. The structure of the
+editor is as follows:
+  - Reader [io.Reader]: where the keys are read from, one at a time
+  - Writer [io.Writer]: where the line is drawn
+  - MakeRaw [func() (func(), error)]: puts the terminal into raw mode and
+    returns a function to put it back. If this is nil, the terminal is
+    assumed to already be in raw mode (eg. in tests).
+  - Complete [func([]rune, int) (int, []string)]: gets the completions for
+    the word before the cursor, returning where the word starts and the
+    words that it could be completed to (with a trailing space where one
+    should follow the word)
+  - History [[]string]: the lines that have been read, oldest first
+*/
+type LineEditor struct {
+	Reader   io.Reader
+	Writer   io.Writer
+	MakeRaw  func() (func(), error)
+	Complete func(line []rune, cursor int) (int, []string)
+	History  []string
+}
+
+/*
+The line_state type houses a line while it is being edited. The structure of
+the state is as follows:
+  - prompt [string]: the prompt that is drawn before the line
+  - line [[]rune]: the line so far
+  - cursor [int]: where the cursor is on the line
+  - history_index [int]: the line of the history being shown, which is the
+    length of the history when it's the line being typed
+  - typed_line [[]rune]: the line being typed, kept while the history is
+    being looked through
+*/
+type line_state struct {
+	prompt        string
+	line          []rune
+	cursor        int
+	history_index int
+	typed_line    []rune
+}
+
+/*
+Read a line. Parameters include the prompt to show. Returns the line and an
+error which is io.EOF if Ctrl-D was pressed on an empty line or if there is
+nothing more to read, ErrInterrupted if Ctrl-C was pressed, and wraps
+ErrNoRawMode if raw mode couldn't be set so that the caller can fall back to
+reading whole lines.
+*/
+func (editor *LineEditor) ReadLine(prompt string) (string, error) {
+	// Put the terminal into raw mode for as long as the line is being read
+	if editor.MakeRaw != nil {
+		restore, raw_error := editor.MakeRaw()
+		if raw_error != nil {
+			return "", fmt.Errorf("%w: %v", ErrNoRawMode, raw_error)
+		}
+		defer restore()
+	}
+
+	state := &line_state{prompt: prompt, history_index: len(editor.History)}
+	editor.Draw(state)
+	for {
+		key, read_error := editor.ReadKey()
+		if read_error != nil {
+			fmt.Fprint(editor.Writer, "\r\n")
+			return "", read_error
+		}
+		switch key {
+		case KEY_ENTER, KEY_NEW_LINE:
+			fmt.Fprint(editor.Writer, "\r\n")
+			line := string(state.line)
+			editor.AddHistory(line)
+			return line, nil
+		case KEY_CTRL_C:
+			fmt.Fprint(editor.Writer, "^C\r\n")
+			return "", ErrInterrupted
+		case KEY_CTRL_D:
+			// Leave on an empty line, otherwise delete as the delete key does
+			if len(state.line) == 0 {
+				fmt.Fprint(editor.Writer, "\r\n")
+				return "", io.EOF
+			}
+			state.Delete(state.cursor, state.cursor+1)
+		case KEY_BACKSPACE, KEY_DELETE:
+			state.Delete(state.cursor-1, state.cursor)
+		case KEY_TAB:
+			editor.Completion(state)
+		case KEY_CTRL_A:
+			state.cursor = 0
+		case KEY_CTRL_E:
+			state.cursor = len(state.line)
+		case KEY_CTRL_B:
+			state.cursor = max(state.cursor-1, 0)
+		case KEY_CTRL_F:
+			state.cursor = min(state.cursor+1, len(state.line))
+		case KEY_CTRL_K:
+			state.Delete(state.cursor, len(state.line))
+		case KEY_CTRL_U:
+			state.Delete(0, state.cursor)
+		case KEY_CTRL_W:
+			// Delete back to the start of the word before the cursor
+			start := state.cursor
+			for start > 0 && state.line[start-1] == ' ' {
+				start -= 1
+			}
+			for start > 0 && state.line[start-1] != ' ' {
+				start -= 1
+			}
+			state.Delete(start, state.cursor)
+		case KEY_CTRL_L:
+			// Clear the screen and move to the top
+			fmt.Fprint(editor.Writer, "\x1b[H\x1b[2J")
+		case KEY_CTRL_P:
+			editor.Browse(state, -1)
+		case KEY_CTRL_N:
+			editor.Browse(state, 1)
+		case KEY_ESCAPE:
+			editor.EscapeSequence(state)
+		default:
+			// Ignore any other control characters
+			if key < ' ' {
+				continue
+			}
+			character, rune_error := editor.ReadRest(key)
+			if rune_error != nil {
+				continue
+			}
+			state.Insert(string(character))
+		}
+		editor.Draw(state)
+	}
+}
+
+/*
+Read a single key (ie. byte). No parameters. Returns the key and an error if
+there was nothing to read.
+*/
+func (editor *LineEditor) ReadKey() (byte, error) {
+	key := make([]byte, 1)
+	for {
+		count, read_error := editor.Reader.Read(key)
+		if count == 1 {
+			return key[0], nil
+		}
+		if read_error != nil {
+			return 0, read_error
+		}
+	}
+}
+
+/*
+Read the rest of a character that takes more than one byte. Parameters
+include the first byte. Returns the character and an error if it couldn't be
+read or isn't valid.
+*/
+func (editor *LineEditor) ReadRest(first byte) (rune, error) {
+	// Work out how many bytes the character takes from the first byte
+	length := 1
+	switch {
+	case first&0xE0 == 0xC0:
+		length = 2
+	case first&0xF0 == 0xE0:
+		length = 3
+	case first&0xF8 == 0xF0:
+		length = 4
+	}
+	character := []byte{first}
+	for len(character) < length {
+		next, read_error := editor.ReadKey()
+		if read_error != nil {
+			return 0, read_error
+		}
+		character = append(character, next)
+	}
+	decoded, _ := utf8.DecodeRune(character)
+	if decoded == utf8.RuneError {
+		return 0, errors.New("invalid character")
+	}
+	return decoded, nil
+}
+
+/*
+Act on an escape sequence (eg. an arrow key). The escape key has been read.
+Parameters include the state of the line. Returns nothing.
+*/
+func (editor *LineEditor) EscapeSequence(state *line_state) {
+	// Sequences start with either [ or O
+	introducer, read_error := editor.ReadKey()
+	if read_error != nil || (introducer != '[' && introducer != 'O') {
+		return
+	}
+	// Read any numbers (eg. the 3 of the delete key, 3~) and the final key
+	sequence := ""
+	for {
+		key, read_error := editor.ReadKey()
+		if read_error != nil {
+			return
+		}
+		sequence += string(key)
+		if key < '0' || key > '9' {
+			break
+		}
+	}
+	switch sequence {
+	case "A":
+		editor.Browse(state, -1)
+	case "B":
+		editor.Browse(state, 1)
+	case "C":
+		state.cursor = min(state.cursor+1, len(state.line))
+	case "D":
+		state.cursor = max(state.cursor-1, 0)
+	case "H", "1~", "7~":
+		state.cursor = 0
+	case "F", "4~", "8~":
+		state.cursor = len(state.line)
+	case "3~":
+		state.Delete(state.cursor, state.cursor+1)
+	}
+}
+
+/*
+Draw the line, replacing what was drawn before, and put the cursor in place.
+Parameters include the state of the line. Returns nothing.
+*/
+func (editor *LineEditor) Draw(state *line_state) {
+	// Go to the start of the line, draw it, and clear anything after it
+	fmt.Fprint(editor.Writer, "\r"+state.prompt+string(state.line)+"\x1b[K")
+	// Move the cursor back from the end of the line
+	if state.cursor < len(state.line) {
+		fmt.Fprintf(editor.Writer, "\x1b[%dD", len(state.line)-state.cursor)
+	}
+}
+
+/*
+Move through the history. Parameters include the state of the line and the
+direction to move, -1 for older and 1 for newer. Returns nothing.
+*/
+func (editor *LineEditor) Browse(state *line_state, direction int) {
+	history_index := state.history_index + direction
+	if history_index < 0 || history_index > len(editor.History) {
+		return
+	}
+	// Keep the line being typed so that it can be gone back to
+	if state.history_index == len(editor.History) {
+		state.typed_line = state.line
+	}
+	state.history_index = history_index
+	if history_index == len(editor.History) {
+		state.line = state.typed_line
+	} else {
+		state.line = []rune(editor.History[history_index])
+	}
+	state.cursor = len(state.line)
+}
+
+/*
+Add a line to the history. Blank lines and repeats of the last line aren't
+added. Parameters include the line. Returns nothing.
+*/
+func (editor *LineEditor) AddHistory(line string) {
+	if strings.TrimSpace(line) == "" ||
+		(len(editor.History) > 0 &&
+			editor.History[len(editor.History)-1] == line) {
+		return
+	}
+	editor.History = append(editor.History, line)
+}
+
+/*
+Complete the word before the cursor. If there is one completion, the word is
+completed. If there are several, the word is completed as far as they agree
+and, if it can't go any further, they are listed. Parameters include the state
+of the line. Returns nothing.
+*/
+func (editor *LineEditor) Completion(state *line_state) {
+	if editor.Complete == nil {
+		return
+	}
+	start, completions := editor.Complete(state.line, state.cursor)
+	typed := string(state.line[start:state.cursor])
+	switch len(completions) {
+	case 0:
+		// Ring the bell as there is nothing to complete to
+		fmt.Fprint(editor.Writer, "\a")
+	case 1:
+		state.Insert(strings.TrimPrefix(completions[0], typed))
+	default:
+		// Complete as far as the completions agree
+		common := completions[0]
+		for _, completion := range completions[1:] {
+			for !strings.HasPrefix(completion, common) {
+				_, size := utf8.DecodeLastRuneInString(common)
+				common = common[:len(common)-size]
+			}
+		}
+		if len(common) > len(typed) {
+			state.Insert(strings.TrimPrefix(common, typed))
+			return
+		}
+		// Otherwise, list them below the line
+		fmt.Fprint(editor.Writer, "\r\n"+
+			strings.Join(slices.Sorted(slices.Values(completions)), "  ")+
+			"\r\n")
+	}
+}
+
+/*
+Insert text at the cursor. Parameters include the text. Returns nothing.
+*/
+func (state *line_state) Insert(text string) {
+	inserted := []rune(text)
+	state.line = slices.Insert(
+		slices.Clone(state.line), state.cursor, inserted...)
+	state.cursor += len(inserted)
+}
+
+/*
+Delete part of the line, moving the cursor to where the deletion was.
+Parameters include where to start and end, which are kept to the line.
+Returns nothing.
+*/
+func (state *line_state) Delete(start int, end int) {
+	start = max(start, 0)
+	end = min(end, len(state.line))
+	if start >= end {
+		return
+	}
+	state.line = slices.Delete(slices.Clone(state.line), start, end)
+	state.cursor = start
+}
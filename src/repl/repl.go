@@ -0,0 +1,192 @@
+/*
+The REPL (read, evaluate, print loop) runs statements as they are typed. It is
+started by running the interpreter without a script. Each statement is run
+with the same interpreter so that variables and procedures are kept from one
+statement to the next, and a define block is read in full before it is run.
+An error is printed but never stops the REPL. Commands that start with a colon
+(eg. :vars) are for the REPL itself rather than the language.
+*/
+package repl
+
+import (
+	"appetit/parser"
+	"appetit/utils"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The prompts that are shown before a statement and inside of a block
+const (
+	PROMPT          string = "appetit> "
+	PROMPT_CONTINUE string = "   ...> "
+)
+
+// The symbol that starts a REPL command
+const SYMBOL_COMMAND string = ":"
+
+/*
+The REPL type houses the state of the REPL. The structure of the REPL is as
+follows:
+  - Interpreter [*parser.Interpreter]: the interpreter that runs the
+    statements
+  - Editor [*LineEditor]: the line editor that reads each line, nil if lines
+    are read in full (eg. when the input isn't a terminal)
+  - Interactive [bool]: whether a person is typing, in which case the
+    prompts are shown
+  - Session [[]string]: the lines that have been run without an error, which
+    are what :save saves
+*/
+type REPL struct {
+	Interpreter *parser.Interpreter
+	Editor      *LineEditor
+	Interactive bool
+	Session     []string
+}
+
+/*
+Create a REPL. Where the interpreter reads from a terminal, the line editor is
+used so that there is history and tab completion. Parameters include the
+interpreter. Returns the REPL.
+*/
+func New(interpreter *parser.Interpreter) *REPL {
+	repl := &REPL{Interpreter: interpreter}
+	// Only use the line editor when reading from a terminal
+	terminal, is_file := interpreter.Stdin.(*os.File)
+	if is_file && IsTerminal(terminal) {
+		repl.Interactive = true
+		repl.Editor = &LineEditor{
+			Reader:   terminal,
+			Writer:   interpreter.Stdout,
+			MakeRaw:  func() (func(), error) { return MakeRaw(terminal) },
+			Complete: repl.Complete,
+		}
+	}
+	return repl
+}
+
+/*
+Run the REPL until there is nothing more to read, the exit statement is run,
+or the user leaves with :quit. No parameters. Returns nothing.
+*/
+func (repl *REPL) Run() {
+	repl.Interpreter.BuildReservedVariables()
+	if repl.Interactive {
+		fmt.Fprintln(repl.Interpreter.Stdout, utils.ColouriseMagenta(
+			parser.LANG_NAME+" "+strconv.Itoa(parser.LANG_VERSION)+
+				" ("+parser.LANG_CODENAME+"). Type "+SYMBOL_COMMAND+
+				"help for help or "+SYMBOL_COMMAND+"quit to leave."))
+	}
+
+	// Hold the lines of a define block until it is ended
+	var block_lines []string
+	for {
+		prompt := PROMPT
+		if len(block_lines) > 0 {
+			prompt = PROMPT_CONTINUE
+		}
+		line, read_error := repl.ReadLine(prompt)
+		// Ctrl-C abandons the line and any block being typed
+		if errors.Is(read_error, ErrInterrupted) {
+			block_lines = nil
+			continue
+		}
+		if read_error != nil {
+			return
+		}
+
+		trimmed_line := strings.TrimSpace(line)
+		if len(block_lines) == 0 {
+			// Skip blank lines and comments
+			if trimmed_line == "" ||
+				strings.HasPrefix(trimmed_line, parser.SYMBOL_COMMENT) {
+				continue
+			}
+			// Run REPL commands, leaving if asked to
+			if strings.HasPrefix(trimmed_line, SYMBOL_COMMAND) {
+				if repl.Command(trimmed_line) {
+					return
+				}
+				continue
+			}
+		}
+
+		// Wait for the end of a define block before running it
+		block_lines = append(block_lines, line)
+		if BlockDepth(block_lines) > 0 {
+			continue
+		}
+		exited, run_error := repl.Execute(block_lines)
+		if run_error != nil {
+			parser.PrintError(repl.Interpreter.Stderr, run_error)
+		} else {
+			repl.Session = append(repl.Session, block_lines...)
+		}
+		block_lines = nil
+		if exited {
+			return
+		}
+	}
+}
+
+/*
+Read a line. The line editor is used where there is one but if the terminal
+can't be put into raw mode, the REPL falls back to reading whole lines.
+Parameters include the prompt. Returns the line and an error if there is
+nothing more to read.
+*/
+func (repl *REPL) ReadLine(prompt string) (string, error) {
+	if repl.Editor != nil {
+		line, read_error := repl.Editor.ReadLine(prompt)
+		if !errors.Is(read_error, ErrNoRawMode) {
+			return line, read_error
+		}
+		repl.Editor = nil
+	}
+	if repl.Interactive {
+		fmt.Fprint(repl.Interpreter.Stdout, prompt)
+	}
+	return repl.Interpreter.ReadLine()
+}
+
+/*
+Run lines as a script, keeping the variables and procedures that they set.
+Parameters include the lines. Returns whether the exit statement was run and
+an error if the lines failed.
+*/
+func (repl *REPL) Execute(lines []string) (bool, error) {
+	script, parse_error := repl.Interpreter.Parse(
+		parser.RemoveComments(lines), repl.Interpreter.ScriptName)
+	if parse_error != nil {
+		return false, parse_error
+	}
+	execute_error := repl.Interpreter.Execute(script)
+	if errors.Is(execute_error, parser.ErrScriptExit) {
+		return true, nil
+	}
+	return false, execute_error
+}
+
+/*
+Get how many define blocks deep the end of some lines is. Parameters include
+the lines. Returns the depth, which is zero once every block has been ended.
+*/
+func BlockDepth(lines []string) int {
+	depth := 0
+	for _, line := range lines {
+		tokens, tokenise_error := parser.Tokenise(line, 1, 1)
+		if tokenise_error != nil || len(tokens) < 2 {
+			continue
+		}
+		// A define statement without a from starts a block
+		if tokens[1].TokenValue == "define" && len(tokens) == 3 {
+			depth += 1
+		}
+		if tokens[1].TokenValue == "end" && depth > 0 {
+			depth -= 1
+		}
+	}
+	return depth
+}
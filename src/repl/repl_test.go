@@ -0,0 +1,112 @@
+package repl
+
+import (
+	"appetit/parser"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+Check to make sure that the REPL keeps variables and procedures between
+statements, carries on after an error, and saves the session as a script.
+*/
+func TestREPL(t *testing.T) {
+	script_file := filepath.Join(t.TempDir(), "session.apt")
+	var output bytes.Buffer
+	var errors_output bytes.Buffer
+	interpreter := parser.New(parser.Options{
+		Stdout: &output,
+		Stderr: &errors_output,
+		Stdin: strings.NewReader(
+			"set name = \"World\"\n" +
+				"bogus\n" +
+				"define \"greet\"\n" +
+				"writeln \"Hello #name\"\n" +
+				"end\n" +
+				"call \"greet\"\n" +
+				":vars\n" +
+				":save " + script_file + "\n" +
+				"exit\n" +
+				"writeln \"never\"\n",
+		),
+	})
+	New(interpreter).Run()
+
+	// Check that the statements ran and that the REPL stopped at exit
+	for _, expected := range []string{"Hello World", "name", "session.apt"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("[repl] Expected %q in the output, got %q",
+				expected,
+				output.String())
+		}
+	}
+	if strings.Contains(output.String(), "never") {
+		t.Errorf("[repl] Expected the REPL to stop at exit")
+	}
+	// Check that the error was printed
+	if !strings.Contains(errors_output.String(), "bogus") {
+		t.Errorf("[repl] Expected an error for bogus, got %q",
+			errors_output.String())
+	}
+
+	// Check that the session was saved without the line that failed
+	saved, read_error := os.ReadFile(script_file)
+	if read_error != nil {
+		t.Fatalf("[save] Expected the session to be saved, got %v",
+			read_error)
+	}
+	expected := "minver 1\n\nset name = \"World\"\ndefine \"greet\"\n" +
+		"    writeln \"Hello #name\"\nend\ncall \"greet\"\n"
+	if string(saved) != expected {
+		t.Errorf("[save] Expected %q, got %q", expected, string(saved))
+	}
+}
+
+/*
+Check to make sure that the line editor completes words, goes through the
+history, and handles Ctrl-C and Ctrl-D.
+*/
+func TestLineEditor(t *testing.T) {
+	interpreter := parser.New(parser.Options{Stdout: io.Discard})
+	interpreter.Variables["name"] = parser.StringValue("World")
+	repl := &REPL{Interpreter: interpreter}
+	editor := &LineEditor{
+		Reader: strings.NewReader(
+			// Complete a statement and a variable
+			"wri\tln \"#na\t\"\r" +
+				// Abandon a line
+				"bogus\x03" +
+				// Go back through the history and edit the line
+				"\x1b[A\x1b[D\x7fe!\r" +
+				// Leave
+				"\x04",
+		),
+		Writer:   io.Discard,
+		Complete: repl.Complete,
+	}
+
+	// The tab after wri can't go further than write as writeln also matches
+	line, read_error := editor.ReadLine(PROMPT)
+	if read_error != nil || line != "writeln \"#name\"" {
+		t.Errorf("[complete] Expected %q, got %q (%v)",
+			"writeln \"#name\"",
+			line,
+			read_error)
+	}
+	_, read_error = editor.ReadLine(PROMPT)
+	if read_error != ErrInterrupted {
+		t.Errorf("[ctrl-c] Expected ErrInterrupted, got %v", read_error)
+	}
+	line, _ = editor.ReadLine(PROMPT)
+	if line != "writeln \"#name!\"" {
+		t.Errorf("[history] Expected %q, got %q", "writeln \"#name!\"", line)
+	}
+	_, read_error = editor.ReadLine(PROMPT)
+	if read_error != io.EOF {
+		t.Errorf("[ctrl-d] Expected io.EOF, got %v", read_error)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+The terminal functions switch the terminal in and out of raw mode so that the
+line editor can see each key as it is pressed. This is done with stty, which
+every *nix system has, rather than with system calls so that the interpreter
+keeps to the standard library. Where stty isn't available (eg. Windows), the
+REPL falls back to reading whole lines.
+*/
+package repl
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+/*
+Check whether a file is a terminal. Parameters include the file. Returns true
+if the file is a terminal (ie. a character device).
+*/
+func IsTerminal(file *os.File) bool {
+	file_info, stat_error := file.Stat()
+	if stat_error != nil {
+		return false
+	}
+	return file_info.Mode()&os.ModeCharDevice != 0
+}
+
+/*
+Run stty against a terminal. Parameters include the terminal and the
+arguments to pass to stty. Returns the output of stty and an error if stty
+couldn't be run.
+*/
+func Stty(terminal *os.File, arguments ...string) (string, error) {
+	stty := exec.Command("stty", arguments...)
+	// stty works on whatever terminal is its standard input
+	stty.Stdin = terminal
+	output, stty_error := stty.Output()
+	return strings.TrimSpace(string(output)), stty_error
+}
+
+/*
+Put a terminal into raw mode, that is, without echoing keys or waiting for the
+enter key. Parameters include the terminal. Returns a function that puts the
+terminal back the way that it was and an error if raw mode couldn't be set.
+*/
+func MakeRaw(terminal *os.File) (func(), error) {
+	// Save the current settings so that they can be put back
+	settings, save_error := Stty(terminal, "-g")
+	if save_error != nil {
+		return nil, save_error
+	}
+	if _, raw_error := Stty(terminal, "raw", "-echo"); raw_error != nil {
+		return nil, raw_error
+	}
+	return func() { Stty(terminal, settings) }, nil
+}
@@ -4,6 +4,8 @@ shell that supports ANSI codes.
 */
 package utils
 
+import "strings"
+
 /*
 Set up the ANSI escape characters for shell colouring
 https://www.dolthub.com/blog/2024-02-23-colors-in-golang/
@@ -79,3 +81,20 @@ colourised string.
 func ColouriseGrey(text string) string {
 	return grey + text + reset
 }
+
+/*
+Strip the colours from text, that is, remove any of the ANSI escape
+characters that the functions above add. This is handy where colourised text
+needs to be used somewhere that doesn't support colour (eg. an error string).
+Parameters include the text to strip. Returns the text absent any colours.
+*/
+func StripColour(text string) string {
+	// Loop over each of the colours and the reset, removing them
+	for _, colour := range []string{
+		red, green, yellow, blue, magenta, cyan, grey, reset,
+	} {
+		text = strings.ReplaceAll(text, colour, "")
+	}
+	// Return the stripped text
+	return text
+}
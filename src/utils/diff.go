@@ -0,0 +1,181 @@
+/*
+The diff functions compare two versions of some text and report the
+differences as a unified diff (ie. what diff -u and git diff show). The
+differences are found with the longest common subsequence of the lines which is
+plenty quick for the size of a script.
+*/
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The number of unchanged lines to show either side of a change
+const DIFF_CONTEXT_LINES int = 3
+
+/*
+The diff_line type houses a single line of a diff. The structure of the line is
+as follows:
+  - kind [byte]: a space if the line is unchanged, - if it was removed, and +
+    if it was added
+  - text [string]: the line itself
+*/
+type diff_line struct {
+	kind byte
+	text string
+}
+
+/*
+Split text into lines for diffing. A trailing new line doesn't start a line of
+its own and where there isn't one, the last line notes that, as diff -u does,
+so that adding or removing it shows up as a change. Parameters include the
+text. Returns the lines.
+*/
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += "\n\\ No newline at end of file"
+	}
+	return lines
+}
+
+/*
+Create a unified diff of two versions of some text. Parameters include the
+names to give the old and new versions in the header and the old and new
+versions of the text. Returns the diff or an empty string if the text is the
+same.
+*/
+func Diff(
+	old_name string, new_name string,
+	old_text string, new_text string) string {
+	// If nothing has changed, there is no diff
+	if old_text == new_text {
+		return ""
+	}
+	lines := DiffLines(SplitLines(old_text), SplitLines(new_text))
+
+	// Start with the header
+	var diff strings.Builder
+	diff.WriteString("--- " + old_name + "\n+++ " + new_name + "\n")
+
+	/*
+		Work through the lines, gathering each change along with the lines
+		around it into a hunk. Changes that are close together share a hunk.
+	*/
+	// Hold where the lines of the next hunk are in the old and new text
+	old_line, new_line := 1, 1
+	for index := 0; index < len(lines); {
+		// Skip over the unchanged lines that are too far from a change
+		if lines[index].kind == ' ' &&
+			!DiffChangeNear(lines, index, DIFF_CONTEXT_LINES) {
+			old_line += 1
+			new_line += 1
+			index += 1
+			continue
+		}
+		// Gather the hunk, keeping count of the lines on either side
+		hunk_start := index
+		old_count, new_count := 0, 0
+		for index < len(lines) && (lines[index].kind != ' ' ||
+			DiffChangeNear(lines, index, DIFF_CONTEXT_LINES)) {
+			if lines[index].kind != '+' {
+				old_count += 1
+			}
+			if lines[index].kind != '-' {
+				new_count += 1
+			}
+			index += 1
+		}
+		// Write the hunk header and the lines
+		diff.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			DiffRange(old_line, old_count), DiffRange(new_line, new_count)))
+		for _, line := range lines[hunk_start:index] {
+			diff.WriteString(string(line.kind) + line.text + "\n")
+		}
+		old_line += old_count
+		new_line += new_count
+	}
+	return diff.String()
+}
+
+/*
+Check whether a line is close enough to a change to be shown with it.
+Parameters include the lines, the index of the line, and how close it needs to
+be. Returns true if there is a change within that many lines.
+*/
+func DiffChangeNear(lines []diff_line, index int, distance int) bool {
+	for near := max(index-distance, 0); near <= index+distance &&
+		near < len(lines); near++ {
+		if lines[near].kind != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Get the range of a hunk for its header. Parameters include the first line and
+the number of lines. Returns the range (eg. 4,3 for three lines from line
+four). An empty range starts at the line before, as diff -u reports it.
+*/
+func DiffRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+/*
+Compare two lists of lines. Parameters include the old and new lines. Returns
+the lines of both, each marked as unchanged, removed, or added.
+*/
+func DiffLines(old_lines []string, new_lines []string) []diff_line {
+	/*
+		Find the length of the longest common subsequence of every pair of
+		endings of the two lists, working back from the end.
+	*/
+	common := make([][]int, len(old_lines)+1)
+	for old_index := range common {
+		common[old_index] = make([]int, len(new_lines)+1)
+	}
+	for old_index := len(old_lines) - 1; old_index >= 0; old_index-- {
+		for new_index := len(new_lines) - 1; new_index >= 0; new_index-- {
+			if old_lines[old_index] == new_lines[new_index] {
+				common[old_index][new_index] =
+					common[old_index+1][new_index+1] + 1
+			} else {
+				common[old_index][new_index] = max(
+					common[old_index+1][new_index],
+					common[old_index][new_index+1])
+			}
+		}
+	}
+
+	// Walk forward, keeping lines in common and noting the rest
+	var lines []diff_line
+	old_index, new_index := 0, 0
+	for old_index < len(old_lines) || new_index < len(new_lines) {
+		switch {
+		case old_index < len(old_lines) && new_index < len(new_lines) &&
+			old_lines[old_index] == new_lines[new_index]:
+			lines = append(lines, diff_line{' ', old_lines[old_index]})
+			old_index += 1
+			new_index += 1
+		case new_index == len(new_lines) || (old_index < len(old_lines) &&
+			common[old_index+1][new_index] >= common[old_index][new_index+1]):
+			lines = append(lines, diff_line{'-', old_lines[old_index]})
+			old_index += 1
+		default:
+			lines = append(lines, diff_line{'+', new_lines[new_index]})
+			new_index += 1
+		}
+	}
+	return lines
+}
@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+/*
+Check to make sure that a unified diff shows the changed lines with the lines
+around them and nothing when the text is the same.
+*/
+func TestDiff(t *testing.T) {
+	old_text := "a\nb\nc\nd\ne\nf\ng\nh\ni\n"
+	new_text := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	expected := "--- old\n+++ new\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -7,3 +7,4 @@\n g\n h\n i\n+j\n"
+
+	// Check the diff
+	result := Diff("old", "new", old_text, new_text)
+	if result != expected {
+		t.Errorf("[Diff] Expected %q, got %q", expected, result)
+	}
+	// Check that there is no diff when nothing has changed
+	if result := Diff("old", "new", old_text, old_text); result != "" {
+		t.Errorf("[Diff] Expected no diff, got %q", result)
+	}
+}
@@ -0,0 +1,1158 @@
+/*
+The xz package houses how archives compressed with xz (eg. tool.tar.xz) are
+decompressed for the untar statement, as Go's standard library can't. An xz
+file is made up of one or more streams, each holding blocks of data
+compressed with LZMA2 followed by an index of the blocks, for example:
+
+	stream header | block | block | index | stream footer
+
+Only blocks compressed with LZMA2 on its own can be read, which is how the
+xz command compresses files unless it is told otherwise. The check at the end
+of each block (CRC32, CRC64, or SHA-256) is checked along with the index.
+See https://tukaani.org/xz/xz-file-format.txt for the format.
+*/
+package xz
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"math"
+	"slices"
+)
+
+// The bytes that an xz stream starts and ends with
+var (
+	headerMagic = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	footerMagic = []byte{'Y', 'Z'}
+)
+
+// The ID of the LZMA2 filter in a block header
+const filterLZMA2 = 0x21
+
+/*
+The number of probabilities that LZMA keeps for each part of what it
+decodes.
+*/
+const (
+	lzmaStates         = 12
+	lzmaPositionStates = 1 << 4
+	lzmaLiteralSize    = 0x300
+	lzmaDistanceSlots  = 64
+	lzmaFullDistances  = 128
+	lzmaEndSlot        = 14
+	lzmaAlignBits      = 4
+	lzmaMatchMin       = 2
+)
+
+/*
+Returned (wrapped) when xz data is corrupt or uses something that can't be
+read.
+*/
+var errData = errors.New("the xz data is corrupt")
+
+/*
+The xzInput type houses the compressed data that is being read, counting the
+bytes along the way. The structure of the input is as follows:
+  - reader [*bufio.Reader]: what the data is read from
+  - count [int64]: the number of bytes read so far
+  - checksum [hash.Hash32]: a checksum of the bytes as they're read, nil if
+    they aren't being checked
+*/
+type xzInput struct {
+	reader   *bufio.Reader
+	count    int64
+	checksum hash.Hash32
+}
+
+/*
+Read a byte of the compressed data. No parameters. Returns the byte and
+io.ErrUnexpectedEOF if there was nothing left to read.
+*/
+func (input *xzInput) readByte() (byte, error) {
+	value, read_error := input.reader.ReadByte()
+	if read_error == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if read_error != nil {
+		return 0, read_error
+	}
+	input.count += 1
+	if input.checksum != nil {
+		input.checksum.Write([]byte{value})
+	}
+	return value, nil
+}
+
+/*
+Read a number of bytes of the compressed data. Parameters include the number
+of bytes. Returns the bytes and io.ErrUnexpectedEOF if there weren't enough
+left to read.
+*/
+func (input *xzInput) next(size int) ([]byte, error) {
+	data := make([]byte, size)
+	read, read_error := io.ReadFull(input.reader, data)
+	input.count += int64(read)
+	if read_error == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if read_error != nil {
+		return nil, read_error
+	}
+	if input.checksum != nil {
+		input.checksum.Write(data)
+	}
+	return data, nil
+}
+
+/*
+Read a number that is stored in one to nine bytes, seven bits at a time. No
+parameters. Returns the number and an error if it isn't stored properly.
+*/
+func (input *xzInput) readNumber() (uint64, error) {
+	var number uint64
+	for index := 0; index < 9; index++ {
+		value, read_error := input.readByte()
+		if read_error != nil {
+			return 0, read_error
+		}
+		// A number can't end with a byte of nothing
+		if index > 0 && value == 0 {
+			break
+		}
+		number |= uint64(value&0x7f) << (7 * index)
+		if value&0x80 == 0 {
+			return number, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: a number isn't stored properly", errData)
+}
+
+/*
+Read bytes that should all be nothing, such as those that pad a block.
+Parameters include the number of bytes. Returns an error if any of them
+aren't nothing.
+*/
+func (input *xzInput) skipPadding(size int) error {
+	padding, read_error := input.next(size)
+	if read_error != nil {
+		return read_error
+	}
+	if slices.ContainsFunc(padding, func(value byte) bool {
+		return value != 0
+	}) {
+		return fmt.Errorf("%w: the padding isn't empty", errData)
+	}
+	return nil
+}
+
+/*
+The xzBlock type houses what is known about a block of an xz stream. The
+structure of the block is as follows:
+  - header_size [int64]: the number of bytes in the block header
+  - start [int64]: where the compressed data starts in the input
+  - compressed_size [int64]: the size that the header says the compressed
+    data is, -1 if it doesn't say
+  - uncompressed_size [int64]: the size that the header says the
+    decompressed data is, -1 if it doesn't say
+  - written [int64]: the number of bytes decompressed so far
+*/
+type xzBlock struct {
+	header_size       int64
+	start             int64
+	compressed_size   int64
+	uncompressed_size int64
+	written           int64
+}
+
+/*
+The xzReader type houses the decompression of xz data, which is read as it
+is decompressed. The structure of the reader is as follows:
+  - input [*xzInput]: the compressed data
+  - decoder [*lzma2Decoder]: the decoder of the block being read
+  - output_position [int]: how much of the decoder's output has been read
+  - check_type [byte]: the type of check at the end of each block
+  - stream_flags [[]byte]: the flags of the stream being read
+  - block [*xzBlock]: the block being read, nil between blocks
+  - block_check [hash.Hash]: the check of the block being read
+  - records [[][2]uint64]: the size of each block of the stream read so far
+    and the size of its decompressed data, to check against the index
+  - in_stream [bool]: whether a stream is being read
+  - done [bool]: whether all of the data has been read
+*/
+type xzReader struct {
+	input           *xzInput
+	decoder         *lzma2Decoder
+	output_position int
+	check_type      byte
+	stream_flags    []byte
+	block           *xzBlock
+	block_check     hash.Hash
+	records         [][2]uint64
+	in_stream       bool
+	done            bool
+}
+
+/*
+Set up to decompress xz data. Parameters include what the data is read from.
+Returns the reader of the decompressed data and an error if the data doesn't
+start with an xz stream.
+*/
+func NewReader(reader io.Reader) (io.Reader, error) {
+	buffered, is_buffered := reader.(*bufio.Reader)
+	if !is_buffered {
+		buffered = bufio.NewReader(reader)
+	}
+	xz_reader := &xzReader{
+		input:   &xzInput{reader: buffered},
+		decoder: &lzma2Decoder{},
+	}
+	if header_error := xz_reader.readStreamHeader(); header_error != nil {
+		return nil, header_error
+	}
+	return xz_reader, nil
+}
+
+/*
+Read the decompressed data. Parameters include where to put the data.
+Returns the number of bytes read and io.EOF once all of the data has been
+read, or an error if the data couldn't be decompressed.
+*/
+func (reader *xzReader) Read(data []byte) (int, error) {
+	output := &reader.decoder.dictionary.output
+	for reader.output_position == len(*output) {
+		if reader.done {
+			return 0, io.EOF
+		}
+		*output = (*output)[:0]
+		reader.output_position = 0
+		if decode_error := reader.decodeNext(); decode_error != nil {
+			return 0, decode_error
+		}
+	}
+	read := copy(data, (*output)[reader.output_position:])
+	reader.output_position += read
+	return read, nil
+}
+
+/*
+Move on to the next part of the data, decoding a chunk of the block being
+read if there is one. No parameters. Returns an error if the data couldn't be
+decompressed.
+*/
+func (reader *xzReader) decodeNext() error {
+	// Between streams, look for the next one
+	if !reader.in_stream {
+		return reader.readStreamPadding()
+	}
+	// Between blocks, read the header of the next block or the index
+	if reader.block == nil {
+		size, read_error := reader.input.readByte()
+		if read_error != nil {
+			return read_error
+		}
+		if size == 0 {
+			return reader.readIndex()
+		}
+		return reader.readBlockHeader(size)
+	}
+
+	// Decode a chunk, checking what it decompresses to
+	output := reader.decoder.dictionary.output
+	finished, decode_error := reader.decoder.decodeChunk(reader.input)
+	if decode_error != nil {
+		return decode_error
+	}
+	written := reader.decoder.dictionary.output[len(output):]
+	if reader.block_check != nil {
+		reader.block_check.Write(written)
+	}
+	reader.block.written += int64(len(written))
+	if finished {
+		return reader.readBlockEnd()
+	}
+	return nil
+}
+
+/*
+Read the header that a stream starts with. No parameters. Returns an error
+if it isn't the header of an xz stream or uses a check that can't be read.
+*/
+func (reader *xzReader) readStreamHeader() error {
+	header, read_error := reader.input.next(12)
+	if read_error != nil {
+		return read_error
+	}
+	if !bytes.Equal(header[:6], headerMagic) {
+		return fmt.Errorf("%w: it doesn't start with an xz header", errData)
+	}
+	if crc32.ChecksumIEEE(header[6:8]) !=
+		binary.LittleEndian.Uint32(header[8:]) {
+		return fmt.Errorf("%w: the stream header is corrupt", errData)
+	}
+	if header[6] != 0 || header[7]&0xf0 != 0 {
+		return fmt.Errorf("%w: the stream uses flags that can't be read",
+			errData)
+	}
+	reader.check_type = header[7]
+	if _, check_error := newCheck(reader.check_type); check_error != nil {
+		return check_error
+	}
+	reader.stream_flags = header[6:8]
+	reader.records = nil
+	reader.in_stream = true
+	return nil
+}
+
+/*
+Read what comes after a stream, which is either the end of the data, padding,
+or another stream. No parameters. Returns an error if what follows isn't one
+of those.
+*/
+func (reader *xzReader) readStreamPadding() error {
+	next, peek_error := reader.input.reader.Peek(1)
+	if peek_error == io.EOF {
+		reader.done = true
+		return nil
+	}
+	if peek_error != nil {
+		return peek_error
+	}
+	// Padding comes four bytes of nothing at a time
+	if next[0] == 0 {
+		return reader.input.skipPadding(4)
+	}
+	return reader.readStreamHeader()
+}
+
+/*
+Read the header of a block and set up to decompress it. Parameters include
+the first byte of the header, which holds its size. Returns an error if the
+header is corrupt or the block uses a filter other than LZMA2.
+*/
+func (reader *xzReader) readBlockHeader(size byte) error {
+	header_size := (int(size) + 1) * 4
+	start := reader.input.count - 1
+	reader.input.checksum = crc32.NewIEEE()
+	reader.input.checksum.Write([]byte{size})
+	defer func() { reader.input.checksum = nil }()
+
+	// Read the flags and the sizes of the block, if they're there
+	flags, read_error := reader.input.readByte()
+	if read_error != nil {
+		return read_error
+	}
+	if flags&0x3c != 0 {
+		return fmt.Errorf("%w: the block uses flags that can't be read",
+			errData)
+	}
+	block := &xzBlock{compressed_size: -1, uncompressed_size: -1}
+	for _, field := range []struct {
+		flag byte
+		size *int64
+	}{
+		{0x40, &block.compressed_size},
+		{0x80, &block.uncompressed_size},
+	} {
+		if flags&field.flag == 0 {
+			continue
+		}
+		number, number_error := reader.input.readNumber()
+		if number_error != nil {
+			return number_error
+		}
+		if number > math.MaxInt64/2 {
+			return fmt.Errorf("%w: the block is too big", errData)
+		}
+		*field.size = int64(number)
+	}
+
+	// Only a single LZMA2 filter can be read
+	filter, filter_error := reader.input.readNumber()
+	if filter_error != nil {
+		return filter_error
+	}
+	properties_size, properties_error := reader.input.readNumber()
+	if properties_error != nil {
+		return properties_error
+	}
+	if flags&0x03 != 0 || filter != filterLZMA2 || properties_size != 1 {
+		return fmt.Errorf("%w: only blocks compressed with LZMA2 on its own "+
+			"can be read", errData)
+	}
+	properties, read_error := reader.input.readByte()
+	if read_error != nil {
+		return read_error
+	}
+	if properties > 40 {
+		return fmt.Errorf("%w: the dictionary size isn't valid", errData)
+	}
+
+	// The rest of the header is padding, followed by its checksum
+	padding := header_size - 4 - int(reader.input.count-start)
+	if padding < 0 {
+		return fmt.Errorf("%w: the block header is too small", errData)
+	}
+	padding_error := reader.input.skipPadding(padding)
+	if padding_error != nil {
+		return padding_error
+	}
+	checksum := reader.input.checksum.Sum32()
+	reader.input.checksum = nil
+	stored, read_error := reader.input.next(4)
+	if read_error != nil {
+		return read_error
+	}
+	if checksum != binary.LittleEndian.Uint32(stored) {
+		return fmt.Errorf("%w: the block header is corrupt", errData)
+	}
+
+	// Set up the decoder with the size of the dictionary
+	dictionary_size := int64(math.MaxUint32)
+	if properties < 40 {
+		dictionary_size = int64(2|properties&1) << (properties/2 + 11)
+	}
+	reader.decoder.reset(int(min(dictionary_size, math.MaxInt32)))
+	block.header_size = int64(header_size)
+	block.start = reader.input.count
+	reader.block = block
+	reader.block_check, _ = newCheck(reader.check_type)
+	return nil
+}
+
+/*
+Read what comes after the compressed data of a block, which is padding and
+the check of the decompressed data. No parameters. Returns an error if the
+block isn't the size its header says or its check doesn't match.
+*/
+func (reader *xzReader) readBlockEnd() error {
+	block := reader.block
+	compressed_size := reader.input.count - block.start
+	if (block.compressed_size != -1 &&
+		block.compressed_size != compressed_size) ||
+		(block.uncompressed_size != -1 &&
+			block.uncompressed_size != block.written) {
+		return fmt.Errorf("%w: the block isn't the size that its header says",
+			errData)
+	}
+	padding_error := reader.input.skipPadding(
+		int((4 - compressed_size%4) % 4))
+	if padding_error != nil {
+		return padding_error
+	}
+
+	// The check is stored little endian unless it is SHA-256
+	check_size := 0
+	if reader.block_check != nil {
+		check_size = reader.block_check.Size()
+		stored, read_error := reader.input.next(check_size)
+		if read_error != nil {
+			return read_error
+		}
+		check := reader.block_check.Sum(nil)
+		if reader.check_type != 0x0a {
+			slices.Reverse(check)
+		}
+		if !bytes.Equal(check, stored) {
+			return fmt.Errorf("%w: the check of a block doesn't match",
+				errData)
+		}
+	}
+	reader.records = append(reader.records, [2]uint64{
+		uint64(block.header_size + compressed_size + int64(check_size)),
+		uint64(block.written),
+	})
+	reader.block = nil
+	return nil
+}
+
+/*
+Read the index of the blocks in a stream and the footer that follows it. No
+parameters. Returns an error if the index doesn't match the blocks that were
+read or the footer doesn't match the header of the stream.
+*/
+func (reader *xzReader) readIndex() error {
+	// The index includes the byte of nothing that marks its start
+	start := reader.input.count - 1
+	reader.input.checksum = crc32.NewIEEE()
+	reader.input.checksum.Write([]byte{0})
+	defer func() { reader.input.checksum = nil }()
+	record_count, count_error := reader.input.readNumber()
+	if count_error != nil {
+		return count_error
+	}
+	if record_count != uint64(len(reader.records)) {
+		return fmt.Errorf("%w: the index doesn't match the blocks", errData)
+	}
+	for _, record := range reader.records {
+		for _, size := range record {
+			number, number_error := reader.input.readNumber()
+			if number_error != nil {
+				return number_error
+			}
+			if number != size {
+				return fmt.Errorf("%w: the index doesn't match the blocks",
+					errData)
+			}
+		}
+	}
+	padding_error := reader.input.skipPadding(
+		int((4 - (reader.input.count-start)%4) % 4))
+	if padding_error != nil {
+		return padding_error
+	}
+	checksum := reader.input.checksum.Sum32()
+	reader.input.checksum = nil
+	index_size := reader.input.count - start
+	stored, read_error := reader.input.next(4)
+	if read_error != nil {
+		return read_error
+	}
+	if checksum != binary.LittleEndian.Uint32(stored) {
+		return fmt.Errorf("%w: the index is corrupt", errData)
+	}
+
+	// The footer holds the size of the index and the flags of the stream
+	footer, read_error := reader.input.next(12)
+	if read_error != nil {
+		return read_error
+	}
+	if crc32.ChecksumIEEE(footer[4:10]) !=
+		binary.LittleEndian.Uint32(footer[:4]) ||
+		!bytes.Equal(footer[10:], footerMagic) ||
+		!bytes.Equal(footer[8:10], reader.stream_flags) ||
+		(int64(binary.LittleEndian.Uint32(footer[4:8]))+1)*4 != index_size+4 {
+		return fmt.Errorf("%w: the stream footer is corrupt", errData)
+	}
+	reader.in_stream = false
+	return nil
+}
+
+/*
+Get the check for a type of check in a stream header. Parameters include the
+type. Returns the check, nil if there isn't one, and an error if the type of
+check can't be read.
+*/
+func newCheck(check_type byte) (hash.Hash, error) {
+	switch check_type {
+	case 0x00:
+		return nil, nil
+	case 0x01:
+		return crc32.NewIEEE(), nil
+	case 0x04:
+		return crc64.New(crc64.MakeTable(crc64.ECMA)), nil
+	case 0x0a:
+		return sha256.New(), nil
+	}
+	return nil, fmt.Errorf("%w: the check with the ID %d can't be read",
+		errData, check_type)
+}
+
+/*
+The lzmaDictionary type houses what has been decompressed, which matches
+copy from. The dictionary grows as it is written to until it reaches its
+size, after which it wraps around. The structure of the dictionary is as
+follows:
+  - buffer [[]byte]: the decompressed bytes
+  - size [int]: the most bytes that the dictionary can hold
+  - position [int]: where in the buffer the next byte goes
+  - full [bool]: whether the buffer has wrapped around
+  - total [uint64]: the number of bytes written since it was reset
+  - output [[]byte]: the bytes written that haven't been read yet
+*/
+type lzmaDictionary struct {
+	buffer   []byte
+	size     int
+	position int
+	full     bool
+	total    uint64
+	output   []byte
+}
+
+/*
+Empty the dictionary. Parameters include the most bytes that it can hold.
+No return value.
+*/
+func (dictionary *lzmaDictionary) reset(size int) {
+	if size != dictionary.size {
+		dictionary.buffer = nil
+	}
+	dictionary.buffer = dictionary.buffer[:0]
+	dictionary.size = size
+	dictionary.position = 0
+	dictionary.full = false
+	dictionary.total = 0
+}
+
+// Write a byte. Parameters include the byte. No return value.
+func (dictionary *lzmaDictionary) put(value byte) {
+	if len(dictionary.buffer) < dictionary.size {
+		dictionary.buffer = append(dictionary.buffer, value)
+	} else {
+		dictionary.buffer[dictionary.position] = value
+	}
+	dictionary.position += 1
+	if dictionary.position == dictionary.size {
+		dictionary.position = 0
+		dictionary.full = true
+	}
+	dictionary.total += 1
+	dictionary.output = append(dictionary.output, value)
+}
+
+/*
+Get a byte that was written. Parameters include how far back the byte was
+written, where 1 is the last byte. Returns the byte, or 0 if nothing has
+been written that far back.
+*/
+func (dictionary *lzmaDictionary) get(distance int) byte {
+	if !dictionary.has(distance) {
+		return 0
+	}
+	index := dictionary.position - distance
+	if index < 0 {
+		index += dictionary.size
+	}
+	return dictionary.buffer[index]
+}
+
+/*
+Check whether a byte that was written that far back is still held.
+Parameters include how far back the byte was written. Returns true if it is.
+*/
+func (dictionary *lzmaDictionary) has(distance int) bool {
+	if dictionary.full {
+		return distance <= dictionary.size
+	}
+	return distance <= dictionary.position
+}
+
+/*
+The lzmaRangeDecoder type houses the decoding of the bits of a chunk, each
+with the probability that it is 0. The structure of the decoder is as
+follows:
+  - data [[]byte]: the compressed data of the chunk
+  - position [int]: where the next byte of the data is
+  - bounds [uint32]: the range that the code sits in
+  - code [uint32]: the part of the data being decoded
+*/
+type lzmaRangeDecoder struct {
+	data     []byte
+	position int
+	bounds   uint32
+	code     uint32
+}
+
+/*
+Set up to decode the compressed data of a chunk. Parameters include the
+data. Returns an error if the data doesn't start as it should.
+*/
+func (decoder *lzmaRangeDecoder) reset(data []byte) error {
+	if len(data) < 5 || data[0] != 0 {
+		return fmt.Errorf("%w: a chunk doesn't start as it should", errData)
+	}
+	decoder.data = data
+	decoder.position = 5
+	decoder.bounds = math.MaxUint32
+	decoder.code = binary.BigEndian.Uint32(data[1:5])
+	return nil
+}
+
+/*
+Check whether the decoder read past the end of the data. No parameters.
+Returns true if it did.
+*/
+func (decoder *lzmaRangeDecoder) overran() bool {
+	return decoder.position > len(decoder.data)
+}
+
+/*
+Check whether the decoder finished where the data ends, as it does once all
+of the bits of a chunk have been decoded. No parameters. Returns true if it
+did.
+*/
+func (decoder *lzmaRangeDecoder) finished() bool {
+	decoder.normalise()
+	return decoder.position == len(decoder.data) && decoder.code == 0
+}
+
+/*
+Read another byte of the data into the code once the range gets too small.
+Reading past the end of the data reads nothing, which overran() picks up.
+No parameters. No return value.
+*/
+func (decoder *lzmaRangeDecoder) normalise() {
+	if decoder.bounds >= 1<<24 {
+		return
+	}
+	var next byte
+	if decoder.position < len(decoder.data) {
+		next = decoder.data[decoder.position]
+	}
+	decoder.position += 1
+	decoder.bounds <<= 8
+	decoder.code = decoder.code<<8 | uint32(next)
+}
+
+/*
+Decode a bit, updating its probability. Parameters include the probability
+that the bit is 0, out of 2048. Returns the bit.
+*/
+func (decoder *lzmaRangeDecoder) bit(probability *uint16) uint32 {
+	decoder.normalise()
+	bound := (decoder.bounds >> 11) * uint32(*probability)
+	if decoder.code < bound {
+		decoder.bounds = bound
+		*probability += (2048 - *probability) >> 5
+		return 0
+	}
+	decoder.bounds -= bound
+	decoder.code -= bound
+	*probability -= *probability >> 5
+	return 1
+}
+
+/*
+Decode bits that are as likely to be 0 as 1. Parameters include the number
+of bits. Returns the bits, the first being the highest.
+*/
+func (decoder *lzmaRangeDecoder) directBits(count int) uint32 {
+	var bits uint32
+	for range count {
+		decoder.normalise()
+		decoder.bounds >>= 1
+		bits <<= 1
+		if decoder.code >= decoder.bounds {
+			decoder.code -= decoder.bounds
+			bits |= 1
+		}
+	}
+	return bits
+}
+
+/*
+Decode bits as a tree of probabilities, the first bit being the highest.
+Parameters include the probabilities and the number of bits. Returns the
+bits.
+*/
+func (decoder *lzmaRangeDecoder) tree(
+	probabilities []uint16, count int) uint32 {
+	symbol := uint32(1)
+	for range count {
+		symbol = symbol<<1 | decoder.bit(&probabilities[symbol])
+	}
+	return symbol - 1<<count
+}
+
+/*
+Decode bits as a tree of probabilities, the first bit being the lowest.
+Parameters include the probabilities and the number of bits. Returns the
+bits.
+*/
+func (decoder *lzmaRangeDecoder) reverseTree(
+	probabilities []uint16, count int) uint32 {
+	symbol := uint32(1)
+	var bits uint32
+	for index := range count {
+		bit := decoder.bit(&probabilities[symbol])
+		symbol = symbol<<1 | bit
+		bits |= bit << index
+	}
+	return bits
+}
+
+/*
+The lzmaLengthDecoder type houses the probabilities of the length of a
+match. The structure of the decoder is as follows:
+  - choice [uint16]: whether the length is more than 9
+  - choice_high [uint16]: whether the length is more than 17
+  - low [[16][8]uint16]: the lengths up to 9 for each position state
+  - middle [[16][8]uint16]: the lengths up to 17 for each position state
+  - high [[256]uint16]: the lengths after 17
+*/
+type lzmaLengthDecoder struct {
+	choice      uint16
+	choice_high uint16
+	low         [lzmaPositionStates][1 << 3]uint16
+	middle      [lzmaPositionStates][1 << 3]uint16
+	high        [1 << 8]uint16
+}
+
+// Set every probability to a half. No parameters. No return value.
+func (decoder *lzmaLengthDecoder) reset() {
+	decoder.choice, decoder.choice_high = 1024, 1024
+	for position_state := range lzmaPositionStates {
+		fillProbabilities(decoder.low[position_state][:])
+		fillProbabilities(decoder.middle[position_state][:])
+	}
+	fillProbabilities(decoder.high[:])
+}
+
+/*
+Decode the length of a match. Parameters include the range decoder and the
+position state. Returns the length.
+*/
+func (decoder *lzmaLengthDecoder) decode(
+	ranges *lzmaRangeDecoder, position_state uint32) int {
+	if ranges.bit(&decoder.choice) == 0 {
+		return lzmaMatchMin +
+			int(ranges.tree(decoder.low[position_state][:], 3))
+	}
+	if ranges.bit(&decoder.choice_high) == 0 {
+		return lzmaMatchMin + 8 +
+			int(ranges.tree(decoder.middle[position_state][:], 3))
+	}
+	return lzmaMatchMin + 16 + int(ranges.tree(decoder.high[:], 8))
+}
+
+// Set each probability to a half. Parameters include the probabilities.
+func fillProbabilities(probabilities []uint16) {
+	for index := range probabilities {
+		probabilities[index] = 1024
+	}
+}
+
+/*
+The lzma2Decoder type houses the decompression of the LZMA2 data in a block,
+a chunk at a time. The structure of the decoder is as follows:
+  - dictionary [lzmaDictionary]: what has been decompressed
+  - ranges [lzmaRangeDecoder]: the decoder of the chunk's bits
+  - literal_bits, position_bits, literal_position_bits [uint32]: the
+    properties that the probabilities are picked with
+  - need_dictionary_reset, need_properties [bool]: whether the next chunk
+    has to reset the dictionary or set the properties
+  - state [uint32]: what the last few things decoded were
+  - repeats [[4]int]: the distances of the last four matches, less one
+  - the rest are the probabilities of each part of what is decoded
+*/
+type lzma2Decoder struct {
+	dictionary            lzmaDictionary
+	ranges                lzmaRangeDecoder
+	literal_bits          uint32
+	position_bits         uint32
+	literal_position_bits uint32
+	need_dictionary_reset bool
+	need_properties       bool
+	state                 uint32
+	repeats               [4]int
+	is_match              [lzmaStates * lzmaPositionStates]uint16
+	is_repeat             [lzmaStates]uint16
+	is_repeat_0           [lzmaStates]uint16
+	is_repeat_1           [lzmaStates]uint16
+	is_repeat_2           [lzmaStates]uint16
+	is_repeat_0_long      [lzmaStates * lzmaPositionStates]uint16
+	literals              [lzmaLiteralSize << 4]uint16
+	distance_slots        [4][lzmaDistanceSlots]uint16
+	distances             [1 + lzmaFullDistances - lzmaEndSlot]uint16
+	align                 [1 << lzmaAlignBits]uint16
+	lengths               lzmaLengthDecoder
+	repeat_lengths        lzmaLengthDecoder
+}
+
+/*
+Set up to decode a new block. Parameters include the size of the
+dictionary. No return value.
+*/
+func (decoder *lzma2Decoder) reset(dictionary_size int) {
+	decoder.dictionary.reset(dictionary_size)
+	decoder.need_dictionary_reset = true
+	decoder.need_properties = true
+}
+
+/*
+Set the state and every probability back to how they start. No parameters.
+No return value.
+*/
+func (decoder *lzma2Decoder) resetState() {
+	decoder.state = 0
+	decoder.repeats = [4]int{}
+	fillProbabilities(decoder.is_match[:])
+	fillProbabilities(decoder.is_repeat[:])
+	fillProbabilities(decoder.is_repeat_0[:])
+	fillProbabilities(decoder.is_repeat_1[:])
+	fillProbabilities(decoder.is_repeat_2[:])
+	fillProbabilities(decoder.is_repeat_0_long[:])
+	fillProbabilities(decoder.literals[:])
+	for index := range decoder.distance_slots {
+		fillProbabilities(decoder.distance_slots[index][:])
+	}
+	fillProbabilities(decoder.distances[:])
+	fillProbabilities(decoder.align[:])
+	decoder.lengths.reset()
+	decoder.repeat_lengths.reset()
+}
+
+/*
+Decode the next chunk of a block into the dictionary. Parameters include the
+compressed data. Returns true once the end of the block's data has been
+reached and an error if the chunk is corrupt.
+*/
+func (decoder *lzma2Decoder) decodeChunk(input *xzInput) (bool, error) {
+	control, read_error := input.readByte()
+	if read_error != nil {
+		return false, read_error
+	}
+	if control == 0x00 {
+		return true, nil
+	}
+
+	// The first chunk of a block has to reset the dictionary
+	if control >= 0xe0 || control == 0x01 {
+		decoder.need_properties = true
+		decoder.need_dictionary_reset = false
+		decoder.dictionary.reset(decoder.dictionary.size)
+	} else if decoder.need_dictionary_reset {
+		return false, fmt.Errorf("%w: the dictionary isn't reset", errData)
+	}
+
+	// A chunk that isn't compressed is copied as it is
+	if control < 0x80 {
+		if control > 0x02 {
+			return false, fmt.Errorf("%w: a chunk isn't valid", errData)
+		}
+		sizes, read_error := input.next(2)
+		if read_error != nil {
+			return false, read_error
+		}
+		data, read_error := input.next(
+			int(binary.BigEndian.Uint16(sizes)) + 1)
+		if read_error != nil {
+			return false, read_error
+		}
+		for _, value := range data {
+			decoder.dictionary.put(value)
+		}
+		return false, nil
+	}
+
+	// Otherwise, read the sizes of the chunk and reset what it says to
+	sizes, read_error := input.next(4)
+	if read_error != nil {
+		return false, read_error
+	}
+	uncompressed_size := int(control&0x1f)<<16 +
+		int(binary.BigEndian.Uint16(sizes[:2])) + 1
+	compressed_size := int(binary.BigEndian.Uint16(sizes[2:])) + 1
+	if control >= 0xc0 {
+		properties, read_error := input.readByte()
+		if read_error != nil {
+			return false, read_error
+		}
+		if properties_error := decoder.setProperties(
+			properties); properties_error != nil {
+			return false, properties_error
+		}
+		decoder.need_properties = false
+		decoder.resetState()
+	} else if decoder.need_properties {
+		return false, fmt.Errorf("%w: a chunk is missing its properties",
+			errData)
+	} else if control >= 0xa0 {
+		decoder.resetState()
+	}
+	data, read_error := input.next(compressed_size)
+	if read_error != nil {
+		return false, read_error
+	}
+	if reset_error := decoder.ranges.reset(data); reset_error != nil {
+		return false, reset_error
+	}
+	if decode_error := decoder.decode(uncompressed_size); decode_error != nil {
+		return false, decode_error
+	}
+	if !decoder.ranges.finished() {
+		return false, fmt.Errorf("%w: a chunk isn't the size it says",
+			errData)
+	}
+	return false, nil
+}
+
+/*
+Set the properties that the probabilities are picked with. Parameters include
+the byte that holds them. Returns an error if they aren't valid.
+*/
+func (decoder *lzma2Decoder) setProperties(properties byte) error {
+	if properties >= 9*5*5 {
+		return fmt.Errorf("%w: the properties aren't valid", errData)
+	}
+	literal_bits := uint32(properties % 9)
+	properties /= 9
+	literal_position_bits := uint32(properties % 5)
+	position_bits := uint32(properties / 5)
+	if literal_bits+literal_position_bits > 4 || position_bits > 4 {
+		return fmt.Errorf("%w: the properties aren't valid", errData)
+	}
+	decoder.literal_bits = literal_bits
+	decoder.literal_position_bits = literal_position_bits
+	decoder.position_bits = position_bits
+	return nil
+}
+
+/*
+Decode the compressed data of a chunk. Parameters include the number of
+bytes that it decompresses to. Returns an error if the data is corrupt.
+*/
+func (decoder *lzma2Decoder) decode(uncompressed_size int) error {
+	ranges := &decoder.ranges
+	dictionary := &decoder.dictionary
+	for remaining := uncompressed_size; remaining > 0; {
+		if ranges.overran() {
+			return fmt.Errorf("%w: a chunk ends too soon", errData)
+		}
+		position_state := uint32(dictionary.total) &
+			(1<<decoder.position_bits - 1)
+		state_index := decoder.state*lzmaPositionStates + position_state
+
+		// A literal is a byte on its own
+		if ranges.bit(&decoder.is_match[state_index]) == 0 {
+			decoder.decodeLiteral()
+			remaining -= 1
+			continue
+		}
+
+		// Otherwise, it is a match, either at a new distance or a repeated one
+		var length int
+		if ranges.bit(&decoder.is_repeat[decoder.state]) == 0 {
+			decoder.repeats = [4]int{
+				0, decoder.repeats[0], decoder.repeats[1], decoder.repeats[2]}
+			length = decoder.lengths.decode(ranges, position_state)
+			decoder.state = nextLZMAState(decoder.state, 7, 10)
+			distance, distance_error := decoder.decodeDistance(length)
+			if distance_error != nil {
+				return distance_error
+			}
+			decoder.repeats[0] = distance
+		} else {
+			if ranges.bit(&decoder.is_repeat_0[decoder.state]) == 0 {
+				// A short repeat is a single byte
+				if ranges.bit(&decoder.is_repeat_0_long[state_index]) == 0 {
+					if !dictionary.has(decoder.repeats[0] + 1) {
+						return fmt.Errorf("%w: a match is too far back",
+							errData)
+					}
+					decoder.state = nextLZMAState(decoder.state, 9, 11)
+					dictionary.put(dictionary.get(decoder.repeats[0] + 1))
+					remaining -= 1
+					continue
+				}
+			} else {
+				var distance int
+				if ranges.bit(&decoder.is_repeat_1[decoder.state]) == 0 {
+					distance = decoder.repeats[1]
+				} else {
+					if ranges.bit(&decoder.is_repeat_2[decoder.state]) == 0 {
+						distance = decoder.repeats[2]
+					} else {
+						distance = decoder.repeats[3]
+						decoder.repeats[3] = decoder.repeats[2]
+					}
+					decoder.repeats[2] = decoder.repeats[1]
+				}
+				decoder.repeats[1] = decoder.repeats[0]
+				decoder.repeats[0] = distance
+			}
+			length = decoder.repeat_lengths.decode(ranges, position_state)
+			decoder.state = nextLZMAState(decoder.state, 8, 11)
+		}
+
+		// Copy the match, which has to be within the chunk
+		if length > remaining || !dictionary.has(decoder.repeats[0]+1) {
+			return fmt.Errorf("%w: a match isn't valid", errData)
+		}
+		for range length {
+			dictionary.put(dictionary.get(decoder.repeats[0] + 1))
+		}
+		remaining -= length
+	}
+	return nil
+}
+
+/*
+Decode a byte on its own, which is picked with the byte before it and,
+after a match, the byte at the distance of the match. No parameters. No
+return value.
+*/
+func (decoder *lzma2Decoder) decodeLiteral() {
+	ranges := &decoder.ranges
+	dictionary := &decoder.dictionary
+	previous := uint32(dictionary.get(1))
+	literal_state := (uint32(dictionary.total)&
+		(1<<decoder.literal_position_bits-1))<<decoder.literal_bits +
+		previous>>(8-decoder.literal_bits)
+	probabilities := decoder.literals[lzmaLiteralSize*literal_state:]
+
+	symbol := uint32(1)
+	if decoder.state >= 7 {
+		// Each bit is picked with the byte of the match until one differs
+		match := uint32(dictionary.get(decoder.repeats[0] + 1))
+		for symbol < 0x100 {
+			match_bit := (match >> 7) & 1
+			match <<= 1
+			bit := ranges.bit(&probabilities[(1+match_bit)<<8+symbol])
+			symbol = symbol<<1 | bit
+			if bit != match_bit {
+				break
+			}
+		}
+	}
+	for symbol < 0x100 {
+		symbol = symbol<<1 | ranges.bit(&probabilities[symbol])
+	}
+	dictionary.put(byte(symbol))
+	switch {
+	case decoder.state < 4:
+		decoder.state = 0
+	case decoder.state < 10:
+		decoder.state -= 3
+	default:
+		decoder.state -= 6
+	}
+}
+
+/*
+Decode the distance of a new match. Parameters include the length of the
+match. Returns the distance less one and an error if it is the marker for
+the end of the data, which LZMA2 doesn't use.
+*/
+func (decoder *lzma2Decoder) decodeDistance(length int) (int, error) {
+	ranges := &decoder.ranges
+	slot := ranges.tree(
+		decoder.distance_slots[min(length-lzmaMatchMin, 3)][:], 6)
+	if slot < 4 {
+		return int(slot), nil
+	}
+	direct_bits := int(slot>>1) - 1
+	distance := (2 | slot&1) << direct_bits
+	if slot < lzmaEndSlot {
+		distance += ranges.reverseTree(
+			decoder.distances[distance-slot:], direct_bits)
+	} else {
+		distance += ranges.directBits(
+			direct_bits-lzmaAlignBits) << lzmaAlignBits
+		distance += ranges.reverseTree(decoder.align[:], lzmaAlignBits)
+	}
+	if distance == math.MaxUint32 {
+		return 0, fmt.Errorf("%w: a chunk has an end marker", errData)
+	}
+	return int(distance), nil
+}
+
+/*
+Work out the state after a match. Parameters include the state before it,
+the state if a literal was decoded before the match, and the state
+otherwise. Returns the new state.
+*/
+func nextLZMAState(
+	state uint32, after_literal uint32, after_match uint32) uint32 {
+	if state < 7 {
+		return after_literal
+	}
+	return after_match
+}
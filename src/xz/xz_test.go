@@ -0,0 +1,108 @@
+package xz
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+/*
+xz data compressed by the xz command. The first is text that is checked with
+SHA-256, the second is two streams, one holding random bytes (which LZMA2
+stores as they are) checked with CRC32 and the other holding "and the rest"
+with no check, and the third is compressed with the delta filter as well.
+*/
+const (
+	TEST_XZ_TEXT = "/Td6WFoAAArh+wyhAgAhARYAAAB0L+Wj4ASDARhdACoaCKIDJWbxS3jF" +
+		"ogX/LubZ0iAarTT44h3oQTb63AZpuzzkEDQnCeuzZuPtN5jtkq3VJzzIEMHzr1e3" +
+		"rKCTlc4pOLAN2ighloXpwtym7TUZfR5gEgjzj1pv9FleSgTuK7sSKyA5rbwvbaF/" +
+		"CfCPifQK/RrXZqiQzxQrjLtn40vZW5qYysYQwxR0YOIaIB1TGqJVX2pdxeMN1hYQ" +
+		"S03o+tDK0k3VvxwH0YqHPr2kErO7Z6Feb2Od65H7D4Cd8r/CsZirgN/hu+QeDZsj" +
+		"zVjwvludhrEC97+UhTqZqcx8h/yh7owUYugrcTJRNeq2nRlNwUHAnCwBAOtgXPNe" +
+		"sIqbgRWHGN/vWAAepQokQRJF04yNEWAmUxuZBwYAa3k1LZeR4sCHVOifJnyUTGIE" +
+		"cjuElgEYIJDhmtMZjMEAAcwChAkAAJFDVbK26d8cAgAAAAAKWVo="
+	TEST_XZ_STREAMS = "/Td6WFoAAAFpIt42AgAhARYAAAB0L+WjAQA/UvImZaYMEtKJGF2V" +
+		"DuiBNgkWb2sRPReNbA/TkB/yOaGglfIPk5VlDPk4C47bIkprJIoekk6P0K4uGpSS" +
+		"ozBfGABm25kLAAFUQOtsjYiQQpkNAQAAAAABWVr9N3pYWgAAAP8S2UECACEBFgAA" +
+		"AHQv5aMBAAthbmQgdGhlIHJlc3QAAAEcDF2kR88Gcp56AQAAAAAAWVo="
+	TEST_XZ_DELTA = "/Td6WFoAAATm1rRGAgEDAQAhARZ5IMTuAQAEZAEHCO0AAAAAFDf7RNvW" +
+		"4mcAAR0FuC2Arx+2830BAAAAAARZWg=="
+)
+
+// Decode xz data that is held as base64. Parameters include the data.
+func decodeTestXZ(t *testing.T, encoded string) []byte {
+	data, decode_error := base64.StdEncoding.DecodeString(encoded)
+	if decode_error != nil {
+		t.Fatal(decode_error)
+	}
+	return data
+}
+
+// Decompress xz data. Parameters include the data.
+func readTestXZ(data []byte) ([]byte, error) {
+	reader, reader_error := NewReader(bytes.NewReader(data))
+	if reader_error != nil {
+		return nil, reader_error
+	}
+	return io.ReadAll(reader)
+}
+
+/*
+Check to make sure that xz data is decompressed whatever check it uses and
+however many streams it has.
+*/
+func TestXZReader(t *testing.T) {
+	var text bytes.Buffer
+	text.WriteString(strings.Repeat(
+		"The quick brown fox jumps over the lazy dog. ", 20))
+	for value := range 256 {
+		text.WriteByte(byte(value))
+	}
+	output, read_error := readTestXZ(decodeTestXZ(t, TEST_XZ_TEXT))
+	if read_error != nil || !bytes.Equal(output, text.Bytes()) {
+		t.Errorf("[xz] Expected the text, got %q (%v)", output, read_error)
+	}
+	output, read_error = readTestXZ(decodeTestXZ(t, TEST_XZ_STREAMS))
+	if read_error != nil || len(output) != 64+12 ||
+		!bytes.HasSuffix(output, []byte("and the rest")) {
+		t.Errorf("[xz] Expected both streams, got %q (%v)", output, read_error)
+	}
+}
+
+/*
+Check to make sure that xz data that is corrupt, cut short, or uses a filter
+other than LZMA2 is reported rather than decompressed.
+*/
+func TestXZReaderErrors(t *testing.T) {
+	data := decodeTestXZ(t, TEST_XZ_TEXT)
+	cases := map[string][]byte{
+		"check":   bytes.Clone(data),
+		"chunk":   bytes.Clone(data),
+		"index":   bytes.Clone(data),
+		"header":  []byte("not xz data at all"),
+		"filters": decodeTestXZ(t, TEST_XZ_DELTA),
+	}
+	// The SHA-256 check sits before the index and footer at the end
+	cases["check"][len(data)-12-12-1] ^= 0x01
+	cases["chunk"][40] ^= 0x10
+	cases["index"][len(data)-12-5] ^= 0x01
+	for name, corrupt := range cases {
+		if _, read_error := readTestXZ(corrupt); !errors.Is(
+			read_error, errData) {
+			t.Errorf("[xz] Expected the %s to be corrupt, got %v",
+				name,
+				read_error)
+		}
+	}
+	for _, size := range []int{0, 6, 40, len(data) - 1} {
+		if _, read_error := readTestXZ(data[:size]); !errors.Is(
+			read_error, io.ErrUnexpectedEOF) {
+			t.Errorf("[xz] Expected %d bytes to be cut short, got %v",
+				size,
+				read_error)
+		}
+	}
+}